@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -9,15 +10,19 @@ import (
 	"syscall"
 
 	"github.com/joho/godotenv"
-	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/term"
+	"gorm.io/gorm"
 
 	"ledsite/internal/config"
 	"ledsite/internal/database"
+	"ledsite/internal/handlers"
 	"ledsite/internal/models"
 )
 
 func main() {
+	resetTwoFactor := flag.String("reset-2fa", "", "отключить двухфакторную аутентификацию у пользователя (потерян телефон)")
+	flag.Parse()
+
 	fmt.Println("=== Утилита создания администратора ===")
 
 	// Загружаем .env файл из backend/.env
@@ -39,6 +44,11 @@ func main() {
 		log.Fatalf("Failed to migrate database: %v", migrateErr)
 	}
 
+	if *resetTwoFactor != "" {
+		resetAdminTwoFactor(db, *resetTwoFactor)
+		return
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 
 	// Ввод имени пользователя
@@ -79,8 +89,8 @@ func main() {
 			log.Fatalf("Error reading password: %v", pwdErr)
 		}
 
-		if len(pwd) < 8 {
-			log.Fatal("Пароль должен содержать минимум 8 символов")
+		if policyErr := handlers.ValidatePassword(pwd); policyErr != nil {
+			log.Fatalf("\n%v", policyErr)
 		}
 
 		fmt.Print("\nПовторите пароль: ")
@@ -94,12 +104,12 @@ func main() {
 		}
 
 		// Хешируем пароль
-		hashedPassword, pwdErr := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.DefaultCost)
+		hashedPassword, pwdErr := handlers.HashPassword(pwd)
 		if pwdErr != nil {
 			log.Fatalf("Error hashing password: %v", pwdErr)
 		}
 
-		existing.PasswordHash = string(hashedPassword)
+		existing.PasswordHash = hashedPassword
 		existing.IsActive = true
 
 		if saveErr := db.Save(&existing).Error; saveErr != nil {
@@ -126,8 +136,8 @@ func main() {
 		log.Fatalf("Error reading password: %v", err)
 	}
 
-	if len(password) < 8 {
-		log.Fatal("\nПароль должен содержать минимум 8 символов")
+	if policyErr := handlers.ValidatePassword(password); policyErr != nil {
+		log.Fatalf("\n%v", policyErr)
 	}
 
 	fmt.Print("\nПовторите пароль: ")
@@ -141,7 +151,7 @@ func main() {
 	}
 
 	// Хешируем пароль
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := handlers.HashPassword(password)
 	if err != nil {
 		log.Fatalf("Error hashing password: %v", err)
 	}
@@ -149,8 +159,9 @@ func main() {
 	// Создаём администратора
 	admin := models.Admin{
 		Username:     username,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		Email:        email,
+		Role:         models.RoleOwner, // администратор из CLI - владелец с полным доступом
 		IsActive:     true,
 	}
 
@@ -160,6 +171,25 @@ func main() {
 
 	fmt.Printf("\n✓ Администратор '%s' успешно создан!\n", username)
 	fmt.Println("\nТеперь вы можете войти в админ-панель по адресу: http://localhost:8080/admin/login")
+	fmt.Println("Остальных пользователей можно приглашать на странице /admin/users")
+}
+
+// resetAdminTwoFactor отключает 2FA и удаляет коды восстановления администратора.
+// После входа по паролю админ может заново настроить 2FA на странице «Безопасность».
+func resetAdminTwoFactor(db *gorm.DB, username string) {
+	var admin models.Admin
+	if err := db.Where("username = ?", username).First(&admin).Error; err != nil {
+		log.Fatalf("Пользователь '%s' не найден", username)
+	}
+	if !admin.TOTPEnabled {
+		fmt.Printf("\nУ пользователя '%s' двухфакторная аутентификация не включена.\n", username)
+		return
+	}
+
+	if err := handlers.ResetTwoFactor(db, admin.ID); err != nil {
+		log.Fatalf("Failed to reset 2FA: %v", err)
+	}
+	fmt.Printf("\n✓ Двухфакторная аутентификация для '%s' отключена. Вход - только по паролю.\n", username)
 }
 
 // readPassword читает пароль без отображения на экране
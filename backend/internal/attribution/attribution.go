@@ -0,0 +1,184 @@
+// Package attribution определяет, откуда пришёл посетитель сайта: UTM-метки, рекламные click ID,
+// реферер и страница входа. Первый и последний визиты хранятся в cookie (middleware.Attribution)
+// и при отправке заявки сохраняются в models.ContactAttribution.
+package attribution
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+)
+
+// Cookie визитов
+const (
+	FirstCookie  = "snr_first_touch"
+	LastCookie   = "snr_last_touch"
+	CookieMaxAge = 90 * 24 * 60 * 60 // 90 дней, в секундах
+)
+
+// Значения для прямых заходов (как в Яндекс Метрике и GA)
+const (
+	DirectSource = "(direct)"
+	DirectMedium = "(none)"
+)
+
+// Каналы, которые определяются по рефереру
+const (
+	MediumCPC      = "cpc"
+	MediumOrganic  = "organic"
+	MediumSocial   = "social"
+	MediumReferral = "referral"
+)
+
+// knownReferrers - источники по домену реферера (домен и его поддомены)
+var knownReferrers = []struct {
+	domain, source, medium string
+}{
+	{"yandex.ru", "yandex", MediumOrganic},
+	{"yandex.com", "yandex", MediumOrganic},
+	{"ya.ru", "yandex", MediumOrganic},
+	{"go.mail.ru", "mail.ru", MediumOrganic},
+	{"bing.com", "bing", MediumOrganic},
+	{"duckduckgo.com", "duckduckgo", MediumOrganic},
+	{"rambler.ru", "rambler", MediumOrganic},
+	{"search.yahoo.com", "yahoo", MediumOrganic},
+	{"vk.com", "vk", MediumSocial},
+	{"vk.ru", "vk", MediumSocial},
+	{"ok.ru", "ok", MediumSocial},
+	{"t.me", "telegram", MediumSocial},
+	{"dzen.ru", "dzen", MediumSocial},
+	{"youtube.com", "youtube", MediumSocial},
+	{"facebook.com", "facebook", MediumSocial},
+	{"instagram.com", "instagram", MediumSocial},
+}
+
+// FromRequest возвращает визит по запросу страницы. ok = false - внутренний переход или прямой заход
+// без меток: такой визит не заменяет последний источник.
+func FromRequest(r *http.Request, now time.Time) (t models.AttributionTouch, ok bool) {
+	return fromURL(r.URL, r.Referer(), r.Host, now)
+}
+
+// FromPage возвращает визит по адресу страницы с UTM-метками (без реферера).
+// Нужен для заявок без cookie: метки остаются в адресе страницы формы.
+func FromPage(page string, now time.Time) (models.AttributionTouch, bool) {
+	u, err := url.Parse(page)
+	if err != nil {
+		return models.AttributionTouch{}, false
+	}
+	return fromURL(u, "", u.Host, now)
+}
+
+// Direct - прямой заход на страницу запроса
+func Direct(r *http.Request, now time.Time) models.AttributionTouch {
+	return models.AttributionTouch{
+		Source:  DirectSource,
+		Medium:  DirectMedium,
+		Landing: clip(r.URL.RequestURI(), 500),
+		At:      &now,
+	}
+}
+
+func fromURL(u *url.URL, referrer, host string, now time.Time) (models.AttributionTouch, bool) {
+	q := u.Query()
+	t := models.AttributionTouch{Landing: clip(u.RequestURI(), 500), At: &now}
+
+	refHost := ""
+	if ref, err := url.Parse(referrer); err == nil && ref.Host != "" && bareHost(ref.Host) != bareHost(host) {
+		refHost = bareHost(ref.Host)
+		t.Referrer = clip(referrer, 500)
+	}
+
+	switch {
+	case q.Get("utm_source") != "":
+		t.Source = clip(q.Get("utm_source"), 100)
+		t.Medium = clip(q.Get("utm_medium"), 100)
+		t.Campaign = clip(q.Get("utm_campaign"), 255)
+		t.Term = clip(q.Get("utm_term"), 255)
+		t.Content = clip(q.Get("utm_content"), 255)
+	case q.Get("yclid") != "":
+		t.Source, t.Medium = "yandex", MediumCPC
+	case q.Get("gclid") != "":
+		t.Source, t.Medium = "google", MediumCPC
+	case refHost != "":
+		t.Source, t.Medium = classifyReferrer(refHost)
+	default:
+		return t, false
+	}
+	return t, true
+}
+
+// classifyReferrer - источник и канал по домену реферера
+func classifyReferrer(host string) (source, medium string) {
+	for _, k := range knownReferrers {
+		if host == k.domain || strings.HasSuffix(host, "."+k.domain) {
+			return k.source, k.medium
+		}
+	}
+	// google.ru, google.com.ua, www.google.de ...
+	if strings.HasPrefix(host, "google.") || strings.Contains(host, ".google.") {
+		return "google", MediumOrganic
+	}
+	return clip(host, 100), MediumReferral
+}
+
+// bareHost - домен без порта и www в нижнем регистре
+func bareHost(host string) string {
+	if h, _, found := strings.Cut(host, ":"); found {
+		host = h
+	}
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// Encode упаковывает визит в значение cookie
+func Encode(t models.AttributionTouch) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode разбирает значение cookie. ok = false - cookie нет или она испорчена.
+// Cookie присылает клиент, поэтому поля обрезаются до размеров колонок в БД.
+func Decode(value string) (t models.AttributionTouch, ok bool) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &t) != nil || t.Source == "" {
+		return models.AttributionTouch{}, false
+	}
+	t.Source = clip(t.Source, 100)
+	t.Medium = clip(t.Medium, 100)
+	t.Campaign = clip(t.Campaign, 255)
+	t.Term = clip(t.Term, 255)
+	t.Content = clip(t.Content, 255)
+	t.Referrer = clip(t.Referrer, 500)
+	t.Landing = clip(t.Landing, 500)
+	return t, true
+}
+
+// FromCookies возвращает первый и последний визиты из cookie запроса
+func FromCookies(r *http.Request) (first, last models.AttributionTouch, ok bool) {
+	if c, err := r.Cookie(FirstCookie); err == nil {
+		first, ok = Decode(c.Value)
+	}
+	if !ok {
+		return models.AttributionTouch{}, models.AttributionTouch{}, false
+	}
+	last = first
+	if c, err := r.Cookie(LastCookie); err == nil {
+		if t, lastOK := Decode(c.Value); lastOK {
+			last = t
+		}
+	}
+	return first, last, true
+}
+
+// clip обрезает строку до max символов (размер колонки в БД)
+func clip(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
@@ -0,0 +1,104 @@
+package attribution
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"ledsite/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pageRequest(target, referrer string) *http.Request {
+	r := httptest.NewRequest("GET", target, nil)
+	r.Host = "s-n-r.ru"
+	if referrer != "" {
+		r.Header.Set("Referer", referrer)
+	}
+	return r
+}
+
+func TestFromRequest(t *testing.T) {
+	now := time.Date(2026, 4, 12, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name, target, referrer string
+		ok                     bool
+		source, medium         string
+	}{
+		{"utm", "/prices?utm_source=yandex&utm_medium=cpc&utm_campaign=spb_led", "https://yandex.ru/", true, "yandex", "cpc"},
+		{"yclid", "/?yclid=123", "", true, "yandex", MediumCPC},
+		{"gclid", "/?gclid=abc", "", true, "google", MediumCPC},
+		{"поиск Яндекса", "/", "https://yandex.ru/search/?text=led", true, "yandex", MediumOrganic},
+		{"google другой зоны", "/", "https://www.google.com.ua/", true, "google", MediumOrganic},
+		{"соцсеть", "/projects", "https://m.vk.com/wall-1", true, "vk", MediumSocial},
+		{"сайт-партнёр", "/", "https://www.partner.example/led", true, "partner.example", MediumReferral},
+		{"внутренний переход", "/contact", "https://www.s-n-r.ru/prices", false, "", ""},
+		{"прямой заход", "/", "", false, "", ""},
+	}
+	for _, tc := range cases {
+		touch, ok := FromRequest(pageRequest(tc.target, tc.referrer), now)
+		assert.Equal(t, tc.ok, ok, tc.name)
+		if tc.ok {
+			assert.Equal(t, tc.source, touch.Source, tc.name)
+			assert.Equal(t, tc.medium, touch.Medium, tc.name)
+			assert.Equal(t, tc.target, touch.Landing, tc.name)
+		}
+	}
+
+	touch, _ := FromRequest(pageRequest("/prices?utm_source=yandex&utm_medium=cpc&utm_campaign=spb_led&utm_term=led+экран", "https://yandex.ru/"), now)
+	assert.Equal(t, "spb_led", touch.Campaign)
+	assert.Equal(t, "led экран", touch.Term)
+	assert.Equal(t, "https://yandex.ru/", touch.Referrer)
+
+	direct := Direct(pageRequest("/privacy", ""), now)
+	assert.Equal(t, DirectSource, direct.Source)
+	assert.Equal(t, DirectMedium, direct.Medium)
+}
+
+func TestFromPage(t *testing.T) {
+	now := time.Now()
+	touch, ok := FromPage("https://s-n-r.ru/contact?utm_source=vk&utm_medium=social", now)
+	assert.True(t, ok)
+	assert.Equal(t, "vk", touch.Source)
+	assert.Equal(t, "/contact?utm_source=vk&utm_medium=social", touch.Landing)
+
+	_, ok = FromPage("https://s-n-r.ru/contact", now)
+	assert.False(t, ok)
+}
+
+func TestCookies(t *testing.T) {
+	now := time.Date(2026, 4, 12, 10, 0, 0, 0, time.UTC)
+	first := models.AttributionTouch{Source: "yandex", Medium: "cpc", Campaign: "spb", Landing: "/prices", At: &now}
+	last := models.AttributionTouch{Source: "google", Medium: "organic", Landing: "/", At: &now}
+
+	decoded, ok := Decode(Encode(first))
+	assert.True(t, ok)
+	assert.Equal(t, first, decoded)
+	_, ok = Decode("испорчено")
+	assert.False(t, ok)
+
+	long := models.AttributionTouch{Source: strings.Repeat("я", 300), Campaign: strings.Repeat("c", 300), Landing: "/" + strings.Repeat("x", 600)}
+	decoded, ok = Decode(Encode(long))
+	assert.True(t, ok)
+	assert.Equal(t, 100, utf8.RuneCountInString(decoded.Source), "значения из cookie обрезаются до размера колонки")
+	assert.Len(t, decoded.Campaign, 255)
+	assert.Len(t, decoded.Landing, 500)
+
+	r := httptest.NewRequest("GET", "/api/contact", nil)
+	_, _, ok = FromCookies(r)
+	assert.False(t, ok, "без cookie")
+
+	r.AddCookie(&http.Cookie{Name: FirstCookie, Value: Encode(first)})
+	f, l, ok := FromCookies(r)
+	assert.True(t, ok)
+	assert.Equal(t, first, f)
+	assert.Equal(t, first, l, "без cookie последнего визита он совпадает с первым")
+
+	r.AddCookie(&http.Cookie{Name: LastCookie, Value: Encode(last)})
+	_, l, _ = FromCookies(r)
+	assert.Equal(t, last, l)
+}
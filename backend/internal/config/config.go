@@ -5,7 +5,8 @@
 //
 // Основные группы настроек:
 //   - Приложение (DatabaseURL, Port, Environment)
-//   - SMTP для отправки email (в разработке)
+//   - SMTP для email уведомлений о заявках и напоминаниях
+//   - Webhook и параметры повторов outbox уведомлений
 //   - Загрузка файлов (UploadPath, MaxUploadSize)
 //   - Connection pooling для PostgreSQL
 //
@@ -31,11 +32,20 @@ type Config struct {
 	Port        string // Порт для HTTP сервера (например "8080")
 	Environment string // "development" или "production"
 
-	// SMTP для email уведомлений (в разработке, не используется)
+	// SMTP для email уведомлений о заявках и напоминаниях (см. internal/mailer).
+	// Отправка включается, когда заданы SMTPFrom и NotifyEmails.
 	SMTPHost     string
 	SMTPPort     string
 	SMTPUsername string
 	SMTPPassword string
+	SMTPFrom     string // Адрес отправителя, например "LED сайт <site@example.com>"
+	NotifyEmails string // Получатели уведомлений через запятую
+	SiteURL      string // Адрес сайта для ссылок на админку в уведомлениях
+
+	// Webhook уведомления о заявках (см. internal/notify). Пустой URL - канал отключен.
+	NotifyWebhookURL    string
+	NotifyWebhookSecret string // Ключ HMAC подписи тела (заголовок X-Notify-Signature)
+	NotifyMaxAttempts   int    // Попыток доставки до статуса "не доставлено" (dead)
 
 	// Файловые загрузки
 	UploadPath    string // Путь для сохранения изображений
@@ -72,6 +82,13 @@ func Load() *Config {
 		SMTPPort:     getEnv("SMTP_PORT", "587"),
 		SMTPUsername: getEnv("SMTP_USERNAME", ""),
 		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", ""),
+		NotifyEmails: getEnv("NOTIFY_EMAILS", ""),
+		SiteURL:      getEnv("SITE_URL", "https://s-n-r.ru"),
+
+		NotifyWebhookURL:    getEnv("NOTIFY_WEBHOOK_URL", ""),
+		NotifyWebhookSecret: getEnv("NOTIFY_WEBHOOK_SECRET", ""),
+		NotifyMaxAttempts:   getEnvInt("NOTIFY_MAX_ATTEMPTS", 8),
 
 		UploadPath:    getEnv("UPLOAD_PATH", "../frontend/static/uploads"),
 		MaxUploadSize: 10485760, // 10MB
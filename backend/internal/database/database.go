@@ -110,7 +110,8 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 // Выполняемые операции:
 //  1. GORM AutoMigrate для всех моделей (создает/обновляет таблицы)
 //  2. Создание дополнительных индексов для project_view_dailies
-//  3. Вызов seedInitialData() для создания начальных данных
+//  3. Полнотекстовый поиск по заявкам (migrateContactSearch)
+//  4. Вызов seedInitialData() для создания начальных данных
 //
 // AutoMigrate безопасен:
 //   - Создает таблицы если их нет
@@ -134,8 +135,19 @@ func Migrate(db *gorm.DB) error {
 		&models.Service{},
 		&models.ContactForm{},
 		&models.ContactNote{},
+		&models.Customer{},
+		&models.PipelineStage{},
+		&models.ContactStageChange{},
+		&models.ContactQuote{},
+		&models.ContactAttribution{},
+		&models.ContactView{},
+		&models.NotificationDelivery{},
 		&models.ProjectViewDaily{},
 		&models.Admin{},
+		&models.AdminSession{},
+		&models.AdminRecoveryCode{},
+		&models.LoginAttempt{},
+		&models.AuditLog{},
 		&models.PriceItem{},
 		&models.PriceSpecification{},
 		&models.PriceImage{},
@@ -164,58 +176,135 @@ func Migrate(db *gorm.DB) error {
 		return fmt.Errorf("ensure pvd indexes: %w", err)
 	}
 
+	if err := migrateContactSearch(db); err != nil {
+		return err
+	}
+
 	return seedInitialData(db)
 }
 
+// migrateContactSearch настраивает полнотекстовый поиск по заявкам (идемпотентно).
+//
+// Колонки contact_forms заполняются триггерами, код приложения их не пишет:
+//   - search_vector: tsvector с русской морфологией. Вес A - имя, email и телефон,
+//     B - компания, C - тип проекта и сообщение, D - текст заметок к заявке
+//   - phone_digits: телефон только цифрами в виде 7XXXXXXXXXX (+7, 8 и пробелы не важны)
+//
+// Триггер на contact_notes пересчитывает вектор заявки при изменении её заметок.
+// Существующие заявки без вектора заполняются при первом запуске.
+func migrateContactSearch(db *gorm.DB) error {
+	if err := db.Exec(`
+		ALTER TABLE contact_forms ADD COLUMN IF NOT EXISTS search_vector tsvector;
+		ALTER TABLE contact_forms ADD COLUMN IF NOT EXISTS phone_digits varchar(20) NOT NULL DEFAULT '';
+
+		CREATE OR REPLACE FUNCTION contact_phone_digits(phone text) RETURNS text AS $$
+			SELECT CASE
+				WHEN length(d) = 11 AND left(d, 1) = '8' THEN '7' || substr(d, 2)
+				WHEN length(d) = 10 AND left(d, 1) = '9' THEN '7' || d
+				ELSE d
+			END
+			FROM regexp_replace(coalesce(phone, ''), '[^0-9]', '', 'g') AS d
+		$$ LANGUAGE sql IMMUTABLE;
+
+		CREATE OR REPLACE FUNCTION contact_search_vector(cf contact_forms) RETURNS tsvector AS $$
+			SELECT
+				setweight(to_tsvector('russian', coalesce(cf.name, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(cf.email, '') || ' ' || contact_phone_digits(cf.phone)), 'A') ||
+				setweight(to_tsvector('russian', coalesce(cf.company, '')), 'B') ||
+				setweight(to_tsvector('russian', coalesce(cf.project_type, '') || ' ' || coalesce(cf.message, '')), 'C') ||
+				setweight(to_tsvector('russian', coalesce(
+					(SELECT string_agg(n.text, ' ') FROM contact_notes n WHERE n.contact_id = cf.id), '')), 'D')
+		$$ LANGUAGE sql STABLE;
+
+		CREATE OR REPLACE FUNCTION contact_forms_search_trigger() RETURNS trigger AS $$
+		BEGIN
+			NEW.phone_digits := contact_phone_digits(NEW.phone);
+			NEW.search_vector := contact_search_vector(NEW);
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS contact_forms_search ON contact_forms;
+		CREATE TRIGGER contact_forms_search
+			BEFORE INSERT OR UPDATE OF name, phone, email, company, project_type, message ON contact_forms
+			FOR EACH ROW EXECUTE PROCEDURE contact_forms_search_trigger();
+
+		CREATE OR REPLACE FUNCTION contact_notes_search_trigger() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP IN ('UPDATE', 'DELETE') THEN
+				UPDATE contact_forms cf SET search_vector = contact_search_vector(cf) WHERE cf.id = OLD.contact_id;
+			END IF;
+			IF TG_OP IN ('INSERT', 'UPDATE') THEN
+				UPDATE contact_forms cf SET search_vector = contact_search_vector(cf) WHERE cf.id = NEW.contact_id;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS contact_notes_search ON contact_notes;
+		CREATE TRIGGER contact_notes_search
+			AFTER INSERT OR UPDATE OR DELETE ON contact_notes
+			FOR EACH ROW EXECUTE PROCEDURE contact_notes_search_trigger();
+
+		CREATE INDEX IF NOT EXISTS idx_contact_forms_search       ON contact_forms USING GIN (search_vector);
+		CREATE INDEX IF NOT EXISTS idx_contact_forms_phone_digits ON contact_forms (phone_digits);
+
+		UPDATE contact_forms
+		SET phone_digits = contact_phone_digits(phone), search_vector = contact_search_vector(contact_forms)
+		WHERE search_vector IS NULL;
+	`).Error; err != nil {
+		return fmt.Errorf("ensure contact search: %w", err)
+	}
+	return nil
+}
+
 // seedInitialData создает начальные данные в базе (идемпотентная операция).
 //
 // Создает:
-//   - 6 базовых категорий проектов (Рекламные щиты, АЗС, ТЦ, и т.д.)
-//   - 4 базовые услуги компании (Продажа, обслуживание, металлоконструкции)
+//   - 5 базовых категорий проектов (только если категорий ещё нет)
+//   - 4 базовые услуги компании (только если услуг ещё нет)
+//   - Этапы воронки продаж (только если этапов ещё нет)
 //   - Базовые настройки сайта (название, телефон, email, SEO)
 //
 // Особенности:
 //   - Проверяет существование по slug перед созданием (идемпотентность)
 //   - Можно запускать многократно - не создаст дубликаты
-//   - При первом запуске (>6 категорий) очищает старые тестовые данные
-//
-// Очистка старых категорий нужна для обратной совместимости
-// с предыдущей версией, где было больше категорий.
+//   - Категории и услуги, созданные или удалённые в админке, не трогает
 //
 // Возвращает ошибку если не удалось создать данные.
 func seedInitialData(db *gorm.DB) error {
-	// Проверяем количество категорий для обратной совместимости
+	// Категории управляются из админки - базовый набор создаём только в пустой БД,
+	// иначе сидер вернул бы удалённые вручную категории при каждом запуске
 	var categoryCount int64
 	db.Model(&models.Category{}).Count(&categoryCount)
 
-	// Если категорий больше 5, значит это старая версия с тестовыми данными - очищаем
-	if categoryCount > 5 {
-		// Удаляем связи проектов с категориями
-		db.Exec("DELETE FROM project_categories")
-
-		// Удаляем все категории
-		db.Exec("DELETE FROM categories")
-	}
-
-	// Создаем базовые категории
-	categories := []models.Category{
-		{Name: "Изготовление металлоконструкций", Slug: "metalwork", Description: "Проектирование и изготовление металлоконструкций для LED экранов"},
-		{Name: "Уличные решения digital реклама", Slug: "outdoor-solutions", Description: "LED экраны для наружной рекламы и уличной установки"},
-		{Name: "Интерьерные решения", Slug: "interior-solutions", Description: "LED экраны для помещений и интерьеров"},
-		{Name: "Медиафасады", Slug: "media-facades", Description: "Медиафасады и крупноформатные LED экраны для зданий"},
-		{Name: "Сервис LED экранов", Slug: "led-service", Description: "Техническое обслуживание, ремонт и настройка LED экранов"},
-	}
+	if categoryCount == 0 {
+		categories := []models.Category{
+			{Name: "Изготовление металлоконструкций", Slug: "metalwork", Description: "Проектирование и изготовление металлоконструкций для LED экранов", SortOrder: 0},
+			{Name: "Уличные решения digital реклама", Slug: "outdoor-solutions", Description: "LED экраны для наружной рекламы и уличной установки", SortOrder: 1},
+			{Name: "Интерьерные решения", Slug: "interior-solutions", Description: "LED экраны для помещений и интерьеров", SortOrder: 2},
+			{Name: "Медиафасады", Slug: "media-facades", Description: "Медиафасады и крупноформатные LED экраны для зданий", SortOrder: 3},
+			{Name: "Сервис LED экранов", Slug: "led-service", Description: "Техническое обслуживание, ремонт и настройка LED экранов", SortOrder: 4},
+		}
 
-	// Создаем категории только если их еще нет (проверка по slug)
-	for _, category := range categories {
-		var existingCategory models.Category
-		if db.Where("slug = ?", category.Slug).First(&existingCategory).Error == gorm.ErrRecordNotFound {
+		for _, category := range categories {
 			if err := db.Create(&category).Error; err != nil {
 				return fmt.Errorf("failed to create category %s: %w", category.Name, err)
 			}
 		}
 	}
 
+	// Этапы воронки настраиваются в админке - базовый набор только в пустой БД
+	var stageCount int64
+	db.Model(&models.PipelineStage{}).Count(&stageCount)
+	if stageCount == 0 {
+		for _, stage := range models.DefaultPipelineStages() {
+			if err := db.Create(&stage).Error; err != nil {
+				return fmt.Errorf("failed to create pipeline stage %s: %w", stage.Slug, err)
+			}
+		}
+	}
+
 	// Создаем базовые услуги
 	services := []models.Service{
 		{
@@ -256,10 +345,11 @@ func seedInitialData(db *gorm.DB) error {
 		},
 	}
 
-	// Создаем услуги только если их еще нет (проверка по slug)
-	for _, service := range services {
-		var existingService models.Service
-		if db.Where("slug = ?", service.Slug).First(&existingService).Error == gorm.ErrRecordNotFound {
+	// Услуги управляются из админки - базовый набор создаём только в пустой БД
+	var serviceCount int64
+	db.Model(&models.Service{}).Count(&serviceCount)
+	if serviceCount == 0 {
+		for _, service := range services {
 			if err := db.Create(&service).Error; err != nil {
 				return fmt.Errorf("failed to create service %s: %w", service.Name, err)
 			}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Двухфакторная аутентификация (TOTP) для входа в админку.
+// После проверки пароля админ с включённой 2FA получает короткоживущий
+// challenge-токен в cookie admin_2fa; сессия создаётся только после ввода кода.
+const (
+	twoFactorCookieName   = "admin_2fa"
+	twoFactorCookiePath   = "/admin/login" // challenge нужен только форме входа
+	twoFactorChallengeTTL = 5 * time.Minute
+	recoveryCodesCount    = 10
+)
+
+// startTwoFactor выставляет challenge-токен второго шага входа
+func startTwoFactor(c *gin.Context, admin models.Admin) error {
+	token, err := signJWT(JWTClaims{
+		UserID:   admin.ID,
+		Username: admin.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(twoFactorChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    jwtIssuerTwoFactor,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	c.SetCookie(twoFactorCookieName, token, int(twoFactorChallengeTTL.Seconds()), twoFactorCookiePath, "", secureCookies(), true)
+	return nil
+}
+
+// clearTwoFactorCookie удаляет challenge-токен
+func clearTwoFactorCookie(c *gin.Context) {
+	c.SetCookie(twoFactorCookieName, "", -1, twoFactorCookiePath, "", secureCookies(), true)
+}
+
+// LoginTwoFactor обрабатывает второй шаг входа: код из приложения или код восстановления
+func (h *Handlers) LoginTwoFactor(c *gin.Context) {
+	token, err := c.Cookie(twoFactorCookieName)
+	if err != nil || token == "" {
+		c.Redirect(http.StatusFound, "/admin/login")
+		return
+	}
+	claims, err := parseJWT(token, jwtIssuerTwoFactor)
+	if err != nil {
+		clearTwoFactorCookie(c)
+		c.HTML(http.StatusUnauthorized, "admin_login.html", LoginPageData{
+			Error: "Время на ввод кода истекло, войдите заново",
+		})
+		return
+	}
+
+	var admin models.Admin
+	if err := h.db.First(&admin, claims.UserID).Error; err != nil || !admin.IsActive || !admin.TOTPEnabled {
+		clearTwoFactorCookie(c)
+		c.HTML(http.StatusUnauthorized, "admin_login.html", LoginPageData{
+			Error: "Войдите заново",
+		})
+		return
+	}
+
+	// Перебор кодов ограничивается так же, как перебор паролей
+	if h.rejectThrottledLogin(c, admin.Username, true) {
+		return
+	}
+
+	if !h.verifySecondFactor(admin, c.PostForm("code"), time.Now()) {
+		h.recordLoginAttempt(admin.Username, c.ClientIP(), c.Request.UserAgent(), LoginResultBad2FA)
+		c.HTML(http.StatusUnauthorized, "admin_login.html", LoginPageData{
+			Error:     "Неверный код",
+			TwoFactor: true,
+		})
+		return
+	}
+
+	clearTwoFactorCookie(c)
+	h.completeLogin(c, admin)
+}
+
+// verifySecondFactor проверяет TOTP код или одноразовый код восстановления.
+// Использованный TOTP шаг запоминается, поэтому один код нельзя ввести дважды.
+func (h *Handlers) verifySecondFactor(admin models.Admin, code string, now time.Time) bool {
+	if step, ok := verifyTOTP(admin.TOTPSecret, code, now, admin.TOTPLastStep); ok {
+		// Условие на totp_last_step защищает от одновременного входа одним кодом
+		res := h.db.Model(&models.Admin{}).
+			Where("id = ? AND totp_last_step < ?", admin.ID, step).
+			Update("totp_last_step", step)
+		return res.Error == nil && res.RowsAffected == 1
+	}
+	return h.useRecoveryCode(admin.ID, code, now)
+}
+
+// useRecoveryCode помечает код восстановления использованным (true, если код подошёл)
+func (h *Handlers) useRecoveryCode(adminID uint, code string, now time.Time) bool {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return false
+	}
+	res := h.db.Model(&models.AdminRecoveryCode{}).
+		Where("admin_id = ? AND code_hash = ? AND used_at IS NULL", adminID, hashToken(code)).
+		Update("used_at", now)
+	if res.Error != nil {
+		log.Printf("Ошибка проверки кода восстановления админа ID=%d: %v", adminID, res.Error)
+		return false
+	}
+	if res.RowsAffected == 1 {
+		log.Printf("Админ ID=%d вошёл по коду восстановления", adminID)
+		return true
+	}
+	return false
+}
+
+// ---------- Настройка 2FA (страница «Безопасность») ----------
+
+// SetupTwoFactor генерирует новый секрет и QR-код для приложения-аутентификатора.
+// Секрет сохраняется, но 2FA включается только после подтверждения кодом.
+func (h *Handlers) SetupTwoFactor(c *gin.Context) {
+	admin, ok := h.loadCurrentAdmin(c)
+	if !ok {
+		return
+	}
+	if admin.TOTPEnabled {
+		jsonErr(c, http.StatusBadRequest, "Двухфакторная аутентификация уже включена")
+		return
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		log.Printf("Ошибка генерации секрета 2FA: %v", err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка настройки 2FA")
+		return
+	}
+	if err := h.db.Model(&admin).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		log.Printf("Ошибка сохранения секрета 2FA админа ID=%d: %v", admin.ID, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка настройки 2FA")
+		return
+	}
+
+	// QR-код рисуется на сервере, чтобы секрет не уходил сторонним сервисам
+	uri := totpProvisioningURI(secret, admin.Username)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		log.Printf("Ошибка генерации QR-кода 2FA: %v", err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка настройки 2FA")
+		return
+	}
+
+	jsonOK(c, gin.H{
+		"secret": secret,
+		"uri":    uri,
+		"qr":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+// EnableTwoFactor включает 2FA после проверки кода и выдаёт коды восстановления
+func (h *Handlers) EnableTwoFactor(c *gin.Context) {
+	admin, ok := h.loadCurrentAdmin(c)
+	if !ok {
+		return
+	}
+	if admin.TOTPEnabled {
+		jsonErr(c, http.StatusBadRequest, "Двухфакторная аутентификация уже включена")
+		return
+	}
+	if admin.TOTPSecret == "" {
+		jsonErr(c, http.StatusBadRequest, "Сначала отсканируйте QR-код")
+		return
+	}
+
+	step, valid := verifyTOTP(admin.TOTPSecret, c.PostForm("code"), time.Now(), 0)
+	if !valid {
+		jsonErr(c, http.StatusBadRequest, "Неверный код. Проверьте время на телефоне")
+		return
+	}
+
+	var codes []string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&admin).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, admin.ID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Ошибка включения 2FA админа ID=%d: %v", admin.ID, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка включения 2FA")
+		return
+	}
+
+	// Другие сессии открыты только по паролю - завершаем их
+	if _, err := h.revokeAdminSessions(admin.ID, c.GetString("admin_session_jti")); err != nil {
+		log.Printf("Ошибка отзыва сессий администратора ID=%d: %v", admin.ID, err)
+	}
+
+	log.Printf("Админ '%s' включил двухфакторную аутентификацию", admin.Username)
+	jsonOK(c, gin.H{"message": "Двухфакторная аутентификация включена", "codes": codes})
+}
+
+// DisableTwoFactor выключает 2FA (требуется текущий пароль)
+func (h *Handlers) DisableTwoFactor(c *gin.Context) {
+	admin, ok := h.loadCurrentAdmin(c)
+	if !ok || !checkAdminPassword(c, admin) {
+		return
+	}
+
+	if err := ResetTwoFactor(h.db, admin.ID); err != nil {
+		log.Printf("Ошибка отключения 2FA админа ID=%d: %v", admin.ID, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка отключения 2FA")
+		return
+	}
+
+	log.Printf("Админ '%s' отключил двухфакторную аутентификацию", admin.Username)
+	jsonOK(c, gin.H{"message": "Двухфакторная аутентификация отключена"})
+}
+
+// RegenerateRecoveryCodes выдаёт новый набор кодов восстановления (старые перестают действовать)
+func (h *Handlers) RegenerateRecoveryCodes(c *gin.Context) {
+	admin, ok := h.loadCurrentAdmin(c)
+	if !ok || !checkAdminPassword(c, admin) {
+		return
+	}
+	if !admin.TOTPEnabled {
+		jsonErr(c, http.StatusBadRequest, "Двухфакторная аутентификация не включена")
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(h.db, admin.ID)
+	if err != nil {
+		log.Printf("Ошибка генерации кодов восстановления админа ID=%d: %v", admin.ID, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка генерации кодов")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Новые коды восстановления созданы", "codes": codes})
+}
+
+// ResetTwoFactor выключает 2FA администратора и удаляет коды восстановления.
+// Используется при отключении на странице «Безопасность» и в cmd/create-admin
+// для админа, потерявшего телефон.
+func ResetTwoFactor(db *gorm.DB, adminID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Admin{}).Where("id = ?", adminID).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("admin_id = ?", adminID).Delete(&models.AdminRecoveryCode{}).Error
+	})
+}
+
+// loadCurrentAdmin загружает текущего администратора (отвечает 404, если его нет)
+func (h *Handlers) loadCurrentAdmin(c *gin.Context) (models.Admin, bool) {
+	var admin models.Admin
+	if err := h.db.First(&admin, c.GetUint("admin_id")).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Пользователь не найден")
+		return admin, false
+	}
+	return admin, true
+}
+
+// checkAdminPassword сверяет поле password формы с паролем администратора
+func checkAdminPassword(c *gin.Context, admin models.Admin) bool {
+	if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(c.PostForm("password"))); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Неверный пароль")
+		return false
+	}
+	return true
+}
+
+// replaceRecoveryCodes удаляет старые коды восстановления и создаёт новые.
+// Возвращает коды в открытом виде - показываются админу один раз.
+func replaceRecoveryCodes(db *gorm.DB, adminID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	rows := make([]models.AdminRecoveryCode, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, models.AdminRecoveryCode{AdminID: adminID, CodeHash: hashToken(normalizeRecoveryCode(code))})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("admin_id = ?", adminID).Delete(&models.AdminRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode генерирует код восстановления вида 1a2b3-c4d5e-f6a7b-c8d9e.
+// 80 бит случайности: перебор по утёкшим SHA-256 хешам без соли остаётся непрактичным
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	s := hex.EncodeToString(buf)
+	return s[:5] + "-" + s[5:10] + "-" + s[10:15] + "-" + s[15:], nil
+}
+
+// normalizeRecoveryCode убирает дефисы, пробелы и регистр из введённого кода
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTwoFactorRouter - роутер с формой входа и вторым шагом
+func setupTwoFactorRouter(t *testing.T) (*gin.Engine, *Handlers) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	router.POST("/admin/login/2fa", h.LoginTwoFactor)
+	return router, h
+}
+
+// enableTestTwoFactor включает 2FA админу напрямую в БД и возвращает коды восстановления
+func enableTestTwoFactor(t *testing.T, h *Handlers, admin models.Admin) []string {
+	t.Helper()
+	assert.NoError(t, h.db.Model(&admin).Updates(map[string]interface{}{
+		"totp_secret":  rfcSecret,
+		"totp_enabled": true,
+	}).Error)
+	codes, err := replaceRecoveryCodes(h.db, admin.ID)
+	assert.NoError(t, err)
+	return codes
+}
+
+// currentTOTP - действующий код для тестового секрета
+func currentTOTP(t *testing.T) string {
+	t.Helper()
+	code, err := totpCode(rfcSecret, totpStep(time.Now()))
+	assert.NoError(t, err)
+	return code
+}
+
+// postTwoFactorCode отправляет код второго шага с challenge cookie
+func postTwoFactorCode(router *gin.Engine, challenge *http.Cookie, code string) *httptest.ResponseRecorder {
+	form := url.Values{"code": {code}}
+	req, _ := http.NewRequest("POST", "/admin/login/2fa", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "127.0.0.1:40000"
+	if challenge != nil {
+		req.AddCookie(&http.Cookie{Name: challenge.Name, Value: challenge.Value})
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// loginChallenge проходит первый шаг входа и возвращает cookie admin_2fa
+func loginChallenge(t *testing.T, router *gin.Engine, username, password string) *http.Cookie {
+	t.Helper()
+	w := postLoginForm(router, username, password)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "[2fa]")
+
+	var challenge *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		assert.NotEqual(t, accessCookieName, c.Name, "JWT не выдаётся до ввода кода")
+		if c.Name == twoFactorCookieName {
+			challenge = c
+		}
+	}
+	assert.NotNil(t, challenge)
+	return challenge
+}
+
+// ---------- Вход с 2FA ----------
+
+func TestLogin_TwoFactorStep(t *testing.T) {
+	router, h := setupTwoFactorRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	enableTestTwoFactor(t, h, admin)
+
+	challenge := loginChallenge(t, router, "admin", "secret123")
+
+	// Challenge-токен не подходит как access токен
+	_, err := ValidateJWT(challenge.Value)
+	assert.Error(t, err)
+
+	w := postTwoFactorCode(router, challenge, "000000")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "[2fa]", "после неверного кода остаётся форма кода")
+
+	w = postTwoFactorCode(router, challenge, currentTOTP(t))
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	var access *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == accessCookieName {
+			access = c
+		}
+	}
+	assert.NotNil(t, access)
+	claims, err := ValidateJWT(access.Value)
+	assert.NoError(t, err)
+	assert.NoError(t, h.CheckSession(claims))
+
+	var attempts []models.LoginAttempt
+	h.db.Order("id ASC").Find(&attempts)
+	assert.Len(t, attempts, 2, "первый шаг не пишется в журнал как успешный вход")
+	assert.Equal(t, LoginResultBad2FA, attempts[0].Result)
+	assert.Equal(t, LoginResultSuccess, attempts[1].Result)
+}
+
+func TestLogin_TwoFactorRejectsReplay(t *testing.T) {
+	router, h := setupTwoFactorRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	enableTestTwoFactor(t, h, admin)
+
+	code := currentTOTP(t)
+	w := postTwoFactorCode(router, loginChallenge(t, router, "admin", "secret123"), code)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	w = postTwoFactorCode(router, loginChallenge(t, router, "admin", "secret123"), code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "перехваченный код нельзя использовать повторно")
+}
+
+func TestLogin_TwoFactorRecoveryCodeSingleUse(t *testing.T) {
+	router, h := setupTwoFactorRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	codes := enableTestTwoFactor(t, h, admin)
+	assert.Len(t, codes, recoveryCodesCount)
+	assert.Len(t, strings.ReplaceAll(codes[0], "-", ""), 20, "код - 10 случайных байт")
+
+	var stored models.AdminRecoveryCode
+	h.db.First(&stored)
+	assert.Equal(t, hashToken(strings.ReplaceAll(codes[0], "-", "")), stored.CodeHash, "в БД хранится только хеш")
+
+	w := postTwoFactorCode(router, loginChallenge(t, router, "admin", "secret123"), strings.ToUpper(codes[0]))
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	w = postTwoFactorCode(router, loginChallenge(t, router, "admin", "secret123"), codes[0])
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogin_TwoFactorRequiresChallenge(t *testing.T) {
+	router, h := setupTwoFactorRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	enableTestTwoFactor(t, h, admin)
+
+	w := postTwoFactorCode(router, nil, currentTOTP(t))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/admin/login", w.Header().Get("Location"))
+
+	// Access токен нельзя подсунуть вместо challenge
+	other := createTestAdmin(t, h, "other", "secret123", true)
+	access, _ := generateJWT(other.ID, other.Username, "", "jti")
+	w = postTwoFactorCode(router, &http.Cookie{Name: twoFactorCookieName, Value: access}, currentTOTP(t))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogin_TwoFactorThrottled(t *testing.T) {
+	router, h := setupTwoFactorRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	enableTestTwoFactor(t, h, admin)
+
+	challenge := loginChallenge(t, router, "admin", "secret123")
+	addLoginAttempts(t, h, loginFreeAttempts, "admin", "198.51.100.10", LoginResultBad2FA, time.Now())
+
+	w := postTwoFactorCode(router, challenge, currentTOTP(t))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "неверные коды 2FA учитываются в защите от перебора")
+}
+
+// ---------- Настройка 2FA ----------
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	router.POST("/setup", withAdmin(admin.ID, models.RoleViewer), h.SetupTwoFactor)
+	router.POST("/enable", withAdmin(admin.ID, models.RoleViewer), h.EnableTwoFactor)
+
+	w := postUserForm(t, router, "/setup", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var setup struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+		QR     string `json:"qr"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+	assert.NotEmpty(t, setup.Secret)
+	assert.Contains(t, setup.URI, "secret="+setup.Secret)
+	assert.True(t, strings.HasPrefix(setup.QR, "data:image/png;base64,"))
+
+	w = postUserForm(t, router, "/enable", url.Values{"code": {"000000"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	code, _ := totpCode(setup.Secret, totpStep(time.Now()))
+	w = postUserForm(t, router, "/enable", url.Values{"code": {code}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var enable struct {
+		Codes []string `json:"codes"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &enable))
+	assert.Len(t, enable.Codes, recoveryCodesCount)
+
+	h.db.First(&admin, admin.ID)
+	assert.True(t, admin.TOTPEnabled)
+	assert.Equal(t, setup.Secret, admin.TOTPSecret)
+
+	// Повторная настройка не перезаписывает действующий секрет
+	w = postUserForm(t, router, "/setup", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDisableTwoFactor_RequiresPassword(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	enableTestTwoFactor(t, h, admin)
+	router.POST("/disable", withAdmin(admin.ID, models.RoleViewer), h.DisableTwoFactor)
+
+	w := postUserForm(t, router, "/disable", url.Values{"password": {"wrong"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postUserForm(t, router, "/disable", url.Values{"password": {"secret123"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	h.db.First(&admin, admin.ID)
+	assert.False(t, admin.TOTPEnabled)
+	assert.Empty(t, admin.TOTPSecret)
+
+	var codes int64
+	h.db.Model(&models.AdminRecoveryCode{}).Count(&codes)
+	assert.Zero(t, codes)
+}
+
+func TestResetTwoFactor_LoginWithPasswordOnly(t *testing.T) {
+	router, h := setupTwoFactorRouter(t)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	enableTestTwoFactor(t, h, admin)
+
+	// Сброс из cmd/create-admin для админа, потерявшего телефон
+	assert.NoError(t, ResetTwoFactor(h.db, admin.ID))
+
+	w := postLoginForm(router, "admin", "secret123")
+	assert.Equal(t, http.StatusFound, w.Code)
+}
@@ -10,7 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Смена статуса заявки (new | processed | archived)
+// Смена статуса заявки: этап воронки, "archived" или "spam".
+// Для этапа проигрыша обязательна lost_reason.
 func (h *Handlers) UpdateContactStatus(c *gin.Context) {
 	id, ok := mustID(c)
 	if !ok {
@@ -18,61 +19,49 @@ func (h *Handlers) UpdateContactStatus(c *gin.Context) {
 	}
 
 	var body struct {
-		Status string `json:"status"`
+		Status     string `json:"status"`
+		LostReason string `json:"lost_reason"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		jsonErr(c, http.StatusBadRequest, "Неверные данные")
 		return
 	}
-	status, valid := parseStatus(body.Status)
+	status, valid := h.parseStatus(body.Status)
 	if !valid {
 		jsonErr(c, http.StatusBadRequest, "Недопустимый статус")
 		return
 	}
 
-	now := NowMSKUTC()
-	var archivedAt *time.Time
-	if status == "archived" {
-		archivedAt = &now
-	}
-
-	if err := h.db.Model(&models.ContactForm{}).
-		Where("id = ?", id).
-		Updates(map[string]any{"status": status, "archived_at": archivedAt}).Error; err != nil {
-		jsonErr(c, http.StatusInternalServerError, "Не удалось обновить заявку")
+	if err := h.setContactsStatus([]uint{id}, status, body.LostReason, c.GetUint("admin_id")); err != nil {
+		statusChangeErr(c, err, "Не удалось обновить заявку")
 		return
 	}
+	h.linkCustomersByIDs([]uint{id})
 	jsonOK(c, gin.H{"message": "Статус изменён", "status": status})
 }
 
 // Массовая смена статуса
 func (h *Handlers) BulkUpdateContacts(c *gin.Context) {
 	var req struct {
-		Action string `json:"action"`
-		IDs    []uint `json:"ids"`
+		Action     string `json:"action"`
+		IDs        []uint `json:"ids"`
+		LostReason string `json:"lost_reason"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
 		jsonErr(c, http.StatusBadRequest, "Неверные данные")
 		return
 	}
-	action, valid := parseStatus(req.Action)
+	action, valid := h.parseStatus(req.Action)
 	if !valid {
 		jsonErr(c, http.StatusBadRequest, "Недопустимое действие")
 		return
 	}
 
-	now := NowMSKUTC()
-	var archivedAt *time.Time
-	if action == "archived" {
-		archivedAt = &now
-	}
-
-	if err := h.db.Model(&models.ContactForm{}).
-		Where("id IN ?", req.IDs).
-		Updates(map[string]any{"status": action, "archived_at": archivedAt}).Error; err != nil {
-		jsonErr(c, http.StatusInternalServerError, "Не удалось обновить заявки")
+	if err := h.setContactsStatus(req.IDs, action, req.LostReason, c.GetUint("admin_id")); err != nil {
+		statusChangeErr(c, err, "Не удалось обновить заявки")
 		return
 	}
+	h.linkCustomersByIDs(req.IDs)
 	jsonOK(c, gin.H{"success": true, "action": action, "ids": req.IDs})
 }
 
@@ -83,17 +72,14 @@ func (h *Handlers) ArchiveContact(c *gin.Context) {
 		return
 	}
 
-	now := NowMSKUTC()
-	if err := h.db.Model(&models.ContactForm{}).
-		Where("id = ?", id).
-		Updates(map[string]any{"status": "archived", "archived_at": &now}).Error; err != nil {
+	if err := h.setContactsStatus([]uint{id}, "archived", "", c.GetUint("admin_id")); err != nil {
 		jsonErr(c, http.StatusInternalServerError, "Не удалось архивировать")
 		return
 	}
 	jsonOK(c, gin.H{"message": "В архиве", "status": "archived"})
 }
 
-// Восстановить из архива
+// Восстановить из архива в этап воронки (по умолчанию - "new")
 func (h *Handlers) RestoreContact(c *gin.Context) {
 	id, ok := mustID(c)
 	if !ok {
@@ -102,18 +88,16 @@ func (h *Handlers) RestoreContact(c *gin.Context) {
 
 	var body struct {
 		To string `json:"to"`
-	} // допустимо: "new" | "processed"
+	} // этап воронки, кроме этапов проигрыша (для них нужна причина)
 	// Игнорируем ошибку binding - используем значение по умолчанию если не передано
 	if err := c.ShouldBindJSON(&body); err != nil {
 		body.To = "new" // default value
 	}
-	if body.To != "processed" {
+	if stage, ok := h.findStage(body.To); !ok || stage.Kind == models.StageLost {
 		body.To = "new"
 	}
 
-	if err := h.db.Model(&models.ContactForm{}).
-		Where("id = ?", id).
-		Updates(map[string]any{"status": body.To, "archived_at": nil}).Error; err != nil {
+	if err := h.setContactsStatus([]uint{id}, body.To, "", c.GetUint("admin_id")); err != nil {
 		jsonErr(c, http.StatusInternalServerError, "Не удалось восстановить")
 		return
 	}
@@ -137,10 +121,7 @@ func (h *Handlers) DeleteContact(c *gin.Context) {
 		return
 	}
 	// мягкий архив
-	now := NowMSKUTC()
-	if err := h.db.Model(&models.ContactForm{}).
-		Where("id = ?", id).
-		Updates(map[string]any{"status": "archived", "archived_at": &now}).Error; err != nil {
+	if err := h.setContactsStatus([]uint{id}, "archived", "", c.GetUint("admin_id")); err != nil {
 		jsonErr(c, http.StatusInternalServerError, "Не удалось архивировать")
 		return
 	}
@@ -190,6 +171,7 @@ func (h *Handlers) CreateContactNote(c *gin.Context) {
 		jsonErr(c, http.StatusInternalServerError, "Не удалось создать заметку")
 		return
 	}
+	setAuditEntityID(c, note.ID)
 	jsonOK(c, gin.H{"note": note})
 }
 
@@ -217,6 +199,17 @@ func (h *Handlers) DeleteContactNote(c *gin.Context) {
 	jsonOK(c, gin.H{"message": "Заметка удалена", "id": noteID})
 }
 
+// parseRemindAt разбирает время напоминания: RFC3339, затем "2006-01-02 15:04" по МСК. Результат в UTC
+func parseRemindAt(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), true
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, moscowLoc); err == nil {
+		return t.UTC(), true
+	}
+	return time.Time{}, false
+}
+
 // Установить/снять напоминание
 func (h *Handlers) UpdateContactReminder(c *gin.Context) {
 	contactID, ok := mustID(c)
@@ -240,17 +233,12 @@ func (h *Handlers) UpdateContactReminder(c *gin.Context) {
 		// очистка напоминания
 		flag = false
 	} else {
-		// пробуем RFC3339, затем "2006-01-02 15:04" в МСК
-		if t, err := time.Parse(time.RFC3339, body.RemindAt); err == nil {
-			tt := t.UTC()
-			remindAtPtr = &tt
-		} else if t2, err2 := time.ParseInLocation("2006-01-02 15:04", body.RemindAt, moscowLoc); err2 == nil {
-			tt := t2.UTC()
-			remindAtPtr = &tt
-		} else {
+		t, ok := parseRemindAt(body.RemindAt)
+		if !ok {
 			jsonErr(c, http.StatusBadRequest, "Неверный формат даты напоминания")
 			return
 		}
+		remindAtPtr = &t
 		flag = true
 	}
 
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,7 +18,8 @@ import (
 
 // LoginPageData - данные для страницы входа
 type LoginPageData struct {
-	Error string
+	Error     string
+	TwoFactor bool // показать второй шаг (код 2FA) вместо формы пароля
 }
 
 // LoginRequest - данные из формы входа
@@ -27,6 +32,7 @@ type LoginRequest struct {
 type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -45,9 +51,18 @@ func (h *Handlers) Login(c *gin.Context) {
 		return
 	}
 
+	ip := c.ClientIP()
+	userAgent := c.Request.UserAgent()
+
+	// Защита от перебора: задержка и блокировка проверяются до bcrypt
+	if h.rejectThrottledLogin(c, req.Username, false) {
+		return
+	}
+
 	// Ищем пользователя в базе данных
 	var admin models.Admin
 	if err := h.db.Where("username = ?", req.Username).First(&admin).Error; err != nil {
+		h.recordLoginAttempt(req.Username, ip, userAgent, LoginResultUnknownUser)
 		c.HTML(http.StatusUnauthorized, "admin_login.html", LoginPageData{
 			Error: "Неверное имя пользователя или пароль",
 		})
@@ -56,6 +71,7 @@ func (h *Handlers) Login(c *gin.Context) {
 
 	// Проверяем активность пользователя
 	if !admin.IsActive {
+		h.recordLoginAttempt(req.Username, ip, userAgent, LoginResultInactive)
 		c.HTML(http.StatusUnauthorized, "admin_login.html", LoginPageData{
 			Error: "Аккаунт деактивирован",
 		})
@@ -64,91 +80,123 @@ func (h *Handlers) Login(c *gin.Context) {
 
 	// Проверяем пароль
 	if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(req.Password)); err != nil {
+		h.recordLoginAttempt(req.Username, ip, userAgent, LoginResultBadPassword)
 		c.HTML(http.StatusUnauthorized, "admin_login.html", LoginPageData{
 			Error: "Неверное имя пользователя или пароль",
 		})
 		return
 	}
 
+	// Второй шаг: код из приложения-аутентификатора (JWT выдаётся только после него)
+	if admin.TOTPEnabled {
+		if err := startTwoFactor(c, admin); err != nil {
+			log.Printf("Ошибка создания 2FA challenge для '%s': %v", admin.Username, err)
+			c.HTML(http.StatusInternalServerError, "admin_login.html", LoginPageData{
+				Error: "Ошибка создания сессии",
+			})
+			return
+		}
+		c.HTML(http.StatusOK, "admin_login.html", LoginPageData{TwoFactor: true})
+		return
+	}
+
+	h.completeLogin(c, admin)
+}
+
+// completeLogin завершает вход после всех проверок: журнал, время входа, сессия, редирект
+func (h *Handlers) completeLogin(c *gin.Context, admin models.Admin) {
+	h.recordLoginAttempt(admin.Username, c.ClientIP(), c.Request.UserAgent(), LoginResultSuccess)
+
 	// Обновляем время последнего входа
 	now := time.Now()
-	admin.LastLoginAt = &now
-	h.db.Save(&admin)
+	h.db.Model(&admin).Update("last_login_at", now)
 
-	// Создаём JWT токен
-	token, err := generateJWT(admin.ID, admin.Username)
-	if err != nil {
+	// Создаём серверную сессию и выставляем cookie access/refresh токенов
+	if err := h.startSession(c, admin); err != nil {
+		log.Printf("Ошибка создания сессии для '%s': %v", admin.Username, err)
 		c.HTML(http.StatusInternalServerError, "admin_login.html", LoginPageData{
 			Error: "Ошибка создания сессии",
 		})
 		return
 	}
 
-	// Устанавливаем cookie с токеном
-	// Secure флаг включается автоматически в production для защиты токена через HTTPS
-	secure := os.Getenv("ENVIRONMENT") == "production"
-	c.SetCookie(
-		"admin_token", // название cookie
-		token,         // значение токена
-		3600*24*7,     // время жизни 7 дней (в секундах)
-		"/",           // путь
-		"",            // домен (пустая строка = текущий домен)
-		secure,        // secure (автоматически true в production)
-		true,          // httpOnly (защита от XSS)
-	)
-
 	// Перенаправляем на главную страницу админки
 	c.Redirect(http.StatusFound, "/admin")
 }
 
-// Logout выход из системы
+// Logout выход из системы: отзыв текущей сессии и удаление cookie
 func (h *Handlers) Logout(c *gin.Context) {
-	// Удаляем cookie
-	c.SetCookie(
-		"admin_token",
-		"",
-		-1, // время жизни -1 = удалить
-		"/",
-		"",
-		false,
-		true,
-	)
+	h.revokeCurrentSession(c)
+	ClearAuthCookies(c)
 
 	// Перенаправляем на страницу входа
 	c.Redirect(http.StatusFound, "/admin/login")
 }
 
-// generateJWT создаёт JWT токен для пользователя
-func generateJWT(userID uint, username string) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-in-production" // fallback для разработки
+// rejectThrottledLogin отвечает 429, если для логина действует задержка или блокировка.
+// twoFactor - запрос со второго шага входа (форма кода вместо формы пароля).
+func (h *Handlers) rejectThrottledLogin(c *gin.Context, username string, twoFactor bool) bool {
+	wait, locked := h.loginRetryAfter(username, c.ClientIP(), time.Now())
+	if wait <= 0 {
+		return false
+	}
+
+	result, msg := LoginResultThrottled, "Слишком много попыток входа. Повторите через "+formatWait(wait)
+	if locked {
+		result, msg = LoginResultLocked, "Вход временно заблокирован из-за неудачных попыток. Повторите через "+formatWait(wait)
 	}
+	h.recordLoginAttempt(username, c.ClientIP(), c.Request.UserAgent(), result)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.HTML(http.StatusTooManyRequests, "admin_login.html", LoginPageData{Error: msg, TwoFactor: twoFactor && !locked})
+	return true
+}
+
+// formatWait форматирует время ожидания для сообщения на странице входа
+func formatWait(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d сек.", int(math.Ceil(d.Seconds())))
+	}
+	return fmt.Sprintf("%d мин.", int(math.Ceil(d.Minutes())))
+}
 
-	claims := JWTClaims{
+// Издатели JWT: access токен сессии и промежуточный токен второго шага входа.
+// ValidateJWT принимает только access токены.
+const (
+	jwtIssuerAccess    = "ledsite-admin"
+	jwtIssuerTwoFactor = "ledsite-admin-2fa"
+)
+
+// generateJWT создаёт короткоживущий access токен сессии jti
+func generateJWT(userID uint, username, role, jti string) (string, error) {
+	return signJWT(JWTClaims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // 7 дней
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "ledsite-admin",
+			Issuer:    jwtIssuerAccess,
 		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	})
 }
 
 // ValidateJWT проверяет JWT токен и возвращает claims
 func ValidateJWT(tokenString string) (*JWTClaims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-in-production"
-	}
+	return parseJWT(tokenString, jwtIssuerAccess)
+}
 
+// signJWT подписывает claims секретом JWT_SECRET (HS256)
+func signJWT(claims JWTClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(jwtSecret()))
+}
+
+// parseJWT проверяет подпись, срок действия и издателя токена
+func parseJWT(tokenString, issuer string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+		return []byte(jwtSecret()), nil
+	}, jwt.WithIssuer(issuer), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -161,6 +209,15 @@ func ValidateJWT(tokenString string) (*JWTClaims, error) {
 	return nil, jwt.ErrSignatureInvalid
 }
 
+// jwtSecret возвращает секрет подписи JWT
+func jwtSecret() string {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "your-secret-key-change-in-production" // fallback для разработки
+	}
+	return secret
+}
+
 // HashPassword хеширует пароль с использованием bcrypt
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
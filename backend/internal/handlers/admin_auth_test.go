@@ -23,7 +23,7 @@ func setupAuthRouter(t *testing.T) (*gin.Engine, *Handlers) {
 	router, h := setupTestRouter(t)
 
 	// Минимальный шаблон для c.HTML() — без него Gin паникует
-	tmpl := template.Must(template.New("admin_login.html").Parse(`{{.Error}}`))
+	tmpl := template.Must(template.New("admin_login.html").Parse(`{{.Error}}{{if .TwoFactor}}[2fa]{{end}}`))
 	router.SetHTMLTemplate(tmpl)
 
 	return router, h
@@ -103,6 +103,26 @@ func TestLogin_Success(t *testing.T) {
 	assert.NotEmpty(t, tokenCookie.Value)
 }
 
+func TestLogin_TokenCarriesRole(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	admin := createTestAdmin(t, h, "manager", "secret123", true)
+	h.db.Model(&admin).Update("role", models.RoleManager)
+
+	w := postLoginForm(router, "manager", "secret123")
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	var token string
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "admin_token" {
+			token = c.Value
+		}
+	}
+	claims, err := ValidateJWT(token)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleManager, claims.Role)
+}
+
 func TestLogin_UpdatesLastLoginAt(t *testing.T) {
 	router, h := setupAuthRouter(t)
 	router.POST("/admin/login", h.Login)
@@ -251,7 +271,7 @@ func TestGenerateJWT_Valid(t *testing.T) {
 	os.Setenv("JWT_SECRET", "test-secret")
 	defer os.Unsetenv("JWT_SECRET")
 
-	tokenStr, err := generateJWT(1, "admin")
+	tokenStr, err := generateJWT(1, "admin", models.RoleOwner, "jti-1")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, tokenStr)
 
@@ -260,30 +280,32 @@ func TestGenerateJWT_Valid(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, uint(1), claims.UserID)
 	assert.Equal(t, "admin", claims.Username)
+	assert.Equal(t, models.RoleOwner, claims.Role)
 	assert.Equal(t, "ledsite-admin", claims.Issuer)
 }
 
 func TestGenerateJWT_FallbackSecret(t *testing.T) {
 	os.Unsetenv("JWT_SECRET")
 
-	tokenStr, err := generateJWT(1, "admin")
+	tokenStr, err := generateJWT(1, "admin", models.RoleOwner, "jti-1")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, tokenStr)
 }
 
-func TestGenerateJWT_ExpiresIn7Days(t *testing.T) {
+func TestGenerateJWT_ShortLived(t *testing.T) {
 	os.Setenv("JWT_SECRET", "test-secret")
 	defer os.Unsetenv("JWT_SECRET")
 
-	tokenStr, err := generateJWT(1, "admin")
+	tokenStr, err := generateJWT(1, "admin", models.RoleOwner, "jti-1")
 	assert.NoError(t, err)
 
 	claims, err := ValidateJWT(tokenStr)
 	assert.NoError(t, err)
 
-	// Проверяем что токен истекает примерно через 7 дней (±1 минута)
-	expectedExpiry := time.Now().Add(7 * 24 * time.Hour)
+	// Access токен короткоживущий, продлевается через refresh (±1 минута)
+	expectedExpiry := time.Now().Add(accessTokenTTL)
 	assert.InDelta(t, expectedExpiry.Unix(), claims.ExpiresAt.Unix(), 60)
+	assert.Equal(t, "jti-1", claims.ID, "JTI связывает токен с серверной сессией")
 }
 
 // ---------- ValidateJWT ----------
@@ -292,7 +314,7 @@ func TestValidateJWT_Valid(t *testing.T) {
 	os.Setenv("JWT_SECRET", "test-secret")
 	defer os.Unsetenv("JWT_SECRET")
 
-	tokenStr, _ := generateJWT(42, "testuser")
+	tokenStr, _ := generateJWT(42, "testuser", models.RoleOwner, "jti-42")
 	claims, err := ValidateJWT(tokenStr)
 
 	assert.NoError(t, err)
@@ -302,7 +324,7 @@ func TestValidateJWT_Valid(t *testing.T) {
 
 func TestValidateJWT_InvalidSignature(t *testing.T) {
 	os.Setenv("JWT_SECRET", "secret-one")
-	tokenStr, _ := generateJWT(1, "admin")
+	tokenStr, _ := generateJWT(1, "admin", models.RoleOwner, "jti-1")
 
 	// Валидируем с другим секретом
 	os.Setenv("JWT_SECRET", "secret-two")
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// categoryWithCount - категория с количеством привязанных проектов (для админки)
+type categoryWithCount struct {
+	models.Category
+	ProjectsCount int64 `json:"projects_count"`
+}
+
+// AdminCategoriesPage — страница управления категориями проектов
+func (h *Handlers) AdminCategoriesPage(c *gin.Context) {
+	var categories []models.Category
+	if err := h.db.Order("sort_order ASC, id ASC").Find(&categories).Error; err != nil {
+		log.Printf("Ошибка загрузки категорий: %v", err)
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
+			"PageID": "admin-error",
+			"error":  "Ошибка загрузки данных",
+		})
+		return
+	}
+
+	items := make([]categoryWithCount, 0, len(categories))
+	for _, cat := range categories {
+		items = append(items, categoryWithCount{
+			Category:      cat,
+			ProjectsCount: h.categoryProjectsCount(cat.ID),
+		})
+	}
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":      "Категории проектов",
+		"PageID":     "admin-categories",
+		"Categories": items,
+	})
+}
+
+// CreateCategory — создание новой категории
+func (h *Handlers) CreateCategory(c *gin.Context) {
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		jsonErr(c, http.StatusBadRequest, "Название не может быть пустым")
+		return
+	}
+
+	category := models.Category{
+		Name:        name,
+		Description: strings.TrimSpace(c.PostForm("description")),
+		Slug:        h.uniqueSlug(&models.Category{}, generateSlug(name)),
+	}
+
+	// Новая категория встаёт в конец списка
+	var maxOrder int
+	h.db.Model(&models.Category{}).Select("COALESCE(MAX(sort_order), -1)").Scan(&maxOrder)
+	category.SortOrder = maxOrder + 1
+
+	if err := h.db.Create(&category).Error; err != nil {
+		log.Printf("Ошибка создания категории '%s': %v", name, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка создания категории")
+		return
+	}
+	setAuditEntityID(c, category.ID)
+
+	jsonOK(c, gin.H{
+		"message":  "Категория успешно создана",
+		"category": category,
+	})
+}
+
+// GetCategory — получение категории для редактирования (JSON)
+func (h *Handlers) GetCategory(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var category models.Category
+	if err := h.db.First(&category, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Категория не найдена")
+		return
+	}
+
+	jsonOK(c, gin.H{
+		"category":       category,
+		"projects_count": h.categoryProjectsCount(category.ID),
+	})
+}
+
+// UpdateCategory — переименование категории.
+// Slug не меняется, чтобы не ломать ссылки вида /projects?category=slug.
+func (h *Handlers) UpdateCategory(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var category models.Category
+	if err := h.db.First(&category, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Категория не найдена")
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		jsonErr(c, http.StatusBadRequest, "Название не может быть пустым")
+		return
+	}
+
+	category.Name = name
+	category.Description = strings.TrimSpace(c.PostForm("description"))
+
+	if err := h.db.Save(&category).Error; err != nil {
+		log.Printf("Ошибка обновления категории ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка обновления категории")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Категория успешно обновлена"})
+}
+
+// DeleteCategory — удаление категории.
+//
+// Если к категории привязаны проекты, без параметра reassign_to возвращается 409
+// с количеством проектов. С reassign_to=<id> проекты переносятся в указанную
+// категорию (без дублей связей), после чего категория удаляется.
+func (h *Handlers) DeleteCategory(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var category models.Category
+	if err := h.db.First(&category, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Категория не найдена")
+		return
+	}
+
+	projectsCount := h.categoryProjectsCount(category.ID)
+
+	var targetID uint
+	if raw := c.Query("reassign_to"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || uint(parsed) == category.ID {
+			jsonErr(c, http.StatusBadRequest, "Некорректная категория для переноса")
+			return
+		}
+		var target models.Category
+		if err := h.db.First(&target, parsed).Error; err != nil {
+			jsonErr(c, http.StatusBadRequest, "Категория для переноса не найдена")
+			return
+		}
+		targetID = target.ID
+	}
+
+	if projectsCount > 0 && targetID == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          "К категории привязаны проекты. Выберите категорию для переноса",
+			"projects_count": projectsCount,
+		})
+		return
+	}
+
+	tx := h.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if targetID != 0 {
+		// Переносим связи, пропуская проекты, которые уже есть в целевой категории
+		if err := tx.Exec(`
+			INSERT INTO project_categories (project_id, category_id)
+			SELECT project_id, ? FROM project_categories
+			WHERE category_id = ?
+			  AND project_id NOT IN (SELECT project_id FROM project_categories WHERE category_id = ?)`,
+			targetID, category.ID, targetID).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Ошибка переноса проектов из категории ID=%d в ID=%d: %v", id, targetID, err)
+			jsonErr(c, http.StatusInternalServerError, "Ошибка переноса проектов")
+			return
+		}
+	}
+
+	if err := tx.Exec("DELETE FROM project_categories WHERE category_id = ?", category.ID).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Ошибка удаления связей категории ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка удаления категории")
+		return
+	}
+
+	if err := tx.Delete(&category).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Ошибка удаления категории ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка удаления категории")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Ошибка коммита удаления категории ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка удаления категории")
+		return
+	}
+
+	jsonOK(c, gin.H{
+		"message":     "Категория успешно удалена",
+		"reassigned":  projectsCount,
+		"reassign_to": targetID,
+	})
+}
+
+// UpdateCategoriesSorting — обновление порядка категорий (drag & drop)
+func (h *Handlers) UpdateCategoriesSorting(c *gin.Context) {
+	var req struct {
+		IDs []uint `json:"ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Некорректные данные")
+		return
+	}
+
+	for i, id := range req.IDs {
+		if err := h.db.Model(&models.Category{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+			log.Printf("Ошибка обновления порядка для категории ID=%d: %v", id, err)
+		}
+	}
+
+	jsonOK(c, gin.H{"message": "Порядок успешно обновлен"})
+}
+
+// categoryProjectsCount возвращает количество проектов, привязанных к категории
+func (h *Handlers) categoryProjectsCount(categoryID uint) int64 {
+	var count int64
+	h.db.Table("project_categories").Where("category_id = ?", categoryID).Count(&count)
+	return count
+}
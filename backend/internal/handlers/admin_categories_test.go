@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"ledsite/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// postCategoryForm отправляет form-urlencoded запрос на роут категорий
+func postCategoryForm(t *testing.T, router http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// ---------- CreateCategory ----------
+
+func TestCreateCategory_Success(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/categories", h.CreateCategory)
+
+	h.db.Create(&models.Category{Name: "Существующая", Slug: "existing", SortOrder: 4})
+
+	w := postCategoryForm(t, router, "/admin/categories", url.Values{
+		"name":        {"Медиафасады"},
+		"description": {"Фасадные экраны"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var cat models.Category
+	h.db.Where("name = ?", "Медиафасады").First(&cat)
+	assert.True(t, strings.HasPrefix(cat.Slug, "mediafasady-"), "slug генерируется через generateSlug: %s", cat.Slug)
+	assert.Equal(t, "Фасадные экраны", cat.Description)
+	assert.Equal(t, 5, cat.SortOrder, "новая категория встаёт в конец списка")
+}
+
+func TestCreateCategory_EmptyName(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/categories", h.CreateCategory)
+
+	w := postCategoryForm(t, router, "/admin/categories", url.Values{"name": {"  "}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUniqueSlug_AddsSuffix(t *testing.T) {
+	_, h := setupTestRouter(t)
+
+	h.db.Create(&models.Category{Name: "A", Slug: "outdoor"})
+	h.db.Create(&models.Category{Name: "B", Slug: "outdoor-2"})
+
+	assert.Equal(t, "outdoor-3", h.uniqueSlug(&models.Category{}, "outdoor"))
+	assert.Equal(t, "indoor", h.uniqueSlug(&models.Category{}, "indoor"))
+}
+
+// ---------- UpdateCategory ----------
+
+func TestUpdateCategory_KeepsSlug(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/categories/:id/update", h.UpdateCategory)
+
+	cat := models.Category{Name: "Старое", Slug: "old-slug"}
+	h.db.Create(&cat)
+
+	w := postCategoryForm(t, router, fmt.Sprintf("/admin/categories/%d/update", cat.ID), url.Values{
+		"name": {"Новое"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.Category
+	h.db.First(&updated, cat.ID)
+	assert.Equal(t, "Новое", updated.Name)
+	assert.Equal(t, "old-slug", updated.Slug)
+}
+
+func TestUpdateCategory_NotFound(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/categories/:id/update", h.UpdateCategory)
+
+	w := postCategoryForm(t, router, "/admin/categories/999/update", url.Values{"name": {"X"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// ---------- DeleteCategory ----------
+
+func TestDeleteCategory_Empty(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.DELETE("/admin/categories/:id", h.DeleteCategory)
+
+	cat := models.Category{Name: "Пустая", Slug: "empty"}
+	h.db.Create(&cat)
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/categories/%d", cat.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var count int64
+	h.db.Model(&models.Category{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDeleteCategory_LinkedWithoutReassign(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.DELETE("/admin/categories/:id", h.DeleteCategory)
+
+	cat := models.Category{Name: "С проектами", Slug: "linked"}
+	h.db.Create(&cat)
+	project := models.Project{Title: "Проект", Slug: "project"}
+	h.db.Create(&project)
+	h.db.Model(&project).Association("Categories").Append(&cat)
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/categories/%d", cat.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, float64(1), resp["projects_count"])
+
+	var count int64
+	h.db.Model(&models.Category{}).Where("id = ?", cat.ID).Count(&count)
+	assert.Equal(t, int64(1), count, "категория не должна удаляться")
+}
+
+func TestDeleteCategory_Reassign(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.DELETE("/admin/categories/:id", h.DeleteCategory)
+
+	from := models.Category{Name: "Старая", Slug: "from"}
+	to := models.Category{Name: "Новая", Slug: "to"}
+	h.db.Create(&from)
+	h.db.Create(&to)
+
+	onlyFrom := models.Project{Title: "Только в старой", Slug: "only-from"}
+	both := models.Project{Title: "В обеих", Slug: "both"}
+	h.db.Create(&onlyFrom)
+	h.db.Create(&both)
+	h.db.Model(&onlyFrom).Association("Categories").Append(&from)
+	h.db.Model(&both).Association("Categories").Append(&from, &to)
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/categories/%d?reassign_to=%d", from.ID, to.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var fromCount int64
+	h.db.Model(&models.Category{}).Where("id = ?", from.ID).Count(&fromCount)
+	assert.Equal(t, int64(0), fromCount)
+
+	// Оба проекта теперь в целевой категории, без дублей связей
+	assert.Equal(t, int64(2), h.categoryProjectsCount(to.ID))
+	assert.Equal(t, int64(0), h.categoryProjectsCount(from.ID))
+}
+
+func TestDeleteCategory_ReassignToSelf(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.DELETE("/admin/categories/:id", h.DeleteCategory)
+
+	cat := models.Category{Name: "Категория", Slug: "self"}
+	h.db.Create(&cat)
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/categories/%d?reassign_to=%d", cat.ID, cat.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// ---------- UpdateCategoriesSorting ----------
+
+func TestUpdateCategoriesSorting_Success(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/categories/sort", h.UpdateCategoriesSorting)
+
+	a := models.Category{Name: "A", Slug: "a"}
+	b := models.Category{Name: "B", Slug: "b"}
+	h.db.Create(&a)
+	h.db.Create(&b)
+
+	body, _ := json.Marshal(map[string][]uint{"ids": {b.ID, a.ID}})
+	req, _ := http.NewRequest("POST", "/admin/categories/sort", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var ordered []models.Category
+	h.db.Order("sort_order ASC").Find(&ordered)
+	assert.Equal(t, "b", ordered[0].Slug)
+	assert.Equal(t, "a", ordered[1].Slug)
+}
@@ -21,11 +21,11 @@ func (h *Handlers) AdminDashboard(c *gin.Context) {
 
 	h.db.Model(&models.Project{}).Count(&stats.ProjectsCount)
 	h.db.Model(&models.Image{}).Count(&stats.ImagesCount)
-	h.db.Model(&models.ContactForm{}).Count(&stats.ContactsCount)
+	h.db.Model(&models.ContactForm{}).Where("status <> ?", "spam").Count(&stats.ContactsCount)
 
-	// заявки за 7 дней
+	// заявки за 7 дней (без спама)
 	var newContacts7 int
-	h.db.Raw(`SELECT COUNT(*) FROM contact_forms WHERE created_at >= NOW() - INTERVAL '7 days'`).Scan(&newContacts7)
+	h.db.Raw(`SELECT COUNT(*) FROM contact_forms WHERE created_at >= NOW() - INTERVAL '7 days' AND status <> 'spam'`).Scan(&newContacts7)
 
 	// --- ПЕРЕЗВОНЫ (сегодня / просрочено / ближайшие) ---
 	now := NowMSK()
@@ -44,6 +44,13 @@ func (h *Handlers) AdminDashboard(c *gin.Context) {
 			true, now.UTC()).
 		Count(&remindOverdue)
 
+	// --- ВОРОНКА ПРОДАЖ (заявки за funnelPeriodDays дней) ---
+	funnel, funnelTotal, lostReasons := h.pipelineFunnel(now.AddDate(0, 0, -funnelPeriodDays).UTC())
+
+	// --- ЗАЯВКИ ПО ИСТОЧНИКАМ (за тот же период; ?touch=first - по первому визиту) ---
+	firstTouch := c.Query("touch") == "first"
+	sources := h.leadSources(now.AddDate(0, 0, -funnelPeriodDays).UTC(), firstTouch)
+
 	// Топ-5 проектов
 	type TopProject struct {
 		ProjectID   uint   `gorm:"column:project_id"`
@@ -117,6 +124,12 @@ func (h *Handlers) AdminDashboard(c *gin.Context) {
 		}
 	}
 
+	// --- ПОПЫТКИ ВХОДА (только владельцу) ---
+	var logins gin.H
+	if RoleHasPermission(c.GetString("admin_role"), PermUsersManage) {
+		logins = h.loginAttemptsSummary(time.Now())
+	}
+
 	sys := gin.H{
 		"dbOK":      dbOK,
 		"env":       os.Getenv("ENVIRONMENT"),
@@ -124,7 +137,7 @@ func (h *Handlers) AdminDashboard(c *gin.Context) {
 		"serverNow": time.Now(),
 	}
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"title":  "Админ панель",
 		"PageID": "admin-dashboard",
 
@@ -136,30 +149,80 @@ func (h *Handlers) AdminDashboard(c *gin.Context) {
 			"overdue": remindOverdue,
 		},
 
+		"funnel": gin.H{
+			"stages":      funnel,
+			"total":       funnelTotal,
+			"lostReasons": lostReasons,
+			"days":        funnelPeriodDays,
+		},
+
+		"sources": gin.H{
+			"rows":  sources,
+			"first": firstTouch,
+			"days":  funnelPeriodDays,
+		},
+
 		"analytics": gin.H{
 			"topProjects":   topProjects,
 			"topPriceItems": topPriceItems,
 			"views30":       views30,
 		},
 
+		"logins": logins,
+
 		"sys": sys,
 	})
 
 }
 
+// loginAttemptRow - строка журнала попыток входа на dashboard
+type loginAttemptRow struct {
+	models.LoginAttempt
+	ResultTitle string
+}
+
+// loginAttemptsSummary - сводка попыток входа за 24 часа и последние неудачные попытки
+func (h *Handlers) loginAttemptsSummary(now time.Time) gin.H {
+	since := now.Add(-24 * time.Hour)
+
+	var success, failed, lockouts, rejected int64
+	h.db.Model(&models.LoginAttempt{}).Where("result = ? AND created_at > ?", LoginResultSuccess, since).Count(&success)
+	h.db.Model(&models.LoginAttempt{}).Where("result IN ? AND created_at > ?", loginFailureResults, since).Count(&failed)
+	h.db.Model(&models.LoginAttempt{}).Where("lockout = ? AND created_at > ?", true, since).Count(&lockouts)
+	h.db.Model(&models.LoginAttempt{}).
+		Where("result IN ? AND created_at > ?", []string{LoginResultThrottled, LoginResultLocked}, since).
+		Count(&rejected)
+
+	var recent []models.LoginAttempt
+	h.db.Where("result <> ?", LoginResultSuccess).Order("created_at DESC").Limit(10).Find(&recent)
+
+	rows := make([]loginAttemptRow, 0, len(recent))
+	for _, a := range recent {
+		rows = append(rows, loginAttemptRow{LoginAttempt: a, ResultTitle: loginResultTitles[a.Result]})
+	}
+
+	return gin.H{
+		"success":  success,
+		"failed":   failed,
+		"lockouts": lockouts,
+		"rejected": rejected,
+		"recent":   rows,
+	}
+}
+
 // AdminProjects - управление проектами
 func (h *Handlers) AdminProjects(c *gin.Context) {
 	var projects []models.Project
 	h.db.Preload("Categories").Preload("Images").Order("sort_order ASC, created_at DESC").Find(&projects)
 
 	var categories []models.Category
-	h.db.Find(&categories)
+	h.db.Order("sort_order ASC, id ASC").Find(&categories)
 
 	// Подсчитываем количество проектов отмеченных для показа на главной
 	var featuredCount int64
 	h.db.Model(&models.Project{}).Where("featured = ?", true).Count(&featuredCount)
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"title":         "Управление проектами",
 		"projects":      projects,
 		"categories":    categories,
@@ -184,7 +247,7 @@ func (h *Handlers) AdminContacts7Days(c *gin.Context) {
 		SELECT to_char(d.day, 'YYYY-MM-DD') AS day,
 		       COALESCE(COUNT(cf.id), 0)::int AS count
 		FROM d
-		LEFT JOIN contact_forms cf ON DATE(cf.created_at) = d.day
+		LEFT JOIN contact_forms cf ON DATE(cf.created_at) = d.day AND cf.status <> 'spam'
 		GROUP BY d.day
 		ORDER BY d.day;
 	`).Scan(&out)
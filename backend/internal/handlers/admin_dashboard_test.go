@@ -18,9 +18,9 @@ func TestAdminContacts7Days_EmptyDatabase(t *testing.T) {
 	t.Skip("Пропуск: AdminContacts7Days использует PostgreSQL-специфичный SQL (generate_series, INTERVAL)")
 
 	router, h := setupTestRouter(t)
-	router.GET("/api/admin/contacts-7d", h.AdminContacts7Days)
+	router.GET("/admin/api/contacts-7d", h.AdminContacts7Days)
 
-	req, _ := http.NewRequest("GET", "/api/admin/contacts-7d", nil)
+	req, _ := http.NewRequest("GET", "/admin/api/contacts-7d", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -46,7 +46,7 @@ func TestAdminContacts7Days_WithData(t *testing.T) {
 	t.Skip("Пропуск: AdminContacts7Days использует PostgreSQL-специфичный SQL (generate_series, INTERVAL)")
 
 	router, h := setupTestRouter(t)
-	router.GET("/api/admin/contacts-7d", h.AdminContacts7Days)
+	router.GET("/admin/api/contacts-7d", h.AdminContacts7Days)
 
 	// Создаем заявки за разные дни
 	now := time.Now()
@@ -91,7 +91,7 @@ func TestAdminContacts7Days_WithData(t *testing.T) {
 	}
 	h.db.Create(&oldContact)
 
-	req, _ := http.NewRequest("GET", "/api/admin/contacts-7d", nil)
+	req, _ := http.NewRequest("GET", "/admin/api/contacts-7d", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -57,21 +57,25 @@ func NowMSKUTC() time.Time {
 
 // applyDateFilter — применяет фильтр дат к запросу
 func applyDateFilter(qb *gorm.DB, dateRange string) *gorm.DB {
-	if dateRange == "" {
-		return qb
+	if sql, args, ok := dateRangeCond(dateRange, NowMSK()); ok {
+		return qb.Where(sql, args...)
 	}
-	now := NowMSK()
+	return qb
+}
+
+// dateRangeCond — условие по created_at для периода today/7d/month (false - период не задан)
+func dateRangeCond(dateRange string, now time.Time) (string, []interface{}, bool) {
 	switch dateRange {
 	case "today":
 		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, moscowLoc)
-		return qb.Where("created_at >= ? AND created_at < ?", start.UTC(), start.Add(24*time.Hour).UTC())
+		return "created_at >= ? AND created_at < ?", []interface{}{start.UTC(), start.Add(24 * time.Hour).UTC()}, true
 	case "7d":
-		return qb.Where("created_at >= ?", now.AddDate(0, 0, -7).UTC())
+		return "created_at >= ?", []interface{}{now.AddDate(0, 0, -7).UTC()}, true
 	case "month":
 		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, moscowLoc)
-		return qb.Where("created_at >= ? AND created_at < ?", start.UTC(), start.AddDate(0, 1, 0).UTC())
+		return "created_at >= ? AND created_at < ?", []interface{}{start.UTC(), start.AddDate(0, 1, 0).UTC()}, true
 	default:
-		return qb
+		return "", nil, false
 	}
 }
 
@@ -135,34 +139,10 @@ func mustID(c *gin.Context) (uint, bool) {
 	return uint(id64), true
 }
 
-// Валидация статуса
-func parseStatus(s string) (string, bool) {
-	switch s {
-	case "new", "processed", "archived":
-		return s, true
-	default:
-		return "", false
-	}
-}
-
-// Базовый запрос: поиск + фильтры по датам
+// Базовый запрос: поиск + фильтры (см. contact_filters.go), без статуса и архива
 func (h *Handlers) baseContactsQB(c *gin.Context) *gorm.DB {
-	qb := h.db.Model(&models.ContactForm{})
-
-	// поиск
-	search := c.Query("search")
-	if search == "" {
-		search = c.Query("q")
-	}
-	if search != "" {
-		q := "%" + search + "%"
-		qb = qb.Where("name ILIKE ? OR phone ILIKE ? OR email ILIKE ? OR message ILIKE ?", q, q, q, q)
-	}
-
-	// даты
-	qb = applyDateFilter(qb, c.Query("date"))
-	return qb
-
+	f := contactFilterFromQuery(c.Request.URL.Query())
+	return f.apply(h.db.Model(&models.ContactForm{}), c.GetUint("admin_id"), NowMSK())
 }
 
 // getPageQuery — читает page/limit из query и возвращает page, limit, offset
@@ -198,3 +178,18 @@ func (h *Handlers) pageMeta(total int64, page, limit int) (int, int, int, []int)
 	}
 	return pages, prevPage, nextPage, buildPageNumbers(page, pages)
 }
+
+// uniqueSlug добавляет суффикс -N, если slug уже занят в таблице модели
+func (h *Handlers) uniqueSlug(model any, baseSlug string) string {
+	slug := baseSlug
+	suffix := 1
+	for {
+		var count int64
+		h.db.Model(model).Where("slug = ?", slug).Count(&count)
+		if count == 0 {
+			return slug
+		}
+		suffix++
+		slug = baseSlug + "-" + strconv.Itoa(suffix)
+	}
+}
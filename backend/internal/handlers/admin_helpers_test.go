@@ -79,18 +79,20 @@ func TestMustID_Negative(t *testing.T) {
 // ---------- parseStatus ----------
 
 func TestParseStatus_Valid(t *testing.T) {
-	tests := []string{"new", "processed", "archived"}
+	_, h := setupTestRouter(t)
+	tests := []string{"new", "processed", "quote_sent", "won", "lost", "archived", "spam"}
 	for _, s := range tests {
-		result, ok := parseStatus(s)
+		result, ok := h.parseStatus(s)
 		assert.True(t, ok, "статус %q должен быть валидным", s)
 		assert.Equal(t, s, result)
 	}
 }
 
 func TestParseStatus_Invalid(t *testing.T) {
+	_, h := setupTestRouter(t)
 	tests := []string{"", "invalid", "deleted", "NEW", "Processed"}
 	for _, s := range tests {
-		_, ok := parseStatus(s)
+		_, ok := h.parseStatus(s)
 		assert.False(t, ok, "статус %q не должен быть валидным", s)
 	}
 }
@@ -18,14 +18,14 @@ func (h *Handlers) AdminMapPointsPage(c *gin.Context) {
 	var points []models.MapPoint
 	if err := h.db.Order("sort_order ASC, id DESC").Find(&points).Error; err != nil {
 		log.Printf("Ошибка загрузки точек на карте: %v", err)
-		c.HTML(http.StatusInternalServerError, "admin_base.html", gin.H{
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
 			"PageID": "admin-error",
 			"error":  "Ошибка загрузки данных",
 		})
 		return
 	}
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"title":     "Точки на карте",
 		"PageID":    "admin-map-points",
 		"MapPoints": points,
@@ -78,6 +78,7 @@ func (h *Handlers) CreateMapPoint(c *gin.Context) {
 		jsonErr(c, http.StatusInternalServerError, "Ошибка создания точки")
 		return
 	}
+	setAuditEntityID(c, point.ID)
 
 	jsonOK(c, gin.H{
 		"message":   "Точка успешно создана",
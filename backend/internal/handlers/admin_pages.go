@@ -4,7 +4,7 @@ import (
 	"encoding/csv"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"ledsite/internal/models"
@@ -13,7 +13,7 @@ import (
 )
 
 var csvHeadersContacts = []string{
-	"Имя", "Телефон", "Email", "Компания", "Тип проекта", "Сообщение", "Статус", "Дата",
+	"Имя", "Телефон", "Email", "Компания", "Тип проекта", "Сообщение", "Статус", "Причина проигрыша", "Ответственный", "Дата",
 }
 
 // /admin/contacts — страница всех заявок (без архива)
@@ -25,33 +25,12 @@ func (h *Handlers) AdminContactsPage(c *gin.Context) {
 		Where("archived_at IS NULL").
 		Where("status <> ?", "archived")
 
-	// Фильтр по статусу (только среди неархивных)
+	// Фильтр по статусу (этап воронки, только среди неархивных). Спам показывается только в своём фильтре
 	status := c.Query("status")
-	if status == "new" || status == "processed" {
+	if _, isStage := h.findStage(status); isStage || status == "spam" {
 		qb = qb.Where("status = ?", status)
-	}
-
-	reminder := strings.ToLower(strings.TrimSpace(c.Query("reminder")))
-	now := NowMSK()
-	startToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, moscowLoc)
-	endToday := startToday.Add(24 * time.Hour)
-
-	switch reminder {
-	case "today":
-		qb = qb.Where(
-			"remind_flag = ? AND remind_at IS NOT NULL AND remind_at >= ? AND remind_at < ?",
-			true, startToday.UTC(), endToday.UTC(),
-		)
-	case "overdue":
-		qb = qb.Where(
-			"remind_flag = ? AND remind_at IS NOT NULL AND remind_at < ?",
-			true, now.UTC(),
-		)
-	case "upcoming":
-		qb = qb.Where(
-			"remind_flag = ? AND remind_at IS NOT NULL AND remind_at >= ?",
-			true, now.UTC(),
-		)
+	} else {
+		qb = qb.Where("status <> ?", "spam")
 	}
 
 	// Пагинация
@@ -74,27 +53,38 @@ func (h *Handlers) AdminContactsPage(c *gin.Context) {
 	pages, prevPage, nextPage, pageNumbers := h.pageMeta(total, page, limit)
 
 	// Параметры для рендера
-	search := c.Query("search")
-	if search == "" {
-		search = c.Query("q")
-	}
-	dateRange := c.Query("date")
-
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
-		"title":       "Заявки",
-		"PageID":      "admin-contacts",
-		"contactsAll": contacts,
-		"total":       total,
-		"page":        page,
-		"pages":       pages,
-		"prevPage":    prevPage,
-		"nextPage":    nextPage,
-		"pageNumbers": pageNumbers,
-		"limit":       limit,
-		"search":      search,
-		"status":      status,
-		"dateRange":   dateRange,
-		"reminder":    reminder,
+	filter := contactFilterFromQuery(c.Request.URL.Query())
+	sources, projectTypes := h.contactFilterOptions()
+	viewID, _ := strconv.ParseUint(c.Query("view"), 10, 64)
+	stages := h.pipelineStages()
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":        "Заявки",
+		"PageID":       "admin-contacts",
+		"contactsAll":  h.withSearchSnippets(h.withAssignees(h.withCustomerContacts(contacts)), parseContactSearch(filter.Search)),
+		"stages":       stages,
+		"stageBadges":  stageBadges(stages),
+		"total":        total,
+		"page":         page,
+		"pages":        pages,
+		"prevPage":     prevPage,
+		"nextPage":     nextPage,
+		"pageNumbers":  pageNumbers,
+		"limit":        limit,
+		"search":       filter.Search,
+		"status":       status,
+		"dateRange":    filter.Date,
+		"reminder":     filter.Reminder,
+		"assigned":     filter.Assigned,
+		"assignees":    assignableAdmins(h.db),
+		"filter":       filter,
+		"advanced":     filter.advanced(),
+		"sources":      sources,
+		"projectTypes": projectTypes,
+		"leadTypes":    projectTypeCodes,
+		"leadSources":  manualContactSources,
+		"views":        h.contactViewTabs(c.GetUint("admin_id")),
+		"viewID":       uint(viewID),
 	})
 }
 
@@ -131,7 +121,7 @@ func (h *Handlers) AdminContactsArchivePage(c *gin.Context) {
 	}
 	dateRange := c.Query("date")
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"title":       "Архив заявок",
 		"PageID":      "admin-contacts-archive",
 		"contactsAll": contacts,
@@ -164,6 +154,8 @@ func (h *Handlers) AdminContactsExportCSV(c *gin.Context) {
 			Where("status <> ?", "archived")
 		if status != "" {
 			qb = qb.Where("status = ?", status)
+		} else {
+			qb = qb.Where("status <> ?", "spam")
 		}
 	}
 
@@ -173,6 +165,20 @@ func (h *Handlers) AdminContactsExportCSV(c *gin.Context) {
 		return
 	}
 
+	badges := stageBadges(h.pipelineStages())
+	assigneeIDs := make([]uint, 0, len(contacts))
+	for _, cf := range contacts {
+		if cf.AssignedAdminID != nil {
+			assigneeIDs = append(assigneeIDs, *cf.AssignedAdminID)
+		}
+	}
+	assignees := adminNames(h.db, assigneeIDs)
+	contactIDs := make([]uint, len(contacts))
+	for i, cf := range contacts {
+		contactIDs[i] = cf.ID
+	}
+	attrs := attributionsFor(h.db, contactIDs)
+
 	// Заголовки и выдача CSV
 	filename := "contacts_export_" + time.Now().Format("20060102_150405") + ".csv"
 	c.Header("Content-Type", "text/csv; charset=utf-8")
@@ -185,15 +191,24 @@ func (h *Handlers) AdminContactsExportCSV(c *gin.Context) {
 	}
 	w := csv.NewWriter(c.Writer)
 	w.Comma = ';'
-	if err := w.Write(csvHeadersContacts); err != nil {
+	if err := w.Write(append(csvHeadersContacts, csvHeadersAttribution...)); err != nil {
 		c.String(http.StatusInternalServerError, "Error writing CSV header")
 		return
 	}
 	for _, cf := range contacts {
-		if err := w.Write([]string{
-			cf.Name, cf.Phone, cf.Email, cf.Company, cf.ProjectType, cf.Message, cf.Status,
+		statusTitle := cf.Status
+		if b, ok := badges[cf.Status]; ok {
+			statusTitle = b.Title
+		}
+		assignee := ""
+		if cf.AssignedAdminID != nil {
+			assignee = assignees[*cf.AssignedAdminID]
+		}
+		row := []string{
+			cf.Name, cf.Phone, cf.Email, cf.Company, cf.ProjectType, cf.Message, statusTitle, cf.LostReason, assignee,
 			cf.CreatedAt.In(moscowLoc).Format("02.01.2006 15:04"),
-		}); err != nil {
+		}
+		if err := w.Write(append(row, attributionCSV(attrs[cf.ID])...)); err != nil {
 			c.String(http.StatusInternalServerError, "Error writing CSV row")
 			return
 		}
@@ -24,14 +24,14 @@ func (h *Handlers) AdminPricesPage(c *gin.Context) {
 		return db.Order("is_primary DESC, sort_order ASC, id ASC")
 	}).Order("sort_order ASC, id DESC").Find(&priceItems).Error; err != nil {
 		log.Printf("Ошибка загрузки позиций прайса: %v", err)
-		c.HTML(http.StatusInternalServerError, "admin_base.html", gin.H{
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
 			"PageID": "admin-error",
 			"error":  "Ошибка загрузки данных",
 		})
 		return
 	}
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"title":      "Управление ценами",
 		"PageID":     "admin-prices",
 		"PriceItems": priceItems,
@@ -116,6 +116,7 @@ func (h *Handlers) CreatePriceItem(c *gin.Context) {
 		jsonErr(c, http.StatusInternalServerError, "Ошибка создания позиции")
 		return
 	}
+	setAuditEntityID(c, priceItem.ID)
 
 	// Если есть характеристики, обрабатываем их
 	if priceItem.HasSpecifications {
@@ -372,6 +373,7 @@ func (h *Handlers) DuplicatePriceItem(c *gin.Context) {
 		jsonErr(c, http.StatusInternalServerError, "Ошибка дублирования позиции")
 		return
 	}
+	setAuditEntityID(c, newItem.ID)
 
 	// Копируем спецификации
 	for _, spec := range original.Specifications {
@@ -47,6 +47,7 @@ func (h *Handlers) CreateProject(c *gin.Context) {
 		})
 		return
 	}
+	setAuditEntityID(c, project.ID)
 
 	// Обрабатываем категории
 	categoryIDs := c.PostFormArray("categories")
@@ -84,7 +85,7 @@ func (h *Handlers) GetProject(c *gin.Context) {
 		return
 	}
 	var allCategories []models.Category
-	h.db.Find(&allCategories)
+	h.db.Order("sort_order ASC, id ASC").Find(&allCategories)
 
 	if project.Categories == nil {
 		project.Categories = []models.Category{}
@@ -190,6 +191,7 @@ func (h *Handlers) DuplicateProject(c *gin.Context) {
 		jsonErr(c, http.StatusInternalServerError, "Ошибка дублирования проекта")
 		return
 	}
+	setAuditEntityID(c, newProject.ID)
 
 	// Копируем категории
 	for _, category := range original.Categories {
@@ -32,7 +32,7 @@ func (h *Handlers) AdminPromoPage(c *gin.Context) {
 		pages = []string{"home"}
 	}
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"PageID":    "admin-promo",
 		"title":     "Управление акцией",
 		"promo":     promo,
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminServicesPage — страница управления услугами
+func (h *Handlers) AdminServicesPage(c *gin.Context) {
+	var services []models.Service
+	if err := h.db.Order("sort_order ASC, id ASC").Find(&services).Error; err != nil {
+		log.Printf("Ошибка загрузки услуг: %v", err)
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
+			"PageID": "admin-error",
+			"error":  "Ошибка загрузки данных",
+		})
+		return
+	}
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":    "Управление услугами",
+		"PageID":   "admin-services",
+		"Services": services,
+	})
+}
+
+// CreateService — создание новой услуги
+func (h *Handlers) CreateService(c *gin.Context) {
+	var service models.Service
+	bindServiceForm(c, &service)
+
+	if service.Name == "" {
+		jsonErr(c, http.StatusBadRequest, "Название не может быть пустым")
+		return
+	}
+
+	service.Slug = h.uniqueSlug(&models.Service{}, generateSlug(service.Name))
+
+	// Новая услуга встаёт в конец списка
+	var maxOrder int
+	h.db.Model(&models.Service{}).Select("COALESCE(MAX(sort_order), -1)").Scan(&maxOrder)
+	service.SortOrder = maxOrder + 1
+
+	if file, err := c.FormFile("icon_file"); err == nil {
+		if err := h.saveServiceIcon(c, file, &service); err != nil {
+			return
+		}
+	}
+
+	if err := h.db.Create(&service).Error; err != nil {
+		log.Printf("Ошибка создания услуги '%s': %v", service.Name, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка создания услуги")
+		return
+	}
+	setAuditEntityID(c, service.ID)
+
+	jsonOK(c, gin.H{
+		"message": "Услуга успешно создана",
+		"service": service,
+	})
+}
+
+// GetService — получение услуги для редактирования (JSON)
+func (h *Handlers) GetService(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var service models.Service
+	if err := h.db.First(&service, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Услуга не найдена")
+		return
+	}
+
+	jsonOK(c, gin.H{"service": service})
+}
+
+// UpdateService — обновление услуги.
+// Slug не меняется, чтобы не ломать ссылки на /services/:slug.
+func (h *Handlers) UpdateService(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var service models.Service
+	if err := h.db.First(&service, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Услуга не найдена")
+		return
+	}
+
+	bindServiceForm(c, &service)
+	if service.Name == "" {
+		jsonErr(c, http.StatusBadRequest, "Название не может быть пустым")
+		return
+	}
+
+	// Новая иконка заменяет старую вместе с миниатюрами. Старые файлы удаляются
+	// только после сохранения услуги: отклонённая загрузка не оставляет запись без иконки.
+	old := service
+	uploaded := false
+	if file, err := c.FormFile("icon_file"); err == nil {
+		if err := h.saveServiceIcon(c, file, &service); err != nil {
+			return
+		}
+		uploaded = true
+	} else if c.PostForm("remove_icon") == "on" {
+		service.IconPath = ""
+		service.ThumbnailSmallPath = ""
+		service.ThumbnailMediumPath = ""
+	}
+
+	if err := h.db.Save(&service).Error; err != nil {
+		log.Printf("Ошибка обновления услуги ID=%d: %v", id, err)
+		if uploaded {
+			h.removeServiceIcon(&service)
+		}
+		jsonErr(c, http.StatusInternalServerError, "Ошибка обновления услуги")
+		return
+	}
+	if old.IconPath != service.IconPath {
+		h.removeServiceIcon(&old)
+	}
+
+	jsonOK(c, gin.H{"message": "Услуга успешно обновлена"})
+}
+
+// DeleteService — удаление услуги вместе с загруженной иконкой
+func (h *Handlers) DeleteService(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var service models.Service
+	if err := h.db.First(&service, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Услуга не найдена")
+		return
+	}
+
+	h.removeServiceIcon(&service)
+
+	if err := h.db.Delete(&service).Error; err != nil {
+		log.Printf("Ошибка удаления услуги ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка удаления услуги")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Услуга успешно удалена"})
+}
+
+// UpdateServicesSorting — обновление порядка услуг (drag & drop)
+func (h *Handlers) UpdateServicesSorting(c *gin.Context) {
+	var req struct {
+		IDs []uint `json:"ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Некорректные данные")
+		return
+	}
+
+	for i, id := range req.IDs {
+		if err := h.db.Model(&models.Service{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+			log.Printf("Ошибка обновления порядка для услуги ID=%d: %v", id, err)
+		}
+	}
+
+	jsonOK(c, gin.H{"message": "Порядок успешно обновлен"})
+}
+
+// bindServiceForm заполняет текстовые поля услуги из формы
+func bindServiceForm(c *gin.Context, service *models.Service) {
+	service.Name = strings.TrimSpace(c.PostForm("name"))
+	service.ShortDesc = strings.TrimSpace(c.PostForm("short_desc"))
+	service.Description = strings.TrimSpace(c.PostForm("description"))
+	service.Icon = strings.TrimSpace(c.PostForm("icon"))
+	service.Featured = c.PostForm("featured") == "on"
+}
+
+// saveServiceIcon сохраняет загруженную иконку и генерирует миниатюры.
+// При ошибке сам отправляет JSON-ответ и возвращает её.
+func (h *Handlers) saveServiceIcon(c *gin.Context, file *multipart.FileHeader, service *models.Service) error {
+	if !isImageFile(file.Filename) {
+		jsonErr(c, http.StatusBadRequest, "Недопустимый формат изображения")
+		return fmt.Errorf("недопустимый формат: %s", file.Filename)
+	}
+
+	// Генерируем имя файла: service_{timestamp}_{slug}.ext
+	ext := filepath.Ext(file.Filename)
+	filename := fmt.Sprintf("service_%d_%s%s", time.Now().Unix(), generateSlug(service.Name), ext)
+	uploadPath := filepath.Join(h.uploadPath, filename)
+
+	if err := os.MkdirAll(filepath.Dir(uploadPath), 0755); err != nil {
+		log.Printf("Ошибка создания директории: %v", err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка сохранения файла")
+		return err
+	}
+
+	if err := c.SaveUploadedFile(file, uploadPath); err != nil {
+		log.Printf("Ошибка сохранения файла: %v", err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка загрузки изображения")
+		return err
+	}
+
+	service.IconPath = "/static/uploads/" + filename
+	service.ThumbnailSmallPath = ""
+	service.ThumbnailMediumPath = ""
+
+	// Генерируем миниатюры с дефолтным кроппингом
+	thumbnails, err := GenerateThumbnails(uploadPath, CropParams{X: 50, Y: 50, Scale: 1.0})
+	if err != nil {
+		log.Printf("Ошибка создания миниатюр для %s: %v", filename, err)
+		return nil
+	}
+	if path, ok := thumbnails[ThumbnailSmall.Suffix]; ok {
+		service.ThumbnailSmallPath = convertToWebPath(path)
+	}
+	if path, ok := thumbnails[ThumbnailMedium.Suffix]; ok {
+		service.ThumbnailMediumPath = convertToWebPath(path)
+	}
+	log.Printf("Миниатюры созданы для %s", filename)
+	return nil
+}
+
+// removeServiceIcon удаляет файл иконки и миниатюры, очищает пути в модели
+func (h *Handlers) removeServiceIcon(service *models.Service) {
+	if service.IconPath == "" {
+		return
+	}
+	filePath := filepath.Join(h.uploadPath, filepath.Base(service.IconPath))
+	if err := os.Remove(filePath); err != nil {
+		log.Printf("Ошибка удаления иконки %s: %v", filePath, err)
+	}
+	DeleteThumbnails(filePath)
+
+	service.IconPath = ""
+	service.ThumbnailSmallPath = ""
+	service.ThumbnailMediumPath = ""
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ledsite/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- CreateService ----------
+
+func TestCreateService_Success(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/services", h.CreateService)
+
+	h.db.Create(&models.Service{Name: "Первая", Slug: "first", SortOrder: 2})
+
+	form := url.Values{
+		"name":        {"Аренда экранов"},
+		"short_desc":  {"Для мероприятий"},
+		"description": {"Подробное описание"},
+		"featured":    {"on"},
+	}
+	req, _ := http.NewRequest("POST", "/admin/services", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var service models.Service
+	h.db.Where("name = ?", "Аренда экранов").First(&service)
+	assert.True(t, strings.HasPrefix(service.Slug, "arenda-ekranov-"))
+	assert.Equal(t, "Для мероприятий", service.ShortDesc)
+	assert.True(t, service.Featured)
+	assert.Equal(t, 3, service.SortOrder)
+}
+
+func TestCreateService_EmptyName(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/services", h.CreateService)
+
+	req, _ := http.NewRequest("POST", "/admin/services", strings.NewReader("name="))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateService_WithIcon(t *testing.T) {
+	router, h := setupTestRouter(t)
+	h.uploadPath = t.TempDir()
+	router.POST("/admin/services", h.CreateService)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("name", "Монтаж")
+	part, _ := writer.CreateFormFile("icon_file", "icon.png")
+	png.Encode(part, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/admin/services", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var service models.Service
+	h.db.First(&service)
+	assert.True(t, strings.HasPrefix(service.IconPath, "/static/uploads/service_"))
+	_, err := os.Stat(filepath.Join(h.uploadPath, filepath.Base(service.IconPath)))
+	assert.NoError(t, err, "иконка должна быть сохранена")
+}
+
+func TestCreateService_InvalidIconType(t *testing.T) {
+	router, h := setupTestRouter(t)
+	h.uploadPath = t.TempDir()
+	router.POST("/admin/services", h.CreateService)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("name", "Монтаж")
+	part, _ := writer.CreateFormFile("icon_file", "icon.exe")
+	part.Write([]byte("MZ"))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/admin/services", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var count int64
+	h.db.Model(&models.Service{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// ---------- UpdateService / DeleteService ----------
+
+func TestUpdateService_KeepsSlug(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/services/:id/update", h.UpdateService)
+
+	service := models.Service{Name: "Старое", Slug: "old-service", Featured: true}
+	h.db.Create(&service)
+
+	form := url.Values{"name": {"Новое"}, "short_desc": {"Кратко"}}
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/services/%d/update", service.ID), strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.Service
+	h.db.First(&updated, service.ID)
+	assert.Equal(t, "Новое", updated.Name)
+	assert.Equal(t, "old-service", updated.Slug)
+	assert.False(t, updated.Featured, "чекбокс не передан - услуга снята с главной")
+}
+
+func TestUpdateService_InvalidIconKeepsOld(t *testing.T) {
+	router, h := setupTestRouter(t)
+	h.uploadPath = t.TempDir()
+	router.POST("/admin/services/:id/update", h.UpdateService)
+
+	iconFile := filepath.Join(h.uploadPath, "service_1_old.png")
+	assert.NoError(t, os.WriteFile(iconFile, []byte("png"), 0644))
+	service := models.Service{Name: "Монтаж", Slug: "montazh", IconPath: "/static/uploads/service_1_old.png"}
+	h.db.Create(&service)
+
+	update := func(filename string) int {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		writer.WriteField("name", "Монтаж")
+		part, _ := writer.CreateFormFile("icon_file", filename)
+		if filename == "icon.png" {
+			png.Encode(part, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+		} else {
+			part.Write([]byte("MZ"))
+		}
+		writer.Close()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/services/%d/update", service.ID), body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Отклонённая загрузка: старая иконка на месте
+	assert.Equal(t, http.StatusBadRequest, update("icon.exe"))
+	_, err := os.Stat(iconFile)
+	assert.NoError(t, err, "старая иконка не удалена")
+
+	// Успешная замена: старый файл удаляется, запись указывает на новый
+	assert.Equal(t, http.StatusOK, update("icon.png"))
+	var updated models.Service
+	h.db.First(&updated, service.ID)
+	assert.NotEqual(t, service.IconPath, updated.IconPath)
+	_, err = os.Stat(filepath.Join(h.uploadPath, filepath.Base(updated.IconPath)))
+	assert.NoError(t, err, "новая иконка сохранена")
+	_, err = os.Stat(iconFile)
+	assert.True(t, os.IsNotExist(err), "старая иконка удалена")
+}
+
+func TestDeleteService_Success(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.DELETE("/admin/services/:id", h.DeleteService)
+
+	service := models.Service{Name: "Удаляемая", Slug: "to-delete"}
+	h.db.Create(&service)
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/services/%d", service.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var count int64
+	h.db.Model(&models.Service{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDeleteService_NotFound(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.DELETE("/admin/services/:id", h.DeleteService)
+
+	req, _ := http.NewRequest("DELETE", "/admin/services/999", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// ---------- UpdateServicesSorting ----------
+
+func TestUpdateServicesSorting_Success(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/services/sort", h.UpdateServicesSorting)
+
+	a := models.Service{Name: "A", Slug: "a"}
+	b := models.Service{Name: "B", Slug: "b"}
+	h.db.Create(&a)
+	h.db.Create(&b)
+
+	body, _ := json.Marshal(map[string][]uint{"ids": {b.ID, a.ID}})
+	req, _ := http.NewRequest("POST", "/admin/services/sort", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var ordered []models.Service
+	h.db.Order("sort_order ASC").Find(&ordered)
+	assert.Equal(t, "b", ordered[0].Slug)
+	assert.Equal(t, "a", ordered[1].Slug)
+}
+
+// ---------- ServiceDetailPage ----------
+
+func TestServiceDetailPage(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.SetHTMLTemplate(template.Must(template.New("public_base.html").Parse(
+		`{{.PageID}}|{{.title}}|{{range .otherServices}}[{{.Slug}}]{{end}}`,
+	)))
+	router.GET("/services/:slug", h.ServiceDetailPage)
+
+	h.db.Create(&models.Service{Name: "Монтаж", Slug: "montage"})
+	h.db.Create(&models.Service{Name: "Ремонт", Slug: "repair"})
+
+	req, _ := http.NewRequest("GET", "/services/montage", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "service-detail|Монтаж")
+	assert.Contains(t, w.Body.String(), "[repair]")
+	assert.NotContains(t, w.Body.String(), "[montage]")
+
+	req, _ = http.NewRequest("GET", "/services/unknown", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "not-found|")
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Сроки жизни токенов и сессий админки
+const (
+	accessTokenTTL       = 15 * time.Minute    // JWT в cookie admin_token
+	sessionIdleTTL       = 7 * 24 * time.Hour  // скользящий срок refresh токена
+	sessionMaxLifetime   = 30 * 24 * time.Hour // абсолютный срок сессии, после него - повторный вход
+	refreshReuseWindow   = 30 * time.Second    // сколько принимается предыдущий refresh токен после ротации
+	sessionTouchInterval = time.Minute         // как часто обновлять last_seen_at
+)
+
+// Cookie токенов админки
+const (
+	accessCookieName  = "admin_token"
+	refreshCookieName = "admin_refresh"
+	refreshCookiePath = "/admin" // refresh токен нужен только роутам админки
+)
+
+// ErrSessionInvalid - сессия не найдена, отозвана, истекла или админ деактивирован
+var ErrSessionInvalid = errors.New("сессия недействительна")
+
+// adminSessionRow - строка таблицы активных сессий
+type adminSessionRow struct {
+	models.AdminSession
+	Current bool
+}
+
+// startSession создаёт серверную сессию после успешного входа и выставляет cookie токенов
+func (h *Handlers) startSession(c *gin.Context, admin models.Admin) error {
+	jti, err := randomToken()
+	if err != nil {
+		return err
+	}
+	refresh, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	session := models.AdminSession{
+		AdminID:     admin.ID,
+		JTI:         jti,
+		RefreshHash: hashToken(refresh),
+		UserAgent:   truncateString(c.Request.UserAgent(), 255),
+		IP:          c.ClientIP(),
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(sessionIdleTTL),
+	}
+	if err := h.db.Create(&session).Error; err != nil {
+		return err
+	}
+
+	access, err := generateJWT(admin.ID, admin.Username, admin.Role, jti)
+	if err != nil {
+		return err
+	}
+
+	setAccessCookie(c, access)
+	setRefreshCookie(c, refresh, session.ExpiresAt)
+
+	// Заодно чистим давно истёкшие и отозванные сессии этого админа
+	h.db.Where("admin_id = ? AND expires_at < ?", admin.ID, now.Add(-sessionMaxLifetime)).
+		Delete(&models.AdminSession{})
+	return nil
+}
+
+// CheckSession проверяет, что сессия access токена не отозвана и админ активен.
+// Роль в claims берётся из БД, поэтому смена роли действует без повторного входа.
+func (h *Handlers) CheckSession(claims *JWTClaims) error {
+	if claims.ID == "" {
+		return ErrSessionInvalid
+	}
+
+	var session models.AdminSession
+	if err := h.db.Preload("Admin").Where("jti = ?", claims.ID).First(&session).Error; err != nil {
+		return ErrSessionInvalid
+	}
+
+	now := time.Now()
+	if !sessionUsable(session, now) || session.AdminID != claims.UserID {
+		return ErrSessionInvalid
+	}
+
+	claims.Username = session.Admin.Username
+	claims.Role = session.Admin.Role
+
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		h.db.Model(&session).Update("last_seen_at", now)
+	}
+	return nil
+}
+
+// RefreshSession выдаёт новый access токен по cookie admin_refresh (скользящее продление).
+//
+// Refresh токен ротируется при каждом обновлении. Предыдущее значение принимается
+// ещё refreshReuseWindow, чтобы параллельные запросы нескольких вкладок не выбивали
+// друг друга; более позднее его использование считается кражей и отзывает сессию.
+func (h *Handlers) RefreshSession(c *gin.Context) (*JWTClaims, error) {
+	refresh, err := c.Cookie(refreshCookieName)
+	if err != nil || refresh == "" {
+		return nil, ErrSessionInvalid
+	}
+	hash := hashToken(refresh)
+
+	var session models.AdminSession
+	if err := h.db.Preload("Admin").
+		Where("refresh_hash = ? OR prev_refresh_hash = ?", hash, hash).
+		First(&session).Error; err != nil {
+		return nil, ErrSessionInvalid
+	}
+
+	now := time.Now()
+	if !sessionUsable(session, now) {
+		return nil, ErrSessionInvalid
+	}
+
+	reused := session.RefreshHash != hash
+	if reused && (session.RotatedAt == nil || now.Sub(*session.RotatedAt) > refreshReuseWindow) {
+		log.Printf("Повторное использование refresh токена: сессия ID=%d админа ID=%d отозвана", session.ID, session.AdminID)
+		h.db.Model(&session).Update("revoked_at", now)
+		return nil, ErrSessionInvalid
+	}
+
+	admin := session.Admin
+	access, err := generateJWT(admin.ID, admin.Username, admin.Role, session.JTI)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reused {
+		newRefresh, err := randomToken()
+		if err != nil {
+			return nil, err
+		}
+
+		expires := now.Add(sessionIdleTTL)
+		if limit := session.CreatedAt.Add(sessionMaxLifetime); expires.After(limit) {
+			expires = limit
+		}
+
+		if err := h.db.Model(&session).Updates(map[string]interface{}{
+			"refresh_hash":      hashToken(newRefresh),
+			"prev_refresh_hash": hash,
+			"rotated_at":        now,
+			"last_seen_at":      now,
+			"expires_at":        expires,
+		}).Error; err != nil {
+			return nil, err
+		}
+		setRefreshCookie(c, newRefresh, expires)
+	}
+	// При reused новый refresh токен уже получил параллельный запрос - меняем только access
+	setAccessCookie(c, access)
+
+	claims := &JWTClaims{UserID: admin.ID, Username: admin.Username, Role: admin.Role}
+	claims.ID = session.JTI
+	return claims, nil
+}
+
+// revokeCurrentSession отзывает сессию текущего запроса (по JTI из контекста или refresh cookie)
+func (h *Handlers) revokeCurrentSession(c *gin.Context) {
+	now := time.Now()
+	if jti := c.GetString("admin_session_jti"); jti != "" {
+		h.db.Model(&models.AdminSession{}).
+			Where("jti = ? AND revoked_at IS NULL", jti).
+			Update("revoked_at", now)
+	}
+	if refresh, err := c.Cookie(refreshCookieName); err == nil && refresh != "" {
+		h.db.Model(&models.AdminSession{}).
+			Where("refresh_hash = ? AND revoked_at IS NULL", hashToken(refresh)).
+			Update("revoked_at", now)
+	}
+}
+
+// revokeAdminSessions отзывает все сессии администратора, кроме exceptJTI (пустой = все).
+// Возвращает количество отозванных сессий.
+func (h *Handlers) revokeAdminSessions(adminID uint, exceptJTI string) (int64, error) {
+	q := h.db.Model(&models.AdminSession{}).Where("admin_id = ? AND revoked_at IS NULL", adminID)
+	if exceptJTI != "" {
+		q = q.Where("jti <> ?", exceptJTI)
+	}
+	res := q.Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// AdminSessionsPage — страница «Безопасность»: активные сессии и 2FA текущего администратора
+func (h *Handlers) AdminSessionsPage(c *gin.Context) {
+	var sessions []models.AdminSession
+	if err := h.db.
+		Where("admin_id = ? AND revoked_at IS NULL AND expires_at > ?", c.GetUint("admin_id"), time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		log.Printf("Ошибка загрузки сессий: %v", err)
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
+			"PageID": "admin-error",
+			"error":  "Ошибка загрузки данных",
+		})
+		return
+	}
+
+	current := c.GetString("admin_session_jti")
+	rows := make([]adminSessionRow, 0, len(sessions))
+	for _, s := range sessions {
+		rows = append(rows, adminSessionRow{AdminSession: s, Current: s.JTI == current})
+	}
+
+	// Состояние 2FA и остаток кодов восстановления для блока «Двухфакторная аутентификация»
+	var admin models.Admin
+	h.db.First(&admin, c.GetUint("admin_id"))
+	var recoveryLeft int64
+	h.db.Model(&models.AdminRecoveryCode{}).
+		Where("admin_id = ? AND used_at IS NULL", admin.ID).
+		Count(&recoveryLeft)
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":        "Безопасность",
+		"PageID":       "admin-sessions",
+		"Sessions":     rows,
+		"TOTPEnabled":  admin.TOTPEnabled,
+		"RecoveryLeft": recoveryLeft,
+	})
+}
+
+// RevokeAdminSession — завершение одной своей сессии (кроме текущей)
+func (h *Handlers) RevokeAdminSession(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var session models.AdminSession
+	if err := h.db.Where("id = ? AND admin_id = ?", id, c.GetUint("admin_id")).First(&session).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Сессия не найдена")
+		return
+	}
+	if session.JTI == c.GetString("admin_session_jti") {
+		jsonErr(c, http.StatusBadRequest, "Для завершения текущей сессии используйте выход")
+		return
+	}
+
+	if err := h.db.Model(&session).Update("revoked_at", time.Now()).Error; err != nil {
+		log.Printf("Ошибка завершения сессии ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка завершения сессии")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Сессия завершена"})
+}
+
+// RevokeOtherAdminSessions — завершение всех сессий текущего администратора, кроме текущей
+func (h *Handlers) RevokeOtherAdminSessions(c *gin.Context) {
+	current := c.GetString("admin_session_jti")
+	if current == "" {
+		jsonErr(c, http.StatusBadRequest, "Текущая сессия не определена")
+		return
+	}
+
+	count, err := h.revokeAdminSessions(c.GetUint("admin_id"), current)
+	if err != nil {
+		log.Printf("Ошибка завершения сессий админа ID=%d: %v", c.GetUint("admin_id"), err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка завершения сессий")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Другие сессии завершены", "revoked": count})
+}
+
+// sessionUsable - сессия не отозвана, не истекла и принадлежит активному админу
+func sessionUsable(s models.AdminSession, now time.Time) bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(now) && s.Admin.IsActive
+}
+
+// setAccessCookie выставляет cookie с access токеном (живёт столько же, сколько JWT)
+func setAccessCookie(c *gin.Context, token string) {
+	c.SetCookie(accessCookieName, token, int(accessTokenTTL.Seconds()), "/", "", secureCookies(), true)
+}
+
+// setRefreshCookie выставляет cookie с refresh токеном до истечения сессии
+func setRefreshCookie(c *gin.Context, token string, expires time.Time) {
+	maxAge := int(time.Until(expires).Seconds())
+	c.SetCookie(refreshCookieName, token, maxAge, refreshCookiePath, "", secureCookies(), true)
+}
+
+// ClearAuthCookies удаляет cookie access и refresh токенов (используется и в middleware)
+func ClearAuthCookies(c *gin.Context) {
+	c.SetCookie(accessCookieName, "", -1, "/", "", secureCookies(), true)
+	c.SetCookie(refreshCookieName, "", -1, refreshCookiePath, "", secureCookies(), true)
+}
+
+// secureCookies - Secure флаг включается в production для передачи токенов только по HTTPS
+func secureCookies() bool {
+	return os.Getenv("ENVIRONMENT") == "production"
+}
+
+// truncateString обрезает строку до max символов (User-Agent бывает очень длинным)
+func truncateString(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
+
+// randomToken генерирует случайный токен (32 байта в hex)
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken возвращает SHA-256 хеш токена в hex (в БД refresh токены хранятся только так)
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// loginCookies выполняет вход и возвращает выставленные cookie по имени
+func loginCookies(t *testing.T, router *gin.Engine, username, password string) map[string]*http.Cookie {
+	t.Helper()
+	w := postLoginForm(router, username, password)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	cookies := map[string]*http.Cookie{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+// requestWithCookies выполняет GET с переданными cookie
+func requestWithCookies(router *gin.Engine, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	for _, c := range cookies {
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// refreshRoute регистрирует роут, который обновляет сессию по refresh cookie
+func refreshRoute(router *gin.Engine, h *Handlers) {
+	router.GET("/admin/refresh", func(c *gin.Context) {
+		claims, err := h.RefreshSession(c)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.String(http.StatusOK, claims.ID)
+	})
+}
+
+// ---------- Login / сессии ----------
+
+func TestLogin_CreatesSession(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+
+	cookies := loginCookies(t, router, "admin", "secret123")
+	assert.NotNil(t, cookies["admin_refresh"], "cookie admin_refresh должен быть установлен")
+	assert.True(t, cookies["admin_refresh"].HttpOnly)
+	assert.Equal(t, "/admin", cookies["admin_refresh"].Path)
+
+	claims, err := ValidateJWT(cookies["admin_token"].Value)
+	assert.NoError(t, err)
+
+	var session models.AdminSession
+	assert.NoError(t, h.db.Where("jti = ?", claims.ID).First(&session).Error)
+	assert.Equal(t, admin.ID, session.AdminID)
+	assert.Equal(t, hashToken(cookies["admin_refresh"].Value), session.RefreshHash, "в БД хранится только хеш")
+	assert.NoError(t, h.CheckSession(claims))
+}
+
+func TestCheckSession_RevokedAndInactive(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+
+	claims, _ := ValidateJWT(loginCookies(t, router, "admin", "secret123")["admin_token"].Value)
+	assert.NoError(t, h.CheckSession(claims))
+
+	// Деактивация админа закрывает доступ даже без отзыва сессии
+	h.db.Model(&admin).Update("is_active", false)
+	assert.ErrorIs(t, h.CheckSession(claims), ErrSessionInvalid)
+
+	h.db.Model(&admin).Update("is_active", true)
+	h.revokeAdminSessions(admin.ID, "")
+	assert.ErrorIs(t, h.CheckSession(claims), ErrSessionInvalid)
+
+	// Токен без JTI (выпущенный до появления сессий) не принимается
+	assert.ErrorIs(t, h.CheckSession(&JWTClaims{UserID: admin.ID}), ErrSessionInvalid)
+}
+
+func TestCheckSession_RoleFromDB(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+
+	claims, _ := ValidateJWT(loginCookies(t, router, "admin", "secret123")["admin_token"].Value)
+	h.db.Model(&admin).Update("role", models.RoleViewer)
+
+	assert.NoError(t, h.CheckSession(claims))
+	assert.Equal(t, models.RoleViewer, claims.Role, "смена роли действует без повторного входа")
+}
+
+func TestRefreshSession_Rotates(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	refreshRoute(router, h)
+	createTestAdmin(t, h, "admin", "secret123", true)
+
+	first := loginCookies(t, router, "admin", "secret123")["admin_refresh"]
+
+	w := requestWithCookies(router, "/admin/refresh", first)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var second *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "admin_refresh" {
+			second = c
+		}
+	}
+	assert.NotNil(t, second, "refresh токен ротируется")
+	assert.NotEqual(t, first.Value, second.Value)
+
+	// Старый токен принимается в окне параллельных запросов
+	w = requestWithCookies(router, "/admin/refresh", first)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// После окна повторное использование старого токена отзывает всю сессию
+	h.db.Model(&models.AdminSession{}).Where("1 = 1").Update("rotated_at", time.Now().Add(-time.Hour))
+	w = requestWithCookies(router, "/admin/refresh", first)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = requestWithCookies(router, "/admin/refresh", second)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "актуальный refresh тоже недействителен после отзыва")
+}
+
+func TestRefreshSession_SlidingExpiry(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	refreshRoute(router, h)
+	createTestAdmin(t, h, "admin", "secret123", true)
+
+	refresh := loginCookies(t, router, "admin", "secret123")["admin_refresh"]
+	h.db.Model(&models.AdminSession{}).Where("1 = 1").Update("expires_at", time.Now().Add(time.Hour))
+
+	w := requestWithCookies(router, "/admin/refresh", refresh)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var session models.AdminSession
+	h.db.First(&session)
+	assert.WithinDuration(t, time.Now().Add(sessionIdleTTL), session.ExpiresAt, time.Minute)
+
+	// Истёкшая сессия не продлевается
+	h.db.Model(&session).Update("expires_at", time.Now().Add(-time.Minute))
+	var rotated *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "admin_refresh" {
+			rotated = c
+		}
+	}
+	w = requestWithCookies(router, "/admin/refresh", rotated)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// ---------- Logout ----------
+
+func TestLogout_RevokesSession(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	router.GET("/admin/logout", h.Logout)
+	createTestAdmin(t, h, "admin", "secret123", true)
+
+	cookies := loginCookies(t, router, "admin", "secret123")
+	claims, _ := ValidateJWT(cookies["admin_token"].Value)
+
+	w := requestWithCookies(router, "/admin/logout", cookies["admin_token"], cookies["admin_refresh"])
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	assert.ErrorIs(t, h.CheckSession(claims), ErrSessionInvalid, "украденный access токен больше не работает")
+}
+
+// ---------- Страница сессий ----------
+
+func TestRevokeOtherAdminSessions(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	admin := createTestAdmin(t, h, "admin", "secret123", true)
+	createTestAdmin(t, h, "other", "secret123", true)
+
+	current, _ := ValidateJWT(loginCookies(t, router, "admin", "secret123")["admin_token"].Value)
+	second, _ := ValidateJWT(loginCookies(t, router, "admin", "secret123")["admin_token"].Value)
+	foreign, _ := ValidateJWT(loginCookies(t, router, "other", "secret123")["admin_token"].Value)
+
+	withSession := func(c *gin.Context) {
+		c.Set("admin_id", admin.ID)
+		c.Set("admin_session_jti", current.ID)
+		c.Next()
+	}
+	router.POST("/admin/sessions/revoke-others", withSession, h.RevokeOtherAdminSessions)
+	router.POST("/admin/sessions/:id/revoke", withSession, h.RevokeAdminSession)
+
+	// Чужую сессию завершить нельзя
+	var foreignSession models.AdminSession
+	h.db.Where("jti = ?", foreign.ID).First(&foreignSession)
+	w := postUserForm(t, router, fmt.Sprintf("/admin/sessions/%d/revoke", foreignSession.ID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Текущую - только через выход
+	var currentSession models.AdminSession
+	h.db.Where("jti = ?", current.ID).First(&currentSession)
+	w = postUserForm(t, router, fmt.Sprintf("/admin/sessions/%d/revoke", currentSession.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postUserForm(t, router, "/admin/sessions/revoke-others", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.NoError(t, h.CheckSession(current))
+	assert.ErrorIs(t, h.CheckSession(second), ErrSessionInvalid)
+	assert.NoError(t, h.CheckSession(foreign), "сессии других админов не затрагиваются")
+}
+
+func TestSetAdminUserActive_RevokesSessions(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	owner := createTestAdmin(t, h, "owner", "secret123", true)
+	createTestAdmin(t, h, "editor", "secret123", true)
+	router.POST("/admin/users/:id/active", withAdmin(owner.ID, models.RoleOwner), h.SetAdminUserActive)
+
+	claims, _ := ValidateJWT(loginCookies(t, router, "editor", "secret123")["admin_token"].Value)
+
+	// Деактивация и повторная активация: старые сессии остаются отозванными
+	w := postUserForm(t, router, fmt.Sprintf("/admin/users/%d/active", claims.UserID), url.Values{"active": {"false"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postUserForm(t, router, fmt.Sprintf("/admin/users/%d/active", claims.UserID), url.Values{"active": {"true"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.ErrorIs(t, h.CheckSession(claims), ErrSessionInvalid)
+}
@@ -2,30 +2,36 @@ package handlers
 
 import (
 	"net/http"
-	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"ledsite/internal/mailer"
 )
 
 // formatPhone форматирует номер +79991234567 → +7 999 123 45 67
 func formatPhone(phone string) string {
-	digits := regexp.MustCompile(`\D`).ReplaceAllString(phone, "")
+	digits := phoneDigits(phone)
 	if len(digits) == 11 && digits[0] == '7' {
 		return "+" + digits[0:1] + " " + digits[1:4] + " " + digits[4:7] + " " + digits[7:9] + " " + digits[9:11]
 	}
 	return phone
 }
 
-// AdminSettingsPage отображает страницу настроек сайта (телефон, email)
+// AdminSettingsPage отображает страницу настроек сайта (телефон, email, письмо клиенту)
 func (h *Handlers) AdminSettingsPage(c *gin.Context) {
 	settings := getSettings(h.db)
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"PageID":    "admin-settings",
 		"title":     "Настройки сайта",
 		"settings":  settings,
 		"csrfToken": c.GetString("csrf_token"),
+
+		"leadAssignModes":   leadAssignModeTitles,
+		"ackDefaultSubject": mailer.DefaultAckSubject,
+		"ackDefaultBody":    mailer.DefaultAckBody,
 	})
 }
 
@@ -49,6 +55,18 @@ func (h *Handlers) AdminSettingsUpdate(c *gin.Context) {
 		settings.StatsYears = v
 	}
 
+	settings.SpamKeywords = strings.TrimSpace(c.PostForm("spam_keywords"))
+	if mode := c.PostForm("lead_assign_mode"); leadAssignModeTitles[mode] != "" {
+		settings.LeadAssignMode = mode
+	}
+	settings.AckEmailEnabled = c.PostForm("ack_email_enabled") != ""
+	settings.AckEmailSubject = strings.TrimSpace(c.PostForm("ack_email_subject"))
+	settings.AckEmailBody = strings.TrimSpace(c.PostForm("ack_email_body"))
+	if err := mailer.ValidateAckTemplates(settings.AckEmailSubject, settings.AckEmailBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка в шаблоне письма клиенту: " + err.Error()})
+		return
+	}
+
 	if err := h.db.Save(&settings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения настроек"})
 		return
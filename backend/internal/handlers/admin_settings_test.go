@@ -49,6 +49,15 @@ func TestFormatPhone_TooLong_ReturnsOriginal(t *testing.T) {
 	assert.Equal(t, "+799999999999", formatPhone("+799999999999")) // 12 цифр
 }
 
+func TestFormatPhone_NormalizedPhone(t *testing.T) {
+	// Номер из заявки после normalizePhone показывается в едином виде
+	for _, in := range []string{"8 (999) 123-45-67", "999 123 45 67", "+7 999 123 45 67"} {
+		stored, ok := normalizePhone(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, "+7 999 123 45 67", formatPhone(stored), in)
+	}
+}
+
 // ============================================================================
 // AdminSettingsUpdate Tests
 // ============================================================================
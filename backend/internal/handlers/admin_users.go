@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Политика паролей администраторов
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt игнорирует байты после 72-го
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,50}$`)
+
+// adminUserRow - строка таблицы администраторов
+type adminUserRow struct {
+	models.Admin
+	RoleTitle string
+}
+
+// AdminUsersPage — страница управления администраторами
+func (h *Handlers) AdminUsersPage(c *gin.Context) {
+	var admins []models.Admin
+	if err := h.db.Order("id ASC").Find(&admins).Error; err != nil {
+		log.Printf("Ошибка загрузки администраторов: %v", err)
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
+			"PageID": "admin-error",
+			"error":  "Ошибка загрузки данных",
+		})
+		return
+	}
+
+	rows := make([]adminUserRow, 0, len(admins))
+	for _, a := range admins {
+		rows = append(rows, adminUserRow{Admin: a, RoleTitle: roleTitles[a.Role]})
+	}
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":     "Пользователи админки",
+		"PageID":    "admin-users",
+		"Users":     rows,
+		"Roles":     roleOptions(),
+		"CurrentID": c.GetUint("admin_id"),
+	})
+}
+
+// InviteAdmin — создание администратора с временным паролем.
+// Пароль возвращается один раз в ответе, владелец передаёт его приглашённому.
+func (h *Handlers) InviteAdmin(c *gin.Context) {
+	username := strings.TrimSpace(c.PostForm("username"))
+	email := strings.TrimSpace(c.PostForm("email"))
+	role := c.PostForm("role")
+
+	if !usernamePattern.MatchString(username) {
+		jsonErr(c, http.StatusBadRequest, "Логин: 3-50 символов, латиница, цифры, точка, дефис или подчёркивание")
+		return
+	}
+	if !IsValidRole(role) {
+		jsonErr(c, http.StatusBadRequest, "Неизвестная роль")
+		return
+	}
+
+	var exists int64
+	h.db.Model(&models.Admin{}).Where("username = ?", username).Count(&exists)
+	if exists > 0 {
+		jsonErr(c, http.StatusConflict, "Пользователь с таким логином уже существует")
+		return
+	}
+
+	password := generateTempPassword()
+	hash, err := HashPassword(password)
+	if err != nil {
+		log.Printf("Ошибка хеширования пароля: %v", err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка создания пользователя")
+		return
+	}
+
+	admin := models.Admin{
+		Username:     username,
+		Email:        email,
+		Role:         role,
+		PasswordHash: hash,
+		IsActive:     true,
+	}
+	if err := h.db.Create(&admin).Error; err != nil {
+		log.Printf("Ошибка создания администратора '%s': %v", username, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка создания пользователя")
+		return
+	}
+	setAuditEntityID(c, admin.ID)
+
+	log.Printf("Администратор '%s' (%s) приглашён пользователем %s", username, role, c.GetString("admin_username"))
+	jsonOK(c, gin.H{
+		"message":  "Пользователь создан",
+		"user":     admin,
+		"password": password,
+	})
+}
+
+// GetAdminUser — получение администратора для редактирования (JSON)
+func (h *Handlers) GetAdminUser(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var admin models.Admin
+	if err := h.db.First(&admin, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Пользователь не найден")
+		return
+	}
+
+	jsonOK(c, gin.H{"user": admin})
+}
+
+// UpdateAdminUser — изменение email и роли администратора
+func (h *Handlers) UpdateAdminUser(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var admin models.Admin
+	if err := h.db.First(&admin, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Пользователь не найден")
+		return
+	}
+
+	role := c.PostForm("role")
+	if !IsValidRole(role) {
+		jsonErr(c, http.StatusBadRequest, "Неизвестная роль")
+		return
+	}
+	if role != models.RoleOwner && h.isLastActiveOwner(admin) {
+		jsonErr(c, http.StatusConflict, "Нельзя понизить роль последнего активного владельца")
+		return
+	}
+
+	admin.Email = strings.TrimSpace(c.PostForm("email"))
+	admin.Role = role
+	if err := h.db.Save(&admin).Error; err != nil {
+		log.Printf("Ошибка обновления администратора ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка обновления пользователя")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Пользователь обновлён"})
+}
+
+// SetAdminUserActive — активация/деактивация администратора (поле формы active=true|false).
+// Свою учётную запись и последнего активного владельца деактивировать нельзя.
+// При деактивации все сессии администратора отзываются.
+func (h *Handlers) SetAdminUserActive(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var admin models.Admin
+	if err := h.db.First(&admin, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Пользователь не найден")
+		return
+	}
+
+	active := c.PostForm("active") == "true"
+	if !active {
+		if admin.ID == c.GetUint("admin_id") {
+			jsonErr(c, http.StatusBadRequest, "Нельзя деактивировать свою учётную запись")
+			return
+		}
+		if h.isLastActiveOwner(admin) {
+			jsonErr(c, http.StatusConflict, "Нельзя деактивировать последнего активного владельца")
+			return
+		}
+	}
+
+	if err := h.db.Model(&admin).Update("is_active", active).Error; err != nil {
+		log.Printf("Ошибка изменения статуса администратора ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка изменения статуса")
+		return
+	}
+
+	// Деактивированный админ теряет доступ сразу, а не по истечении токена
+	if !active {
+		if _, err := h.revokeAdminSessions(admin.ID, ""); err != nil {
+			log.Printf("Ошибка отзыва сессий администратора ID=%d: %v", id, err)
+		}
+	}
+
+	msg := "Пользователь деактивирован"
+	if active {
+		msg = "Пользователь активирован"
+	}
+	jsonOK(c, gin.H{"message": msg, "is_active": active})
+}
+
+// ResetAdminUserPassword — сброс пароля администратора.
+// Если пароль не передан, генерируется временный и возвращается в ответе.
+// Сессии администратора завершаются.
+func (h *Handlers) ResetAdminUserPassword(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var admin models.Admin
+	if err := h.db.First(&admin, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Пользователь не найден")
+		return
+	}
+
+	password := c.PostForm("password")
+	generated := password == ""
+	if generated {
+		password = generateTempPassword()
+	} else if err := ValidatePassword(password); err != nil {
+		jsonErr(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		log.Printf("Ошибка хеширования пароля: %v", err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка сброса пароля")
+		return
+	}
+
+	if err := h.db.Model(&admin).Update("password_hash", hash).Error; err != nil {
+		log.Printf("Ошибка сброса пароля администратора ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка сброса пароля")
+		return
+	}
+
+	// Старый пароль мог быть скомпрометирован - завершаем сессии (свою текущую оставляем)
+	except := ""
+	if admin.ID == c.GetUint("admin_id") {
+		except = c.GetString("admin_session_jti")
+	}
+	if _, err := h.revokeAdminSessions(admin.ID, except); err != nil {
+		log.Printf("Ошибка отзыва сессий администратора ID=%d: %v", id, err)
+	}
+
+	resp := gin.H{"message": "Пароль изменён"}
+	if generated {
+		resp["password"] = password
+	}
+	jsonOK(c, resp)
+}
+
+// ValidatePassword проверяет пароль на соответствие политике:
+// 8-72 символа, хотя бы одна буква и одна цифра.
+// Используется в админке и утилите cmd/create-admin.
+func ValidatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return errors.New("Пароль должен содержать минимум 8 символов")
+	}
+	if len(password) > maxPasswordLength {
+		return errors.New("Пароль слишком длинный (максимум 72 байта)")
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("Пароль должен содержать буквы и цифры")
+	}
+	return nil
+}
+
+// isLastActiveOwner проверяет, что админ - единственный активный владелец
+func (h *Handlers) isLastActiveOwner(admin models.Admin) bool {
+	if admin.Role != models.RoleOwner || !admin.IsActive {
+		return false
+	}
+	var owners int64
+	h.db.Model(&models.Admin{}).
+		Where("role = ? AND is_active = ?", models.RoleOwner, true).
+		Count(&owners)
+	return owners <= 1
+}
+
+// roleOptions возвращает роли в порядке убывания прав (для select в шаблоне)
+func roleOptions() []gin.H {
+	roles := []string{models.RoleOwner, models.RoleManager, models.RoleEditor, models.RoleViewer}
+	opts := make([]gin.H, 0, len(roles))
+	for _, r := range roles {
+		opts = append(opts, gin.H{"Value": r, "Title": roleTitles[r]})
+	}
+	return opts
+}
+
+// generateTempPassword генерирует временный пароль, удовлетворяющий ValidatePassword
+func generateTempPassword() string {
+	const letters = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	const digits = "23456789"
+	const alphabet = letters + digits
+
+	for {
+		buf := make([]byte, 12)
+		for i := range buf {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				panic(err) // crypto/rand не должен возвращать ошибку
+			}
+			buf[i] = alphabet[n.Int64()]
+		}
+		if ValidatePassword(string(buf)) == nil {
+			return string(buf)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// withAdmin подставляет в контекст текущего админа, как это делает AuthMiddleware
+func withAdmin(id uint, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("admin_id", id)
+		c.Set("admin_role", role)
+		c.Next()
+	}
+}
+
+// postUserForm отправляет form-urlencoded запрос на роут пользователей
+func postUserForm(t *testing.T, router http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// createRoleAdmin создаёт активного администратора с ролью
+func createRoleAdmin(t *testing.T, h *Handlers, username, role string) models.Admin {
+	t.Helper()
+	admin := models.Admin{Username: username, PasswordHash: "x", Role: role, IsActive: true}
+	assert.NoError(t, h.db.Create(&admin).Error)
+	return admin
+}
+
+// ---------- InviteAdmin ----------
+
+func TestInviteAdmin_Success(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/users", h.InviteAdmin)
+
+	w := postUserForm(t, router, "/admin/users", url.Values{
+		"username": {"manager1"},
+		"email":    {"m@example.com"},
+		"role":     {models.RoleManager},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	password, _ := resp["password"].(string)
+	assert.NoError(t, ValidatePassword(password), "временный пароль соответствует политике")
+
+	var admin models.Admin
+	h.db.Where("username = ?", "manager1").First(&admin)
+	assert.Equal(t, models.RoleManager, admin.Role)
+	assert.True(t, admin.IsActive)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(password)))
+	assert.NotContains(t, w.Body.String(), admin.PasswordHash, "хеш не попадает в ответ")
+}
+
+func TestInviteAdmin_Validation(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/users", h.InviteAdmin)
+	createRoleAdmin(t, h, "taken", models.RoleOwner)
+
+	w := postUserForm(t, router, "/admin/users", url.Values{"username": {"a b"}, "role": {models.RoleViewer}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postUserForm(t, router, "/admin/users", url.Values{"username": {"valid"}, "role": {"root"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postUserForm(t, router, "/admin/users", url.Values{"username": {"taken"}, "role": {models.RoleViewer}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// ---------- SetAdminUserActive ----------
+
+func TestSetAdminUserActive_Deactivate(t *testing.T) {
+	router, h := setupTestRouter(t)
+	owner := createRoleAdmin(t, h, "owner", models.RoleOwner)
+	editor := createRoleAdmin(t, h, "editor", models.RoleEditor)
+	router.POST("/admin/users/:id/active", withAdmin(owner.ID, models.RoleOwner), h.SetAdminUserActive)
+
+	w := postUserForm(t, router, fmt.Sprintf("/admin/users/%d/active", editor.ID), url.Values{"active": {"false"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.Admin
+	h.db.First(&updated, editor.ID)
+	assert.False(t, updated.IsActive)
+
+	w = postUserForm(t, router, fmt.Sprintf("/admin/users/%d/active", editor.ID), url.Values{"active": {"true"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	h.db.First(&updated, editor.ID)
+	assert.True(t, updated.IsActive)
+}
+
+func TestSetAdminUserActive_LastOwner(t *testing.T) {
+	router, h := setupTestRouter(t)
+	owner := createRoleAdmin(t, h, "owner", models.RoleOwner)
+	other := createRoleAdmin(t, h, "owner2", models.RoleOwner)
+	h.db.Model(&other).Update("is_active", false)
+	// Запрос от имени третьего администратора, чтобы не сработала защита от самодеактивации
+	router.POST("/admin/users/:id/active", withAdmin(999, models.RoleOwner), h.SetAdminUserActive)
+	path := fmt.Sprintf("/admin/users/%d/active", owner.ID)
+
+	w := postUserForm(t, router, path, url.Values{"active": {"false"}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var updated models.Admin
+	h.db.First(&updated, owner.ID)
+	assert.True(t, updated.IsActive)
+
+	// Когда есть второй активный владелец - деактивация разрешена
+	h.db.Model(&other).Update("is_active", true)
+	w = postUserForm(t, router, path, url.Values{"active": {"false"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSetAdminUserActive_Self(t *testing.T) {
+	router, h := setupTestRouter(t)
+	owner := createRoleAdmin(t, h, "owner", models.RoleOwner)
+	createRoleAdmin(t, h, "owner2", models.RoleOwner)
+	router.POST("/admin/users/:id/active", withAdmin(owner.ID, models.RoleOwner), h.SetAdminUserActive)
+
+	w := postUserForm(t, router, fmt.Sprintf("/admin/users/%d/active", owner.ID), url.Values{"active": {"false"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// ---------- UpdateAdminUser ----------
+
+func TestUpdateAdminUser_DemoteLastOwner(t *testing.T) {
+	router, h := setupTestRouter(t)
+	owner := createRoleAdmin(t, h, "owner", models.RoleOwner)
+	router.POST("/admin/users/:id/update", h.UpdateAdminUser)
+
+	w := postUserForm(t, router, fmt.Sprintf("/admin/users/%d/update", owner.ID), url.Values{"role": {models.RoleViewer}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = postUserForm(t, router, fmt.Sprintf("/admin/users/%d/update", owner.ID), url.Values{
+		"role":  {models.RoleOwner},
+		"email": {"owner@example.com"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.Admin
+	h.db.First(&updated, owner.ID)
+	assert.Equal(t, "owner@example.com", updated.Email)
+}
+
+// ---------- ResetAdminUserPassword ----------
+
+func TestResetAdminUserPassword(t *testing.T) {
+	router, h := setupTestRouter(t)
+	admin := createRoleAdmin(t, h, "editor", models.RoleEditor)
+	router.POST("/admin/users/:id/reset-password", h.ResetAdminUserPassword)
+	path := fmt.Sprintf("/admin/users/%d/reset-password", admin.ID)
+
+	// Слабый пароль отклоняется политикой
+	w := postUserForm(t, router, path, url.Values{"password": {"password"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postUserForm(t, router, path, url.Values{"password": {"NewPassw0rd"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "NewPassw0rd", "заданный пароль не возвращается")
+
+	var updated models.Admin
+	h.db.First(&updated, admin.ID)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(updated.PasswordHash), []byte("NewPassw0rd")))
+}
+
+// ---------- ValidatePassword ----------
+
+func TestValidatePassword(t *testing.T) {
+	assert.Error(t, ValidatePassword("short1"))
+	assert.Error(t, ValidatePassword("onlyletters"))
+	assert.Error(t, ValidatePassword("1234567890"))
+	assert.NoError(t, ValidatePassword("пароль2024"))
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, ValidatePassword(generateTempPassword()), "временный пароль соответствует политике")
+	}
+}
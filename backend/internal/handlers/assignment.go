@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Ответственные за заявки: назначаются вручную из модалки заявки или автоматически
+// при отправке формы (режим SiteSettings.LeadAssignMode). Ответственным может быть
+// активный администратор с правом работы с заявками (владелец, менеджер).
+
+// leadAssignModeTitles - подписи режимов распределения для страницы настроек
+var leadAssignModeTitles = map[string]string{
+	"":                           "Вручную",
+	models.LeadAssignRoundRobin:  "По очереди",
+	models.LeadAssignLeastLoaded: "Наименее загруженному",
+}
+
+// assignableAdmins возвращает администраторов, которым можно назначить заявку (по ID)
+func assignableAdmins(db *gorm.DB) []models.Admin {
+	var admins []models.Admin
+	if err := db.Where("is_active = ?", true).Order("id ASC").Find(&admins).Error; err != nil {
+		log.Printf("Ошибка загрузки ответственных: %v", err)
+		return nil
+	}
+	out := admins[:0]
+	for _, a := range admins {
+		if RoleHasPermission(a.Role, PermContactsEdit) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// adminNames - логины администраторов по ID (включая отключённых: они остаются в старых заявках)
+func adminNames(db *gorm.DB, ids []uint) map[uint]string {
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names
+	}
+	var admins []models.Admin
+	db.Select("id", "username").Where("id IN ?", ids).Find(&admins)
+	for _, a := range admins {
+		names[a.ID] = a.Username
+	}
+	return names
+}
+
+// assigneeName - логин ответственного за заявку ("" - не назначен)
+func assigneeName(db *gorm.DB, contact *models.ContactForm) string {
+	if contact.AssignedAdminID == nil {
+		return ""
+	}
+	return adminNames(db, []uint{*contact.AssignedAdminID})[*contact.AssignedAdminID]
+}
+
+// autoAssign назначает ответственного за новую заявку по режиму из настроек.
+// Вызывается внутри транзакции сохранения заявки; без подходящих администраторов заявка остаётся без ответственного.
+// Заданный заранее ответственный (заявка из админки) не меняется; публичная форма его не передаёт.
+func autoAssign(tx *gorm.DB, contact *models.ContactForm) error {
+	mode := getSettings(tx).LeadAssignMode
+	if mode == "" || contact.AssignedAdminID != nil {
+		return nil
+	}
+	admins := assignableAdmins(tx)
+	if len(admins) == 0 {
+		return nil
+	}
+
+	var id uint
+	var err error
+	switch mode {
+	case models.LeadAssignRoundRobin:
+		id, err = nextRoundRobin(tx, admins)
+	case models.LeadAssignLeastLoaded:
+		id, err = leastLoaded(tx, admins)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	contact.AssignedAdminID = &id
+	return nil
+}
+
+// nextRoundRobin - следующий по ID администратор после ответственного за последнюю назначенную заявку
+func nextRoundRobin(tx *gorm.DB, admins []models.Admin) (uint, error) {
+	var last models.ContactForm
+	err := tx.Select("id", "assigned_admin_id").
+		Where("assigned_admin_id IS NOT NULL").
+		Order("id DESC").
+		Limit(1).
+		Find(&last).Error
+	if err != nil {
+		return 0, err
+	}
+	if last.AssignedAdminID != nil {
+		for _, a := range admins {
+			if a.ID > *last.AssignedAdminID {
+				return a.ID, nil
+			}
+		}
+	}
+	return admins[0].ID, nil
+}
+
+// leastLoaded - администратор с наименьшим числом заявок в работе (этапы вида "open", не в архиве).
+// При равенстве - с меньшим ID.
+func leastLoaded(tx *gorm.DB, admins []models.Admin) (uint, error) {
+	var openStages []string
+	if err := tx.Model(&models.PipelineStage{}).Where("kind = ?", models.StageOpen).Pluck("slug", &openStages).Error; err != nil {
+		return 0, err
+	}
+	ids := make([]uint, len(admins))
+	for i, a := range admins {
+		ids[i] = a.ID
+	}
+
+	var counts []struct {
+		AssignedAdminID uint
+		Total           int64
+	}
+	if len(openStages) > 0 {
+		if err := tx.Model(&models.ContactForm{}).
+			Select("assigned_admin_id, COUNT(*) AS total").
+			Where("assigned_admin_id IN ? AND archived_at IS NULL AND status IN ?", ids, openStages).
+			Group("assigned_admin_id").
+			Scan(&counts).Error; err != nil {
+			return 0, err
+		}
+	}
+	load := make(map[uint]int64, len(counts))
+	for _, cnt := range counts {
+		load[cnt.AssignedAdminID] = cnt.Total
+	}
+
+	sort.SliceStable(ids, func(i, j int) bool { return load[ids[i]] < load[ids[j]] })
+	return ids[0], nil
+}
+
+// withAssignees дополняет строки таблицы заявок логином ответственного (один запрос)
+func (h *Handlers) withAssignees(rows []contactRow) []contactRow {
+	ids := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		if r.AssignedAdminID != nil {
+			ids = append(ids, *r.AssignedAdminID)
+		}
+	}
+	names := adminNames(h.db, ids)
+	for i := range rows {
+		if rows[i].AssignedAdminID != nil {
+			rows[i].AssignedTo = names[*rows[i].AssignedAdminID]
+		}
+	}
+	return rows
+}
+
+// AssignContact назначает ответственного за заявку (admin_id = 0 - снять).
+// Смена ответственного записывается заметкой к заявке.
+//
+// POST /admin/contacts/:id/assign
+func (h *Handlers) AssignContact(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		AdminID uint `json:"admin_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Неверные данные")
+		return
+	}
+
+	var contact models.ContactForm
+	if err := h.db.First(&contact, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Заявка не найдена")
+		return
+	}
+
+	var assignee *uint
+	if body.AdminID != 0 {
+		valid := false
+		for _, a := range assignableAdmins(h.db) {
+			if a.ID == body.AdminID {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			jsonErr(c, http.StatusBadRequest, "Этому администратору нельзя назначить заявку")
+			return
+		}
+		assignee = &body.AdminID
+	}
+
+	unchanged := (contact.AssignedAdminID == nil && assignee == nil) ||
+		(contact.AssignedAdminID != nil && assignee != nil && *contact.AssignedAdminID == *assignee)
+	from := assigneeName(h.db, &contact)
+	contact.AssignedAdminID = assignee
+	to := assigneeName(h.db, &contact)
+	if unchanged {
+		jsonOK(c, gin.H{"message": "Ответственный не изменился", "assigned_admin_id": assignee, "assigned_to": to})
+		return
+	}
+
+	note := models.ContactNote{
+		ContactID: id,
+		Text:      fmt.Sprintf("Ответственный: %s → %s", orUnassigned(from), orUnassigned(to)),
+		Author:    c.GetString("admin_username"),
+		CreatedAt: NowMSKUTC(),
+	}
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ContactForm{}).Where("id = ?", id).Update("assigned_admin_id", assignee).Error; err != nil {
+			return err
+		}
+		return tx.Create(&note).Error
+	}); err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось назначить ответственного")
+		return
+	}
+	jsonOK(c, gin.H{"message": "Ответственный изменён", "assigned_admin_id": assignee, "assigned_to": to, "note": note})
+}
+
+// orUnassigned - подпись для пустого ответственного в заметке
+func orUnassigned(name string) string {
+	if name == "" {
+		return "не назначен"
+	}
+	return name
+}
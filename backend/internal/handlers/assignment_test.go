@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// createAdmin - администратор с ролью role (active = false - отключён)
+func createAdmin(t *testing.T, h *Handlers, username, role string, active bool) models.Admin {
+	t.Helper()
+	admin := models.Admin{Username: username, PasswordHash: "x", Role: role}
+	assert.NoError(t, h.db.Create(&admin).Error)
+	if !active {
+		// default:true в теге: false при создании заменился бы значением по умолчанию
+		h.db.Model(&admin).Update("is_active", false)
+	}
+	return admin
+}
+
+// setAssignMode включает автоматическое распределение заявок
+func setAssignMode(h *Handlers, mode string) {
+	settings := getSettings(h.db)
+	settings.LeadAssignMode = mode
+	h.db.Save(&settings)
+}
+
+// assigneeOf - ID ответственного за заявку (0 - не назначен)
+func assigneeOf(t *testing.T, h *Handlers, contactID uint) uint {
+	t.Helper()
+	var contact models.ContactForm
+	assert.NoError(t, h.db.First(&contact, contactID).Error)
+	if contact.AssignedAdminID == nil {
+		return 0
+	}
+	return *contact.AssignedAdminID
+}
+
+func TestSubmitContact_RoundRobin(t *testing.T) {
+	_, h := setupTestRouter(t)
+	tg := &recordingNotifier{name: "telegram", kinds: []notify.EventKind{notify.EventNewContact}}
+	o := withTestOutbox(h, tg)
+
+	owner := createAdmin(t, h, "owner", models.RoleOwner, true)
+	createAdmin(t, h, "editor", models.RoleEditor, true)
+	createAdmin(t, h, "former", models.RoleManager, false)
+	manager := createAdmin(t, h, "manager", models.RoleManager, true)
+	setAssignMode(h, models.LeadAssignRoundRobin)
+
+	for i := 0; i < 3; i++ {
+		// Ответственный из публичной формы не принимается
+		fields := map[string]string{"name": "Клиент", "phone": fmt.Sprintf("+7921000000%d", i), "assigned_admin_id": "999"}
+		assert.Equal(t, http.StatusOK, postContact(h, fields))
+	}
+
+	var contacts []models.ContactForm
+	h.db.Order("id").Find(&contacts)
+	if !assert.Len(t, contacts, 3) {
+		return
+	}
+	assert.Equal(t, owner.ID, assigneeOf(t, h, contacts[0].ID))
+	assert.Equal(t, manager.ID, assigneeOf(t, h, contacts[1].ID), "редактор и отключённый менеджер пропускаются")
+	assert.Equal(t, owner.ID, assigneeOf(t, h, contacts[2].ID), "очередь по кругу")
+
+	assert.Equal(t, 3, o.ProcessDue(context.Background(), time.Now()))
+	if assert.Len(t, tg.events, 3) {
+		assert.Equal(t, "owner", tg.events[0].Contact.AssignedTo)
+		assert.Equal(t, "manager", tg.events[1].Contact.AssignedTo)
+	}
+}
+
+func TestSubmitContact_LeastLoaded(t *testing.T) {
+	_, h := setupTestRouter(t)
+	busy := createAdmin(t, h, "busy", models.RoleManager, true)
+	free := createAdmin(t, h, "free", models.RoleManager, true)
+	setAssignMode(h, models.LeadAssignLeastLoaded)
+
+	now := time.Now()
+	for _, cf := range []models.ContactForm{
+		{Name: "А", Phone: "+79211111111", Status: "new", AssignedAdminID: &busy.ID},
+		{Name: "Б", Phone: "+79211111112", Status: "qualified", AssignedAdminID: &busy.ID},
+		{Name: "В", Phone: "+79211111113", Status: "new", AssignedAdminID: &free.ID},
+		// Закрытые и архивные заявки не считаются нагрузкой
+		{Name: "Г", Phone: "+79211111114", Status: "won", AssignedAdminID: &free.ID},
+		{Name: "Д", Phone: "+79211111115", Status: "lost", AssignedAdminID: &free.ID},
+		{Name: "Е", Phone: "+79211111116", Status: "new", ArchivedAt: &now, AssignedAdminID: &free.ID},
+	} {
+		cf.IP = "10.0.0.1" // не участвуют в лимите заявок с адреса теста
+		h.db.Create(&cf)
+	}
+
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Иван", "phone": "+79212222221"}))
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Пётр", "phone": "+79212222222"}))
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Бот", "phone": "+79212222223", "message": "https://spam.example"}))
+
+	var contacts []models.ContactForm
+	h.db.Where("phone LIKE ?", "+7921222222%").Order("id").Find(&contacts)
+	if !assert.Len(t, contacts, 3) {
+		return
+	}
+	assert.Equal(t, free.ID, assigneeOf(t, h, contacts[0].ID))
+	assert.Equal(t, busy.ID, assigneeOf(t, h, contacts[1].ID), "при равной нагрузке - меньший ID")
+	assert.Equal(t, "spam", contacts[2].Status)
+	assert.Zero(t, assigneeOf(t, h, contacts[2].ID), "спам не назначается")
+}
+
+func TestAssignContact(t *testing.T) {
+	_, h := setupTestRouter(t)
+	owner := createAdmin(t, h, "owner", models.RoleOwner, true)
+	manager := createAdmin(t, h, "manager", models.RoleManager, true)
+	editor := createAdmin(t, h, "editor", models.RoleEditor, true)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_id", owner.ID)
+		c.Set("admin_username", owner.Username)
+		c.Next()
+	})
+	router.POST("/admin/contacts/:id/assign", h.AssignContact)
+
+	contact := models.ContactForm{Name: "Иван", Phone: "+79211234567", Status: "new"}
+	h.db.Create(&contact)
+
+	assign := func(contactID, adminID uint) int {
+		data, _ := json.Marshal(map[string]uint{"admin_id": adminID})
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/contacts/%d/assign", contactID), bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, assign(contact.ID, manager.ID))
+	assert.Equal(t, manager.ID, assigneeOf(t, h, contact.ID))
+	assert.Equal(t, http.StatusOK, assign(contact.ID, manager.ID), "повтор не пишет заметку")
+	assert.Equal(t, http.StatusBadRequest, assign(contact.ID, editor.ID), "у редактора нет права на заявки")
+	assert.Equal(t, http.StatusNotFound, assign(contact.ID+100, manager.ID))
+	assert.Equal(t, http.StatusOK, assign(contact.ID, 0))
+	assert.Zero(t, assigneeOf(t, h, contact.ID))
+
+	var notes []models.ContactNote
+	h.db.Where("contact_id = ?", contact.ID).Order("id").Find(&notes)
+	if assert.Len(t, notes, 2) {
+		assert.Equal(t, "Ответственный: не назначен → manager", notes[0].Text)
+		assert.Equal(t, "owner", notes[0].Author)
+		assert.Equal(t, "Ответственный: manager → не назначен", notes[1].Text)
+	}
+}
+
+func TestAdminContactsPage_AssignedFilter(t *testing.T) {
+	_, h := setupTestRouter(t)
+	owner := createAdmin(t, h, "owner", models.RoleOwner, true)
+	manager := createAdmin(t, h, "manager", models.RoleManager, true)
+
+	router := gin.New()
+	router.Use(withAdmin(manager.ID, models.RoleManager))
+	router.SetHTMLTemplate(template.Must(template.New("admin_base.html").Parse(
+		`{{range .contactsAll}}{{.Name}}:{{.AssignedTo}},{{end}}`,
+	)))
+	router.GET("/admin/contacts", h.AdminContactsPage)
+
+	h.db.Create(&models.ContactForm{Name: "Моя", Phone: "+79211111111", Status: "new", AssignedAdminID: &manager.ID})
+	h.db.Create(&models.ContactForm{Name: "Чужая", Phone: "+79212222222", Status: "new", AssignedAdminID: &owner.ID})
+	h.db.Create(&models.ContactForm{Name: "Ничья", Phone: "+79213333333", Status: "new"})
+
+	get := func(query string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/contacts?"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	assert.Equal(t, "Моя:manager,", get("assigned=me"))
+	assert.Equal(t, "Ничья:,", get("assigned=none"))
+	assert.Equal(t, "Чужая:owner,", get(fmt.Sprintf("assigned=%d", owner.ID)))
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"ledsite/internal/attribution"
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Атрибуция заявок: middleware.Attribution запоминает в cookie первый и последний визит
+// (UTM-метки, реферер, страница входа), SubmitContact сохраняет их в contact_attributions.
+
+// Источник для заявок без атрибуции (старые заявки, Telegram бот, cookie отключены)
+const noAttributionSource = "(нет данных)"
+
+// csvHeadersAttribution - колонки атрибуции в экспорте заявок
+var csvHeadersAttribution = []string{
+	"Источник", "Канал", "Кампания", "Ключевое слово", "Объявление", "Реферер", "Страница входа",
+	"Первый источник", "Первый канал", "Первая кампания", "Первый реферер", "Первая страница входа",
+	"Страница заявки",
+}
+
+// contactAttribution собирает атрибуцию заявки из cookie визитов и страницы, с которой отправлена форма.
+// Без cookie используются UTM-метки в адресе страницы формы; ok = false - источник неизвестен.
+func contactAttribution(r *http.Request, now time.Time) (a models.ContactAttribution, ok bool) {
+	formPage := r.Referer()
+	if u, err := url.Parse(formPage); err == nil && u.Host != "" {
+		a.FormPage = truncateString(u.RequestURI(), 500)
+	}
+
+	if first, last, found := attribution.FromCookies(r); found {
+		a.First, a.Last = first, last
+		return a, true
+	}
+	if t, found := attribution.FromPage(formPage, now); found {
+		a.First, a.Last = t, t
+		return a, true
+	}
+	return a, false
+}
+
+// attributionsFor - атрибуция заявок по ID заявки (запросы пачками: лимит параметров в БД)
+func attributionsFor(db *gorm.DB, contactIDs []uint) map[uint]models.ContactAttribution {
+	out := make(map[uint]models.ContactAttribution, len(contactIDs))
+	const chunk = 500
+	for start := 0; start < len(contactIDs); start += chunk {
+		end := start + chunk
+		if end > len(contactIDs) {
+			end = len(contactIDs)
+		}
+		var rows []models.ContactAttribution
+		db.Where("contact_id IN ?", contactIDs[start:end]).Find(&rows)
+		for _, a := range rows {
+			out[a.ContactID] = a
+		}
+	}
+	return out
+}
+
+// attributionCSV - значения колонок csvHeadersAttribution
+func attributionCSV(a models.ContactAttribution) []string {
+	return []string{
+		a.Last.Source, a.Last.Medium, a.Last.Campaign, a.Last.Term, a.Last.Content, a.Last.Referrer, a.Last.Landing,
+		a.First.Source, a.First.Medium, a.First.Campaign, a.First.Referrer, a.First.Landing,
+		a.FormPage,
+	}
+}
+
+// GetContactAttribution возвращает источник заявки (null - нет данных)
+//
+// GET /admin/contacts/:id/attribution
+func (h *Handlers) GetContactAttribution(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var rows []models.ContactAttribution
+	if err := h.db.Where("contact_id = ?", id).Limit(1).Find(&rows).Error; err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось получить источник заявки")
+		return
+	}
+	var a *models.ContactAttribution
+	if len(rows) > 0 {
+		a = &rows[0]
+	}
+	jsonOK(c, gin.H{"attribution": a})
+}
+
+// leadSourceRow - строка отчёта «Заявки по источникам» на dashboard
+type leadSourceRow struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Leads    int
+	Won      int // заявки в этапах вида "сделка"
+	Percent  int // доля от всех заявок за период
+}
+
+// leadSources группирует заявки с since (без спама) по источнику, каналу и кампании
+// последнего визита (first = true - первого). Сортировка по числу заявок.
+func (h *Handlers) leadSources(since time.Time, first bool) []leadSourceRow {
+	var contacts []models.ContactForm
+	h.db.Select("id", "status").Where("created_at >= ? AND status <> ?", since, "spam").Find(&contacts)
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	won := map[string]bool{}
+	for _, s := range h.pipelineStages() {
+		if s.Kind == models.StageWon {
+			won[s.Slug] = true
+		}
+	}
+
+	ids := make([]uint, len(contacts))
+	for i, cf := range contacts {
+		ids[i] = cf.ID
+	}
+	attrs := attributionsFor(h.db, ids)
+
+	type key struct{ source, medium, campaign string }
+	index := map[key]int{}
+	var rows []leadSourceRow
+	for _, cf := range contacts {
+		k := key{source: noAttributionSource}
+		if a, ok := attrs[cf.ID]; ok {
+			t := a.Last
+			if first {
+				t = a.First
+			}
+			k = key{t.Source, t.Medium, t.Campaign}
+		}
+		i, ok := index[k]
+		if !ok {
+			i = len(rows)
+			index[k] = i
+			rows = append(rows, leadSourceRow{Source: k.source, Medium: k.medium, Campaign: k.campaign})
+		}
+		rows[i].Leads++
+		if won[cf.Status] {
+			rows[i].Won++
+		}
+	}
+
+	for i := range rows {
+		rows[i].Percent = rows[i].Leads * 100 / len(contacts)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Leads != rows[j].Leads {
+			return rows[i].Leads > rows[j].Leads
+		}
+		return rows[i].Source < rows[j].Source
+	})
+	return rows
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ledsite/internal/attribution"
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitContact_Attribution(t *testing.T) {
+	_, h := setupTestRouter(t)
+	at := time.Now().Add(-time.Hour)
+	first := models.AttributionTouch{Source: "google", Medium: "organic", Referrer: "https://www.google.ru/", Landing: "/", At: &at}
+	last := models.AttributionTouch{Source: "yandex", Medium: "cpc", Campaign: "spb_led", Landing: "/prices?utm_source=yandex", At: &at}
+
+	submit := func(phone, referrer string, cookies ...*http.Cookie) {
+		fields := map[string]string{"name": "Иван", "phone": phone}
+		code := postContact(h, fields, contactRequest{Headers: map[string]string{"Referer": referrer}, Cookies: cookies})
+		assert.Equal(t, http.StatusOK, code)
+	}
+	submit("+79211111111", "https://s-n-r.ru/contact",
+		&http.Cookie{Name: attribution.FirstCookie, Value: attribution.Encode(first)},
+		&http.Cookie{Name: attribution.LastCookie, Value: attribution.Encode(last)})
+	// Без cookie - метки из адреса страницы формы
+	submit("+79212222222", "https://s-n-r.ru/contact?utm_source=vk&utm_medium=social")
+	// Без cookie и меток источник неизвестен
+	submit("+79213333333", "https://s-n-r.ru/contact")
+
+	var contacts []models.ContactForm
+	h.db.Order("id").Find(&contacts)
+	if !assert.Len(t, contacts, 3) {
+		return
+	}
+	var attrs []models.ContactAttribution
+	h.db.Order("contact_id").Find(&attrs)
+	if !assert.Len(t, attrs, 2) {
+		return
+	}
+	assert.Equal(t, contacts[0].ID, attrs[0].ContactID)
+	assert.Equal(t, "google", attrs[0].First.Source)
+	assert.Equal(t, "yandex", attrs[0].Last.Source)
+	assert.Equal(t, "spb_led", attrs[0].Last.Campaign)
+	assert.Equal(t, "/contact", attrs[0].FormPage)
+	assert.Equal(t, "vk", attrs[1].First.Source)
+	assert.Equal(t, "vk", attrs[1].Last.Source)
+
+	router := gin.New()
+	router.GET("/admin/contacts/:id/attribution", h.GetContactAttribution)
+	for i, want := range []string{`"source":"yandex"`, `"attribution":null`} {
+		id := contacts[i*2].ID
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/admin/contacts/%d/attribution", id), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), want)
+	}
+}
+
+func TestSubmitContact_AttributionErrorKeepsContact(t *testing.T) {
+	_, h := setupTestRouter(t)
+	// Ошибка вставки атрибуции (на PostgreSQL - например, слишком длинное значение)
+	assert.NoError(t, h.db.Migrator().DropTable(&models.ContactAttribution{}))
+
+	code := postContact(h, map[string]string{"name": "Иван", "phone": "+79211111111"},
+		contactRequest{Headers: map[string]string{"Referer": "https://s-n-r.ru/contact?utm_source=vk"}})
+	assert.Equal(t, http.StatusOK, code)
+	var count int64
+	h.db.Model(&models.ContactForm{}).Count(&count)
+	assert.Equal(t, int64(1), count, "заявка сохраняется без атрибуции")
+}
+
+func TestLeadSources(t *testing.T) {
+	_, h := setupTestRouter(t)
+	create := func(status string, touch *models.AttributionTouch) {
+		cf := models.ContactForm{Name: "Клиент", Phone: "+79210000000", Status: status}
+		h.db.Create(&cf)
+		if touch != nil {
+			h.db.Create(&models.ContactAttribution{ContactID: cf.ID, First: models.AttributionTouch{Source: "google", Medium: "organic"}, Last: *touch})
+		}
+	}
+	cpc := &models.AttributionTouch{Source: "yandex", Medium: "cpc", Campaign: "spb_led"}
+	create("new", cpc)
+	create("won", cpc)
+	create("new", &models.AttributionTouch{Source: "vk", Medium: "social"})
+	create("new", nil)
+	create("spam", cpc)
+
+	rows := h.leadSources(time.Now().Add(-time.Hour), false)
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, leadSourceRow{Source: "yandex", Medium: "cpc", Campaign: "spb_led", Leads: 2, Won: 1, Percent: 50}, rows[0])
+		assert.Equal(t, noAttributionSource, rows[1].Source, "при равенстве - по алфавиту")
+		assert.Equal(t, "vk", rows[2].Source)
+	}
+
+	rows = h.leadSources(time.Now().Add(-time.Hour), true)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, "google", rows[0].Source)
+		assert.Equal(t, 3, rows[0].Leads)
+	}
+}
+
+func TestAdminContactsExportCSV_Attribution(t *testing.T) {
+	_, h := setupTestRouter(t)
+	cf := models.ContactForm{Name: "Иван", Phone: "+79211111111", Status: "new"}
+	h.db.Create(&cf)
+	h.db.Create(&models.ContactAttribution{
+		ContactID: cf.ID,
+		First:     models.AttributionTouch{Source: "google", Medium: "organic"},
+		Last:      models.AttributionTouch{Source: "yandex", Medium: "cpc", Campaign: "spb_led"},
+		FormPage:  "/contact",
+	})
+
+	router := gin.New()
+	router.GET("/admin/contacts/export.csv", h.AdminContactsExportCSV)
+	req, _ := http.NewRequest("GET", "/admin/contacts/export.csv", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], "Источник;Канал;Кампания")
+		assert.True(t, strings.HasSuffix(lines[1], ";yandex;cpc;spb_led;;;;;google;organic;;;;/contact"), lines[1])
+	}
+}
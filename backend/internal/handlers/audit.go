@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Журнал изменений в админке.
+// middleware.Audit вызывает BeginAudit до обработчика и FinishAudit после него:
+// до и после снимается снимок сущности из БД, в журнал пишутся только изменившиеся поля.
+const (
+	auditEntityIDKey = "audit_entity_id" // ключ gin.Context: ID сущности, созданной обработчиком
+	auditMaxParams   = 4 << 10           // ограничение на размер параметров запроса в журнале
+	auditMaxBody     = 64 << 10          // сколько JSON тела запроса читается для журнала
+)
+
+// auditEntity описывает, какая сущность меняется роутами с префиксом prefix
+type auditEntity struct {
+	prefix    string // шаблон роута (c.FullPath()) без /admin
+	name      string // тип сущности в журнале
+	model     any    // модель GORM для снимков; nil - только параметры запроса
+	idParam   string // параметр роута с ID сущности
+	singleton bool   // единственная запись (настройки): снимок первой строки таблицы
+}
+
+// auditEntities - сущности админки. Порядок важен: более длинные префиксы раньше.
+var auditEntities = []auditEntity{
+	{prefix: "/contacts/:id/notes/:note_id", name: "contact_note", model: &models.ContactNote{}, idParam: "note_id"},
+	{prefix: "/contacts/:id/notes", name: "contact_note", model: &models.ContactNote{}},
+	{prefix: "/contacts/:id/quotes", name: "contact_quote"}, // без снимков: в строке PDF
+	{prefix: "/contacts/:id/notifications/:delivery_id", name: "notification_delivery", model: &models.NotificationDelivery{}, idParam: "delivery_id"},
+	{prefix: "/contacts/import", name: "contact_import"}, // без снимков: итог в журнале сервера
+	{prefix: "/contacts/views", name: "contact_view", model: &models.ContactView{}, idParam: "id"},
+	{prefix: "/contacts", name: "contact", model: &models.ContactForm{}, idParam: "id"},
+	{prefix: "/customers", name: "customer", model: &models.Customer{}, idParam: "id"},
+	{prefix: "/pipeline", name: "pipeline_stage", model: &models.PipelineStage{}, idParam: "id"},
+	{prefix: "/prices/images", name: "price_image", model: &models.PriceImage{}, idParam: "id"},
+	{prefix: "/prices/upload-images", name: "price_image"},
+	{prefix: "/prices", name: "price_item", model: &models.PriceItem{}, idParam: "id"},
+	{prefix: "/projects", name: "project", model: &models.Project{}, idParam: "id"},
+	{prefix: "/images", name: "image", model: &models.Image{}, idParam: "id"},
+	{prefix: "/upload-images", name: "image"},
+	{prefix: "/categories", name: "category", model: &models.Category{}, idParam: "id"},
+	{prefix: "/services", name: "service", model: &models.Service{}, idParam: "id"},
+	{prefix: "/map-points", name: "map_point", model: &models.MapPoint{}, idParam: "id"},
+	{prefix: "/calculator/pitches", name: "calculator_pitch", model: &models.CalculatorPixelPitch{}, idParam: "id"},
+	{prefix: "/calculator/settings", name: "calculator_settings", model: &models.CalculatorSettings{}, singleton: true},
+	{prefix: "/promo", name: "promo_popup", model: &models.PromoPopup{}, singleton: true},
+	{prefix: "/settings", name: "site_settings", model: &models.SiteSettings{}, singleton: true},
+	{prefix: "/users", name: "admin", model: &models.Admin{}, idParam: "id"},
+	{prefix: "/sessions", name: "admin_session", model: &models.AdminSession{}, idParam: "id"},
+	{prefix: "/security", name: "admin", model: &models.Admin{}},
+	{prefix: "/analytics", name: "analytics"},
+}
+
+// auditEntityTitles - подписи сущностей для страницы журнала
+var auditEntityTitles = map[string]string{
+	"contact":               "Заявка",
+	"contact_note":          "Заметка",
+	"contact_quote":         "КП",
+	"contact_view":          "Вид списка заявок",
+	"contact_import":        "Импорт заявок",
+	"notification_delivery": "Уведомление",
+	"customer":              "Клиент",
+	"pipeline_stage":        "Этап воронки",
+	"price_item":            "Позиция прайса",
+	"price_image":           "Изображение прайса",
+	"project":               "Проект",
+	"image":                 "Изображение проекта",
+	"category":              "Категория",
+	"service":               "Услуга",
+	"map_point":             "Точка на карте",
+	"calculator_pitch":      "Шаг пикселя",
+	"calculator_settings":   "Настройки калькулятора",
+	"promo_popup":           "Промо popup",
+	"site_settings":         "Настройки сайта",
+	"admin":                 "Администратор",
+	"admin_session":         "Сессия",
+	"analytics":             "Статистика",
+}
+
+// auditSecretFields - поля, которые не попадают в журнал (только факт изменения)
+var auditSecretFields = map[string]bool{
+	"password_hash":     true,
+	"totp_secret":       true,
+	"refresh_hash":      true,
+	"prev_refresh_hash": true,
+	"code_hash":         true,
+}
+
+// auditIgnoredFields - служебные поля, изменение которых само по себе не интересно
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// AuditRecord - изменяющий запрос в процессе обработки (между BeginAudit и FinishAudit)
+type AuditRecord struct {
+	entity   auditEntity
+	entityID uint
+	before   map[string]any
+	body     []byte // JSON тело запроса (формы читаются после обработчика)
+}
+
+// setAuditEntityID сообщает журналу ID сущности, созданной обработчиком
+func setAuditEntityID(c *gin.Context, id uint) {
+	c.Set(auditEntityIDKey, id)
+}
+
+// BeginAudit снимает состояние сущности до изменения
+func (h *Handlers) BeginAudit(c *gin.Context) *AuditRecord {
+	rec := &AuditRecord{entity: findAuditEntity(c.FullPath())}
+
+	if rec.entity.idParam != "" {
+		if id, err := strconv.ParseUint(c.Param(rec.entity.idParam), 10, 64); err == nil {
+			rec.entityID = uint(id)
+		}
+	}
+	if rec.entity.prefix == "/security" {
+		rec.entityID = c.GetUint("admin_id") // 2FA меняет собственный аккаунт
+	}
+	rec.before = h.auditSnapshot(rec.entity, rec.entityID)
+	if rec.entity.singleton && rec.before != nil {
+		rec.entityID = auditRowID(rec.before)
+	}
+
+	// JSON тело читается сейчас и возвращается обработчику
+	if strings.HasPrefix(c.ContentType(), "application/json") && c.Request.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, auditMaxBody))
+		if err == nil {
+			rec.body = body
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+		}
+	}
+	return rec
+}
+
+// FinishAudit пишет запись в журнал, если запрос завершился успешно
+func (h *Handlers) FinishAudit(c *gin.Context, rec *AuditRecord) {
+	if rec == nil || c.Writer.Status() >= http.StatusBadRequest {
+		return
+	}
+
+	// Обработчик создал новую сущность (в т.ч. дубликат) - состояния «до» нет
+	if id := c.GetUint(auditEntityIDKey); id != 0 && id != rec.entityID {
+		rec.entityID = id
+		rec.before = nil
+	}
+	after := h.auditSnapshot(rec.entity, rec.entityID)
+	if rec.entity.singleton && after != nil {
+		rec.entityID = auditRowID(after)
+	}
+
+	before, afterDiff := auditDiff(rec.before, after)
+	entry := models.AuditLog{
+		AdminID:       c.GetUint("admin_id"),
+		AdminUsername: c.GetString("admin_username"),
+		Action:        auditAction(c, rec.entity),
+		Entity:        rec.entity.name,
+		EntityID:      rec.entityID,
+		Method:        c.Request.Method,
+		Path:          truncateString(c.Request.URL.Path, 255),
+		IP:            c.ClientIP(),
+		Before:        auditJSON(before),
+		After:         auditJSON(afterDiff),
+		CreatedAt:     time.Now(),
+	}
+	// Параметры нужны, когда изменение не видно по одной сущности (массовые действия, сортировка)
+	if entry.EntityID == 0 || (entry.Before == "" && entry.After == "") {
+		entry.Params = auditParams(c, rec.body)
+	}
+
+	if err := h.db.Create(&entry).Error; err != nil {
+		log.Printf("Ошибка записи в журнал изменений: %v", err)
+	}
+}
+
+// findAuditEntity подбирает сущность по шаблону роута
+func findAuditEntity(fullPath string) auditEntity {
+	path := strings.TrimPrefix(fullPath, "/admin")
+	for _, e := range auditEntities {
+		if path == e.prefix || strings.HasPrefix(path, e.prefix+"/") {
+			return e
+		}
+	}
+	// Неизвестный роут: сущность - первый сегмент пути
+	name := strings.Trim(path, "/")
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		name = "admin_panel"
+	}
+	return auditEntity{prefix: "/" + name, name: name}
+}
+
+// auditAction - действие: последний статичный сегмент роута (update, archive, sort...)
+// или create/update/delete по HTTP методу
+func auditAction(c *gin.Context, e auditEntity) string {
+	rest := strings.TrimPrefix(strings.TrimPrefix(c.FullPath(), "/admin"), e.prefix)
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if last := segments[len(segments)-1]; last != "" && !strings.HasPrefix(last, ":") {
+		return last
+	}
+	switch c.Request.Method {
+	case http.MethodDelete:
+		return "delete"
+	case http.MethodPost:
+		if e.singleton {
+			return "update"
+		}
+		return "create"
+	default:
+		return "update"
+	}
+}
+
+// auditSnapshot читает строку сущности как map (без секретных полей)
+func (h *Handlers) auditSnapshot(e auditEntity, id uint) map[string]any {
+	if e.model == nil || (id == 0 && !e.singleton) {
+		return nil
+	}
+
+	row := map[string]any{}
+	q := h.db.Model(e.model)
+	if e.singleton {
+		q = q.Order("id ASC")
+	} else {
+		q = q.Where("id = ?", id)
+	}
+	res := q.Limit(1).Find(&row)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil
+	}
+
+	for k, v := range row {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+			row[k] = v
+		}
+		if auditSecretFields[k] {
+			// Вместо секрета - отпечаток: по нему видно изменение, но не значение
+			row[k] = hashToken(auditValue(v))
+		}
+	}
+	return row
+}
+
+// auditRowID возвращает id из снимка строки
+func auditRowID(row map[string]any) uint {
+	switch v := row["id"].(type) {
+	case int64:
+		return uint(v)
+	case int32:
+		return uint(v)
+	case int:
+		return uint(v)
+	case uint:
+		return v
+	case uint64:
+		return uint(v)
+	}
+	return 0
+}
+
+// auditDiff оставляет только изменившиеся поля. При создании возвращает всё состояние «после»,
+// при удалении - всё состояние «до». Секретные поля показываются только как факт изменения.
+func auditDiff(before, after map[string]any) (map[string]any, map[string]any) {
+	if before == nil || after == nil {
+		return maskSecrets(before), maskSecrets(after)
+	}
+
+	b, a := map[string]any{}, map[string]any{}
+	for k, av := range after {
+		if auditIgnoredFields[k] {
+			continue
+		}
+		bv := before[k]
+		if auditValue(bv) == auditValue(av) {
+			continue
+		}
+		if auditSecretFields[k] {
+			b[k], a[k] = "***", "*** (изменено)"
+			continue
+		}
+		b[k], a[k] = bv, av
+	}
+	return b, a
+}
+
+// maskSecrets убирает отпечатки секретных полей из полного снимка
+func maskSecrets(row map[string]any) map[string]any {
+	for k := range row {
+		if auditSecretFields[k] {
+			row[k] = "***"
+		}
+	}
+	return row
+}
+
+// auditValue - значение в виде JSON для сравнения (time.Time и числа разных типов)
+func auditValue(v any) string {
+	if t, ok := v.(time.Time); ok {
+		v = t.UTC()
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// auditJSON сериализует снимок; пустой снимок - пустая строка
+func auditJSON(m map[string]any) string {
+	if len(m) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// auditParams - параметры запроса без паролей и кодов (для массовых операций)
+func auditParams(c *gin.Context, body []byte) string {
+	var out string
+	switch {
+	case len(body) > 0:
+		out = string(body)
+	case c.Request.MultipartForm != nil:
+		out = auditFormValues(c.Request.MultipartForm.Value)
+	case c.Request.PostForm != nil:
+		out = auditFormValues(c.Request.PostForm)
+	}
+	if len(out) > auditMaxParams {
+		out = out[:auditMaxParams]
+	}
+	return out
+}
+
+// auditFormValues сериализует поля формы, скрывая пароли и коды
+func auditFormValues(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+	clean := make(map[string]any, len(values))
+	for k, v := range values {
+		lk := strings.ToLower(k)
+		switch {
+		case strings.Contains(lk, "password"), strings.Contains(lk, "code"), strings.Contains(lk, "token"):
+			clean[k] = "***"
+		case len(v) == 1:
+			clean[k] = v[0]
+		default:
+			clean[k] = v
+		}
+	}
+	b, _ := json.Marshal(clean)
+	return string(b)
+}
+
+// ---------- Страница журнала ----------
+
+// auditFilter - фильтры журнала из query (общие для страницы и CSV)
+type auditFilter struct {
+	AdminID  uint
+	Entity   string
+	EntityID uint
+	Action   string
+	Date     string
+}
+
+// parseAuditFilter читает фильтры журнала из query
+func parseAuditFilter(c *gin.Context) auditFilter {
+	f := auditFilter{
+		Entity: strings.TrimSpace(c.Query("entity")),
+		Action: strings.TrimSpace(c.Query("action")),
+		Date:   c.Query("date"),
+	}
+	if v, err := strconv.ParseUint(c.Query("admin"), 10, 64); err == nil {
+		f.AdminID = uint(v)
+	}
+	if v, err := strconv.ParseUint(c.Query("entity_id"), 10, 64); err == nil {
+		f.EntityID = uint(v)
+	}
+	return f
+}
+
+// query строит запрос к журналу с фильтрами
+func (f auditFilter) query(db *gorm.DB) *gorm.DB {
+	qb := db.Model(&models.AuditLog{})
+	if f.AdminID != 0 {
+		qb = qb.Where("admin_id = ?", f.AdminID)
+	}
+	if f.Entity != "" {
+		qb = qb.Where("entity = ?", f.Entity)
+	}
+	if f.EntityID != 0 {
+		qb = qb.Where("entity_id = ?", f.EntityID)
+	}
+	if f.Action != "" {
+		qb = qb.Where("action = ?", f.Action)
+	}
+	return applyDateFilter(qb, f.Date)
+}
+
+// values - фильтры в виде query строки (для ссылок пагинации и экспорта)
+func (f auditFilter) values() url.Values {
+	v := url.Values{}
+	if f.AdminID != 0 {
+		v.Set("admin", strconv.FormatUint(uint64(f.AdminID), 10))
+	}
+	if f.Entity != "" {
+		v.Set("entity", f.Entity)
+	}
+	if f.EntityID != 0 {
+		v.Set("entity_id", strconv.FormatUint(uint64(f.EntityID), 10))
+	}
+	if f.Action != "" {
+		v.Set("action", f.Action)
+	}
+	if f.Date != "" {
+		v.Set("date", f.Date)
+	}
+	return v
+}
+
+// auditEntityOption - пункт фильтра по сущности
+type auditEntityOption struct {
+	Value string
+	Title string
+}
+
+// AdminAuditPage — /admin/audit: журнал изменений с фильтрами и пагинацией
+func (h *Handlers) AdminAuditPage(c *gin.Context) {
+	filter := parseAuditFilter(c)
+	page, limit, offset := h.getPageQuery(c)
+
+	var total int64
+	if err := filter.query(h.db).Count(&total).Error; err != nil {
+		log.Printf("Ошибка подсчёта журнала изменений: %v", err)
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
+			"PageID": "admin-error",
+			"error":  "Ошибка загрузки данных",
+		})
+		return
+	}
+
+	var entries []models.AuditLog
+	if err := filter.query(h.db).Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		log.Printf("Ошибка загрузки журнала изменений: %v", err)
+		renderAdmin(c, http.StatusInternalServerError, gin.H{
+			"PageID": "admin-error",
+			"error":  "Ошибка загрузки данных",
+		})
+		return
+	}
+
+	var admins []models.Admin
+	h.db.Order("username ASC").Find(&admins)
+
+	entities := make([]auditEntityOption, 0, len(auditEntityTitles))
+	for value, title := range auditEntityTitles {
+		entities = append(entities, auditEntityOption{Value: value, Title: title})
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].Title < entities[j].Title })
+
+	var actions []string
+	h.db.Model(&models.AuditLog{}).Distinct("action").Order("action ASC").Pluck("action", &actions)
+
+	pages, prevPage, nextPage, pageNumbers := h.pageMeta(total, page, limit)
+	query := filter.values()
+	query.Set("limit", strconv.Itoa(limit))
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":        "Журнал изменений",
+		"PageID":       "admin-audit",
+		"entries":      entries,
+		"entityTitles": auditEntityTitles,
+		"entities":     entities,
+		"actions":      actions,
+		"admins":       admins,
+		"filter":       filter,
+		"filterQuery":  template.URL(query.Encode()),
+		"exportQuery":  template.URL(filter.values().Encode()),
+		"total":        total,
+		"page":         page,
+		"pages":        pages,
+		"prevPage":     prevPage,
+		"nextPage":     nextPage,
+		"pageNumbers":  pageNumbers,
+		"limit":        limit,
+	})
+}
+
+var csvHeadersAudit = []string{
+	"Дата", "Администратор", "Действие", "Сущность", "ID", "Метод", "Путь", "IP", "До", "После", "Параметры",
+}
+
+// AdminAuditExportCSV — выгрузка журнала изменений с теми же фильтрами в CSV
+func (h *Handlers) AdminAuditExportCSV(c *gin.Context) {
+	var entries []models.AuditLog
+	if err := parseAuditFilter(c).query(h.db).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		c.String(http.StatusInternalServerError, "DB error")
+		return
+	}
+
+	filename := "audit_export_" + time.Now().Format("20060102_150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	// UTF-8 BOM для корректного открытия в Excel
+	if _, err := c.Writer.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		c.String(http.StatusInternalServerError, "Error writing BOM")
+		return
+	}
+	w := csv.NewWriter(c.Writer)
+	w.Comma = ';'
+	if err := w.Write(csvHeadersAudit); err != nil {
+		c.String(http.StatusInternalServerError, "Error writing CSV header")
+		return
+	}
+	for _, e := range entries {
+		if err := w.Write([]string{
+			e.CreatedAt.In(moscowLoc).Format("02.01.2006 15:04:05"),
+			e.AdminUsername, e.Action, e.Entity, strconv.FormatUint(uint64(e.EntityID), 10),
+			e.Method, e.Path, e.IP, e.Before, e.After, e.Params,
+		}); err != nil {
+			c.String(http.StatusInternalServerError, "Error writing CSV row")
+			return
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("CSV flush error: %v", err)
+	}
+}
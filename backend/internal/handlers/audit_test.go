@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAuditRouter - роутер с админом в контексте и журналом изменений
+// (повторяет middleware.Audit: handlers не может импортировать middleware)
+func setupAuditRouter(t *testing.T) (*gin.Engine, *Handlers, models.Admin) {
+	router, h := setupTestRouter(t)
+	owner := createRoleAdmin(t, h, "owner", models.RoleOwner)
+
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_id", owner.ID)
+		c.Set("admin_username", owner.Username)
+		c.Set("admin_role", owner.Role)
+		if c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+		rec := h.BeginAudit(c)
+		c.Next()
+		h.FinishAudit(c, rec)
+	})
+	return router, h, owner
+}
+
+// lastAudit возвращает последнюю запись журнала
+func lastAudit(t *testing.T, h *Handlers) models.AuditLog {
+	t.Helper()
+	var entry models.AuditLog
+	assert.NoError(t, h.db.Order("id DESC").First(&entry).Error)
+	return entry
+}
+
+// auditFields разбирает JSON снимка из журнала
+func auditFields(t *testing.T, s string) map[string]any {
+	t.Helper()
+	m := map[string]any{}
+	assert.NoError(t, json.Unmarshal([]byte(s), &m))
+	return m
+}
+
+// ---------- Запись изменений ----------
+
+func TestAudit_UpdateRecordsDiff(t *testing.T) {
+	router, h, owner := setupAuditRouter(t)
+	router.POST("/admin/categories/:id/update", h.UpdateCategory)
+
+	cat := models.Category{Name: "Старое", Slug: "old", Description: "Описание"}
+	h.db.Create(&cat)
+
+	w := postUserForm(t, router, fmt.Sprintf("/admin/categories/%d/update", cat.ID),
+		url.Values{"name": {"Новое"}, "description": {"Описание"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	entry := lastAudit(t, h)
+	assert.Equal(t, owner.ID, entry.AdminID)
+	assert.Equal(t, "owner", entry.AdminUsername)
+	assert.Equal(t, "category", entry.Entity)
+	assert.Equal(t, cat.ID, entry.EntityID)
+	assert.Equal(t, "update", entry.Action)
+	assert.Equal(t, map[string]any{"name": "Старое"}, auditFields(t, entry.Before), "только изменившиеся поля")
+	assert.Equal(t, map[string]any{"name": "Новое"}, auditFields(t, entry.After))
+	assert.Empty(t, entry.Params)
+}
+
+func TestAudit_CreateAndDelete(t *testing.T) {
+	router, h, _ := setupAuditRouter(t)
+	router.POST("/admin/categories", h.CreateCategory)
+	router.DELETE("/admin/categories/:id", h.DeleteCategory)
+
+	w := postUserForm(t, router, "/admin/categories", url.Values{"name": {"Уличные"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	created := lastAudit(t, h)
+	assert.Equal(t, "create", created.Action)
+	assert.NotZero(t, created.EntityID, "ID новой сущности сообщает обработчик")
+	assert.Empty(t, created.Before)
+	assert.Equal(t, "Уличные", auditFields(t, created.After)["name"])
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/categories/%d", created.EntityID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	deleted := lastAudit(t, h)
+	assert.Equal(t, "delete", deleted.Action)
+	assert.Equal(t, created.EntityID, deleted.EntityID)
+	assert.Equal(t, "Уличные", auditFields(t, deleted.Before)["name"])
+	assert.Empty(t, deleted.After)
+}
+
+func TestAudit_FailedRequestNotRecorded(t *testing.T) {
+	router, h, _ := setupAuditRouter(t)
+	router.POST("/admin/categories/:id/update", h.UpdateCategory)
+
+	w := postUserForm(t, router, "/admin/categories/999/update", url.Values{"name": {"Новое"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var count int64
+	h.db.Model(&models.AuditLog{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestAudit_SecretsMasked(t *testing.T) {
+	router, h, _ := setupAuditRouter(t)
+	router.POST("/admin/users/:id/reset-password", h.ResetAdminUserPassword)
+	editor := createRoleAdmin(t, h, "editor", models.RoleEditor)
+
+	w := postUserForm(t, router, fmt.Sprintf("/admin/users/%d/reset-password", editor.ID),
+		url.Values{"password": {"NewPassw0rd"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	entry := lastAudit(t, h)
+	assert.Equal(t, "admin", entry.Entity)
+	assert.Equal(t, "reset-password", entry.Action)
+	assert.Equal(t, "*** (изменено)", auditFields(t, entry.After)["password_hash"], "факт смены пароля виден")
+	assert.NotContains(t, entry.Before+entry.After+entry.Params, "NewPassw0rd")
+	assert.NotContains(t, entry.After, "$2a$", "bcrypt хеш не попадает в журнал")
+}
+
+func TestAudit_BulkRecordsParams(t *testing.T) {
+	router, h, _ := setupAuditRouter(t)
+	router.POST("/admin/contacts/bulk", h.BulkUpdateContacts)
+
+	contact := models.ContactForm{Name: "Иван", Phone: "+79990000000", Status: "new"}
+	h.db.Create(&contact)
+
+	body := fmt.Sprintf(`{"action":"processed","ids":[%d]}`, contact.ID)
+	req, _ := http.NewRequest("POST", "/admin/contacts/bulk", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "обработчик получает тело запроса целиком")
+
+	entry := lastAudit(t, h)
+	assert.Equal(t, "contact", entry.Entity)
+	assert.Equal(t, "bulk", entry.Action)
+	assert.Zero(t, entry.EntityID)
+	assert.JSONEq(t, body, entry.Params)
+}
+
+func TestAudit_SingletonSettings(t *testing.T) {
+	router, h, _ := setupAuditRouter(t)
+	router.POST("/admin/promo", h.AdminPromoUpdate)
+	promo := models.PromoPopup{Title: "Акция", Content: "Текст"}
+	h.db.Create(&promo)
+
+	w := postUserForm(t, router, "/admin/promo", url.Values{"title": {"Скидка"}, "content": {"Текст"}})
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	entry := lastAudit(t, h)
+	assert.Equal(t, "promo_popup", entry.Entity)
+	assert.Equal(t, "update", entry.Action, "POST на страницу настроек - изменение, а не создание")
+	assert.Equal(t, promo.ID, entry.EntityID)
+	assert.Equal(t, map[string]any{"title": "Скидка"}, pick(auditFields(t, entry.After), "title"))
+}
+
+// pick оставляет в map только указанные ключи
+func pick(m map[string]any, keys ...string) map[string]any {
+	out := map[string]any{}
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// ---------- Страница и экспорт ----------
+
+func TestAdminAuditExportCSV_Filters(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.GET("/admin/audit/export.csv", h.AdminAuditExportCSV)
+
+	h.db.Create(&models.AuditLog{AdminUsername: "owner", Action: "update", Entity: "category", EntityID: 1})
+	h.db.Create(&models.AuditLog{AdminUsername: "owner", Action: "delete", Entity: "project", EntityID: 2})
+
+	req, _ := http.NewRequest("GET", "/admin/audit/export.csv?entity=project", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	body := strings.TrimPrefix(w.Body.String(), "\xEF\xBB\xBF")
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	assert.Len(t, lines, 2, "заголовок + одна запись")
+	assert.Contains(t, lines[1], ";delete;project;2;")
+}
+
+func TestFindAuditEntity(t *testing.T) {
+	assert.Equal(t, "contact_note", findAuditEntity("/admin/contacts/:id/notes/:note_id").name)
+	assert.Equal(t, "contact", findAuditEntity("/admin/contacts/:id/archive").name)
+	assert.Equal(t, "price_image", findAuditEntity("/admin/prices/images/:id/crop").name)
+	assert.Equal(t, "price_item", findAuditEntity("/admin/prices/:id/update").name)
+	assert.Equal(t, "calculator_pitch", findAuditEntity("/admin/calculator/pitches/:id").name)
+	assert.Equal(t, "reports", findAuditEntity("/admin/reports/rebuild").name, "неизвестный роут - по первому сегменту")
+}
@@ -188,7 +188,7 @@ func (h *Handlers) AdminCalculatorPage(c *gin.Context) {
 	var pitches []models.CalculatorPixelPitch
 	h.db.Order("screen_type, sort_order").Find(&pitches)
 
-	c.HTML(http.StatusOK, "admin_base.html", gin.H{
+	renderAdmin(c, http.StatusOK, gin.H{
 		"title":    "Калькулятор",
 		"PageID":   "admin-calculator",
 		"settings": settings,
@@ -232,6 +232,7 @@ func (h *Handlers) AdminCalculatorCreatePitch(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "create failed"})
 		return
 	}
+	setAuditEntityID(c, pitch.ID)
 	c.JSON(http.StatusOK, pitch)
 }
 
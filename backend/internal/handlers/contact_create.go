@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// manualSource - источник заявки, которую менеджер заводит в админке вручную
+type manualSource struct {
+	Code  string
+	Title string
+}
+
+// manualContactSources - источники формы «Новая заявка» (ContactForm.Source)
+var manualContactSources = []manualSource{
+	{Code: "phone_call", Title: "Звонок"},
+	{Code: "email", Title: "Письмо"},
+	{Code: "whatsapp", Title: "WhatsApp"},
+	{Code: "exhibition", Title: "Выставка"},
+}
+
+// isManualContactSource - источник из списка формы «Новая заявка»
+func isManualContactSource(code string) bool {
+	return slices.ContainsFunc(manualContactSources, func(s manualSource) bool { return s.Code == code })
+}
+
+// CreateContact — заявка, заведённая менеджером (звонок, письмо, выставка).
+// Проходит тот же путь, что и заявка с сайта: клиент, ответственный, история этапов
+// и уведомления через outbox. Напоминание и первая заметка - по желанию.
+//
+// assigned_admin_id: не передан - по режиму распределения из настроек, 0 - без ответственного.
+//
+// POST /admin/contacts
+func (h *Handlers) CreateContact(c *gin.Context) {
+	var body struct {
+		Name            string `json:"name"`
+		Phone           string `json:"phone"`
+		Email           string `json:"email"`
+		Company         string `json:"company"`
+		ProjectType     string `json:"project_type"`
+		Message         string `json:"message"`
+		Source          string `json:"source"`
+		RemindAt        string `json:"remind_at"` // RFC3339 или "2006-01-02 15:04" (МСК)
+		Note            string `json:"note"`
+		AssignedAdminID *uint  `json:"assigned_admin_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Неверные данные")
+		return
+	}
+
+	form := models.ContactForm{
+		Name:        strings.TrimSpace(body.Name),
+		Email:       strings.TrimSpace(body.Email),
+		Company:     strings.TrimSpace(body.Company),
+		ProjectType: body.ProjectType,
+		Message:     strings.TrimSpace(body.Message),
+		Source:      body.Source,
+		Status:      "new",
+	}
+	if form.Name == "" || utf8.RuneCountInString(form.Name) > 200 {
+		jsonErr(c, http.StatusBadRequest, "Укажите имя клиента")
+		return
+	}
+	phone, ok := normalizePhone(body.Phone)
+	if !ok {
+		jsonErr(c, http.StatusBadRequest, "Укажите корректный российский номер телефона")
+		return
+	}
+	form.Phone = phone
+	if form.Email != "" {
+		if _, err := mail.ParseAddress(form.Email); err != nil {
+			jsonErr(c, http.StatusBadRequest, "Некорректный email")
+			return
+		}
+	}
+	if form.ProjectType != "" && !slices.Contains(projectTypeCodes, form.ProjectType) {
+		jsonErr(c, http.StatusBadRequest, "Неизвестный тип проекта")
+		return
+	}
+	if !isManualContactSource(form.Source) {
+		jsonErr(c, http.StatusBadRequest, "Выберите источник заявки")
+		return
+	}
+	if body.RemindAt != "" {
+		t, ok := parseRemindAt(body.RemindAt)
+		if !ok {
+			jsonErr(c, http.StatusBadRequest, "Неверный формат даты напоминания")
+			return
+		}
+		form.RemindAt = &t
+		form.RemindFlag = true
+	}
+	if body.AssignedAdminID != nil && *body.AssignedAdminID != 0 {
+		if !slices.ContainsFunc(assignableAdmins(h.db), func(a models.Admin) bool { return a.ID == *body.AssignedAdminID }) {
+			jsonErr(c, http.StatusBadRequest, "Этому администратору нельзя назначить заявку")
+			return
+		}
+		form.AssignedAdminID = body.AssignedAdminID
+	}
+	note := strings.TrimSpace(body.Note)
+
+	now := time.Now()
+	form.StageChangedAt = &now
+
+	// Как в SubmitContact: заявка и уведомления о ней сохраняются одной транзакцией
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.linkCustomer(tx, &form); err != nil {
+			return err
+		}
+		if body.AssignedAdminID == nil {
+			if err := autoAssign(tx, &form); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&form).Error; err != nil {
+			return err
+		}
+		if err := recordInitialStage(tx, &form); err != nil {
+			return err
+		}
+		if note != "" {
+			if err := tx.Create(&models.ContactNote{
+				ContactID: form.ID,
+				Text:      note,
+				Author:    c.GetString("admin_username"),
+				CreatedAt: NowMSKUTC(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := h.enqueueContactEvent(tx, notify.EventNewContact, &form); err != nil {
+			return err
+		}
+		return h.enqueueCustomerAck(tx, &form)
+	}); err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось сохранить заявку")
+		return
+	}
+	h.wakeOutbox()
+
+	setAuditEntityID(c, form.ID)
+	jsonOK(c, gin.H{"message": "Заявка создана", "contact": form})
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// postNewContact создаёт заявку от имени менеджера
+func postNewContact(t *testing.T, h *Handlers, adminID uint, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	router := gin.New()
+	router.POST("/admin/contacts", withAdmin(adminID, models.RoleManager), func(c *gin.Context) {
+		c.Set("admin_username", "manager")
+		c.Next()
+	}, h.CreateContact)
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/admin/contacts", bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateContact(t *testing.T) {
+	_, h := setupTestRouter(t)
+	tg := &recordingNotifier{name: "telegram", kinds: []notify.EventKind{notify.EventNewContact}}
+	o := withTestOutbox(h, tg)
+	manager := createAdmin(t, h, "manager", models.RoleManager, true)
+	setAssignMode(h, models.LeadAssignRoundRobin)
+
+	w := postNewContact(t, h, manager.ID, map[string]any{
+		"name":         " Иван ",
+		"phone":        "8 (921) 123-45-67",
+		"email":        "ivan@example.com",
+		"company":      "ООО Экран",
+		"project_type": "outdoor",
+		"message":      "Звонил, нужен билборд 6x3",
+		"source":       "phone_call",
+		"remind_at":    "2026-10-20 11:00",
+		"note":         "Перезвонить после обеда",
+	})
+	if !assert.Equal(t, http.StatusOK, w.Code, w.Body.String()) {
+		return
+	}
+
+	var contact models.ContactForm
+	assert.NoError(t, h.db.First(&contact).Error)
+	assert.Equal(t, "Иван", contact.Name)
+	assert.Equal(t, "+79211234567", contact.Phone)
+	assert.Equal(t, "phone_call", contact.Source)
+	assert.Equal(t, "new", contact.Status)
+	assert.NotNil(t, contact.CustomerID, "заявка привязана к клиенту")
+	if assert.NotNil(t, contact.AssignedAdminID, "ответственный по режиму распределения") {
+		assert.Equal(t, manager.ID, *contact.AssignedAdminID)
+	}
+	assert.True(t, contact.RemindFlag)
+	if assert.NotNil(t, contact.RemindAt) {
+		assert.Equal(t, time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC), contact.RemindAt.UTC())
+	}
+
+	var notes []models.ContactNote
+	h.db.Where("contact_id = ?", contact.ID).Find(&notes)
+	if assert.Len(t, notes, 1) {
+		assert.Equal(t, "Перезвонить после обеда", notes[0].Text)
+		assert.Equal(t, "manager", notes[0].Author)
+	}
+
+	var history int64
+	h.db.Model(&models.ContactStageChange{}).Where("contact_id = ?", contact.ID).Count(&history)
+	assert.Equal(t, int64(1), history)
+
+	// Уведомления - как у заявки с сайта
+	assert.Equal(t, 1, o.ProcessDue(context.Background(), time.Now()))
+	if assert.Len(t, tg.events, 1) {
+		assert.Equal(t, notify.EventNewContact, tg.events[0].Kind)
+		assert.Equal(t, contact.ID, tg.events[0].Contact.ID)
+		assert.Equal(t, "manager", tg.events[0].Contact.AssignedTo)
+	}
+}
+
+func TestCreateContact_Assignee(t *testing.T) {
+	_, h := setupTestRouter(t)
+	owner := createAdmin(t, h, "owner", models.RoleOwner, true)
+	createAdmin(t, h, "manager", models.RoleManager, true)
+	setAssignMode(h, models.LeadAssignRoundRobin)
+
+	lead := func(phone string, assignee any) *models.ContactForm {
+		body := map[string]any{"name": "Иван", "phone": phone, "source": "exhibition"}
+		if assignee != nil {
+			body["assigned_admin_id"] = assignee
+		}
+		w := postNewContact(t, h, owner.ID, body)
+		if !assert.Equal(t, http.StatusOK, w.Code, w.Body.String()) {
+			return nil
+		}
+		var contact models.ContactForm
+		h.db.Where("phone = ?", phone).First(&contact)
+		return &contact
+	}
+
+	if c := lead("+79211111111", owner.ID); c != nil && assert.NotNil(t, c.AssignedAdminID) {
+		assert.Equal(t, owner.ID, *c.AssignedAdminID, "выбранный ответственный важнее режима распределения")
+	}
+	if c := lead("+79212222222", 0); c != nil {
+		assert.Nil(t, c.AssignedAdminID, "0 - без ответственного")
+	}
+}
+
+func TestCreateContact_Validation(t *testing.T) {
+	_, h := setupTestRouter(t)
+	disabled := createAdmin(t, h, "old", models.RoleManager, false)
+
+	valid := func() map[string]any {
+		return map[string]any{"name": "Иван", "phone": "+79211234567", "source": "whatsapp"}
+	}
+	cases := map[string]func(map[string]any){
+		"без имени":               func(b map[string]any) { b["name"] = "  " },
+		"телефон":                 func(b map[string]any) { b["phone"] = "123" },
+		"email":                   func(b map[string]any) { b["email"] = "не-email" },
+		"тип проекта":             func(b map[string]any) { b["project_type"] = "space" },
+		"источник с сайта":        func(b map[string]any) { b["source"] = "contact_form" },
+		"без источника":           func(b map[string]any) { delete(b, "source") },
+		"дата напоминания":        func(b map[string]any) { b["remind_at"] = "завтра" },
+		"отключённый менеджер":    func(b map[string]any) { b["assigned_admin_id"] = disabled.ID },
+		"несуществующий менеджер": func(b map[string]any) { b["assigned_admin_id"] = 999 },
+	}
+	for name, mutate := range cases {
+		body := valid()
+		mutate(body)
+		w := postNewContact(t, h, 1, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+
+	var count int64
+	h.db.Model(&models.ContactForm{}).Count(&count)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Фильтры списка заявок. Один разбор query используют список, архив, CSV экспорт
+// и сохранённые виды (ContactView.Query хранит query в виде contactFilter.values).
+
+// Как объединять условия фильтра (contactFilter.Match)
+const (
+	matchAll = "all" // все условия (И), по умолчанию
+	matchAny = "any" // любое из условий (ИЛИ)
+)
+
+// contactFilter - параметры фильтра из query /admin/contacts.
+//
+// Поиск и статус всегда сужают выборку. Остальные условия (даты, напоминание, ответственный,
+// источник, тип проекта, заметки) объединяются по Match: все сразу или любое из них.
+type contactFilter struct {
+	Search      string // search или q (см. contact_search.go)
+	Status      string // этап воронки, spam или archived - применяет страница (список, архив, экспорт)
+	Date        string // today/7d/month; не используется, если задан From или To
+	From        string // YYYY-MM-DD (МСК) включительно
+	To          string // YYYY-MM-DD (МСК) включительно
+	Reminder    string // today/overdue/upcoming/any - есть активное, none - нет активного
+	Assigned    string // me/none/ID администратора
+	Source      string // ContactForm.Source или источник последнего визита (атрибуция)
+	ProjectType string
+	Notes       string // yes - есть заметки, no - нет
+	Match       string // matchAll или matchAny
+}
+
+// contactFilterFromQuery разбирает фильтр из query; некорректные значения отбрасываются
+func contactFilterFromQuery(q url.Values) contactFilter {
+	get := func(key string) string { return strings.TrimSpace(q.Get(key)) }
+	f := contactFilter{
+		Search:      get("search"),
+		Status:      get("status"),
+		Date:        get("date"),
+		From:        get("from"),
+		To:          get("to"),
+		Reminder:    strings.ToLower(get("reminder")),
+		Assigned:    get("assigned"),
+		Source:      get("source"),
+		ProjectType: get("project_type"),
+		Notes:       get("notes"),
+		Match:       get("match"),
+	}
+	if f.Search == "" {
+		f.Search = get("q")
+	}
+	if _, ok := parseFilterDay(f.From); !ok {
+		f.From = ""
+	}
+	if _, ok := parseFilterDay(f.To); !ok {
+		f.To = ""
+	}
+	if f.Match != matchAny {
+		f.Match = matchAll
+	}
+	return f
+}
+
+// values - query фильтра без пустых параметров (ссылки на сохранённые виды)
+func (f contactFilter) values() url.Values {
+	v := url.Values{}
+	set := func(key, val string) {
+		if val != "" {
+			v.Set(key, val)
+		}
+	}
+	set("search", f.Search)
+	set("status", f.Status)
+	set("date", f.Date)
+	set("from", f.From)
+	set("to", f.To)
+	set("reminder", f.Reminder)
+	set("assigned", f.Assigned)
+	set("source", f.Source)
+	set("project_type", f.ProjectType)
+	set("notes", f.Notes)
+	if f.Match == matchAny {
+		v.Set("match", matchAny)
+	}
+	return v
+}
+
+// advanced - задано ли хоть одно условие из блока «Ещё фильтры»
+func (f contactFilter) advanced() bool {
+	return f.From != "" || f.To != "" || f.Source != "" || f.ProjectType != "" || f.Notes != "" || f.Match == matchAny
+}
+
+// parseFilterDay разбирает дату YYYY-MM-DD как начало дня по Москве
+func parseFilterDay(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, moscowLoc)
+	return t, err == nil
+}
+
+// filterCond - условие WHERE с параметрами
+type filterCond struct {
+	sql  string
+	args []interface{}
+}
+
+// conds - условия фильтра, объединяемые по Match (без поиска и статуса)
+func (f contactFilter) conds(adminID uint, now time.Time) []filterCond {
+	var out []filterCond
+	add := func(sql string, args ...interface{}) {
+		out = append(out, filterCond{sql: sql, args: args})
+	}
+
+	// даты: произвольный интервал важнее периода
+	from, hasFrom := parseFilterDay(f.From)
+	to, hasTo := parseFilterDay(f.To)
+	switch {
+	case hasFrom && hasTo:
+		add("created_at >= ? AND created_at < ?", from.UTC(), to.AddDate(0, 0, 1).UTC())
+	case hasFrom:
+		add("created_at >= ?", from.UTC())
+	case hasTo:
+		add("created_at < ?", to.AddDate(0, 0, 1).UTC())
+	default:
+		if sql, args, ok := dateRangeCond(f.Date, now); ok {
+			add(sql, args...)
+		}
+	}
+
+	startToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, moscowLoc)
+	switch f.Reminder {
+	case "today":
+		add("remind_flag = ? AND remind_at IS NOT NULL AND remind_at >= ? AND remind_at < ?",
+			true, startToday.UTC(), startToday.Add(24*time.Hour).UTC())
+	case "overdue":
+		add("remind_flag = ? AND remind_at IS NOT NULL AND remind_at < ?", true, now.UTC())
+	case "upcoming":
+		add("remind_flag = ? AND remind_at IS NOT NULL AND remind_at >= ?", true, now.UTC())
+	case "any":
+		add("remind_flag = ? AND remind_at IS NOT NULL", true)
+	case "none":
+		add("(remind_flag = ? OR remind_at IS NULL)", false)
+	}
+
+	// ответственный: "me" - текущий администратор, "none" - не назначен, число - ID администратора
+	switch f.Assigned {
+	case "":
+	case "me":
+		add("assigned_admin_id = ?", adminID)
+	case "none":
+		add("assigned_admin_id IS NULL")
+	default:
+		if id, err := strconv.ParseUint(f.Assigned, 10, 64); err == nil {
+			add("assigned_admin_id = ?", id)
+		}
+	}
+
+	if f.Source != "" {
+		add("(source = ? OR EXISTS (SELECT 1 FROM contact_attributions a WHERE a.contact_id = contact_forms.id AND a.last_source = ?))",
+			f.Source, f.Source)
+	}
+	if f.ProjectType != "" {
+		add("project_type = ?", f.ProjectType)
+	}
+	switch f.Notes {
+	case "yes":
+		add("EXISTS (SELECT 1 FROM contact_notes n WHERE n.contact_id = contact_forms.id)")
+	case "no":
+		add("NOT EXISTS (SELECT 1 FROM contact_notes n WHERE n.contact_id = contact_forms.id)")
+	}
+	return out
+}
+
+// apply добавляет к запросу поиск и условия фильтра (статус - на стороне страницы)
+func (f contactFilter) apply(qb *gorm.DB, adminID uint, now time.Time) *gorm.DB {
+	qb = parseContactSearch(f.Search).apply(qb)
+
+	conds := f.conds(adminID, now)
+	if f.Match != matchAny || len(conds) < 2 {
+		for _, cond := range conds {
+			qb = qb.Where(cond.sql, cond.args...)
+		}
+		return qb
+	}
+	parts := make([]string, len(conds))
+	var args []interface{}
+	for i, cond := range conds {
+		parts[i] = "(" + cond.sql + ")"
+		args = append(args, cond.args...)
+	}
+	return qb.Where("("+strings.Join(parts, " OR ")+")", args...)
+}
+
+// contactFilterOptions - значения для выпадающих списков источника и типа проекта
+func (h *Handlers) contactFilterOptions() (sources, projectTypes []string) {
+	var formSources, touchSources []string
+	h.db.Model(&models.ContactForm{}).Where("source <> ''").Distinct().Pluck("source", &formSources)
+	h.db.Model(&models.ContactAttribution{}).Where("last_source <> ''").Distinct().Pluck("last_source", &touchSources)
+	seen := map[string]bool{}
+	for _, s := range append(formSources, touchSources...) {
+		if !seen[s] {
+			seen[s] = true
+			sources = append(sources, s)
+		}
+	}
+	sort.Strings(sources)
+
+	h.db.Model(&models.ContactForm{}).Where("project_type <> ''").Distinct().Order("project_type").Pluck("project_type", &projectTypes)
+	return sources, projectTypes
+}
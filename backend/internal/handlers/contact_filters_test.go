@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// exportNames возвращает имена заявок из CSV экспорта с фильтрами query
+func exportNames(t *testing.T, h *Handlers, query string) []string {
+	t.Helper()
+	router := gin.New()
+	router.GET("/admin/contacts/export.csv", withAdmin(1, models.RoleOwner), h.AdminContactsExportCSV)
+	req, _ := http.NewRequest("GET", "/admin/contacts/export.csv?"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var names []string
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	for _, line := range lines[1:] {
+		names = append(names, strings.SplitN(line, ";", 2)[0])
+	}
+	return names
+}
+
+func TestContactFilters_Export(t *testing.T) {
+	_, h := setupTestRouter(t)
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation("2006-01-02 15:04", s, moscowLoc)
+		return d.UTC()
+	}
+	remind := day("2099-01-01 10:00")
+	contacts := []models.ContactForm{
+		{Name: "Анна", Phone: "+79210000001", Status: "new", ProjectType: "indoor", CreatedAt: day("2026-03-01 10:00")},
+		{Name: "Борис", Phone: "+79210000002", Status: "new", ProjectType: "outdoor", Source: "import", CreatedAt: day("2026-03-10 23:30")},
+		{Name: "Вера", Phone: "+79210000003", Status: "new", ProjectType: "outdoor", CreatedAt: day("2026-03-20 09:00"),
+			RemindAt: &remind, RemindFlag: true},
+	}
+	for i := range contacts {
+		h.db.Create(&contacts[i])
+	}
+	h.db.Create(&models.ContactAttribution{ContactID: contacts[0].ID, Last: models.AttributionTouch{Source: "yandex", Medium: "cpc"}})
+	h.db.Create(&models.ContactNote{ContactID: contacts[2].ID, Text: "Перезвонить"})
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Вера", "Борис", "Анна"}},
+		{"from=2026-03-01&to=2026-03-10", []string{"Борис", "Анна"}},
+		{"from=2026-03-11", []string{"Вера"}},
+		{"from=плохая-дата&to=2026-03-09", []string{"Анна"}},
+		{"source=yandex", []string{"Анна"}},
+		{"source=import", []string{"Борис"}},
+		{"project_type=outdoor", []string{"Вера", "Борис"}},
+		{"notes=yes", []string{"Вера"}},
+		{"notes=no&project_type=outdoor", []string{"Борис"}},
+		{"reminder=any", []string{"Вера"}},
+		{"reminder=none", []string{"Борис", "Анна"}},
+		{"source=yandex&notes=yes", nil},
+		{"source=yandex&notes=yes&match=any", []string{"Вера", "Анна"}},
+		{"source=import&to=2026-03-01&match=any", []string{"Борис", "Анна"}},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, exportNames(t, h, tc.query), tc.query)
+	}
+}
+
+func TestContactFilterValues(t *testing.T) {
+	q, _ := url.ParseQuery("q=экран&status=new&from=2026-13-01&to=2026-03-10&match=or&page=3&limit=25&view=1")
+	f := contactFilterFromQuery(q)
+	assert.Equal(t, "экран", f.Search)
+	assert.Empty(t, f.From, "некорректная дата отбрасывается")
+	assert.Equal(t, matchAll, f.Match)
+	assert.Equal(t, "search=%D1%8D%D0%BA%D1%80%D0%B0%D0%BD&status=new&to=2026-03-10", f.values().Encode())
+	assert.True(t, f.advanced())
+
+	f = contactFilterFromQuery(url.Values{"status": {"won"}, "match": {"any"}})
+	assert.Equal(t, "match=any&status=won", f.values().Encode())
+}
+
+func TestContactViews(t *testing.T) {
+	_, h := setupTestRouter(t)
+	router := gin.New()
+	router.Use(withAdmin(1, models.RoleManager))
+	router.POST("/admin/contacts/views", h.CreateContactView)
+	router.DELETE("/admin/contacts/views/:id", h.DeleteContactView)
+	other := gin.New()
+	other.Use(withAdmin(2, models.RoleManager))
+	other.DELETE("/admin/contacts/views/:id", h.DeleteContactView)
+
+	create := func(name, query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"name": name, "query": query})
+		req, _ := http.NewRequest("POST", "/admin/contacts/views", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create("Мои горячие", "?assigned=me&reminder=overdue&page=2&limit=100&view=7")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		URL string `json:"url"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.True(t, strings.HasPrefix(resp.URL, "/admin/contacts?assigned=me&reminder=overdue&view="), resp.URL)
+
+	assert.Equal(t, http.StatusBadRequest, create("", "?status=new").Code)
+	assert.Equal(t, http.StatusBadRequest, create("Пустой", "?page=2").Code)
+
+	tabs := h.contactViewTabs(1)
+	if assert.Len(t, tabs, 1) {
+		assert.Equal(t, "Мои горячие", tabs[0].Name)
+		assert.Equal(t, fmt.Sprintf("/admin/contacts?assigned=me&reminder=overdue&view=%d", tabs[0].ID), tabs[0].Href)
+	}
+	assert.Empty(t, h.contactViewTabs(2), "виды у каждого администратора свои")
+
+	for i := 1; i < maxContactViews; i++ {
+		create(fmt.Sprintf("Вид %d", i), "?status=new")
+	}
+	assert.Equal(t, http.StatusBadRequest, create("Лишний", "?status=new").Code)
+
+	del := func(r http.Handler, id uint) int {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/contacts/views/%d", id), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNotFound, del(other, tabs[0].ID), "чужой вид не удаляется")
+	assert.Equal(t, http.StatusOK, del(router, tabs[0].ID))
+	assert.Len(t, h.contactViewTabs(1), maxContactViews-1)
+}
@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+	"ledsite/internal/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Импорт заявок из CSV/XLSX (звонки, выставки, старая CRM). Два шага без состояния на сервере:
+// предпросмотр предлагает сопоставление колонок, импорт получает файл ещё раз вместе
+// с сопоставлением. Проверка (dry run) строит тот же отчёт, ничего не сохраняя.
+// Заявки создаются с Source = "import" без уведомлений и автоназначения.
+
+const (
+	importSource      = "import"
+	importMaxFileSize = 5 << 20 // 5 МБ
+	importMaxRows     = 5000
+	importPreviewRows = 5
+	importDateLayout  = "02.01.2006" // дата без времени; с временем - как в экспорте
+)
+
+// Результат строки импорта (importRowResult.Result)
+const (
+	importOK        = "ok"        // строка будет создана (проверка)
+	importCreated   = "created"   // заявка создана
+	importDuplicate = "duplicate" // телефон или email уже есть в заявках или выше в файле
+	importInvalid   = "invalid"   // ошибка в данных строки
+)
+
+// importField - поле заявки, в которое загружается колонка файла.
+// Названия совпадают с заголовками CSV экспорта, поэтому экспорт загружается без ручного сопоставления
+type importField struct {
+	Key      string   `json:"key"`
+	Title    string   `json:"title"`
+	Required bool     `json:"required"`
+	aliases  []string // заголовки колонок в нижнем регистре для автосопоставления
+}
+
+var importFields = []importField{
+	{Key: "name", Title: "Имя", Required: true, aliases: []string{"имя", "name", "фио", "клиент", "контактное лицо"}},
+	{Key: "phone", Title: "Телефон", Required: true, aliases: []string{"телефон", "phone", "тел", "тел.", "номер телефона", "мобильный"}},
+	{Key: "email", Title: "Email", aliases: []string{"email", "e-mail", "почта", "эл. почта"}},
+	{Key: "company", Title: "Компания", aliases: []string{"компания", "company", "организация"}},
+	{Key: "project_type", Title: "Тип проекта", aliases: []string{"тип проекта", "project_type", "тип"}},
+	{Key: "message", Title: "Сообщение", aliases: []string{"сообщение", "message", "комментарий", "примечание"}},
+	{Key: "status", Title: "Статус", aliases: []string{"статус", "status", "этап"}},
+	{Key: "lost_reason", Title: "Причина проигрыша", aliases: []string{"причина проигрыша", "lost_reason"}},
+	{Key: "created_at", Title: "Дата", aliases: []string{"дата", "date", "created_at", "дата заявки", "дата обращения"}},
+}
+
+// importMapping - номер колонки файла (с нуля) для ключа поля; поля без колонки не загружаются
+type importMapping map[string]int
+
+// importRowResult - строка отчёта импорта
+type importRowResult struct {
+	Line        int    `json:"line"` // номер строки в файле (заголовок - 1)
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	Result      string `json:"result"`
+	Error       string `json:"error,omitempty"`
+	DuplicateOf uint   `json:"duplicate_of,omitempty"` // существующая заявка; 0 - повтор строки файла
+}
+
+// AdminContactsImportPage — страница импорта заявок
+func (h *Handlers) AdminContactsImportPage(c *gin.Context) {
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":   "Импорт заявок",
+		"PageID":  "admin-contacts-import",
+		"fields":  importFields,
+		"maxRows": importMaxRows,
+		"maxMB":   importMaxFileSize >> 20,
+		"dateFmt": importDateLayout,
+	})
+}
+
+// PreviewContactImport — заголовки и первые строки файла с предложенным сопоставлением колонок
+func (h *Handlers) PreviewContactImport(c *gin.Context) {
+	rows, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+	headers := rows[0]
+	sample := rows[1:]
+	if len(sample) > importPreviewRows {
+		sample = sample[:importPreviewRows]
+	}
+	jsonOK(c, gin.H{
+		"fields":  importFields,
+		"headers": headers,
+		"rows":    sample,
+		"total":   len(rows) - 1,
+		"mapping": suggestImportMapping(headers),
+	})
+}
+
+// ImportContacts — проверка (dry_run=true) или импорт файла по сопоставлению колонок.
+// Строки с ошибками и повторы пропускаются, остальные сохраняются одной транзакцией
+func (h *Handlers) ImportContacts(c *gin.Context) {
+	rows, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+	var mapping importMapping
+	if err := json.Unmarshal([]byte(c.PostForm("mapping")), &mapping); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Некорректное сопоставление колонок")
+		return
+	}
+	if err := validateImportMapping(mapping, len(rows[0])); err != nil {
+		jsonErr(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	dryRun := c.PostForm("dry_run") == "true"
+
+	contacts, report, err := h.prepareImport(rows[1:], mapping, NowMSKUTC())
+	if err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось проверить повторы")
+		return
+	}
+
+	if !dryRun && len(contacts) > 0 {
+		if err := h.db.Transaction(func(tx *gorm.DB) error {
+			for i := range contacts {
+				if err := h.linkCustomer(tx, &contacts[i]); err != nil {
+					return err
+				}
+				if err := tx.Create(&contacts[i]).Error; err != nil {
+					return err
+				}
+				if err := recordInitialStage(tx, &contacts[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			log.Printf("Импорт заявок: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "Не удалось сохранить заявки")
+			return
+		}
+		for i := range report {
+			if report[i].Result == importOK {
+				report[i].Result = importCreated
+			}
+		}
+		log.Printf("Импорт заявок: создано %d из %d строк (администратор %d)", len(contacts), len(report), c.GetUint("admin_id"))
+	}
+
+	counts := map[string]int{}
+	for _, r := range report {
+		counts[r.Result]++
+	}
+	jsonOK(c, gin.H{
+		"dry_run":    dryRun,
+		"total":      len(report),
+		"ready":      len(contacts),
+		"created":    counts[importCreated],
+		"duplicates": counts[importDuplicate],
+		"invalid":    counts[importInvalid],
+		"rows":       report,
+	})
+}
+
+// readImportFile читает файл из поля "file"; первая строка - заголовки. При ошибке отвечает 400
+func readImportFile(c *gin.Context) ([][]string, bool) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, "Выберите файл CSV или XLSX")
+		return nil, false
+	}
+	if file.Size > importMaxFileSize {
+		jsonErr(c, http.StatusBadRequest, "Файл больше "+strconv.Itoa(importMaxFileSize>>20)+" МБ")
+		return nil, false
+	}
+	f, err := file.Open()
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, "Не удалось прочитать файл")
+		return nil, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, importMaxFileSize))
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, "Не удалось прочитать файл")
+		return nil, false
+	}
+
+	rows, err := spreadsheet.Read(file.Filename, data)
+	switch {
+	case errors.Is(err, spreadsheet.ErrUnsupported), errors.Is(err, spreadsheet.ErrBadXLSX):
+		jsonErr(c, http.StatusBadRequest, err.Error())
+		return nil, false
+	case err != nil:
+		jsonErr(c, http.StatusBadRequest, "Не удалось разобрать CSV: "+err.Error())
+		return nil, false
+	case len(rows) < 2:
+		jsonErr(c, http.StatusBadRequest, "В файле нет строк с данными")
+		return nil, false
+	case len(rows)-1 > importMaxRows:
+		jsonErr(c, http.StatusBadRequest, "Не больше "+strconv.Itoa(importMaxRows)+" строк за один импорт")
+		return nil, false
+	}
+	return rows, true
+}
+
+// suggestImportMapping сопоставляет колонки с полями по заголовкам
+func suggestImportMapping(headers []string) importMapping {
+	mapping := importMapping{}
+	for _, f := range importFields {
+		for col, header := range headers {
+			if slices.Contains(f.aliases, strings.ToLower(strings.Join(strings.Fields(header), " "))) {
+				mapping[f.Key] = col
+				break
+			}
+		}
+	}
+	return mapping
+}
+
+// validateImportMapping проверяет поля и номера колонок; имя и телефон обязательны
+func validateImportMapping(mapping importMapping, columns int) error {
+	known := map[string]bool{}
+	for _, f := range importFields {
+		known[f.Key] = true
+		if _, ok := mapping[f.Key]; f.Required && !ok {
+			return errors.New("Укажите колонку для поля «" + f.Title + "»")
+		}
+	}
+	for key, col := range mapping {
+		if !known[key] || col < 0 || col >= columns {
+			return errors.New("Некорректное сопоставление колонок")
+		}
+	}
+	return nil
+}
+
+// prepareImport разбирает строки файла: возвращает заявки к созданию и отчёт по каждой строке
+func (h *Handlers) prepareImport(rows [][]string, mapping importMapping, now time.Time) ([]models.ContactForm, []importRowResult, error) {
+	existingPhones, existingEmails, err := h.importExistingKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	stages := h.pipelineStages()
+	seenPhones := map[string]bool{}
+	seenEmails := map[string]bool{}
+
+	var contacts []models.ContactForm
+	report := make([]importRowResult, 0, len(rows))
+	for i, cells := range rows {
+		cell := func(key string) string {
+			if col, ok := mapping[key]; ok && col < len(cells) {
+				return cells[col]
+			}
+			return ""
+		}
+		res := importRowResult{Line: i + 2, Name: cell("name"), Phone: cell("phone")}
+
+		contact, err := parseImportRow(cell, stages, now)
+		if err != nil {
+			res.Result, res.Error = importInvalid, err.Error()
+			report = append(report, res)
+			continue
+		}
+		res.Phone = contact.Phone
+
+		phoneKey, emailKey := customerPhoneKey(contact.Phone), customerEmailKey(contact.Email)
+		switch {
+		case existingPhones[phoneKey] != 0:
+			res.Result, res.DuplicateOf = importDuplicate, existingPhones[phoneKey]
+		case emailKey != "" && existingEmails[emailKey] != 0:
+			res.Result, res.DuplicateOf = importDuplicate, existingEmails[emailKey]
+		case seenPhones[phoneKey] || (emailKey != "" && seenEmails[emailKey]):
+			res.Result, res.Error = importDuplicate, "Повтор строки выше в файле"
+		default:
+			res.Result = importOK
+			contacts = append(contacts, contact)
+		}
+		seenPhones[phoneKey] = true
+		if emailKey != "" {
+			seenEmails[emailKey] = true
+		}
+		report = append(report, res)
+	}
+	return contacts, report, nil
+}
+
+// importExistingKeys - телефоны и email существующих заявок (кроме спама) с ID последней заявки
+func (h *Handlers) importExistingKeys() (map[string]uint, map[string]uint, error) {
+	var existing []struct {
+		ID    uint
+		Phone string
+		Email string
+	}
+	if err := h.db.Model(&models.ContactForm{}).
+		Select("id, phone, email").
+		Where("status <> ?", "spam").
+		Order("id").
+		Find(&existing).Error; err != nil {
+		return nil, nil, err
+	}
+	phones := make(map[string]uint, len(existing))
+	emails := make(map[string]uint, len(existing))
+	for _, e := range existing {
+		phones[customerPhoneKey(e.Phone)] = e.ID
+		if key := customerEmailKey(e.Email); key != "" {
+			emails[key] = e.ID
+		}
+	}
+	return phones, emails, nil
+}
+
+// parseImportRow собирает заявку из ячеек строки
+func parseImportRow(cell func(key string) string, stages []models.PipelineStage, now time.Time) (models.ContactForm, error) {
+	contact := models.ContactForm{
+		Name:        cell("name"),
+		Email:       cell("email"),
+		Company:     cell("company"),
+		ProjectType: importProjectType(cell("project_type")),
+		Message:     cell("message"),
+		LostReason:  cell("lost_reason"),
+		Source:      importSource,
+		Status:      "new",
+	}
+	if contact.Name == "" {
+		return contact, errors.New("Нет имени")
+	}
+	phone, ok := normalizePhone(cell("phone"))
+	if !ok {
+		return contact, errors.New("Некорректный телефон")
+	}
+	contact.Phone = phone
+	if contact.Email != "" && !strings.Contains(contact.Email, "@") {
+		return contact, errors.New("Некорректный email")
+	}
+
+	if status := cell("status"); status != "" {
+		if system, ok := importSystemStatus(status); ok {
+			contact.Status = system
+		} else if stage, ok := importStage(stages, status); ok {
+			contact.Status = stage.Slug
+			if stage.Kind == models.StageLost && contact.LostReason == "" {
+				return contact, errors.New("Для статуса «" + stage.Title + "» нужна причина проигрыша")
+			}
+		} else {
+			return contact, errors.New("Неизвестный статус «" + status + "»")
+		}
+	}
+	if stage, _ := importStage(stages, contact.Status); stage.Kind != models.StageLost {
+		contact.LostReason = ""
+	}
+
+	contact.CreatedAt = now
+	if raw := cell("created_at"); raw != "" {
+		created, ok := parseImportDate(raw)
+		if !ok {
+			return contact, errors.New("Некорректная дата «" + raw + "»")
+		}
+		contact.CreatedAt = created
+	}
+	stageChangedAt := contact.CreatedAt
+	contact.StageChangedAt = &stageChangedAt
+	if contact.Status == "archived" {
+		archivedAt := now
+		contact.ArchivedAt = &archivedAt
+	}
+	return contact, nil
+}
+
+// importSystemStatus - системный статус ("archived", "spam") по slug или названию из экспорта (см. stageBadges)
+func importSystemStatus(value string) (string, bool) {
+	for status, badge := range stageBadges(nil) {
+		if systemStatuses[status] && (strings.EqualFold(status, value) || strings.EqualFold(badge.Title, value)) {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// importStage ищет этап воронки по slug или названию (без учёта регистра)
+func importStage(stages []models.PipelineStage, value string) (models.PipelineStage, bool) {
+	for _, s := range stages {
+		if strings.EqualFold(s.Slug, value) || strings.EqualFold(s.Title, value) {
+			return s, true
+		}
+	}
+	return models.PipelineStage{}, false
+}
+
+// importProjectType - код типа проекта по коду или русскому названию (см. translateProjectType)
+func importProjectType(value string) string {
+	for _, code := range projectTypeCodes {
+		if strings.EqualFold(value, code) || strings.EqualFold(value, translateProjectType(code)) {
+			return code
+		}
+	}
+	return value
+}
+
+// parseImportDate разбирает дату заявки по Москве: как в экспорте (02.01.2006 15:04),
+// ISO или число - дата Excel (дни с 30.12.1899)
+func parseImportDate(value string) (time.Time, bool) {
+	for _, layout := range []string{"02.01.2006 15:04", "02.01.2006 15:04:05", importDateLayout, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, moscowLoc); err == nil {
+			return t.UTC(), true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), true
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 1 && serial < 100000 {
+		days := math.Floor(serial)
+		seconds := math.Round((serial - days) * 24 * 60 * 60)
+		base := time.Date(1899, 12, 30, 0, 0, 0, 0, moscowLoc)
+		return base.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second).UTC(), true
+	}
+	return time.Time{}, false
+}
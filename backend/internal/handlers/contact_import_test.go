@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// postImport отправляет файл на роут импорта с полями формы
+func postImport(t *testing.T, h *Handlers, path, filename, content string, fields map[string]string) (int, map[string]any) {
+	t.Helper()
+	router := gin.New()
+	router.POST("/admin/contacts/import/preview", h.PreviewContactImport)
+	router.POST("/admin/contacts/import", h.ImportContacts)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", filename)
+	fw.Write([]byte(content))
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", path, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+// Файл в формате CSV экспорта заявок
+const importCSV = "\xEF\xBB\xBFИмя;Телефон;Email;Компания;Тип проекта;Сообщение;Статус;Причина проигрыша;Ответственный;Дата\n" +
+	"Иван;8 (921) 111-22-33;;ООО Экран;Наружные LED экраны;Звонок с выставки;Квалифицирована;;;05.03.2026 14:30\n" +
+	"Пётр;+7 921 000-00-01;;;;;;;;\n" +
+	"Анна;89212223344;anna@example.com;;;;;;;\n" +
+	"Анна повтор;+79212223344;;;;;;;;\n" +
+	";+79215556677;;;;;;;;\n" +
+	"Олег;12345;;;;;;;;\n" +
+	"Ольга;+79217778899;;;;;Этап Х;;;\n" +
+	"Игорь;+79218889900;;;;;Проиграна;;;\n"
+
+func TestPreviewContactImport(t *testing.T) {
+	_, h := setupTestRouter(t)
+	code, resp := postImport(t, h, "/admin/contacts/import/preview", "contacts.csv", importCSV, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(8), resp["total"])
+	assert.Len(t, resp["rows"], importPreviewRows)
+	mapping := resp["mapping"].(map[string]any)
+	assert.Equal(t, float64(0), mapping["name"])
+	assert.Equal(t, float64(1), mapping["phone"])
+	assert.Equal(t, float64(9), mapping["created_at"])
+	assert.NotContains(t, mapping, "assigned")
+
+	code, _ = postImport(t, h, "/admin/contacts/import/preview", "contacts.xls", importCSV, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = postImport(t, h, "/admin/contacts/import/preview", "contacts.csv", "Имя;Телефон\n", nil)
+	assert.Equal(t, http.StatusBadRequest, code, "нет строк с данными")
+}
+
+func TestImportContacts(t *testing.T) {
+	_, h := setupTestRouter(t)
+	existing := models.ContactForm{Name: "Пётр", Phone: "+79210000001", Status: "new"}
+	h.db.Create(&existing)
+	h.db.Create(&models.ContactForm{Name: "Спамер", Phone: "+79212223344", Status: "spam"})
+
+	mapping := `{"name":0,"phone":1,"email":2,"company":3,"project_type":4,"message":5,"status":6,"lost_reason":7,"created_at":9}`
+
+	code, _ := postImport(t, h, "/admin/contacts/import", "contacts.csv", importCSV, map[string]string{"mapping": `{"name":0}`})
+	assert.Equal(t, http.StatusBadRequest, code, "телефон обязателен")
+	code, _ = postImport(t, h, "/admin/contacts/import", "contacts.csv", importCSV, map[string]string{"mapping": `{"name":0,"phone":42}`})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Проверка ничего не сохраняет
+	code, resp := postImport(t, h, "/admin/contacts/import", "contacts.csv", importCSV, map[string]string{"mapping": mapping, "dry_run": "true"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2), resp["ready"])
+	assert.Equal(t, float64(2), resp["duplicates"])
+	assert.Equal(t, float64(4), resp["invalid"])
+	var count int64
+	h.db.Model(&models.ContactForm{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	rows := resp["rows"].([]any)
+	want := []struct{ result, errText string }{
+		{importOK, ""},
+		{importDuplicate, ""},
+		{importOK, ""},
+		{importDuplicate, "Повтор строки выше в файле"},
+		{importInvalid, "Нет имени"},
+		{importInvalid, "Некорректный телефон"},
+		{importInvalid, "Неизвестный статус «Этап Х»"},
+		{importInvalid, "Для статуса «Проиграна» нужна причина проигрыша"},
+	}
+	for i, w := range want {
+		row := rows[i].(map[string]any)
+		assert.Equal(t, w.result, row["result"], "строка %v", row["line"])
+		if w.errText != "" {
+			assert.Equal(t, w.errText, row["error"])
+		}
+	}
+	assert.Equal(t, float64(existing.ID), rows[1].(map[string]any)["duplicate_of"])
+
+	code, resp = postImport(t, h, "/admin/contacts/import", "contacts.csv", importCSV, map[string]string{"mapping": mapping})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2), resp["created"])
+
+	var imported []models.ContactForm
+	h.db.Where("source = ?", importSource).Order("id").Find(&imported)
+	if assert.Len(t, imported, 2) {
+		ivan := imported[0]
+		assert.Equal(t, "+79211112233", ivan.Phone)
+		assert.Equal(t, "outdoor", ivan.ProjectType)
+		assert.Equal(t, "qualified", ivan.Status)
+		assert.Equal(t, time.Date(2026, 3, 5, 11, 30, 0, 0, time.UTC), ivan.CreatedAt.UTC())
+		assert.NotNil(t, ivan.CustomerID)
+		assert.Equal(t, "anna@example.com", imported[1].Email)
+	}
+	var changes int64
+	h.db.Model(&models.ContactStageChange{}).Where("contact_id = ?", imported[0].ID).Count(&changes)
+	assert.Equal(t, int64(1), changes)
+
+	// Повторный импорт того же файла ничего не создаёт
+	_, resp = postImport(t, h, "/admin/contacts/import", "contacts.csv", importCSV, map[string]string{"mapping": mapping})
+	assert.Equal(t, float64(0), resp["created"])
+	assert.Equal(t, float64(4), resp["duplicates"])
+}
+
+func TestImportContacts_ExportRoundTrip(t *testing.T) {
+	_, h := setupTestRouter(t)
+	router := gin.New()
+	router.GET("/admin/contacts/export.csv", h.AdminContactsExportCSV)
+	created := time.Date(2026, 3, 5, 11, 30, 0, 0, time.UTC)
+	archivedAt := created.Add(time.Hour)
+	h.db.Create(&models.ContactForm{Name: "Иван", Phone: "+79211112233", Status: "archived", ArchivedAt: &archivedAt, CreatedAt: created})
+	h.db.Create(&models.ContactForm{Name: "Бот", Phone: "+79214445566", Status: "spam", CreatedAt: created})
+
+	exports := map[string]string{}
+	for _, status := range []string{"archived", "spam"} {
+		req, _ := http.NewRequest("GET", "/admin/contacts/export.csv?status="+status, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		exports[status] = w.Body.String()
+	}
+
+	for status, export := range exports {
+		// Загружаем экспорт в пустую базу
+		h.db.Where("1 = 1").Delete(&models.ContactForm{})
+		_, preview := postImport(t, h, "/admin/contacts/import/preview", "export.csv", export, nil)
+		mapping, _ := json.Marshal(preview["mapping"])
+		code, resp := postImport(t, h, "/admin/contacts/import", "export.csv", export, map[string]string{"mapping": string(mapping)})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(1), resp["created"], status)
+
+		var contact models.ContactForm
+		h.db.Where("source = ?", importSource).First(&contact)
+		assert.Equal(t, status, contact.Status)
+		assert.Equal(t, created, contact.CreatedAt.UTC())
+		assert.Equal(t, status == "archived", contact.ArchivedAt != nil, "дата архивации только у архивных")
+		assert.Equal(t, status != "spam", contact.CustomerID != nil, "спам не привязывается к клиенту")
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	cases := map[string]time.Time{
+		"05.03.2026 14:30":     time.Date(2026, 3, 5, 11, 30, 0, 0, time.UTC),
+		"05.03.2026":           time.Date(2026, 3, 4, 21, 0, 0, 0, time.UTC),
+		"2026-03-05 14:30":     time.Date(2026, 3, 5, 11, 30, 0, 0, time.UTC),
+		"2026-03-05T14:30:00Z": time.Date(2026, 3, 5, 14, 30, 0, 0, time.UTC),
+		"46086.5":              time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC), // дата Excel: 05.03.2026 12:00 МСК
+	}
+	for in, want := range cases {
+		got, ok := parseImportDate(in)
+		if assert.True(t, ok, in) {
+			assert.Equal(t, want, got, in)
+		}
+	}
+	_, ok := parseImportDate("вчера")
+	assert.False(t, ok)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+
+	"ledsite/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Полнотекстовый поиск по заявкам. Колонки contact_forms.search_vector и phone_digits
+// поддерживаются триггерами PostgreSQL (см. database.migrateContactSearch).
+
+// Маркеры подсветки в ts_headline: заменяются на <mark> после экранирования текста
+const (
+	snippetStartSel = "[[["
+	snippetStopSel  = "]]]"
+)
+
+// minPhoneDigits - с какой длины запрос из цифр считается частью телефона
+const minPhoneDigits = 4
+
+// contactSearch - разобранный поисковый запрос. Заполнено одно из полей
+type contactSearch struct {
+	Phone   string // цифры телефона: "+7 (921) 123-45-67", "8 921 1234567", "4567"
+	Email   string // запрос с "@" - часть email
+	TSQuery string // запрос для to_tsquery('russian'): слова с поиском по началу через &
+}
+
+// parseContactSearch определяет вид поиска: по телефону, email или по тексту
+func parseContactSearch(q string) contactSearch {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return contactSearch{}
+	}
+	if digits, ok := phoneQueryDigits(q); ok {
+		return contactSearch{Phone: digits}
+	}
+	if strings.Contains(q, "@") {
+		return contactSearch{Email: strings.ToLower(q)}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return contactSearch{TSQuery: strings.Join(words, " & ")}
+}
+
+// phoneQueryDigits - цифры запроса, если он похож на телефон, в виде как в phone_digits
+func phoneQueryDigits(q string) (string, bool) {
+	var b strings.Builder
+	for _, r := range q {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' || r == '-' || r == '(' || r == ')' || r == '.' || unicode.IsSpace(r):
+		default:
+			return "", false
+		}
+	}
+	d := b.String()
+	if len(d) < minPhoneDigits {
+		return "", false
+	}
+	switch {
+	case len(d) == 11 && d[0] == '8':
+		d = "7" + d[1:]
+	case len(d) == 10 && d[0] == '9':
+		d = "7" + d
+	}
+	return d, true
+}
+
+// apply добавляет к запросу условие поиска; текстовый поиск сортируется по релевантности
+func (s contactSearch) apply(qb *gorm.DB) *gorm.DB {
+	switch {
+	case s.Phone != "" && len(s.Phone) == 11:
+		return qb.Where("phone_digits = ?", s.Phone)
+	case s.Phone != "":
+		return qb.Where("phone_digits LIKE ?", "%"+s.Phone+"%")
+	case s.Email != "":
+		return qb.Where("email ILIKE ?", "%"+s.Email+"%")
+	case s.TSQuery != "":
+		// Сортировка с параметром (clause.Expr) теряется при слиянии со следующими Order,
+		// поэтому запрос подставляется литералом: в нём только буквы, цифры, ":*" и "&"
+		literal := "'" + strings.ReplaceAll(s.TSQuery, "'", "''") + "'"
+		return qb.Where("search_vector @@ to_tsquery('russian', ?)", s.TSQuery).
+			Order(clause.OrderByColumn{
+				Column: clause.Column{Name: "ts_rank(search_vector, to_tsquery('russian', " + literal + "))", Raw: true},
+				Desc:   true,
+			})
+	}
+	return qb
+}
+
+// withSearchSnippets дополняет заявки страницы фрагментами сообщения, компании и заметок
+// с подсвеченными словами запроса (один запрос). Только для текстового поиска
+func (h *Handlers) withSearchSnippets(rows []contactRow, s contactSearch) []contactRow {
+	if s.TSQuery == "" || len(rows) == 0 {
+		return rows
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	var found []struct {
+		ID      uint
+		Snippet string
+	}
+	err := h.db.Model(&models.ContactForm{}).
+		Select(`id, ts_headline('russian',
+			concat_ws(' · ', NULLIF(company, ''), NULLIF(message, ''),
+				(SELECT string_agg(n.text, ' · ') FROM contact_notes n WHERE n.contact_id = contact_forms.id)),
+			to_tsquery('russian', ?), ?) AS snippet`,
+			s.TSQuery,
+			`StartSel="`+snippetStartSel+`", StopSel="`+snippetStopSel+`", MaxFragments=2, MaxWords=18, MinWords=6, FragmentDelimiter=" … "`).
+		Where("id IN ?", ids).
+		Scan(&found).Error
+	if err != nil {
+		return rows
+	}
+	byID := make(map[uint]template.HTML, len(found))
+	for _, f := range found {
+		if sn := highlightSnippet(f.Snippet); sn != "" {
+			byID[f.ID] = sn
+		}
+	}
+	for i := range rows {
+		rows[i].Snippet = byID[rows[i].ID]
+	}
+	return rows
+}
+
+// highlightSnippet экранирует фрагмент и размечает совпадения тегом <mark>.
+// Фрагмент без совпадений (слово нашлось в имени или email) не нужен
+func highlightSnippet(s string) template.HTML {
+	if !strings.Contains(s, snippetStartSel) {
+		return ""
+	}
+	s = html.EscapeString(s)
+	s = strings.NewReplacer(snippetStartSel, "<mark>", snippetStopSel, "</mark>").Replace(s)
+	return template.HTML(s)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContactSearch(t *testing.T) {
+	cases := []struct {
+		q    string
+		want contactSearch
+	}{
+		{"+7 (921) 123-45-67", contactSearch{Phone: "79211234567"}},
+		{"8 921 1234567", contactSearch{Phone: "79211234567"}},
+		{"9211234567", contactSearch{Phone: "79211234567"}},
+		{"45-67", contactSearch{Phone: "4567"}},
+		{"2026", contactSearch{Phone: "2026"}},
+		{"921", contactSearch{TSQuery: "921:*"}},
+		{"Ivan@Example", contactSearch{Email: "ivan@example"}},
+		{"  Экран для ТЦ!  ", contactSearch{TSQuery: "экран:* & для:* & тц:*"}},
+		{"o'reilly & (led)", contactSearch{TSQuery: "o:* & reilly:* & led:*"}},
+		{"", contactSearch{}},
+		{"!!!", contactSearch{}},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, parseContactSearch(tc.q), tc.q)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	assert.Equal(t, template.HTML("нужен <mark>экран</mark> &lt;b&gt;"), highlightSnippet("нужен [[[экран]]] <b>"))
+	assert.Empty(t, highlightSnippet("совпадение только в имени"))
+}
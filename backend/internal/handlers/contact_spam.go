@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+)
+
+// Антиспам формы заявки (SubmitContact), проверки по порядку:
+//  1. Honeypot поле Website - бот, заявка отклоняется
+//  2. Телефон - только российские номера, сохраняется в виде +7XXXXXXXXXX
+//  3. Частота заявок с одного IP - отклоняется с 429
+//  4. Повтор заявки с того же телефона - не сохраняется, клиент видит обычный ответ
+//  5. Токен времени заполнения, ссылки и стоп-слова - заявка сохраняется со статусом "spam":
+//     уведомления по ней не отправляются, менеджер проверяет её в фильтре «Спам»
+const (
+	contactRateWindow      = 10 * time.Minute // окно подсчёта заявок с одного IP
+	contactRateLimit       = 5                // заявок с одного IP за окно
+	contactDuplicateWindow = 10 * time.Minute // повтор с того же телефона в этом окне не сохраняется
+	contactMinFillTime     = 3 * time.Second  // быстрее форму заполняют только боты
+	contactMaxTokenAge     = 24 * time.Hour   // старый токен - скорее всего собран ботом со страницы
+)
+
+// contactLinkPattern - ссылки в тексте заявки (типичный признак спама)
+var contactLinkPattern = regexp.MustCompile(`(?i)(https?://|www\.|\[url|<a\s)`)
+
+// nonDigits - всё, кроме цифр (разбор телефонов)
+var nonDigits = regexp.MustCompile(`\D`)
+
+// phoneDigits - цифры номера без разделителей. Общий разбор для normalizePhone (хранение)
+// и formatPhone (показ): сохранённый +7XXXXXXXXXX всегда показывается как +7 XXX XXX XX XX.
+func phoneDigits(phone string) string {
+	return nonDigits.ReplaceAllString(phone, "")
+}
+
+// normalizePhone приводит российский номер к виду +7XXXXXXXXXX.
+// Принимает 8XXXXXXXXXX, 7XXXXXXXXXX и 10 цифр без кода страны с любыми разделителями.
+// Код оператора/города должен начинаться с 3, 4, 8 или 9.
+func normalizePhone(phone string) (string, bool) {
+	digits := phoneDigits(phone)
+	switch {
+	case len(digits) == 10:
+		digits = "7" + digits
+	case len(digits) == 11 && digits[0] == '8':
+		digits = "7" + digits[1:]
+	}
+	if len(digits) != 11 || digits[0] != '7' || !strings.ContainsRune("3489", rune(digits[1])) {
+		return "", false
+	}
+	return "+" + digits, true
+}
+
+// ContactFormToken выдаёт токен формы заявки: время выдачи и его HMAC на секрете JWT.
+// Токен вставляется в страницу с формой; по нему SubmitContact проверяет время заполнения.
+func ContactFormToken(now time.Time) string {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	return ts + "." + contactTokenSignature(ts)
+}
+
+func contactTokenSignature(ts string) string {
+	mac := hmac.New(sha256.New, []byte(jwtSecret()))
+	mac.Write([]byte("contact-form:" + ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// contactTokenIssuedAt проверяет подпись токена формы и возвращает время его выдачи
+func contactTokenIssuedAt(token string) (time.Time, bool) {
+	ts, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(contactTokenSignature(ts))) {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// contactSpamReason возвращает причину, по которой заявка считается спамом (пусто - не спам)
+func (h *Handlers) contactSpamReason(form *models.ContactForm, now time.Time) string {
+	issuedAt, ok := contactTokenIssuedAt(form.FormToken)
+	if !ok {
+		return "нет токена формы"
+	}
+	fill := now.Sub(issuedAt)
+	if fill < contactMinFillTime {
+		return fmt.Sprintf("форма заполнена за %d с", int(fill.Seconds()))
+	}
+	if fill > contactMaxTokenAge {
+		return "устаревший токен формы"
+	}
+
+	text := strings.ToLower(strings.Join([]string{form.Name, form.Company, form.Message}, "\n"))
+	if contactLinkPattern.MatchString(text) {
+		return "ссылка в тексте"
+	}
+	for _, word := range strings.Split(getSettings(h.db).SpamKeywords, "\n") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" && strings.Contains(text, word) {
+			return "стоп-слово «" + word + "»"
+		}
+	}
+	return ""
+}
+
+// contactRateLimited - превышено ли число заявок с адреса ip за contactRateWindow
+func (h *Handlers) contactRateLimited(ip string, now time.Time) bool {
+	var count int64
+	h.db.Model(&models.ContactForm{}).
+		Where("ip = ? AND created_at > ?", ip, now.Add(-contactRateWindow)).
+		Count(&count)
+	return count >= contactRateLimit
+}
+
+// isDuplicateContact - была ли заявка с этого телефона за contactDuplicateWindow
+func (h *Handlers) isDuplicateContact(phone string, now time.Time) bool {
+	var count int64
+	h.db.Model(&models.ContactForm{}).
+		Where("phone = ? AND created_at > ?", phone, now.Add(-contactDuplicateWindow)).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// filledFormToken - токен формы, выданный минуту назад (человек успел заполнить форму)
+func filledFormToken() string {
+	return ContactFormToken(time.Now().Add(-time.Minute))
+}
+
+// contactRequest - необязательные параметры запроса формы заявки
+type contactRequest struct {
+	IP      string            // адрес клиента (RemoteAddr), по умолчанию - адрес httptest
+	Headers map[string]string // например Referer страницы формы
+	Cookies []*http.Cookie
+}
+
+// postContact отправляет форму заявки (POST /api/contact) и возвращает код ответа.
+// Без form_token в fields подставляется токен заполненной формы.
+func postContact(h *Handlers, fields map[string]string, opts ...contactRequest) int {
+	router := gin.New()
+	router.POST("/api/contact", h.SubmitContact)
+	if _, ok := fields["form_token"]; !ok {
+		fields["form_token"] = filledFormToken()
+	}
+	body, _ := json.Marshal(fields)
+	req, _ := http.NewRequest("POST", "/api/contact", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	for _, o := range opts {
+		if o.IP != "" {
+			req.RemoteAddr = o.IP + ":12345"
+		}
+		for k, v := range o.Headers {
+			req.Header.Set(k, v)
+		}
+		for _, c := range o.Cookies {
+			req.AddCookie(c)
+		}
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestNormalizePhone(t *testing.T) {
+	valid := map[string]string{
+		"+7 (921) 123-45-67": "+79211234567",
+		"89211234567":        "+79211234567",
+		"79211234567":        "+79211234567",
+		"921 123 45 67":      "+79211234567",
+		"8 (812) 555-12-34":  "+78125551234",
+	}
+	for in, want := range valid {
+		got, ok := normalizePhone(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "12345", "+1 202 555 0100", "+7 (121) 123-45-67", "+7921123456789"} {
+		_, ok := normalizePhone(in)
+		assert.False(t, ok, "номер %q не должен приниматься", in)
+	}
+}
+
+func TestContactFormToken(t *testing.T) {
+	now := time.Now()
+	issued, ok := contactTokenIssuedAt(ContactFormToken(now))
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix(), issued.Unix())
+
+	_, ok = contactTokenIssuedAt("1700000000.deadbeef")
+	assert.False(t, ok, "поддельная подпись")
+	_, ok = contactTokenIssuedAt("")
+	assert.False(t, ok)
+}
+
+func TestContactSpamReason(t *testing.T) {
+	_, h := setupTestRouter(t)
+	settings := getSettings(h.db)
+	h.db.Model(&settings).Update("spam_keywords", "Казино\n\n  быстрый заработок ")
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		form models.ContactForm
+		want string
+	}{
+		{"обычная заявка", models.ContactForm{Message: "Нужен экран 3x2", FormToken: filledFormToken()}, ""},
+		{"без токена", models.ContactForm{}, "нет токена формы"},
+		{"слишком быстро", models.ContactForm{FormToken: ContactFormToken(now.Add(-time.Second))}, "форма заполнена за 1 с"},
+		{"старый токен", models.ContactForm{FormToken: ContactFormToken(now.Add(-25 * time.Hour))}, "устаревший токен формы"},
+		{"ссылка", models.ContactForm{Message: "Смотрите https://example.com", FormToken: filledFormToken()}, "ссылка в тексте"},
+		{"стоп-слово", models.ContactForm{Company: "КАЗИНО Вулкан", FormToken: filledFormToken()}, "стоп-слово «казино»"},
+		{"фраза", models.ContactForm{Message: "Быстрый заработок", FormToken: filledFormToken()}, "стоп-слово «быстрый заработок»"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, h.contactSpamReason(&tt.form, now), tt.name)
+	}
+}
+
+func TestSubmitContact_SpamSavedWithoutNotifications(t *testing.T) {
+	_, h := setupTestRouter(t)
+	hook := &recordingNotifier{name: "webhook", kinds: []notify.EventKind{notify.EventNewContact}}
+	o := withTestOutbox(h, hook)
+
+	code := postContact(h, map[string]string{
+		"name": "Бот", "phone": "8 921 123-45-67", "message": "www.spam.example",
+	}, contactRequest{IP: "10.0.0.1"})
+	assert.Equal(t, http.StatusOK, code, "спамеру ответ не отличается от обычного")
+
+	var contact models.ContactForm
+	h.db.First(&contact)
+	assert.Equal(t, "spam", contact.Status)
+	assert.Equal(t, "ссылка в тексте", contact.SpamReason)
+	assert.Equal(t, "+79211234567", contact.Phone)
+	assert.Equal(t, "10.0.0.1", contact.IP)
+
+	assert.Zero(t, o.ProcessDue(context.Background(), time.Now()))
+	assert.Empty(t, hook.events)
+}
+
+func TestSubmitContact_InvalidPhone(t *testing.T) {
+	_, h := setupTestRouter(t)
+	code := postContact(h, map[string]string{"name": "Иван", "phone": "12345"}, contactRequest{IP: "10.0.0.1"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	var count int64
+	h.db.Model(&models.ContactForm{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestSubmitContact_DuplicateAndRateLimit(t *testing.T) {
+	_, h := setupTestRouter(t)
+	// Дата из запроса не выводит заявку из окна лимита и проверки повтора
+	fields := map[string]string{"name": "Иван", "phone": "+79211234567", "created_at": "2001-01-01T00:00:00Z"}
+
+	assert.Equal(t, http.StatusOK, postContact(h, fields, contactRequest{IP: "10.0.0.1"}))
+	assert.Equal(t, http.StatusOK, postContact(h, fields, contactRequest{IP: "10.0.0.1"}), "повтор отвечает как обычно")
+	var count int64
+	h.db.Model(&models.ContactForm{}).Count(&count)
+	assert.Equal(t, int64(1), count, "повтор с того же телефона не сохраняется")
+	var contact models.ContactForm
+	h.db.First(&contact)
+	assert.WithinDuration(t, time.Now(), contact.CreatedAt, time.Minute)
+
+	for i := 2; i <= contactRateLimit; i++ {
+		fields["phone"] = "+7921123456" + string(rune('0'+i))
+		assert.Equal(t, http.StatusOK, postContact(h, fields, contactRequest{IP: "10.0.0.1"}))
+	}
+	fields["phone"] = "+79219999999"
+	assert.Equal(t, http.StatusTooManyRequests, postContact(h, fields, contactRequest{IP: "10.0.0.1"}))
+	assert.Equal(t, http.StatusOK, postContact(h, fields, contactRequest{IP: "10.0.0.2"}), "другой IP не ограничен")
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxContactViews - сколько видов списка заявок может сохранить один администратор
+const maxContactViews = 20
+
+// contactViewTab - вкладка сохранённого вида над списком заявок
+type contactViewTab struct {
+	ID   uint
+	Name string
+	Href string
+}
+
+// contactViewTabs - сохранённые виды администратора в порядке создания
+func (h *Handlers) contactViewTabs(adminID uint) []contactViewTab {
+	var views []models.ContactView
+	h.db.Where("admin_id = ?", adminID).Order("id").Find(&views)
+	tabs := make([]contactViewTab, 0, len(views))
+	for _, v := range views {
+		tabs = append(tabs, contactViewTab{
+			ID:   v.ID,
+			Name: v.Name,
+			Href: contactViewURL(v.Query, v.ID),
+		})
+	}
+	return tabs
+}
+
+// contactViewURL - ссылка на список с фильтрами вида; view отмечает активную вкладку
+func contactViewURL(query string, id uint) string {
+	return "/admin/contacts?" + query + "&view=" + strconv.FormatUint(uint64(id), 10)
+}
+
+// CreateContactView — сохранить фильтры списка заявок как вид текущего администратора
+func (h *Handlers) CreateContactView(c *gin.Context) {
+	var body struct {
+		Name  string `json:"name"`
+		Query string `json:"query"` // query строка списка, например "?status=new&source=yandex"
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Неверные данные")
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		jsonErr(c, http.StatusBadRequest, "Название вида: от 1 до 100 символов")
+		return
+	}
+	values, err := url.ParseQuery(strings.TrimPrefix(body.Query, "?"))
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, "Некорректные параметры фильтра")
+		return
+	}
+	query := contactFilterFromQuery(values).values().Encode()
+	if query == "" {
+		jsonErr(c, http.StatusBadRequest, "Выберите фильтры для вида")
+		return
+	}
+	if len(query) > 2000 {
+		jsonErr(c, http.StatusBadRequest, "Слишком длинный фильтр")
+		return
+	}
+
+	adminID := c.GetUint("admin_id")
+	var count int64
+	h.db.Model(&models.ContactView{}).Where("admin_id = ?", adminID).Count(&count)
+	if count >= maxContactViews {
+		jsonErr(c, http.StatusBadRequest, "Можно сохранить не больше "+strconv.Itoa(maxContactViews)+" видов")
+		return
+	}
+
+	view := models.ContactView{AdminID: adminID, Name: name, Query: query}
+	if err := h.db.Create(&view).Error; err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось сохранить вид")
+		return
+	}
+	setAuditEntityID(c, view.ID)
+	jsonOK(c, gin.H{"view": view, "url": contactViewURL(view.Query, view.ID)})
+}
+
+// DeleteContactView — удалить свой сохранённый вид
+func (h *Handlers) DeleteContactView(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+	res := h.db.Where("id = ? AND admin_id = ?", id, c.GetUint("admin_id")).Delete(&models.ContactView{})
+	if res.Error != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось удалить вид")
+		return
+	}
+	if res.RowsAffected == 0 {
+		jsonErr(c, http.StatusNotFound, "Вид не найден")
+		return
+	}
+	jsonOK(c, gin.H{"id": id})
+}
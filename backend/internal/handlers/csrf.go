@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// CSRFToken возвращает CSRF-токен сессии админки (synchronizer token).
+//
+// Токен - HMAC-SHA256 от JTI серверной сессии на секрете JWT: у каждой сессии свой токен,
+// он не хранится в БД, не меняется при обновлении access токена
+// и перестаёт действовать вместе с сессией. Пустой JTI - пустой токен.
+func CSRFToken(jti string) string {
+	if jti == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(jwtSecret()))
+	mac.Write([]byte("csrf:" + jti))
+	return hex.EncodeToString(mac.Sum(nil))
+}
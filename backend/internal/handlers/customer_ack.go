@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"ledsite/internal/mailer"
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+)
+
+// Письмо-подтверждение клиенту после отправки формы.
+// Включается в /admin/settings; уходит через outbox (канал customer_email), если клиент указал email.
+const ackPriceLinks = 3 // сколько позиций прайса предлагать в письме
+
+// ackPriceCategories - категории прайса (PriceItem.Category), подходящие типу проекта заявки
+var ackPriceCategories = map[string][]string{
+	"indoor":  {"indoor"},
+	"outdoor": {"outdoor"},
+	"rental":  {"indoor", "outdoor"},
+}
+
+// leadReference - номер заявки, который видит клиент
+func leadReference(id uint) string {
+	return fmt.Sprintf("L-%06d", id)
+}
+
+// formatRub форматирует цену с пробелами между разрядами: 125000 → "125 000"
+func formatRub(price int) string {
+	s := strconv.Itoa(price)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// enqueueCustomerAck ставит в outbox письмо-подтверждение клиенту внутри транзакции tx.
+// Ошибка шаблона не мешает сохранить заявку: письмо пропускается с записью в лог.
+func (h *Handlers) enqueueCustomerAck(tx *gorm.DB, contact *models.ContactForm) error {
+	if h.outbox == nil || !h.outbox.Supports(notify.EventCustomerAck) || contact.Email == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(contact.Email)
+	if err != nil {
+		return nil
+	}
+	settings := getSettings(tx)
+	if !settings.AckEmailEnabled {
+		return nil
+	}
+
+	msg, err := mailer.AckMessage(settings.AckEmailSubject, settings.AckEmailBody, h.ackEmailData(tx, contact, settings))
+	if err != nil {
+		log.Printf("Письмо-подтверждение по заявке ID=%d не собрано: %v", contact.ID, err)
+		return nil
+	}
+
+	ev := h.contactEvent(notify.EventCustomerAck, contact)
+	ev.AdminURL = "" // в письмо клиенту ссылка на админку не попадает
+	// Только адрес: имя из формы ("Текст" <a@b>) не должно попасть в заголовок To
+	ev.Contact.Email = addr.Address
+	ev.Mail = &msg
+	return h.outbox.EnqueueTx(tx, ev)
+}
+
+// ackEmailData собирает данные письма: заявка, контакты из настроек, цены по типу проекта
+func (h *Handlers) ackEmailData(tx *gorm.DB, contact *models.ContactForm, settings models.SiteSettings) mailer.AckEmail {
+	data := mailer.AckEmail{
+		ContactEmail: mailer.ContactEmail{
+			ID:          contact.ID,
+			Name:        contact.Name,
+			Phone:       contact.Phone,
+			Email:       contact.Email,
+			Company:     contact.Company,
+			ProjectType: translateProjectType(contact.ProjectType),
+			Message:     contact.Message,
+			CreatedAt:   contact.CreatedAt.In(moscowLoc).Format("02.01.2006 15:04"),
+		},
+		Reference: leadReference(contact.ID),
+		Site: mailer.SiteContacts{
+			Phone:        settings.Phone,
+			PhoneDisplay: settings.PhoneDisplay,
+			Email:        settings.Email,
+			Address:      settings.Address,
+			WorkHours:    settings.WorkHours,
+			SiteURL:      h.siteURL,
+		},
+	}
+
+	categories := ackPriceCategories[contact.ProjectType]
+	if len(categories) == 0 || h.siteURL == "" {
+		return data
+	}
+	var items []models.PriceItem
+	if err := tx.Where("is_active = ? AND category IN ?", true, categories).
+		Order("sort_order ASC, id ASC").
+		Limit(ackPriceLinks).
+		Find(&items).Error; err != nil {
+		log.Printf("Ошибка подбора цен для письма клиенту: %v", err)
+		return data
+	}
+	for _, item := range items {
+		data.Prices = append(data.Prices, mailer.PriceLink{
+			Title:     item.Title,
+			PriceFrom: formatRub(item.PriceFrom),
+			URL:       fmt.Sprintf("%s/prices#price-%d", h.siteURL, item.ID),
+		})
+	}
+	return data
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitContact_CustomerAck(t *testing.T) {
+	_, h := setupTestRouter(t)
+	ack := &recordingNotifier{name: "customer_email", kinds: []notify.EventKind{notify.EventCustomerAck}}
+	o := withTestOutbox(h, ack)
+
+	settings := getSettings(h.db)
+	h.db.Model(&settings).Update("ack_email_enabled", true)
+	h.db.Create(&models.PriceItem{Title: "Билборд 6x3", PriceFrom: 1200000, Category: "outdoor", IsActive: true})
+	h.db.Create(&models.PriceItem{Title: "Экран в зал", PriceFrom: 300000, Category: "indoor", IsActive: true})
+
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{
+		"name": "Иван", "phone": "+79211234567", "email": "ivan@example.com", "project_type": "outdoor",
+	}))
+	assert.Equal(t, 1, o.ProcessDue(context.Background(), time.Now()))
+
+	if !assert.Len(t, ack.events, 1) {
+		return
+	}
+	ev := ack.events[0]
+	assert.Equal(t, "ivan@example.com", ev.Contact.Email)
+	assert.Empty(t, ev.AdminURL, "ссылка на админку не уходит клиенту")
+	if assert.NotNil(t, ev.Mail) {
+		assert.Contains(t, ev.Mail.Subject, leadReference(ev.Contact.ID))
+		assert.Contains(t, ev.Mail.Text, "Билборд 6x3 - от 1 200 000 ₽: https://s-n-r.ru/prices#price-1")
+		assert.NotContains(t, ev.Mail.Text, "Экран в зал", "цены только по типу проекта")
+		assert.Contains(t, ev.Mail.Text, settings.PhoneDisplay)
+	}
+}
+
+func TestSubmitContact_CustomerAckAddressOnly(t *testing.T) {
+	_, h := setupTestRouter(t)
+	ack := &recordingNotifier{name: "customer_email", kinds: []notify.EventKind{notify.EventCustomerAck}}
+	o := withTestOutbox(h, ack)
+	settings := getSettings(h.db)
+	h.db.Model(&settings).Update("ack_email_enabled", true)
+
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Иван", "phone": "+79211234567", "email": `"Любой текст" <victim@example.com>`}))
+	assert.Equal(t, 1, o.ProcessDue(context.Background(), time.Now()))
+	if assert.Len(t, ack.events, 1) {
+		assert.Equal(t, "victim@example.com", ack.events[0].Contact.Email, "имя из формы не попадает в заголовок To")
+	}
+}
+
+func TestSubmitContact_CustomerAckSkipped(t *testing.T) {
+	_, h := setupTestRouter(t)
+	withTestOutbox(h, &recordingNotifier{name: "customer_email", kinds: []notify.EventKind{notify.EventCustomerAck}})
+
+	// Подтверждение выключено в настройках
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Иван", "phone": "+79211234567", "email": "ivan@example.com"}))
+
+	settings := getSettings(h.db)
+	h.db.Model(&settings).Update("ack_email_enabled", true)
+	// Без email и с некорректным email
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Пётр", "phone": "+79211234568"}))
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Олег", "phone": "+79211234569", "email": "не-email"}))
+
+	var count int64
+	h.db.Model(&models.NotificationDelivery{}).Count(&count)
+	assert.Zero(t, count)
+	h.db.Model(&models.ContactForm{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestAdminSettingsUpdate_AckTemplate(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/settings", h.AdminSettingsUpdate)
+
+	post := func(form url.Values) int {
+		req, _ := http.NewRequest("POST", "/admin/settings", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusFound, post(url.Values{
+		"ack_email_enabled": {"1"},
+		"ack_email_subject": {"Заявка {{.Reference}}"},
+		"ack_email_body":    {"Здравствуйте, {{.Name}}!"},
+	}))
+	s := getSettings(h.db)
+	assert.True(t, s.AckEmailEnabled)
+	assert.Equal(t, "Заявка {{.Reference}}", s.AckEmailSubject)
+	assert.Equal(t, "Здравствуйте, {{.Name}}!", s.AckEmailBody)
+
+	assert.Equal(t, http.StatusBadRequest, post(url.Values{"ack_email_body": {"{{.Name"}}))
+	assert.Equal(t, "Здравствуйте, {{.Name}}!", getSettings(h.db).AckEmailBody, "ошибочный шаблон не сохраняется")
+}
+
+func TestFormatRub(t *testing.T) {
+	assert.Equal(t, "900", formatRub(900))
+	assert.Equal(t, "1 000", formatRub(1000))
+	assert.Equal(t, "1 200 000", formatRub(1200000))
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"ledsite/internal/models"
+)
+
+// Клиенты: повторные заявки с одного телефона или email группируются в models.Customer.
+// Заявка привязывается при сохранении (SubmitContact), спам не привязывается до смены статуса.
+// Менеджер может объединить двух клиентов или отделить ошибочно привязанную заявку.
+
+// customerPhoneKey - телефон для сравнения: +7XXXXXXXXXX, а нероссийский номер - как есть
+func customerPhoneKey(phone string) string {
+	if normalized, ok := normalizePhone(phone); ok {
+		return normalized
+	}
+	return strings.TrimSpace(phone)
+}
+
+// customerEmailKey - email для сравнения (без регистра)
+func customerEmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// findCustomerID ищет клиента для заявки: сначала по последней привязанной заявке с тем же
+// телефоном или email (так находятся и объединённые клиенты с несколькими телефонами),
+// затем по ключам самого клиента. 0 - клиент не найден.
+func findCustomerID(tx *gorm.DB, contact *models.ContactForm) (uint, error) {
+	phone := customerPhoneKey(contact.Phone)
+	email := customerEmailKey(contact.Email)
+
+	var ids []uint
+	if err := tx.Model(&models.ContactForm{}).
+		Where("customer_id IS NOT NULL").
+		Where("phone IN ? OR (? <> '' AND LOWER(email) = ?)", []string{phone, contact.Phone}, email, email).
+		Order("created_at DESC, id DESC").
+		Limit(1).
+		Pluck("customer_id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) > 0 {
+		return ids[0], nil
+	}
+
+	if err := tx.Model(&models.Customer{}).
+		Where("phone = ? OR (? <> '' AND email = ?)", phone, email, email).
+		Order("id ASC").
+		Limit(1).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) > 0 {
+		return ids[0], nil
+	}
+	return 0, nil
+}
+
+// newCustomerFor создаёт клиента по данным заявки
+func newCustomerFor(tx *gorm.DB, contact *models.ContactForm) (*models.Customer, error) {
+	customer := models.Customer{
+		Name:    strings.TrimSpace(contact.Name),
+		Phone:   customerPhoneKey(contact.Phone),
+		Email:   customerEmailKey(contact.Email),
+		Company: strings.TrimSpace(contact.Company),
+	}
+	if err := tx.Create(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// linkCustomer привязывает заявку к существующему или новому клиенту.
+// Для сохранённой заявки (ID != 0) customer_id обновляется в БД, иначе только в структуре.
+// Спам не привязывается: чужой номер в спаме не должен попасть в историю клиента.
+// Уже привязанная сохранённая заявка не перепривязывается.
+func (h *Handlers) linkCustomer(tx *gorm.DB, contact *models.ContactForm) error {
+	if contact.Status == "spam" || (contact.ID != 0 && contact.CustomerID != nil) {
+		return nil
+	}
+
+	customerID, err := findCustomerID(tx, contact)
+	if err != nil {
+		return err
+	}
+	if customerID == 0 {
+		customer, err := newCustomerFor(tx, contact)
+		if err != nil {
+			return err
+		}
+		customerID = customer.ID
+	} else {
+		// Клиент уже есть - дополняем недостающие контакты из новой заявки
+		updates := map[string]any{}
+		var customer models.Customer
+		if err := tx.First(&customer, customerID).Error; err != nil {
+			return err
+		}
+		if customer.Email == "" && contact.Email != "" {
+			updates["email"] = customerEmailKey(contact.Email)
+		}
+		if customer.Company == "" && contact.Company != "" {
+			updates["company"] = strings.TrimSpace(contact.Company)
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&customer).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	contact.CustomerID = &customerID
+	if contact.ID == 0 {
+		return nil
+	}
+	return tx.Model(&models.ContactForm{}).Where("id = ?", contact.ID).Update("customer_id", customerID).Error
+}
+
+// linkCustomersByIDs привязывает заявки ids без клиента (после снятия статуса "spam")
+func (h *Handlers) linkCustomersByIDs(ids []uint) {
+	var contacts []models.ContactForm
+	h.db.Where("id IN ? AND customer_id IS NULL AND status <> ?", ids, "spam").Order("id ASC").Find(&contacts)
+	for i := range contacts {
+		if err := h.linkCustomer(h.db, &contacts[i]); err != nil {
+			log.Printf("Ошибка привязки заявки ID=%d к клиенту: %v", contacts[i].ID, err)
+		}
+	}
+}
+
+// previousContacts - сколько заявок клиент оставлял раньше этой (без спама)
+func previousContacts(tx *gorm.DB, contact *models.ContactForm) int {
+	if contact.CustomerID == nil {
+		return 0
+	}
+	var count int64
+	tx.Model(&models.ContactForm{}).
+		Where("customer_id = ? AND id <> ? AND status <> ? AND created_at <= ?", *contact.CustomerID, contact.ID, "spam", contact.CreatedAt).
+		Count(&count)
+	return int(count)
+}
+
+// BackfillCustomers привязывает к клиентам заявки, сохранённые до появления клиентов.
+// Вызывается при старте сервера; повторный запуск обрабатывает только непривязанные заявки.
+func (h *Handlers) BackfillCustomers() (int, error) {
+	linked := 0
+	var batch []models.ContactForm
+	err := h.db.Where("customer_id IS NULL AND status <> ?", "spam").
+		Order("id ASC").
+		FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				if err := h.linkCustomer(h.db, &batch[i]); err != nil {
+					return err
+				}
+				linked++
+			}
+			return nil
+		}).Error
+	return linked, err
+}
+
+// customerRow - строка списка клиентов
+type customerRow struct {
+	models.Customer
+	Contacts      int64
+	LastContactID uint
+	LastContact   *models.ContactForm `gorm:"-"`
+}
+
+// AdminCustomersPage — список клиентов с числом заявок (поиск по имени, телефону, email, компании)
+func (h *Handlers) AdminCustomersPage(c *gin.Context) {
+	search := strings.TrimSpace(c.Query("search"))
+	qb := h.db.Model(&models.Customer{})
+	if search != "" {
+		q := "%" + search + "%"
+		qb = qb.Where("name ILIKE ? OR phone ILIKE ? OR email ILIKE ? OR company ILIKE ?", q, q, q, q)
+	}
+
+	page, limit, offset := h.getPageQuery(c)
+	var total int64
+	if err := qb.Count(&total).Error; err != nil {
+		c.String(http.StatusInternalServerError, "DB error")
+		return
+	}
+
+	var rows []customerRow
+	if err := qb.Select("customers.*, COUNT(cf.id) AS contacts, MAX(cf.id) AS last_contact_id").
+		Joins("LEFT JOIN contact_forms cf ON cf.customer_id = customers.id").
+		Group("customers.id").
+		Order("last_contact_id DESC, customers.id DESC").
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error; err != nil {
+		c.String(http.StatusInternalServerError, "DB error")
+		return
+	}
+
+	// Последняя заявка клиента: дата и тип проекта в списке
+	lastIDs := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		lastIDs = append(lastIDs, r.LastContactID)
+	}
+	var last []models.ContactForm
+	if len(lastIDs) > 0 {
+		h.db.Where("id IN ?", lastIDs).Find(&last)
+	}
+	for i := range last {
+		for j := range rows {
+			if rows[j].LastContactID == last[i].ID {
+				rows[j].LastContact = &last[i]
+			}
+		}
+	}
+
+	pages, prevPage, nextPage, pageNumbers := h.pageMeta(total, page, limit)
+	query := url.Values{}
+	if search != "" {
+		query.Set("search", search)
+	}
+	query.Set("limit", strconv.Itoa(limit))
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":       "Клиенты",
+		"PageID":      "admin-customers",
+		"customers":   rows,
+		"search":      search,
+		"filterQuery": template.URL(query.Encode()),
+		"total":       total,
+		"page":        page,
+		"pages":       pages,
+		"prevPage":    prevPage,
+		"nextPage":    nextPage,
+		"pageNumbers": pageNumbers,
+		"limit":       limit,
+	})
+}
+
+// contactRow - заявка в списке с числом заявок её клиента (повторное обращение - больше одной)
+type contactRow struct {
+	models.ContactForm
+	CustomerContacts int64
+	AssignedTo       string        // логин ответственного (см. withAssignees)
+	Snippet          template.HTML // найденный фрагмент с подсветкой (см. withSearchSnippets)
+}
+
+// withCustomerContacts дополняет заявки страницы числом заявок их клиентов (один запрос)
+func (h *Handlers) withCustomerContacts(contacts []models.ContactForm) []contactRow {
+	customerIDs := make([]uint, 0, len(contacts))
+	for _, ct := range contacts {
+		if ct.CustomerID != nil {
+			customerIDs = append(customerIDs, *ct.CustomerID)
+		}
+	}
+	var counts []struct {
+		CustomerID uint
+		Total      int64
+	}
+	if len(customerIDs) > 0 {
+		h.db.Model(&models.ContactForm{}).
+			Select("customer_id, COUNT(*) AS total").
+			Where("customer_id IN ?", customerIDs).
+			Group("customer_id").
+			Scan(&counts)
+	}
+	byCustomer := make(map[uint]int64, len(counts))
+	for _, cnt := range counts {
+		byCustomer[cnt.CustomerID] = cnt.Total
+	}
+
+	rows := make([]contactRow, 0, len(contacts))
+	for _, ct := range contacts {
+		row := contactRow{ContactForm: ct}
+		if ct.CustomerID != nil {
+			row.CustomerContacts = byCustomer[*ct.CustomerID]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// customerTimelineItem - заявка или заметка в истории клиента
+type customerTimelineItem struct {
+	At      time.Time
+	Contact *models.ContactForm
+	Note    *models.ContactNote
+}
+
+// AdminCustomerPage — карточка клиента: общая лента заявок и заметок, объединение и разделение
+func (h *Handlers) AdminCustomerPage(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+	var customer models.Customer
+	if err := h.db.First(&customer, id).Error; err != nil {
+		renderAdmin(c, http.StatusNotFound, gin.H{
+			"PageID": "admin-error",
+			"title":  "Клиент не найден",
+			"error":  "Клиент не найден или объединён с другим",
+		})
+		return
+	}
+
+	var contacts []models.ContactForm
+	h.db.Where("customer_id = ?", customer.ID).Order("created_at DESC").Find(&contacts)
+
+	contactIDs := make([]uint, 0, len(contacts))
+	byID := make(map[uint]*models.ContactForm, len(contacts))
+	timeline := make([]customerTimelineItem, 0, len(contacts))
+	for i := range contacts {
+		contactIDs = append(contactIDs, contacts[i].ID)
+		byID[contacts[i].ID] = &contacts[i]
+		timeline = append(timeline, customerTimelineItem{At: contacts[i].CreatedAt, Contact: &contacts[i]})
+	}
+
+	var notes []models.ContactNote
+	if len(contactIDs) > 0 {
+		h.db.Where("contact_id IN ?", contactIDs).Find(&notes)
+	}
+	for i := range notes {
+		timeline = append(timeline, customerTimelineItem{At: notes[i].CreatedAt, Contact: byID[notes[i].ContactID], Note: &notes[i]})
+	}
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].At.After(timeline[j].At) })
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":       "Клиент " + customer.Name,
+		"PageID":      "admin-customer",
+		"customer":    customer,
+		"contacts":    contacts,
+		"timeline":    timeline,
+		"stageBadges": stageBadges(h.pipelineStages()),
+	})
+}
+
+// MergeCustomer — объединение: заявки клиента source_id переходят к клиенту :id, source удаляется
+func (h *Handlers) MergeCustomer(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		SourceID uint `json:"source_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.SourceID == 0 {
+		jsonErr(c, http.StatusBadRequest, "Укажите клиента для объединения")
+		return
+	}
+	if body.SourceID == id {
+		jsonErr(c, http.StatusBadRequest, "Нельзя объединить клиента с самим собой")
+		return
+	}
+
+	errNotFound := errors.New("клиент не найден")
+	var moved int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var target, source models.Customer
+		if err := tx.First(&target, id).Error; err != nil {
+			return errNotFound
+		}
+		if err := tx.First(&source, body.SourceID).Error; err != nil {
+			return errNotFound
+		}
+
+		res := tx.Model(&models.ContactForm{}).Where("customer_id = ?", source.ID).Update("customer_id", target.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		moved = res.RowsAffected
+
+		// Недостающие контакты берём у объединяемого клиента
+		updates := map[string]any{}
+		if target.Email == "" && source.Email != "" {
+			updates["email"] = source.Email
+		}
+		if target.Company == "" && source.Company != "" {
+			updates["company"] = source.Company
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&target).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&source).Error
+	})
+	if errors.Is(err, errNotFound) {
+		jsonErr(c, http.StatusNotFound, "Клиент не найден")
+		return
+	}
+	if err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось объединить клиентов")
+		return
+	}
+	jsonOK(c, gin.H{"message": "Клиенты объединены", "moved": moved})
+}
+
+// DetachCustomerContact — разделение: заявка :contact_id уходит от клиента :id в нового клиента
+func (h *Handlers) DetachCustomerContact(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+	contactID, err := strconv.ParseUint(c.Param("contact_id"), 10, 64)
+	if err != nil || contactID == 0 {
+		jsonErr(c, http.StatusBadRequest, "Некорректный id заявки")
+		return
+	}
+
+	var contact models.ContactForm
+	if err := h.db.Where("id = ? AND customer_id = ?", contactID, id).First(&contact).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Заявка не найдена у этого клиента")
+		return
+	}
+	var count int64
+	h.db.Model(&models.ContactForm{}).Where("customer_id = ?", id).Count(&count)
+	if count <= 1 {
+		jsonErr(c, http.StatusConflict, "У клиента только эта заявка")
+		return
+	}
+
+	var customer *models.Customer
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if customer, err = newCustomerFor(tx, &contact); err != nil {
+			return err
+		}
+		return tx.Model(&contact).Update("customer_id", customer.ID).Error
+	}); err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось отделить заявку")
+		return
+	}
+	setAuditEntityID(c, customer.ID)
+	jsonOK(c, gin.H{"message": "Заявка отделена в нового клиента", "customer_id": customer.ID})
+}
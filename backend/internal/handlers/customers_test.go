@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// customerOf - ID клиента заявки (0 - не привязана)
+func customerOf(t *testing.T, h *Handlers, contactID uint) uint {
+	t.Helper()
+	var contact models.ContactForm
+	assert.NoError(t, h.db.First(&contact, contactID).Error)
+	if contact.CustomerID == nil {
+		return 0
+	}
+	return *contact.CustomerID
+}
+
+func TestSubmitContact_ReturningCustomer(t *testing.T) {
+	_, h := setupTestRouter(t)
+	tg := &recordingNotifier{name: "telegram", kinds: []notify.EventKind{notify.EventNewContact}}
+	o := withTestOutbox(h, tg)
+
+	// Старая заявка того же клиента: другой телефон, тот же email в другом регистре
+	old := models.ContactForm{Name: "Иван", Phone: "+79210000000", Email: "Ivan@Example.com", CreatedAt: time.Now().Add(-48 * time.Hour)}
+	h.db.Create(&old)
+	linked, err := h.BackfillCustomers()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, linked)
+
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Иван", "phone": "8 921 123-45-67", "email": "ivan@example.com"}))
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Пётр", "phone": "+79217654321"}))
+
+	var contacts []models.ContactForm
+	h.db.Order("id").Find(&contacts)
+	if !assert.Len(t, contacts, 3) {
+		return
+	}
+	assert.Equal(t, customerOf(t, h, old.ID), customerOf(t, h, contacts[1].ID), "повторная заявка у того же клиента")
+	assert.NotEqual(t, customerOf(t, h, old.ID), customerOf(t, h, contacts[2].ID))
+
+	assert.Equal(t, 2, o.ProcessDue(context.Background(), time.Now()))
+	if assert.Len(t, tg.events, 2) {
+		assert.Equal(t, 1, tg.events[0].Contact.PreviousContacts)
+		assert.True(t, tg.events[0].Contact.Returning())
+		assert.False(t, tg.events[1].Contact.Returning(), "первое обращение")
+	}
+}
+
+func TestSubmitContact_IgnoresServerFields(t *testing.T) {
+	_, h := setupTestRouter(t)
+	other := models.ContactForm{Name: "Анна", Phone: "+79210000000"}
+	h.db.Create(&other)
+	_, err := h.BackfillCustomers()
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{
+		"name": "Иван", "phone": "+79211234567", "id": "500", "customer_id": "1", "status": "won",
+	}))
+	var contact models.ContactForm
+	h.db.Where("phone = ?", "+79211234567").First(&contact)
+	assert.NotEqual(t, uint(500), contact.ID, "id задаёт база")
+	assert.NotEqual(t, customerOf(t, h, other.ID), customerOf(t, h, contact.ID), "чужой клиент из формы не принимается")
+	assert.Equal(t, "new", contact.Status)
+}
+
+func TestBackfillCustomers_NormalizesKeys(t *testing.T) {
+	_, h := setupTestRouter(t)
+	a := models.ContactForm{Name: "Анна", Phone: "8 (921) 111-22-33"}
+	b := models.ContactForm{Name: "Анна", Phone: "+79211112233"}
+	other := models.ContactForm{Name: "Олег", Phone: "+79214445566", Email: "oleg@example.com"}
+	spam := models.ContactForm{Name: "Бот", Phone: "+79211112233", Status: "spam"}
+	for _, c := range []*models.ContactForm{&a, &b, &other, &spam} {
+		h.db.Create(c)
+	}
+
+	linked, err := h.BackfillCustomers()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, linked)
+	assert.Equal(t, customerOf(t, h, a.ID), customerOf(t, h, b.ID))
+	assert.NotEqual(t, customerOf(t, h, a.ID), customerOf(t, h, other.ID))
+	assert.Zero(t, customerOf(t, h, spam.ID), "спам не привязывается")
+
+	var customer models.Customer
+	h.db.First(&customer, customerOf(t, h, a.ID))
+	assert.Equal(t, "+79211112233", customer.Phone)
+
+	linked, _ = h.BackfillCustomers()
+	assert.Zero(t, linked, "повторный запуск ничего не меняет")
+
+	// Снятие статуса "spam" привязывает заявку к клиенту
+	h.db.Model(&spam).Update("status", "new")
+	h.linkCustomersByIDs([]uint{spam.ID})
+	assert.Equal(t, customerOf(t, h, a.ID), customerOf(t, h, spam.ID))
+}
+
+func TestMergeAndDetachCustomer(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/customers/:id/merge", h.MergeCustomer)
+	router.POST("/admin/customers/:id/contacts/:contact_id/detach", h.DetachCustomerContact)
+
+	first := models.ContactForm{Name: "Иван", Phone: "+79211234567"}
+	second := models.ContactForm{Name: "Иван Петров", Phone: "+79031234567", Email: "ivan@example.com"}
+	h.db.Create(&first)
+	h.db.Create(&second)
+	h.BackfillCustomers()
+	target, source := customerOf(t, h, first.ID), customerOf(t, h, second.ID)
+	assert.NotEqual(t, target, source)
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	merge := fmt.Sprintf("/admin/customers/%d/merge", target)
+	assert.Equal(t, http.StatusBadRequest, post(merge, mergeBody(target)).Code, "сам с собой")
+	assert.Equal(t, http.StatusNotFound, post(merge, mergeBody(source+100)).Code)
+	assert.Equal(t, http.StatusOK, post(merge, mergeBody(source)).Code)
+
+	assert.Equal(t, target, customerOf(t, h, second.ID))
+	var count int64
+	h.db.Model(&models.Customer{}).Where("id = ?", source).Count(&count)
+	assert.Zero(t, count, "объединённый клиент удалён")
+	var customer models.Customer
+	h.db.First(&customer, target)
+	assert.Equal(t, "ivan@example.com", customer.Email, "недостающий email перенесён")
+
+	// Новая заявка со вторым телефоном находит объединённого клиента
+	third := models.ContactForm{Name: "Иван", Phone: "+79031234567"}
+	assert.NoError(t, h.linkCustomer(h.db, &third))
+	assert.Equal(t, target, *third.CustomerID)
+
+	detach := fmt.Sprintf("/admin/customers/%d/contacts/%d/detach", target, second.ID)
+	w := post(detach, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, target, customerOf(t, h, second.ID))
+	assert.Equal(t, target, customerOf(t, h, first.ID))
+
+	assert.Equal(t, http.StatusNotFound, post(detach, nil).Code, "заявка уже у другого клиента")
+	last := fmt.Sprintf("/admin/customers/%d/contacts/%d/detach", target, first.ID)
+	assert.Equal(t, http.StatusConflict, post(last, nil).Code, "единственную заявку не отделить")
+}
+
+// mergeBody - тело запроса объединения
+func mergeBody(sourceID uint) map[string]uint {
+	return map[string]uint{"source_id": sourceID}
+}
+
+func TestAdminCustomerPage_Timeline(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.SetHTMLTemplate(template.Must(template.New("admin_base.html").Parse(
+		`{{.PageID}}|{{len .contacts}}|{{range .timeline}}{{if .Note}}note:{{.Note.Text}}{{else}}contact:{{.Contact.ID}}{{end}};{{end}}`,
+	)))
+	router.GET("/admin/customers/:id", h.AdminCustomerPage)
+
+	now := time.Now()
+	first := models.ContactForm{Name: "Иван", Phone: "+79211234567", CreatedAt: now.Add(-3 * time.Hour)}
+	second := models.ContactForm{Name: "Иван", Phone: "+79211234567", CreatedAt: now.Add(-time.Hour)}
+	h.db.Create(&first)
+	h.db.Create(&second)
+	h.db.Create(&models.ContactNote{ContactID: first.ID, Text: "перезвонить", CreatedAt: now.Add(-2 * time.Hour)})
+	h.BackfillCustomers()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/admin/customers/%d", customerOf(t, h, first.ID)), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, fmt.Sprintf("admin-customer|2|contact:%d;note:перезвонить;contact:%d;", second.ID, first.ID), w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/customers/999", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -11,6 +11,7 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"path/filepath"
 	"strconv"
@@ -22,6 +23,7 @@ import (
 	"gorm.io/gorm/clause"
 
 	"ledsite/internal/models"
+	"ledsite/internal/notify"
 )
 
 // Handlers содержит зависимости для HTTP обработчиков.
@@ -30,6 +32,9 @@ type Handlers struct {
 	db            *gorm.DB // Подключение к PostgreSQL через GORM
 	maxUploadSize int64    // Максимальный размер загружаемого файла в байтах
 	uploadPath    string   // Путь для сохранения загружаемых файлов
+
+	outbox  *notify.Outbox // Доставка уведомлений о заявках (nil - отключена), см. SetOutbox
+	siteURL string         // Адрес сайта для ссылок на админку в уведомлениях
 }
 
 // New создает новый экземпляр Handlers с внедренной зависимостью базы данных.
@@ -126,7 +131,7 @@ func (h *Handlers) ProjectsPage(c *gin.Context) {
 	query.Order("sort_order ASC, created_at DESC").Find(&projects)
 
 	var categories []models.Category
-	h.db.Find(&categories)
+	h.db.Order("sort_order ASC, id ASC").Find(&categories)
 
 	settings := getSettings(h.db)
 	c.HTML(http.StatusOK, "public_base.html", gin.H{
@@ -147,13 +152,104 @@ func (h *Handlers) ProjectsPage(c *gin.Context) {
 	})
 }
 
+// ProjectDetailPage рендерит страницу отдельного проекта портфолио.
+//
+// Отображает:
+//   - Галерею изображений (главное изображение первым, затем по sort_order)
+//   - Категории, размер, шаг пикселя и локацию
+//   - До 3 похожих проектов из тех же категорий
+//
+// OpenGraph изображение строится из medium thumbnail главного изображения.
+// Каждое открытие страницы засчитывается в project_view_dailies
+// тем же UPSERT, что и /api/track/project-view/:id.
+//
+// Для неизвестного slug возвращается 404 со страницей "not-found".
+//
+// GET /projects/:slug
+func (h *Handlers) ProjectDetailPage(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var project models.Project
+	if err := h.db.Where("slug = ?", slug).
+		Preload("Categories").
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Order("is_primary DESC, sort_order ASC, id ASC")
+		}).
+		First(&project).Error; err != nil {
+		h.renderNotFound(c)
+		return
+	}
+
+	// Похожие проекты: пересечение по категориям, без текущего проекта
+	var related []models.Project
+	if len(project.Categories) > 0 {
+		categoryIDs := make([]uint, 0, len(project.Categories))
+		for _, cat := range project.Categories {
+			categoryIDs = append(categoryIDs, cat.ID)
+		}
+		h.db.Where("id <> ?", project.ID).
+			Where("id IN (SELECT project_id FROM project_categories WHERE category_id IN ?)", categoryIDs).
+			Preload("Images").
+			Order("sort_order ASC, created_at DESC").
+			Limit(3).
+			Find(&related)
+	}
+
+	if err := h.recordProjectView(project.ID); err != nil {
+		log.Printf("Ошибка учёта просмотра проекта ID=%d: %v", project.ID, err)
+	}
+
+	description := project.Description
+	if description == "" {
+		description = "Проект LED экрана от S'n'R"
+		if project.Location != "" {
+			description += ": " + project.Location
+		}
+	}
+
+	ogImage := "https://s-n-r.ru/static/images/og-preview.png"
+	if len(project.Images) > 0 {
+		ogImage = getBaseURL(c) + projectImageURL(project.Images[0], "medium")
+	}
+
+	settings := getSettings(h.db)
+	c.HTML(http.StatusOK, "public_base.html", gin.H{
+		"title":            project.Title + " | Портфолио S'n'R",
+		"description":      description,
+		"ogTitle":          project.Title + " | S'n'R",
+		"ogDescription":    description,
+		"ogUrl":            "/projects/" + project.Slug,
+		"ogImage":          ogImage,
+		"ogType":           "article",
+		"project":          project,
+		"relatedProjects":  related,
+		"PageID":           "project-detail",
+		"sitePhone":        settings.Phone,
+		"sitePhoneDisplay": settings.PhoneDisplay,
+		"siteEmail":        settings.Email,
+	})
+}
+
+// renderNotFound рендерит публичную страницу 404.
+func (h *Handlers) renderNotFound(c *gin.Context) {
+	settings := getSettings(h.db)
+	c.HTML(http.StatusNotFound, "public_base.html", gin.H{
+		"title":            "Страница не найдена | S'n'R",
+		"description":      "Запрошенная страница не найдена.",
+		"PageID":           "not-found",
+		"sitePhone":        settings.Phone,
+		"sitePhoneDisplay": settings.PhoneDisplay,
+		"siteEmail":        settings.Email,
+	})
+}
+
 // ServicesPage рендерит страницу со списком всех услуг компании.
 // Услуги отсортированы по полю sort_order.
 //
 // GET /services
 func (h *Handlers) ServicesPage(c *gin.Context) {
 	var services []models.Service
-	h.db.Order("sort_order").Find(&services)
+	h.db.Order("sort_order ASC, id ASC").Find(&services)
 
 	settings := getSettings(h.db)
 	c.HTML(http.StatusOK, "public_base.html", gin.H{
@@ -170,6 +266,54 @@ func (h *Handlers) ServicesPage(c *gin.Context) {
 	})
 }
 
+// ServiceDetailPage рендерит страницу отдельной услуги.
+//
+// Включает описание услуги, иконку (если загружена) и список
+// остальных услуг для перелинковки.
+// Для неизвестного slug отдаёт 404.
+//
+// GET /services/:slug
+func (h *Handlers) ServiceDetailPage(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var service models.Service
+	if err := h.db.Where("slug = ?", slug).First(&service).Error; err != nil {
+		h.renderNotFound(c)
+		return
+	}
+
+	var otherServices []models.Service
+	h.db.Where("id <> ?", service.ID).Order("sort_order ASC, id ASC").Find(&otherServices)
+
+	description := service.ShortDesc
+	if description == "" {
+		description = "Услуги по LED экранам компании S'n'R."
+	}
+
+	ogImage := "https://s-n-r.ru/static/images/og-preview.png"
+	if service.ThumbnailMediumPath != "" {
+		ogImage = getBaseURL(c) + service.ThumbnailMediumPath
+	} else if service.IconPath != "" {
+		ogImage = getBaseURL(c) + service.IconPath
+	}
+
+	settings := getSettings(h.db)
+	c.HTML(http.StatusOK, "public_base.html", gin.H{
+		"title":            service.Name + " | Услуги S'n'R",
+		"description":      description,
+		"ogTitle":          service.Name + " | S'n'R",
+		"ogDescription":    description,
+		"ogUrl":            "/services/" + service.Slug,
+		"ogImage":          ogImage,
+		"service":          service,
+		"otherServices":    otherServices,
+		"PageID":           "service-detail",
+		"sitePhone":        settings.Phone,
+		"sitePhoneDisplay": settings.PhoneDisplay,
+		"siteEmail":        settings.Email,
+	})
+}
+
 // LEDGuidePage рендерит информационную страницу о LED экранах.
 //
 // GET /led-screens-guide
@@ -216,6 +360,7 @@ func (h *Handlers) ContactPage(c *gin.Context) {
 		"siteWorkHoursNote": settings.WorkHoursNote,
 		"siteStatsProjects": settings.StatsProjects,
 		"siteStatsYears":    settings.StatsYears,
+		"formToken":         ContactFormToken(time.Now()),
 	})
 }
 
@@ -285,26 +430,41 @@ func (h *Handlers) GetProjects(c *gin.Context) {
 	})
 }
 
+// contactFormRequest - поля публичной формы заявки. Служебные поля ContactForm
+// (id, customer_id, assigned_admin_id, status, даты) задаёт только сервер.
+type contactFormRequest struct {
+	Name        string `json:"name" form:"name"`
+	Phone       string `json:"phone" form:"phone"`
+	Email       string `json:"email" form:"email"`
+	Company     string `json:"company" form:"company"`
+	ProjectType string `json:"project_type" form:"project_type"`
+	Message     string `json:"message" form:"message"`
+	Source      string `json:"source" form:"source"`
+	Website     string `json:"website" form:"website"` // Honeypot поле
+	FormToken   string `json:"form_token" form:"form_token"`
+}
+
 // SubmitContact обрабатывает отправку формы обратной связи от клиентов.
 //
 // Принимает данные в формате JSON или form-data:
 //   - name (required): имя клиента
-//   - phone (required): телефон
+//   - phone (required): российский телефон, сохраняется как +7XXXXXXXXXX
 //   - email (optional): email
 //   - company (optional): название компании
 //   - project_type (optional): тип проекта
 //   - message (optional): сообщение
+//   - form_token: токен времени заполнения, выдаётся со страницей (см. ContactFormToken)
 //
-// Статус заявки по умолчанию устанавливается в "new".
+// Статус заявки - "new", подозрительные заявки сохраняются со статусом "spam" (см. contact_spam.go).
 //
 // POST /api/contact
 func (h *Handlers) SubmitContact(c *gin.Context) {
-	var form models.ContactForm
+	var req contactFormRequest
 
 	// Проверяем Content-Type и парсим данные соответственно
 	contentType := c.GetHeader("Content-Type")
 	if strings.Contains(contentType, "application/json") {
-		if err := c.ShouldBindJSON(&form); err != nil {
+		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Неверные данные формы",
 			})
@@ -312,13 +472,24 @@ func (h *Handlers) SubmitContact(c *gin.Context) {
 		}
 	} else {
 		// Парсим данные формы
-		if err := c.ShouldBind(&form); err != nil {
+		if err := c.ShouldBind(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Неверные данные формы",
 			})
 			return
 		}
 	}
+	form := models.ContactForm{
+		Name:        req.Name,
+		Phone:       req.Phone,
+		Email:       req.Email,
+		Company:     req.Company,
+		ProjectType: req.ProjectType,
+		Message:     req.Message,
+		Source:      req.Source,
+		Website:     req.Website,
+		FormToken:   req.FormToken,
+	}
 
 	// Honeypot защита от спама: если скрытое поле заполнено - это бот
 	if form.Website != "" {
@@ -336,20 +507,83 @@ func (h *Handlers) SubmitContact(c *gin.Context) {
 		return
 	}
 
-	// Сохраняем в базу
-	if err := h.db.Create(&form).Error; err != nil {
+	// Антиспам (см. contact_spam.go)
+	phone, ok := normalizePhone(form.Phone)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Укажите корректный российский номер телефона",
+		})
+		return
+	}
+	form.Phone = phone
+
+	now := time.Now()
+	form.CreatedAt = now // время для лимитов и истории этапов задаёт только сервер
+	form.IP = c.ClientIP()
+	if h.contactRateLimited(form.IP, now) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Слишком много заявок. Попробуйте позже или позвоните нам.",
+		})
+		return
+	}
+	successMsg := gin.H{
+		"message": "Заявка успешно отправлена! Мы свяжемся с вами в ближайшее время.",
+	}
+	if h.isDuplicateContact(form.Phone, now) {
+		// Повторное нажатие «Отправить»: заявка уже есть, уведомления уже отправлены
+		c.JSON(http.StatusOK, successMsg)
+		return
+	}
+
+	form.Status = "new"
+	form.StageChangedAt = &now
+	if form.SpamReason = h.contactSpamReason(&form, now); form.SpamReason != "" {
+		form.Status = "spam"
+		log.Printf("Заявка с IP %s отмечена как спам: %s", form.IP, form.SpamReason)
+	}
+
+	// Сохраняем заявку и уведомления о ней одной транзакцией: уведомление не теряется,
+	// даже если Telegram бот или SMTP сейчас недоступны (доставляет воркер outbox).
+	// По спаму уведомления не отправляются.
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.linkCustomer(tx, &form); err != nil {
+			return err
+		}
+		if form.Status != "spam" {
+			if err := autoAssign(tx, &form); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&form).Error; err != nil {
+			return err
+		}
+		if err := recordInitialStage(tx, &form); err != nil {
+			return err
+		}
+		if attr, ok := contactAttribution(c.Request, now.UTC()); ok {
+			// Атрибуция не должна мешать сохранить заявку: вставка во вложенной транзакции
+			// (savepoint), ошибка только логируется
+			attr.ContactID = form.ID
+			if err := tx.Transaction(func(tx *gorm.DB) error { return tx.Create(&attr).Error }); err != nil {
+				log.Printf("Ошибка сохранения атрибуции заявки ID=%d: %v", form.ID, err)
+			}
+		}
+		if form.Status == "spam" {
+			return nil
+		}
+		if err := h.enqueueContactEvent(tx, notify.EventNewContact, &form); err != nil {
+			return err
+		}
+		return h.enqueueCustomerAck(tx, &form)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Ошибка сохранения заявки",
 		})
 		return
 	}
+	h.wakeOutbox()
 
-	// Отправляем уведомление в Telegram (асинхронно, не блокируем ответ пользователю)
-	SendTelegramNotificationAsync(&form)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Заявка успешно отправлена! Мы свяжемся с вами в ближайшее время.",
-	})
+	c.JSON(http.StatusOK, successMsg)
 }
 
 // TrackProjectView увеличивает счетчик просмотров проекта за текущий день (по МСК).
@@ -381,19 +615,30 @@ func (h *Handlers) TrackProjectView(c *gin.Context) {
 		return
 	}
 
+	if err := h.recordProjectView(uint(pid)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db upsert error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// recordProjectView засчитывает один просмотр проекта за текущий день (по МСК).
+//
+// UPSERT: если запись (project_id, day) существует - инкрементируем views,
+// иначе создаем новую запись с views=1.
+func (h *Handlers) recordProjectView(projectID uint) error {
 	// Получаем текущую дату по Московскому времени, обнуляем время до полуночи
 	now := NowMSK()
 	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, moscowLoc)
 
 	rec := models.ProjectViewDaily{
-		ProjectID: uint(pid),
+		ProjectID: projectID,
 		Day:       day,
 		Views:     1,
 	}
 
-	// UPSERT: если запись (project_id, day) существует - инкрементируем views,
-	// иначе создаем новую запись с views=1
-	if err := h.db.Clauses(clause.OnConflict{
+	return h.db.Clauses(clause.OnConflict{
 		Columns: []clause.Column{
 			{Name: "project_id"},
 			{Name: "day"},
@@ -401,12 +646,7 @@ func (h *Handlers) TrackProjectView(c *gin.Context) {
 		DoUpdates: clause.Assignments(map[string]any{
 			"views": gorm.Expr(`"project_view_dailies"."views" + EXCLUDED."views"`),
 		}),
-	}).Create(&rec).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db upsert error"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"ok": true})
+	}).Create(&rec).Error
 }
 
 // TrackPriceView увеличивает счетчик просмотров позиции прайса за текущий день (по МСК).
@@ -518,6 +758,29 @@ func generateSlug(title string) string {
 	return fmt.Sprintf("%s-%d", slug, time.Now().Unix())
 }
 
+// projectImageURL возвращает веб-путь к изображению проекта нужного размера.
+//
+// size: "small" (карточки), "medium" (галерея), иначе - оригинал.
+// Повторяет логику template-функции getThumbnail: если thumbnail
+// не сгенерирован - используется оригинальный файл.
+func projectImageURL(img models.Image, size string) string {
+	var thumbPath string
+	switch size {
+	case "small":
+		thumbPath = img.ThumbnailSmallPath
+	case "medium":
+		thumbPath = img.ThumbnailMediumPath
+	}
+	if thumbPath == "" {
+		return "/static/uploads/" + img.Filename
+	}
+	// Извлекаем только имя файла из полного пути (Unix или Windows разделители)
+	if i := strings.LastIndexAny(thumbPath, `/\`); i >= 0 {
+		thumbPath = thumbPath[i+1:]
+	}
+	return "/static/uploads/" + thumbPath
+}
+
 // isImageFile проверяет, является ли файл допустимым форматом изображения.
 //
 // Поддерживаемые форматы: .jpg, .jpeg, .jfif, .png, .gif, .webp
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -30,8 +31,19 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.Service{},
 		&models.ContactForm{},
 		&models.ContactNote{},
+		&models.Customer{},
+		&models.PipelineStage{},
+		&models.ContactStageChange{},
+		&models.ContactQuote{},
+		&models.ContactAttribution{},
+		&models.ContactView{},
+		&models.NotificationDelivery{},
 		&models.ProjectViewDaily{},
 		&models.Admin{},
+		&models.AdminSession{},
+		&models.AdminRecoveryCode{},
+		&models.LoginAttempt{},
+		&models.AuditLog{},
 		&models.MapPoint{},
 		&models.PriceItem{},
 		&models.PriceImage{},
@@ -45,6 +57,11 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
+	for _, stage := range models.DefaultPipelineStages() {
+		if err := db.Create(&stage).Error; err != nil {
+			t.Fatalf("Failed to seed pipeline stages: %v", err)
+		}
+	}
 
 	return db
 }
@@ -248,11 +265,12 @@ func TestSubmitContact_Success(t *testing.T) {
 	router.POST("/api/contact", h.SubmitContact)
 
 	contactData := map[string]string{
-		"name":    "Иван Иванов",
-		"phone":   "+79211234567",
-		"email":   "ivan@example.com",
-		"company": "ООО Тест",
-		"message": "Хочу заказать LED экран",
+		"name":       "Иван Иванов",
+		"phone":      "+79211234567",
+		"email":      "ivan@example.com",
+		"company":    "ООО Тест",
+		"message":    "Хочу заказать LED экран",
+		"form_token": filledFormToken(),
 	}
 
 	jsonData, _ := json.Marshal(contactData)
@@ -330,6 +348,86 @@ func TestTrackProjectView(t *testing.T) {
 	assert.Equal(t, int64(1), viewRecord.Views)
 }
 
+// setupProjectDetailRouter подключает ProjectDetailPage с упрощённым шаблоном
+func setupProjectDetailRouter(t *testing.T) (*gin.Engine, *Handlers) {
+	router, h := setupTestRouter(t)
+	router.SetHTMLTemplate(template.Must(template.New("public_base.html").Parse(
+		`{{.PageID}}|{{.title}}|{{.ogType}}|{{.ogImage}}|{{range .relatedProjects}}[{{.Slug}}]{{end}}`,
+	)))
+	router.GET("/projects/:slug", h.ProjectDetailPage)
+	return router, h
+}
+
+// TestProjectDetailPage_Success проверяет рендер страницы проекта и учёт просмотра
+func TestProjectDetailPage_Success(t *testing.T) {
+	router, h := setupProjectDetailRouter(t)
+
+	project := models.Project{Title: "Экран в ТЦ", Slug: "ekran-v-tc", Size: "6x3 м"}
+	h.db.Create(&project)
+	h.db.Create(&models.Image{ProjectID: &project.ID, Filename: "a.jpg", FilePath: "uploads/a.jpg", SortOrder: 1})
+	h.db.Create(&models.Image{
+		ProjectID: &project.ID, Filename: "b.jpg", FilePath: "uploads/b.jpg", SortOrder: 2,
+		IsPrimary: true, ThumbnailMediumPath: "uploads/b_medium.jpg",
+	})
+
+	req, _ := http.NewRequest("GET", "/projects/ekran-v-tc", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "project-detail|Экран в ТЦ")
+	assert.Contains(t, body, "|article|")
+	// OG-картинка берётся из миниатюры главного изображения
+	assert.Contains(t, body, "/static/uploads/b_medium.jpg")
+
+	var views int64
+	h.db.Model(&models.ProjectViewDaily{}).Where("project_id = ?", project.ID).Select("COALESCE(SUM(views), 0)").Scan(&views)
+	assert.Equal(t, int64(1), views)
+}
+
+// TestProjectDetailPage_NotFound проверяет 404 для неизвестного slug
+func TestProjectDetailPage_NotFound(t *testing.T) {
+	router, _ := setupProjectDetailRouter(t)
+
+	req, _ := http.NewRequest("GET", "/projects/unknown", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "not-found|")
+}
+
+// TestProjectDetailPage_RelatedProjects проверяет подбор похожих проектов по общим категориям
+func TestProjectDetailPage_RelatedProjects(t *testing.T) {
+	router, h := setupProjectDetailRouter(t)
+
+	indoor := models.Category{Name: "Интерьерные", Slug: "indoor"}
+	outdoor := models.Category{Name: "Уличные", Slug: "outdoor"}
+	h.db.Create(&indoor)
+	h.db.Create(&outdoor)
+
+	main := models.Project{Title: "Основной", Slug: "main"}
+	sibling := models.Project{Title: "Похожий", Slug: "sibling"}
+	other := models.Project{Title: "Другой", Slug: "other"}
+	h.db.Create(&main)
+	h.db.Create(&sibling)
+	h.db.Create(&other)
+	h.db.Model(&main).Association("Categories").Append(&indoor)
+	h.db.Model(&sibling).Association("Categories").Append(&indoor)
+	h.db.Model(&other).Association("Categories").Append(&outdoor)
+
+	req, _ := http.NewRequest("GET", "/projects/main", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "[sibling]")
+	assert.NotContains(t, body, "[other]")
+	assert.NotContains(t, body, "[main]")
+}
+
 // TestTrackProjectView_InvalidID проверяет обработку невалидного ID
 func TestTrackProjectView_InvalidID(t *testing.T) {
 	router, h := setupTestRouter(t)
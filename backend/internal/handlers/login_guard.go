@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ledsite/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Защита входа от перебора паролей.
+// Состояние хранится в журнале login_attempts, поэтому переживает перезапуск сервера.
+const (
+	loginFailureWindow     = 15 * time.Minute    // окно подсчёта неудачных попыток
+	loginFreeAttempts      = 3                   // неудач по логину без задержки
+	loginIPFreeAttempts    = 10                  // неудач с одного IP без задержки (офис за NAT)
+	loginBackoffBase       = time.Second         // первая задержка, дальше удваивается
+	loginBackoffMax        = 5 * time.Minute     // максимальная задержка между попытками
+	loginLockoutThreshold  = 5                   // неудач подряд до блокировки логина
+	loginLockoutDuration   = 15 * time.Minute    // длительность блокировки
+	loginAlertWindow       = time.Hour           // окно подсчёта блокировок для алерта
+	loginAlertLockouts     = 3                   // блокировок за окно для Telegram-алерта
+	loginAlertCooldown     = 30 * time.Minute    // не чаще одного алерта за период
+	loginAttemptsRetention = 90 * 24 * time.Hour // срок хранения журнала попыток
+)
+
+// Результаты попытки входа (models.LoginAttempt.Result)
+const (
+	LoginResultSuccess     = "success"
+	LoginResultBadPassword = "bad_password"
+	LoginResultUnknownUser = "unknown_user"
+	LoginResultInactive    = "inactive"
+	LoginResultBad2FA      = "bad_2fa"   // пароль верный, код 2FA - нет
+	LoginResultThrottled   = "throttled" // отклонена до проверки пароля: действует задержка
+	LoginResultLocked      = "locked"    // отклонена до проверки пароля: логин заблокирован
+)
+
+// loginFailureResults - результаты, которые считаются неудачной попыткой.
+// Отклонённые без проверки пароля (throttled, locked) не учитываются, иначе
+// повторные нажатия «Войти» бесконечно продлевали бы задержку.
+var loginFailureResults = []string{LoginResultBadPassword, LoginResultUnknownUser, LoginResultInactive, LoginResultBad2FA}
+
+// loginResultTitles - подписи результатов для журнала на dashboard
+var loginResultTitles = map[string]string{
+	LoginResultSuccess:     "Успешный вход",
+	LoginResultBadPassword: "Неверный пароль",
+	LoginResultUnknownUser: "Неизвестный логин",
+	LoginResultInactive:    "Аккаунт деактивирован",
+	LoginResultThrottled:   "Слишком частые попытки",
+	LoginResultLocked:      "Логин заблокирован",
+	LoginResultBad2FA:      "Неверный код 2FA",
+}
+
+// sendSecurityAlert отправляет алерт в Telegram (подменяется в тестах)
+var sendSecurityAlert = SendTelegramAlertAsync
+
+// lockoutAlertState - время последнего алерта о всплеске блокировок
+var lockoutAlertState struct {
+	sync.Mutex
+	last time.Time
+}
+
+// loginRetryAfter проверяет, можно ли сейчас проверять пароль для username с адреса ip.
+// Возвращает оставшееся время ожидания и признак блокировки логина.
+func (h *Handlers) loginRetryAfter(username, ip string, now time.Time) (time.Duration, bool) {
+	userFails, userLast := h.loginFailures("username = ?", username, h.loginWindowStart(username, now))
+	ipFails, ipLast := h.loginFailures("ip = ?", ip, now.Add(-loginFailureWindow))
+
+	if userFails >= loginLockoutThreshold {
+		if until := userLast.Add(loginLockoutDuration); until.After(now) {
+			return until.Sub(now), true
+		}
+	}
+
+	wait := userLast.Add(loginBackoff(userFails, loginFreeAttempts)).Sub(now)
+	if ipWait := ipLast.Add(loginBackoff(ipFails, loginIPFreeAttempts)).Sub(now); ipWait > wait {
+		wait = ipWait
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, false
+}
+
+// loginFailures возвращает число неудачных попыток по условию и время последней из них
+func (h *Handlers) loginFailures(cond, value string, since time.Time) (int64, time.Time) {
+	scope := func() *gorm.DB {
+		return h.db.Model(&models.LoginAttempt{}).
+			Where(cond, value).
+			Where("result IN ? AND created_at > ?", loginFailureResults, since)
+	}
+
+	var count int64
+	scope().Count(&count)
+	if count == 0 {
+		return 0, time.Time{}
+	}
+
+	var last models.LoginAttempt
+	scope().Order("created_at DESC").First(&last)
+	return count, last.CreatedAt
+}
+
+// isLoginFailure - результат считается неудачной попыткой (см. loginFailureResults)
+func isLoginFailure(result string) bool {
+	for _, r := range loginFailureResults {
+		if r == result {
+			return true
+		}
+	}
+	return false
+}
+
+// loginBackoff - экспоненциальная задержка после fails неудач: первые free без задержки,
+// затем 1с, 2с, 4с ... но не более loginBackoffMax
+func loginBackoff(fails int64, free int64) time.Duration {
+	if fails < free {
+		return 0
+	}
+	shift := fails - free
+	if shift > 16 {
+		return loginBackoffMax
+	}
+	if d := loginBackoffBase << uint(shift); d < loginBackoffMax {
+		return d
+	}
+	return loginBackoffMax
+}
+
+// recordLoginAttempt пишет попытку в журнал. Если неудача привела к блокировке логина,
+// попытка помечается Lockout и проверяется всплеск блокировок для алерта.
+func (h *Handlers) recordLoginAttempt(username, ip, userAgent, result string) {
+	now := time.Now()
+	attempt := models.LoginAttempt{
+		Username:  truncateString(username, 50),
+		IP:        ip,
+		UserAgent: truncateString(userAgent, 255),
+		Result:    result,
+		CreatedAt: now,
+	}
+
+	if isLoginFailure(result) {
+		// Эта неудача - N-я подряд: логин блокируется
+		fails, _ := h.loginFailures("username = ?", attempt.Username, h.loginWindowStart(attempt.Username, now))
+		attempt.Lockout = fails+1 == loginLockoutThreshold
+	}
+
+	if err := h.db.Create(&attempt).Error; err != nil {
+		log.Printf("Ошибка записи попытки входа: %v", err)
+		return
+	}
+
+	if attempt.Lockout {
+		log.Printf("Логин '%s' заблокирован на %v после %d неудачных попыток (IP %s)",
+			attempt.Username, loginLockoutDuration, loginLockoutThreshold, ip)
+		h.checkLockoutSpike(now)
+	}
+	if result == LoginResultSuccess {
+		h.db.Where("created_at < ?", now.Add(-loginAttemptsRetention)).Delete(&models.LoginAttempt{})
+	}
+}
+
+// loginWindowStart - начало окна подсчёта неудач по логину:
+// последний успешный вход, но не раньше now - loginFailureWindow
+func (h *Handlers) loginWindowStart(username string, now time.Time) time.Time {
+	since := now.Add(-loginFailureWindow)
+	var last models.LoginAttempt
+	res := h.db.Where("username = ? AND result = ?", username, LoginResultSuccess).
+		Order("created_at DESC").Limit(1).Find(&last)
+	if res.Error == nil && res.RowsAffected > 0 && last.CreatedAt.After(since) {
+		return last.CreatedAt
+	}
+	return since
+}
+
+// checkLockoutSpike отправляет Telegram-алерт, если за loginAlertWindow набралось
+// loginAlertLockouts блокировок (не чаще раза в loginAlertCooldown)
+func (h *Handlers) checkLockoutSpike(now time.Time) {
+	var lockouts int64
+	h.db.Model(&models.LoginAttempt{}).
+		Where("lockout = ? AND created_at > ?", true, now.Add(-loginAlertWindow)).
+		Count(&lockouts)
+	if lockouts < loginAlertLockouts {
+		return
+	}
+
+	lockoutAlertState.Lock()
+	if !lockoutAlertState.last.IsZero() && now.Sub(lockoutAlertState.last) < loginAlertCooldown {
+		lockoutAlertState.Unlock()
+		return
+	}
+	lockoutAlertState.last = now
+	lockoutAlertState.Unlock()
+
+	var ips []string
+	h.db.Model(&models.LoginAttempt{}).
+		Where("result IN ? AND created_at > ?", loginFailureResults, now.Add(-loginAlertWindow)).
+		Distinct("ip").Limit(5).Pluck("ip", &ips)
+
+	text := fmt.Sprintf("За последний час заблокировано логинов: %d.\nIP с неудачными попытками: %s\nЖурнал попыток - на главной странице админки.",
+		lockouts, strings.Join(ips, ", "))
+	sendSecurityAlert("Подбор паролей в админке", text)
+}
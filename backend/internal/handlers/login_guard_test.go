@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// postLoginFrom отправляет форму входа с указанным X-Forwarded-For
+func postLoginFrom(router *gin.Engine, username, password, forwardedFor string) *httptest.ResponseRecorder {
+	form := url.Values{"username": {username}, "password": {password}}
+	req, _ := http.NewRequest("POST", "/admin/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	req.RemoteAddr = "127.0.0.1:40000" // запрос пришёл через nginx
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// addLoginAttempts добавляет в журнал n попыток с заданным результатом и временем
+func addLoginAttempts(t *testing.T, h *Handlers, n int, username, ip, result string, at time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		assert.NoError(t, h.db.Create(&models.LoginAttempt{
+			Username: username, IP: ip, Result: result, CreatedAt: at,
+		}).Error)
+	}
+}
+
+// ---------- loginBackoff ----------
+
+func TestLoginBackoff(t *testing.T) {
+	assert.Equal(t, time.Duration(0), loginBackoff(0, 3))
+	assert.Equal(t, time.Duration(0), loginBackoff(2, 3))
+	assert.Equal(t, time.Second, loginBackoff(3, 3))
+	assert.Equal(t, 2*time.Second, loginBackoff(4, 3))
+	assert.Equal(t, 8*time.Second, loginBackoff(6, 3))
+	assert.Equal(t, loginBackoffMax, loginBackoff(100, 3))
+}
+
+// ---------- Login: журнал и ограничения ----------
+
+func TestLogin_RecordsAttempts(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	createTestAdmin(t, h, "admin", "secret123", true)
+
+	postLoginFrom(router, "admin", "wrong", "198.51.100.10")
+	postLoginFrom(router, "ghost", "wrong", "198.51.100.10")
+	postLoginFrom(router, "admin", "secret123", "198.51.100.10")
+
+	var attempts []models.LoginAttempt
+	h.db.Order("id ASC").Find(&attempts)
+	assert.Len(t, attempts, 3)
+	assert.Equal(t, LoginResultBadPassword, attempts[0].Result)
+	assert.Equal(t, LoginResultUnknownUser, attempts[1].Result)
+	assert.Equal(t, LoginResultSuccess, attempts[2].Result)
+	assert.Equal(t, "198.51.100.10", attempts[0].IP, "IP берётся из X-Forwarded-For доверенного прокси")
+}
+
+func TestLogin_Backoff(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	createTestAdmin(t, h, "admin", "secret123", true)
+
+	// 3 неудачи только что - следующая попытка не раньше чем через секунду
+	addLoginAttempts(t, h, loginFreeAttempts, "admin", "198.51.100.10", LoginResultBadPassword, time.Now())
+
+	w := postLoginFrom(router, "admin", "secret123", "198.51.100.10")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "даже верный пароль не проверяется во время задержки")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var last models.LoginAttempt
+	h.db.Order("id DESC").First(&last)
+	assert.Equal(t, LoginResultThrottled, last.Result)
+}
+
+func TestLogin_Lockout(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	createTestAdmin(t, h, "admin", "secret123", true)
+
+	// Задержка после 4 неудач давно прошла, пятая неудача блокирует логин
+	addLoginAttempts(t, h, loginLockoutThreshold-1, "admin", "198.51.100.10", LoginResultBadPassword, time.Now().Add(-5*time.Minute))
+	w := postLoginFrom(router, "admin", "wrong", "198.51.100.11")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var lockout models.LoginAttempt
+	h.db.Order("id DESC").First(&lockout)
+	assert.True(t, lockout.Lockout)
+
+	// Блокировка действует для любого IP
+	w = postLoginFrom(router, "admin", "secret123", "203.0.113.50")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "заблокирован")
+
+	// После истечения блокировки вход разрешён
+	h.db.Model(&models.LoginAttempt{}).Where("1 = 1").Update("created_at", time.Now().Add(-loginLockoutDuration-time.Minute))
+	w = postLoginFrom(router, "admin", "secret123", "203.0.113.50")
+	assert.Equal(t, http.StatusFound, w.Code)
+}
+
+func TestLogin_IPBackoff(t *testing.T) {
+	router, h := setupAuthRouter(t)
+	router.POST("/admin/login", h.Login)
+	createTestAdmin(t, h, "admin", "secret123", true)
+
+	// Перебор разных логинов с одного IP
+	for i := 0; i < loginIPFreeAttempts; i++ {
+		addLoginAttempts(t, h, 1, "user"+string(rune('a'+i)), "203.0.113.7", LoginResultUnknownUser, time.Now())
+	}
+
+	w := postLoginFrom(router, "admin", "secret123", "203.0.113.7")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = postLoginFrom(router, "admin", "secret123", "198.51.100.10")
+	assert.Equal(t, http.StatusFound, w.Code, "другой IP не затронут")
+}
+
+func TestLogin_SuccessResetsUserFailures(t *testing.T) {
+	_, h := setupAuthRouter(t)
+	now := time.Now()
+
+	addLoginAttempts(t, h, 4, "admin", "198.51.100.10", LoginResultBadPassword, now.Add(-3*time.Minute))
+	addLoginAttempts(t, h, 1, "admin", "198.51.100.10", LoginResultSuccess, now.Add(-2*time.Minute))
+
+	wait, locked := h.loginRetryAfter("admin", "198.51.100.20", now)
+	assert.Zero(t, wait)
+	assert.False(t, locked)
+}
+
+// ---------- Алерт о всплеске блокировок ----------
+
+func TestCheckLockoutSpike_SendsAlertOnce(t *testing.T) {
+	_, h := setupAuthRouter(t)
+
+	var alerts []string
+	origSend := sendSecurityAlert
+	sendSecurityAlert = func(title, text string) { alerts = append(alerts, text) }
+	defer func() { sendSecurityAlert = origSend }()
+
+	lockoutAlertState.Lock()
+	lockoutAlertState.last = time.Time{}
+	lockoutAlertState.Unlock()
+
+	now := time.Now()
+	for i := 0; i < loginAlertLockouts-1; i++ {
+		h.db.Create(&models.LoginAttempt{Username: "admin", IP: "203.0.113.7", Result: LoginResultBadPassword, Lockout: true, CreatedAt: now})
+	}
+	h.checkLockoutSpike(now)
+	assert.Empty(t, alerts, "ниже порога алерт не отправляется")
+
+	h.db.Create(&models.LoginAttempt{Username: "manager", IP: "203.0.113.7", Result: LoginResultBadPassword, Lockout: true, CreatedAt: now})
+	h.checkLockoutSpike(now)
+	h.checkLockoutSpike(now.Add(time.Minute))
+
+	assert.Len(t, alerts, 1, "повторный алерт не раньше loginAlertCooldown")
+	assert.Contains(t, alerts[0], "203.0.113.7")
+}
+
+// ---------- Dashboard ----------
+
+func TestLoginAttemptsSummary(t *testing.T) {
+	_, h := setupAuthRouter(t)
+	now := time.Now()
+
+	addLoginAttempts(t, h, 2, "admin", "198.51.100.10", LoginResultSuccess, now.Add(-time.Hour))
+	addLoginAttempts(t, h, 3, "admin", "203.0.113.7", LoginResultBadPassword, now.Add(-time.Hour))
+	addLoginAttempts(t, h, 1, "admin", "203.0.113.7", LoginResultLocked, now.Add(-time.Hour))
+	addLoginAttempts(t, h, 5, "admin", "203.0.113.7", LoginResultBadPassword, now.Add(-48*time.Hour))
+
+	summary := h.loginAttemptsSummary(now)
+	assert.Equal(t, int64(2), summary["success"])
+	assert.Equal(t, int64(3), summary["failed"], "учитываются только последние 24 часа")
+	assert.Equal(t, int64(1), summary["rejected"])
+	assert.Len(t, summary["recent"], 9, "в журнале только неудачные попытки")
+}
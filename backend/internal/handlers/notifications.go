@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+)
+
+// Уведомления о новых заявках и наступивших напоминаниях идут через outbox (см. internal/notify).
+// Пока SetOutbox не вызван, заявки сохраняются без уведомлений.
+const (
+	reminderNotifyInterval = time.Minute
+	// Напоминания старше суток не отправляются (например, после простоя сервера)
+	reminderNotifyLookback = 24 * time.Hour
+)
+
+// deliveryChannelTitles, deliveryEventTitles и deliveryStatusTitles - подписи для модалки заявки
+var (
+	deliveryChannelTitles = map[string]string{
+		"telegram":       "Telegram",
+		"email":          "Email",
+		"webhook":        "Webhook",
+		"customer_email": "Email клиенту",
+	}
+	deliveryEventTitles = map[string]string{
+		string(notify.EventNewContact):  "новая заявка",
+		string(notify.EventReminder):    "напоминание",
+		string(notify.EventCustomerAck): "подтверждение клиенту",
+	}
+	deliveryStatusTitles = map[string]string{
+		models.DeliveryPending: "Ожидает отправки",
+		models.DeliverySent:    "Доставлено",
+		models.DeliveryDead:    "Не доставлено",
+	}
+)
+
+// SetOutbox подключает доставку уведомлений.
+// siteURL - адрес сайта для ссылок на заявку в админке.
+func (h *Handlers) SetOutbox(o *notify.Outbox, siteURL string) {
+	h.outbox = o
+	h.siteURL = strings.TrimRight(siteURL, "/")
+}
+
+// contactEvent собирает событие для outbox по заявке
+func (h *Handlers) contactEvent(kind notify.EventKind, contact *models.ContactForm) notify.Event {
+	ev := notify.Event{
+		Kind:    kind,
+		Contact: notify.ContactSnapshot(contact, translateProjectType(contact.ProjectType)),
+	}
+	if h.siteURL != "" {
+		ev.AdminURL = h.siteURL + "/admin/contacts?search=" + url.QueryEscape(contact.Phone)
+	}
+	return ev
+}
+
+// enqueueContactEvent ставит уведомление в outbox внутри транзакции tx (без outbox - ничего)
+func (h *Handlers) enqueueContactEvent(tx *gorm.DB, kind notify.EventKind, contact *models.ContactForm) error {
+	if h.outbox == nil {
+		return nil
+	}
+	ev := h.contactEvent(kind, contact)
+	ev.Contact.PreviousContacts = previousContacts(tx, contact)
+	ev.Contact.AssignedTo = assigneeName(tx, contact)
+	return h.outbox.EnqueueTx(tx, ev)
+}
+
+// wakeOutbox запускает доставку сразу после коммита
+func (h *Handlers) wakeOutbox() {
+	if h.outbox != nil {
+		h.outbox.Wake()
+	}
+}
+
+// StartReminderNotifications запускает фоновую постановку напоминаний в outbox (раз в минуту).
+// Возвращает функцию остановки.
+func (h *Handlers) StartReminderNotifications() func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(reminderNotifyInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				h.EnqueueDueReminders(now)
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// EnqueueDueReminders ставит в outbox напоминания, время которых наступило.
+// Возвращает количество напоминаний.
+//
+// Поставленное напоминание отмечается в reminder_emailed_at, а не сбросом remind_flag:
+// флаг сбрасывает Telegram бот после своего уведомления, и остальные каналы от него не зависят.
+// Снятое напоминание (remind_at = NULL) не отправляется.
+func (h *Handlers) EnqueueDueReminders(now time.Time) int {
+	if h.outbox == nil || !h.outbox.Supports(notify.EventReminder) {
+		return 0
+	}
+
+	var contacts []models.ContactForm
+	if err := h.db.
+		Where("remind_at IS NOT NULL AND remind_at <= ? AND remind_at > ?", now.UTC(), now.Add(-reminderNotifyLookback).UTC()).
+		Where("reminder_emailed_at IS NULL OR reminder_emailed_at < remind_at").
+		Order("remind_at ASC").
+		Find(&contacts).Error; err != nil {
+		log.Printf("Ошибка поиска наступивших напоминаний: %v", err)
+		return 0
+	}
+
+	queued := 0
+	for i := range contacts {
+		contact := &contacts[i]
+		marked := false
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			// Условие повторяется в UPDATE: напоминание ставится один раз, даже если проверок несколько
+			res := tx.Model(&models.ContactForm{}).
+				Where("id = ? AND (reminder_emailed_at IS NULL OR reminder_emailed_at < remind_at)", contact.ID).
+				Update("reminder_emailed_at", now.UTC())
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			marked = true
+			return h.enqueueContactEvent(tx, notify.EventReminder, contact)
+		})
+		if err != nil {
+			log.Printf("Ошибка постановки напоминания заявки ID=%d: %v", contact.ID, err)
+			continue
+		}
+		if marked {
+			queued++
+		}
+	}
+	if queued > 0 {
+		h.wakeOutbox()
+	}
+	return queued
+}
+
+// deliveryView - доставка уведомления с подписями для модалки заявки
+type deliveryView struct {
+	models.NotificationDelivery
+	ChannelTitle string `json:"channel_title"`
+	EventTitle   string `json:"event_title"`
+	StatusTitle  string `json:"status_title"`
+}
+
+// GetContactNotifications возвращает доставки уведомлений по заявке (новые сверху)
+//
+// GET /admin/contacts/:id/notifications
+func (h *Handlers) GetContactNotifications(c *gin.Context) {
+	contactID, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var deliveries []models.NotificationDelivery
+	if err := h.db.Where("contact_id = ?", contactID).
+		Order("created_at DESC, id DESC").
+		Find(&deliveries).Error; err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось получить уведомления")
+		return
+	}
+
+	views := make([]deliveryView, 0, len(deliveries))
+	for _, d := range deliveries {
+		views = append(views, deliveryView{
+			NotificationDelivery: d,
+			ChannelTitle:         deliveryChannelTitles[d.Channel],
+			EventTitle:           deliveryEventTitles[d.Event],
+			StatusTitle:          deliveryStatusTitles[d.Status],
+		})
+	}
+	jsonOK(c, gin.H{"deliveries": views})
+}
+
+// RetryContactNotification повторно ставит в очередь недоставленное (dead) уведомление
+//
+// POST /admin/contacts/:id/notifications/:delivery_id/retry
+func (h *Handlers) RetryContactNotification(c *gin.Context) {
+	contactID, ok := mustID(c)
+	if !ok {
+		return
+	}
+	deliveryID64, err := strconv.ParseUint(c.Param("delivery_id"), 10, 64)
+	if err != nil || deliveryID64 == 0 {
+		jsonErr(c, http.StatusBadRequest, "Некорректный id уведомления")
+		return
+	}
+	deliveryID := uint(deliveryID64)
+
+	if h.outbox == nil {
+		jsonErr(c, http.StatusServiceUnavailable, "Уведомления не настроены")
+		return
+	}
+
+	var delivery models.NotificationDelivery
+	res := h.db.Where("id = ? AND contact_id = ?", deliveryID, contactID).Limit(1).Find(&delivery)
+	if res.Error != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось получить уведомление")
+		return
+	}
+	if res.RowsAffected == 0 {
+		jsonErr(c, http.StatusNotFound, "Уведомление не найдено")
+		return
+	}
+
+	if err := h.outbox.Retry(deliveryID); err != nil {
+		if errors.Is(err, notify.ErrNotDead) {
+			jsonErr(c, http.StatusConflict, "Повторить можно только недоставленное уведомление")
+			return
+		}
+		jsonErr(c, http.StatusInternalServerError, "Не удалось повторить отправку")
+		return
+	}
+	jsonOK(c, gin.H{"message": "Уведомление поставлено в очередь", "id": deliveryID})
+}
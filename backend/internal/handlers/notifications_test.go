@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+	"ledsite/internal/notify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingNotifier - канал уведомлений в памяти; fail - канал недоступен
+type recordingNotifier struct {
+	name  string
+	kinds []notify.EventKind
+	fail  bool
+
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Channel() string { return n.name }
+
+func (n *recordingNotifier) Supports(kind notify.EventKind) bool {
+	for _, k := range n.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, ev notify.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.fail {
+		return errors.New("бот недоступен")
+	}
+	n.events = append(n.events, ev)
+	return nil
+}
+
+// withTestOutbox подключает к h outbox с каналами notifiers (воркер не запускается, см. ProcessDue)
+func withTestOutbox(h *Handlers, notifiers ...notify.Notifier) *notify.Outbox {
+	o := notify.NewOutbox(h.db, notify.OutboxConfig{MaxAttempts: 1}, notifiers...)
+	h.SetOutbox(o, "https://s-n-r.ru/")
+	return o
+}
+
+func TestSubmitContact_EnqueuesNotifications(t *testing.T) {
+	_, h := setupTestRouter(t)
+	tg := &recordingNotifier{name: "telegram", kinds: []notify.EventKind{notify.EventNewContact}, fail: true}
+	hook := &recordingNotifier{name: "webhook", kinds: []notify.EventKind{notify.EventNewContact, notify.EventReminder}}
+	o := withTestOutbox(h, tg, hook)
+
+	code := postContact(h, map[string]string{"name": "Иван", "phone": "+79211234567", "project_type": "outdoor"})
+	assert.Equal(t, http.StatusOK, code, "недоступный канал не мешает сохранить заявку")
+
+	assert.Equal(t, 1, o.ProcessDue(context.Background(), time.Now()))
+	if assert.Len(t, hook.events, 1) {
+		ev := hook.events[0]
+		assert.Equal(t, notify.EventNewContact, ev.Kind)
+		assert.Equal(t, "Наружные LED экраны", ev.Contact.ProjectTitle)
+		assert.Equal(t, "https://s-n-r.ru/admin/contacts?search=%2B79211234567", ev.AdminURL)
+	}
+
+	var deliveries []models.NotificationDelivery
+	h.db.Order("channel").Find(&deliveries)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, models.DeliveryDead, deliveries[0].Status, "telegram после исчерпания попыток")
+		assert.Equal(t, "бот недоступен", deliveries[0].LastError)
+		assert.Equal(t, models.DeliverySent, deliveries[1].Status)
+	}
+}
+
+func TestEnqueueDueReminders(t *testing.T) {
+	_, h := setupTestRouter(t)
+	hook := &recordingNotifier{name: "webhook", kinds: []notify.EventKind{notify.EventReminder}}
+	o := withTestOutbox(h, hook)
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { t := now.Add(d).UTC(); return &t }
+	due := models.ContactForm{Name: "Пора", Phone: "+79990000001", RemindAt: at(-time.Minute), RemindFlag: true}
+	// Telegram бот уже сбросил флаг - остальные каналы всё равно уведомляются
+	botSent := models.ContactForm{Name: "Бот отправил", Phone: "+79990000002", RemindAt: at(-2 * time.Minute)}
+	future := models.ContactForm{Name: "Позже", Phone: "+79990000003", RemindAt: at(time.Hour), RemindFlag: true}
+	stale := models.ContactForm{Name: "Давно", Phone: "+79990000004", RemindAt: at(-48 * time.Hour), RemindFlag: true}
+	cleared := models.ContactForm{Name: "Снято", Phone: "+79990000005"}
+	for _, c := range []*models.ContactForm{&due, &botSent, &future, &stale, &cleared} {
+		assert.NoError(t, h.db.Create(c).Error)
+	}
+
+	assert.Equal(t, 2, h.EnqueueDueReminders(now))
+	assert.Equal(t, 0, h.EnqueueDueReminders(now.Add(time.Minute)), "напоминание ставится один раз")
+
+	// Новое время напоминания - новое уведомление
+	h.db.Model(&due).Update("remind_at", at(30*time.Minute))
+	assert.Equal(t, 1, h.EnqueueDueReminders(now.Add(31*time.Minute)))
+
+	assert.Equal(t, 3, o.ProcessDue(context.Background(), time.Now()))
+	if assert.Len(t, hook.events, 3) {
+		assert.Equal(t, "Бот отправил", hook.events[0].Contact.Name, "напоминания по порядку времени")
+		assert.Equal(t, "Пора", hook.events[1].Contact.Name)
+		assert.Equal(t, "Пора", hook.events[2].Contact.Name)
+	}
+
+	var reloaded models.ContactForm
+	h.db.First(&reloaded, future.ID)
+	assert.Nil(t, reloaded.ReminderEmailedAt)
+}
+
+func TestEnqueueDueReminders_NoReminderChannel(t *testing.T) {
+	_, h := setupTestRouter(t)
+	withTestOutbox(h, &recordingNotifier{name: "telegram", kinds: []notify.EventKind{notify.EventNewContact}})
+	remindAt := time.Now().Add(-time.Minute).UTC()
+	h.db.Create(&models.ContactForm{Name: "Пора", Phone: "+79990000001", RemindAt: &remindAt})
+
+	assert.Zero(t, h.EnqueueDueReminders(time.Now()), "без каналов напоминаний они не отмечаются отправленными")
+
+	var contact models.ContactForm
+	h.db.First(&contact)
+	assert.Nil(t, contact.ReminderEmailedAt)
+}
+
+func TestContactNotifications_ListAndRetry(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.GET("/admin/contacts/:id/notifications", h.GetContactNotifications)
+	router.POST("/admin/contacts/:id/notifications/:delivery_id/retry", h.RetryContactNotification)
+	tg := &recordingNotifier{name: "telegram", kinds: []notify.EventKind{notify.EventNewContact}, fail: true}
+	o := withTestOutbox(h, tg)
+
+	contact := models.ContactForm{Name: "Иван", Phone: "+79211234567"}
+	h.db.Create(&contact)
+	assert.NoError(t, h.enqueueContactEvent(h.db, notify.EventNewContact, &contact))
+	o.ProcessDue(context.Background(), time.Now())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/admin/contacts/%d/notifications", contact.ID), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Deliveries []struct {
+			ID           uint   `json:"id"`
+			Status       string `json:"status"`
+			ChannelTitle string `json:"channel_title"`
+			StatusTitle  string `json:"status_title"`
+			LastError    string `json:"last_error"`
+		} `json:"deliveries"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	if !assert.Len(t, resp.Deliveries, 1) {
+		return
+	}
+	d := resp.Deliveries[0]
+	assert.Equal(t, models.DeliveryDead, d.Status)
+	assert.Equal(t, "Telegram", d.ChannelTitle)
+	assert.Equal(t, "Не доставлено", d.StatusTitle)
+	assert.Equal(t, "бот недоступен", d.LastError)
+
+	retry := func(contactID, deliveryID uint) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/contacts/%d/notifications/%d/retry", contactID, deliveryID), nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNotFound, retry(contact.ID+1, d.ID), "доставка другой заявки")
+
+	tg.fail = false
+	assert.Equal(t, http.StatusOK, retry(contact.ID, d.ID))
+	assert.Equal(t, http.StatusConflict, retry(contact.ID, d.ID), "повтор только из dead")
+
+	assert.Equal(t, 1, o.ProcessDue(context.Background(), time.Now()))
+	assert.Len(t, tg.events, 1)
+}
@@ -0,0 +1,489 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Воронка продаж: этапы заявок настраиваются в админке (models.PipelineStage),
+// каждая смена статуса пишется в историю (models.ContactStageChange).
+
+// systemStatuses - статусы заявки вне воронки
+var systemStatuses = map[string]bool{"archived": true, "spam": true}
+
+// protectedStages - этапы, которые нельзя удалить или сделать закрывающими:
+// их ставят форма заявки ("new") и Telegram бот ("processed")
+var protectedStages = map[string]bool{"new": true, "processed": true}
+
+// stageKindTitles - подписи видов этапов для интерфейса
+var stageKindTitles = map[string]string{
+	models.StageOpen: "В работе",
+	models.StageWon:  "Сделка",
+	models.StageLost: "Проигрыш",
+}
+
+// boardColumnLimit - сколько карточек показывается в колонке канбан-доски
+const boardColumnLimit = 50
+
+// funnelPeriodDays - за какой период строится воронка на dashboard
+const funnelPeriodDays = 90
+
+// errLostReasonRequired - перевод в этап проигрыша без причины
+var errLostReasonRequired = errors.New("Укажите причину проигрыша")
+
+// pipelineStages возвращает этапы воронки в порядке колонок
+func (h *Handlers) pipelineStages() []models.PipelineStage {
+	var stages []models.PipelineStage
+	if err := h.db.Order("sort_order ASC, id ASC").Find(&stages).Error; err != nil {
+		log.Printf("Ошибка загрузки этапов воронки: %v", err)
+	}
+	return stages
+}
+
+// findStage ищет этап воронки по slug
+func (h *Handlers) findStage(slug string) (models.PipelineStage, bool) {
+	var stage models.PipelineStage
+	if slug == "" || h.db.Where("slug = ?", slug).First(&stage).Error != nil {
+		return stage, false
+	}
+	return stage, true
+}
+
+// parseStatus проверяет статус заявки: этап воронки или системный статус
+func (h *Handlers) parseStatus(s string) (string, bool) {
+	if systemStatuses[s] {
+		return s, true
+	}
+	if _, ok := h.findStage(s); ok {
+		return s, true
+	}
+	return "", false
+}
+
+// stageBadge - подпись и класс бейджа статуса заявки
+type stageBadge struct {
+	Title string
+	Class string
+}
+
+// stageBadges - бейджи всех статусов для шаблонов: {{index $.stageBadges .Status}}
+func stageBadges(stages []models.PipelineStage) map[string]stageBadge {
+	badges := map[string]stageBadge{
+		"archived": {Title: "В архиве"},
+		"spam":     {Title: "Спам", Class: "badge-error"},
+	}
+	for _, s := range stages {
+		b := stageBadge{Title: s.Title}
+		switch {
+		case s.Slug == "new":
+			b.Class = "badge-blue"
+		case s.Kind == models.StageWon:
+			b.Class = "badge-ok"
+		case s.Kind == models.StageLost:
+			b.Class = "badge-error"
+		}
+		badges[s.Slug] = b
+	}
+	return badges
+}
+
+// setContactsStatus переводит заявки в статус и пишет переходы в историю.
+//
+// Для этапа вида "lost" обязательна причина (errLostReasonRequired), при переходе
+// в другой этап причина очищается. Заявки, уже находящиеся в статусе, в историю
+// не попадают. adminID - кто перевёл (0 - Telegram бот).
+func (h *Handlers) setContactsStatus(ids []uint, status, lostReason string, adminID uint) error {
+	stage, isStage := h.findStage(status)
+	lostReason = strings.TrimSpace(lostReason)
+	if isStage && stage.Kind == models.StageLost && lostReason == "" {
+		return errLostReasonRequired
+	}
+
+	now := NowMSKUTC()
+	updates := map[string]any{"status": status, "archived_at": nil}
+	if status == "archived" {
+		updates["archived_at"] = &now
+	}
+	if isStage {
+		if stage.Kind != models.StageLost {
+			lostReason = ""
+		}
+		updates["lost_reason"] = lostReason
+	}
+
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		var contacts []models.ContactForm
+		if err := tx.Select("id", "status").Where("id IN ?", ids).Find(&contacts).Error; err != nil {
+			return err
+		}
+
+		var changes []models.ContactStageChange
+		var changed []uint
+		for _, cf := range contacts {
+			if cf.Status == status {
+				continue
+			}
+			changes = append(changes, models.ContactStageChange{
+				ContactID:  cf.ID,
+				FromStatus: cf.Status,
+				ToStatus:   status,
+				AdminID:    adminID,
+				ChangedAt:  now,
+			})
+			changed = append(changed, cf.ID)
+		}
+
+		if err := tx.Model(&models.ContactForm{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.ContactForm{}).Where("id IN ?", changed).Update("stage_changed_at", &now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&changes).Error
+	})
+}
+
+// statusChangeErr отвечает на ошибку setContactsStatus
+func statusChangeErr(c *gin.Context, err error, msg string) {
+	if errors.Is(err, errLostReasonRequired) {
+		jsonErr(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("Ошибка смены статуса заявок: %v", err)
+	jsonErr(c, http.StatusInternalServerError, msg)
+}
+
+// recordInitialStage записывает в историю первый статус новой заявки
+func recordInitialStage(tx *gorm.DB, contact *models.ContactForm) error {
+	return tx.Create(&models.ContactStageChange{
+		ContactID: contact.ID,
+		ToStatus:  contact.Status,
+		ChangedAt: contact.CreatedAt,
+	}).Error
+}
+
+// ---------- Канбан-доска ----------
+
+// boardColumn - колонка канбан-доски: этап и последние заявки в нём
+type boardColumn struct {
+	Stage    models.PipelineStage
+	Total    int64
+	Contacts []models.ContactForm
+}
+
+// AdminContactsBoardPage - канбан-доска заявок по этапам воронки (без архива и спама).
+// В колонке - последние boardColumnLimit заявок по времени перехода в этап.
+//
+// GET /admin/contacts/board
+func (h *Handlers) AdminContactsBoardPage(c *gin.Context) {
+	stages := h.pipelineStages()
+	columns := make([]boardColumn, 0, len(stages))
+	for _, stage := range stages {
+		col := boardColumn{Stage: stage}
+		qb := h.db.Model(&models.ContactForm{}).Where("status = ? AND archived_at IS NULL", stage.Slug)
+		if err := qb.Count(&col.Total).Error; err != nil {
+			c.String(http.StatusInternalServerError, "DB error")
+			return
+		}
+		if err := qb.Order("COALESCE(stage_changed_at, created_at) DESC").Limit(boardColumnLimit).Find(&col.Contacts).Error; err != nil {
+			c.String(http.StatusInternalServerError, "DB error")
+			return
+		}
+		columns = append(columns, col)
+	}
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":       "Воронка заявок",
+		"PageID":      "admin-contacts-board",
+		"columns":     columns,
+		"columnLimit": boardColumnLimit,
+	})
+}
+
+// ---------- Настройка этапов ----------
+
+// stageRow - этап воронки с количеством заявок в нём (для страницы настройки)
+type stageRow struct {
+	models.PipelineStage
+	Contacts  int64
+	KindTitle string
+	Protected bool
+}
+
+// AdminPipelinePage - страница настройки этапов воронки
+func (h *Handlers) AdminPipelinePage(c *gin.Context) {
+	stages := h.pipelineStages()
+	rows := make([]stageRow, 0, len(stages))
+	for _, s := range stages {
+		row := stageRow{PipelineStage: s, KindTitle: stageKindTitles[s.Kind], Protected: protectedStages[s.Slug]}
+		h.db.Model(&models.ContactForm{}).Where("status = ?", s.Slug).Count(&row.Contacts)
+		rows = append(rows, row)
+	}
+
+	renderAdmin(c, http.StatusOK, gin.H{
+		"title":      "Этапы воронки",
+		"PageID":     "admin-pipeline",
+		"stages":     rows,
+		"stageKinds": stageKindTitles,
+	})
+}
+
+// stageForm читает название и вид этапа из формы
+func stageForm(c *gin.Context) (title, kind string, ok bool) {
+	title = strings.TrimSpace(c.PostForm("title"))
+	if title == "" {
+		jsonErr(c, http.StatusBadRequest, "Название не может быть пустым")
+		return "", "", false
+	}
+	kind = c.DefaultPostForm("kind", models.StageOpen)
+	if _, known := stageKindTitles[kind]; !known {
+		jsonErr(c, http.StatusBadRequest, "Неизвестный вид этапа")
+		return "", "", false
+	}
+	return title, kind, true
+}
+
+// CreatePipelineStage - создание этапа воронки (встаёт в конец).
+// Slug генерируется автоматически и больше не меняется.
+func (h *Handlers) CreatePipelineStage(c *gin.Context) {
+	title, kind, ok := stageForm(c)
+	if !ok {
+		return
+	}
+
+	stage := models.PipelineStage{
+		Title: title,
+		Kind:  kind,
+		Slug:  h.uniqueSlug(&models.PipelineStage{}, fmt.Sprintf("stage-%d", time.Now().Unix())),
+	}
+
+	var maxOrder int
+	h.db.Model(&models.PipelineStage{}).Select("COALESCE(MAX(sort_order), -1)").Scan(&maxOrder)
+	stage.SortOrder = maxOrder + 1
+
+	if err := h.db.Create(&stage).Error; err != nil {
+		log.Printf("Ошибка создания этапа воронки '%s': %v", title, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка создания этапа")
+		return
+	}
+	setAuditEntityID(c, stage.ID)
+
+	jsonOK(c, gin.H{"message": "Этап создан", "stage": stage})
+}
+
+// UpdatePipelineStage - переименование этапа и смена его вида
+func (h *Handlers) UpdatePipelineStage(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var stage models.PipelineStage
+	if err := h.db.First(&stage, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Этап не найден")
+		return
+	}
+
+	title, kind, ok := stageForm(c)
+	if !ok {
+		return
+	}
+	if protectedStages[stage.Slug] && kind != models.StageOpen {
+		jsonErr(c, http.StatusBadRequest, "Этот этап используется формой заявки и ботом и должен оставаться в работе")
+		return
+	}
+
+	stage.Title = title
+	stage.Kind = kind
+	if err := h.db.Save(&stage).Error; err != nil {
+		log.Printf("Ошибка обновления этапа воронки ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка обновления этапа")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Этап обновлён", "stage": stage})
+}
+
+// DeletePipelineStage - удаление этапа.
+// Этап с заявками не удаляется (409): сначала заявки нужно перевести в другой этап.
+func (h *Handlers) DeletePipelineStage(c *gin.Context) {
+	id, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var stage models.PipelineStage
+	if err := h.db.First(&stage, id).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Этап не найден")
+		return
+	}
+	if protectedStages[stage.Slug] {
+		jsonErr(c, http.StatusConflict, "Этот этап используется формой заявки и ботом, его нельзя удалить")
+		return
+	}
+
+	var contacts int64
+	h.db.Model(&models.ContactForm{}).Where("status = ?", stage.Slug).Count(&contacts)
+	if contacts > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          "В этапе есть заявки. Переведите их в другой этап",
+			"contacts_count": contacts,
+		})
+		return
+	}
+
+	if err := h.db.Delete(&stage).Error; err != nil {
+		log.Printf("Ошибка удаления этапа воронки ID=%d: %v", id, err)
+		jsonErr(c, http.StatusInternalServerError, "Ошибка удаления этапа")
+		return
+	}
+
+	jsonOK(c, gin.H{"message": "Этап удалён"})
+}
+
+// UpdatePipelineStagesSorting - порядок этапов (drag & drop)
+func (h *Handlers) UpdatePipelineStagesSorting(c *gin.Context) {
+	var req struct {
+		IDs []uint `json:"ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Некорректные данные")
+		return
+	}
+
+	for i, id := range req.IDs {
+		if err := h.db.Model(&models.PipelineStage{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+			log.Printf("Ошибка обновления порядка для этапа ID=%d: %v", id, err)
+		}
+	}
+
+	jsonOK(c, gin.H{"message": "Порядок успешно обновлен"})
+}
+
+// ---------- Воронка на dashboard ----------
+
+// funnelRow - этап в отчёте воронки
+type funnelRow struct {
+	Stage   models.PipelineStage
+	Reached int    // сколько заявок дошло до этапа
+	Percent int    // конверсия от всех заявок периода, %
+	AvgTime string // среднее время в этапе (по заявкам, которые из него вышли)
+}
+
+// lostReasonCount - причина проигрыша и число заявок с ней
+type lostReasonCount struct {
+	Reason string
+	Count  int64
+}
+
+// pipelineFunnel считает воронку по заявкам, созданным после since (без спама).
+//
+// Заявка дошла до этапа "в работе" или "сделка", если была в нём или в любом
+// следующем таком этапе. До этапа проигрыша - только если была в нём.
+// Время в этапе - от входа в этап до следующего перехода; заявки, которые ещё
+// в этапе, в среднее не входят.
+func (h *Handlers) pipelineFunnel(since time.Time) ([]funnelRow, int, []lostReasonCount) {
+	stages := h.pipelineStages()
+
+	var contacts []models.ContactForm
+	h.db.Select("id", "status").Where("created_at >= ? AND status <> ?", since, "spam").Find(&contacts)
+	if len(contacts) == 0 {
+		return nil, 0, nil
+	}
+
+	var changes []models.ContactStageChange
+	h.db.Where("contact_id IN (?)", h.db.Model(&models.ContactForm{}).Select("id").
+		Where("created_at >= ? AND status <> ?", since, "spam")).
+		Order("contact_id ASC, changed_at ASC, id ASC").Find(&changes)
+
+	// Позиция этапа среди этапов "в работе" и "сделка" (для накопительного подсчёта)
+	progress := map[string]int{}
+	for _, s := range stages {
+		if s.Kind != models.StageLost {
+			progress[s.Slug] = len(progress)
+		}
+	}
+
+	visited := make(map[uint]map[string]bool, len(contacts))
+	for _, cf := range contacts {
+		visited[cf.ID] = map[string]bool{cf.Status: true}
+	}
+
+	spent := map[string]time.Duration{}
+	spentCount := map[string]int{}
+	for i, ch := range changes {
+		visited[ch.ContactID][ch.ToStatus] = true
+		if i+1 < len(changes) && changes[i+1].ContactID == ch.ContactID {
+			spent[ch.ToStatus] += changes[i+1].ChangedAt.Sub(ch.ChangedAt)
+			spentCount[ch.ToStatus]++
+		}
+	}
+
+	reached := map[string]int{}
+	for _, statuses := range visited {
+		furthest := -1
+		for status := range statuses {
+			if pos, ok := progress[status]; ok && pos > furthest {
+				furthest = pos
+			}
+			reached[status]++
+		}
+		for slug, pos := range progress {
+			if pos <= furthest && !statuses[slug] {
+				reached[slug]++
+			}
+		}
+	}
+
+	rows := make([]funnelRow, 0, len(stages))
+	lostSlugs := []string{}
+	for _, s := range stages {
+		row := funnelRow{Stage: s, Reached: reached[s.Slug], AvgTime: "—"}
+		row.Percent = row.Reached * 100 / len(contacts)
+		if n := spentCount[s.Slug]; n > 0 {
+			row.AvgTime = formatStageDuration(spent[s.Slug] / time.Duration(n))
+		}
+		if s.Kind == models.StageLost {
+			lostSlugs = append(lostSlugs, s.Slug)
+		}
+		rows = append(rows, row)
+	}
+
+	var reasons []lostReasonCount
+	if len(lostSlugs) > 0 {
+		h.db.Model(&models.ContactForm{}).
+			Select("lost_reason AS reason, COUNT(*) AS count").
+			Where("created_at >= ? AND status IN ? AND lost_reason <> ''", since, lostSlugs).
+			Group("lost_reason").
+			Order("count DESC").
+			Limit(5).
+			Scan(&reasons)
+	}
+
+	return rows, len(contacts), reasons
+}
+
+// formatStageDuration - длительность для отчёта: часы до суток, дальше дни
+func formatStageDuration(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return "< 1 ч"
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d ч", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%.1f дн", d.Hours()/24)
+	}
+}
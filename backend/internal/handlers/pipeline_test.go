@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// postStatus - смена статуса заявки через UpdateContactStatus
+func postStatus(t *testing.T, router http.Handler, id uint, body map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/contacts/%d/status", id), bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// stageHistory - переходы заявки в порядке записи
+func stageHistory(h *Handlers, contactID uint) []string {
+	var changes []models.ContactStageChange
+	h.db.Where("contact_id = ?", contactID).Order("id").Find(&changes)
+	out := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		out = append(out, ch.FromStatus+">"+ch.ToStatus)
+	}
+	return out
+}
+
+func TestUpdateContactStatus_StagesAndLostReason(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/contacts/:id/status", h.UpdateContactStatus)
+
+	contact := models.ContactForm{Name: "Иван", Phone: "+79211234567", Status: "new"}
+	h.db.Create(&contact)
+
+	assert.Equal(t, http.StatusOK, postStatus(t, router, contact.ID, map[string]string{"status": "quote_sent"}).Code)
+	assert.Equal(t, http.StatusBadRequest, postStatus(t, router, contact.ID, map[string]string{"status": "unknown"}).Code)
+
+	w := postStatus(t, router, contact.ID, map[string]string{"status": "lost", "lost_reason": "  "})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "проигрыш без причины")
+	assert.Contains(t, w.Body.String(), "причину")
+
+	assert.Equal(t, http.StatusOK, postStatus(t, router, contact.ID, map[string]string{"status": "lost", "lost_reason": "Дорого"}).Code)
+	h.db.First(&contact, contact.ID)
+	assert.Equal(t, "lost", contact.Status)
+	assert.Equal(t, "Дорого", contact.LostReason)
+	assert.NotNil(t, contact.StageChangedAt)
+
+	// Повтор того же статуса не пишет переход
+	assert.Equal(t, http.StatusOK, postStatus(t, router, contact.ID, map[string]string{"status": "lost", "lost_reason": "Дорого"}).Code)
+
+	assert.Equal(t, http.StatusOK, postStatus(t, router, contact.ID, map[string]string{"status": "won"}).Code)
+	h.db.First(&contact, contact.ID)
+	assert.Empty(t, contact.LostReason, "причина очищается при выходе из проигрыша")
+
+	assert.Equal(t, []string{"new>quote_sent", "quote_sent>lost", "lost>won"}, stageHistory(h, contact.ID))
+}
+
+func TestBulkUpdateContacts_LostRequiresReason(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/contacts/bulk", h.BulkUpdateContacts)
+
+	a := models.ContactForm{Name: "А", Phone: "+79211111111", Status: "new"}
+	b := models.ContactForm{Name: "Б", Phone: "+79212222222", Status: "processed"}
+	h.db.Create(&a)
+	h.db.Create(&b)
+
+	post := func(body map[string]any) int {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/admin/contacts/bulk", bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	ids := []uint{a.ID, b.ID}
+	assert.Equal(t, http.StatusBadRequest, post(map[string]any{"action": "lost", "ids": ids}))
+	assert.Equal(t, http.StatusOK, post(map[string]any{"action": "lost", "ids": ids, "lost_reason": "Не вышли на связь"}))
+
+	var lost int64
+	h.db.Model(&models.ContactForm{}).Where("status = ? AND lost_reason = ?", "lost", "Не вышли на связь").Count(&lost)
+	assert.Equal(t, int64(2), lost)
+	assert.Equal(t, []string{"processed>lost"}, stageHistory(h, b.ID))
+
+	assert.Equal(t, http.StatusOK, post(map[string]any{"action": "archived", "ids": ids}))
+	h.db.First(&a, a.ID)
+	assert.NotNil(t, a.ArchivedAt)
+	assert.Equal(t, "Не вышли на связь", a.LostReason, "архив не стирает причину")
+}
+
+func TestSubmitContact_RecordsInitialStage(t *testing.T) {
+	_, h := setupTestRouter(t)
+	assert.Equal(t, http.StatusOK, postContact(h, map[string]string{"name": "Иван", "phone": "+79211234567"}))
+
+	var contact models.ContactForm
+	assert.NoError(t, h.db.First(&contact).Error)
+	assert.NotNil(t, contact.StageChangedAt)
+	assert.Equal(t, []string{">new"}, stageHistory(h, contact.ID))
+}
+
+func TestPipelineStagesCRUD(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.POST("/admin/pipeline", h.CreatePipelineStage)
+	router.POST("/admin/pipeline/:id/update", h.UpdatePipelineStage)
+	router.DELETE("/admin/pipeline/:id", h.DeletePipelineStage)
+
+	w := postCategoryForm(t, router, "/admin/pipeline", url.Values{"title": {"Замер"}, "kind": {"open"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Stage models.PipelineStage `json:"stage"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	stage := resp.Stage
+	assert.Equal(t, len(models.DefaultPipelineStages()), stage.SortOrder, "новый этап в конце")
+	assert.LessOrEqual(t, len(stage.Slug), 20, "slug помещается в contact_forms.status")
+	_, ok := h.parseStatus(stage.Slug)
+	assert.True(t, ok)
+
+	assert.Equal(t, http.StatusBadRequest, postCategoryForm(t, router, "/admin/pipeline", url.Values{"title": {"X"}, "kind": {"closed"}}).Code)
+
+	var processed models.PipelineStage
+	h.db.Where("slug = ?", "processed").First(&processed)
+	w = postCategoryForm(t, router, fmt.Sprintf("/admin/pipeline/%d/update", processed.ID), url.Values{"title": {"Обработана"}, "kind": {"lost"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "этап бота остаётся в работе")
+
+	del := func(id uint) int {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/pipeline/%d", id), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusConflict, del(processed.ID))
+
+	contact := models.ContactForm{Name: "Иван", Phone: "+79211234567", Status: stage.Slug}
+	h.db.Create(&contact)
+	assert.Equal(t, http.StatusConflict, del(stage.ID), "в этапе есть заявки")
+
+	h.db.Model(&contact).Update("status", "new")
+	assert.Equal(t, http.StatusOK, del(stage.ID))
+	_, ok = h.parseStatus(stage.Slug)
+	assert.False(t, ok)
+}
+
+func TestPipelineFunnel(t *testing.T) {
+	_, h := setupTestRouter(t)
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -funnelPeriodDays)
+
+	// contact создаёт заявку и её историю: каждый следующий статус через step после предыдущего
+	contact := func(step time.Duration, reason string, statuses ...string) {
+		cf := models.ContactForm{Name: "Клиент", Phone: "+79211234567", Status: statuses[len(statuses)-1],
+			LostReason: reason, CreatedAt: now.Add(-10 * 24 * time.Hour)}
+		h.db.Create(&cf)
+		prev := ""
+		for i, s := range statuses {
+			h.db.Create(&models.ContactStageChange{ContactID: cf.ID, FromStatus: prev, ToStatus: s,
+				ChangedAt: cf.CreatedAt.Add(time.Duration(i) * step)})
+			prev = s
+		}
+	}
+	contact(48*time.Hour, "", "new", "qualified", "won") // пропущены processed, quote_sent, negotiating
+	contact(2*time.Hour, "Дорого", "new", "processed", "lost")
+	contact(0, "", "new")
+	h.db.Create(&models.ContactForm{Name: "Спам", Phone: "+79210000000", Status: "spam", CreatedAt: now})
+	h.db.Create(&models.ContactForm{Name: "Старая", Phone: "+79210000001", Status: "won", CreatedAt: since.Add(-time.Hour)})
+
+	rows, total, reasons := h.pipelineFunnel(since)
+	assert.Equal(t, 3, total, "без спама и заявок до периода")
+
+	bySlug := map[string]funnelRow{}
+	for _, r := range rows {
+		bySlug[r.Stage.Slug] = r
+	}
+	assert.Equal(t, 3, bySlug["new"].Reached)
+	assert.Equal(t, 100, bySlug["new"].Percent)
+	assert.Equal(t, 2, bySlug["processed"].Reached, "won засчитывается во все предыдущие этапы")
+	assert.Equal(t, 1, bySlug["negotiating"].Reached)
+	assert.Equal(t, 1, bySlug["won"].Reached)
+	assert.Equal(t, 33, bySlug["won"].Percent)
+	assert.Equal(t, 1, bySlug["lost"].Reached)
+
+	assert.Equal(t, "1.0 дн", bySlug["new"].AvgTime, "(48ч + 2ч) / 2, заявка в new без перехода не считается")
+	assert.Equal(t, "2 ч", bySlug["processed"].AvgTime)
+	assert.Equal(t, "—", bySlug["won"].AvgTime, "из этапа ещё не выходили")
+
+	assert.Equal(t, []lostReasonCount{{Reason: "Дорого", Count: 1}}, reasons)
+}
+
+func TestAdminContactsBoardPage(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.SetHTMLTemplate(template.Must(template.New("admin_base.html").Parse(
+		`{{.PageID}}|{{range .columns}}{{.Stage.Slug}}={{.Total}}:{{range .Contacts}}{{.Name}},{{end}};{{end}}`,
+	)))
+	router.GET("/admin/contacts/board", h.AdminContactsBoardPage)
+
+	now := time.Now()
+	older, newer := now.Add(-2*time.Hour), now.Add(-time.Hour)
+	h.db.Create(&models.ContactForm{Name: "Анна", Phone: "+79211111111", Status: "qualified", StageChangedAt: &older})
+	h.db.Create(&models.ContactForm{Name: "Борис", Phone: "+79212222222", Status: "qualified", StageChangedAt: &newer})
+	h.db.Create(&models.ContactForm{Name: "Архив", Phone: "+79213333333", Status: "qualified", ArchivedAt: &now})
+	h.db.Create(&models.ContactForm{Name: "Бот", Phone: "+79214444444", Status: "spam"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/contacts/board", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "admin-contacts-board|new=0:;processed=0:;qualified=2:Борис,Анна,;quote_sent=0:;negotiating=0:;won=0:;lost=0:;", w.Body.String())
+}
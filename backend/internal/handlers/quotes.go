@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ledsite/internal/models"
+	"ledsite/internal/quote"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Коммерческие предложения по заявке: менеджер вводит параметры экрана, как в калькуляторе
+// на странице цен, сервер считает спецификацию (internal/quote) и сохраняет PDF с номером.
+
+// nextQuoteNumber - следующий номер КП в году: "2026-0001", "2026-0002", ...
+// Берётся максимум числовой части: строковая сортировка поставила бы "2026-10000" ниже "2026-9999".
+func nextQuoteNumber(tx *gorm.DB, year int) (string, error) {
+	prefix := fmt.Sprintf("%d-", year)
+	var seq int
+	if err := tx.Model(&models.ContactQuote{}).
+		Select("COALESCE(MAX(CAST(SUBSTR(number, ?) AS INTEGER)), 0)", len(prefix)+1).
+		Where("number LIKE ?", prefix+"%").
+		Scan(&seq).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, seq+1), nil
+}
+
+// isUniqueViolation - ошибка нарушения уникального индекса (PostgreSQL 23505, SQLite в тестах)
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return errors.Is(err, gorm.ErrDuplicatedKey) ||
+		strings.Contains(msg, "SQLSTATE 23505") ||
+		strings.Contains(msg, "UNIQUE constraint failed")
+}
+
+// GetContactQuotes возвращает КП по заявке (новые сверху)
+//
+// GET /admin/contacts/:id/quotes
+func (h *Handlers) GetContactQuotes(c *gin.Context) {
+	contactID, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var quotes []models.ContactQuote
+	if err := h.db.Omit("pdf", "spec").
+		Where("contact_id = ?", contactID).
+		Order("id DESC").
+		Find(&quotes).Error; err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось получить КП")
+		return
+	}
+	jsonOK(c, gin.H{"quotes": quotes})
+}
+
+// CreateContactQuote считает спецификацию по данным калькулятора и сохраняет PDF.
+// К заявке добавляется заметка с номером и суммой КП.
+//
+// POST /admin/contacts/:id/quotes
+func (h *Handlers) CreateContactQuote(c *gin.Context) {
+	contactID, ok := mustID(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		ScreenType string `json:"screen_type"`
+		PitchID    uint   `json:"pitch_id"`
+		Width      int    `json:"width"`  // в кабинетах
+		Height     int    `json:"height"` // в кабинетах
+		Light      bool   `json:"light"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		jsonErr(c, http.StatusBadRequest, "Неверные данные")
+		return
+	}
+
+	var contact models.ContactForm
+	if err := h.db.First(&contact, contactID).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "Заявка не найдена")
+		return
+	}
+
+	// Те же данные, что отдаёт GetCalculatorData: настройки, активные шаги пикселя, курс с надбавкой
+	var settings models.CalculatorSettings
+	if err := h.db.First(&settings).Error; err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Настройки калькулятора не найдены")
+		return
+	}
+	var pitch models.CalculatorPixelPitch
+	if err := h.db.Where("id = ? AND is_active = ?", body.PitchID, true).First(&pitch).Error; err != nil {
+		jsonErr(c, http.StatusBadRequest, "Шаг пикселя не найден")
+		return
+	}
+	rate, err := getOrRefreshUSDRate(h.db)
+	if err != nil {
+		rate = 0
+	}
+
+	calc, err := quote.Calculate(settings, pitch, quote.Params{
+		ScreenType: body.ScreenType,
+		Width:      body.Width,
+		Height:     body.Height,
+		Light:      body.Light,
+	}, rate)
+	if errors.Is(err, quote.ErrNoRate) {
+		jsonErr(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		jsonErr(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	spec, _ := json.Marshal(calc)
+
+	site := getSettings(h.db)
+	author := c.GetString("admin_username")
+	now := NowMSK()
+	q := models.ContactQuote{
+		ContactID: contactID,
+		Summary:   calc.Summary(),
+		Total:     calc.Total,
+		Spec:      string(spec),
+		Author:    author,
+		CreatedAt: now.UTC(),
+	}
+
+	create := func(tx *gorm.DB) error {
+		number, err := nextQuoteNumber(tx, now.Year())
+		if err != nil {
+			return err
+		}
+		q.Number = number
+		q.PDF, err = quote.Render(quote.Document{
+			Number:   number,
+			Date:     now,
+			Seller:   quote.Seller{Phone: site.PhoneDisplay, Email: site.Email, Address: site.Address},
+			Customer: quote.Customer{Name: contact.Name, Company: contact.Company, Phone: contact.Phone, Email: contact.Email},
+			Manager:  author,
+			Calc:     calc,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&q).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ContactNote{
+			ContactID: contactID,
+			Text:      fmt.Sprintf("КП № %s: %s, %s ₽", number, q.Summary, quote.FormatMoney(q.Total)),
+			Author:    author,
+			CreatedAt: now.UTC(),
+		}).Error
+	}
+	// Одновременное создание КП может занять тот же номер: второй запрос повторяет попытку со следующим
+	err = h.db.Transaction(create)
+	if isUniqueViolation(err) {
+		q.ID = 0
+		err = h.db.Transaction(create)
+	}
+	if err != nil {
+		jsonErr(c, http.StatusInternalServerError, "Не удалось сформировать КП")
+		return
+	}
+
+	setAuditEntityID(c, q.ID)
+	jsonOK(c, gin.H{"message": "КП № " + q.Number + " сформировано", "quote": q, "calculation": calc})
+}
+
+// DownloadContactQuote отдаёт сохранённый PDF
+//
+// GET /admin/contacts/:id/quotes/:quote_id/pdf
+func (h *Handlers) DownloadContactQuote(c *gin.Context) {
+	contactID, ok := mustID(c)
+	if !ok {
+		return
+	}
+	quoteID, err := strconv.ParseUint(c.Param("quote_id"), 10, 64)
+	if err != nil || quoteID == 0 {
+		jsonErr(c, http.StatusBadRequest, "Некорректный id КП")
+		return
+	}
+
+	var q models.ContactQuote
+	if err := h.db.Where("id = ? AND contact_id = ?", quoteID, contactID).First(&q).Error; err != nil {
+		jsonErr(c, http.StatusNotFound, "КП не найдено")
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="KP-`+q.Number+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", q.PDF)
+}
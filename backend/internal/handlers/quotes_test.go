@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContactQuotes(t *testing.T) {
+	_, h := setupTestRouter(t)
+	h.db.Create(&models.CalculatorSettings{UsdRate: 100, UsdMarkupPct: 2, UsdRateAt: time.Now()})
+	pitch := models.CalculatorPixelPitch{ScreenType: "indoor", Name: "P2,5", ModulePrice: 10, IsActive: true}
+	h.db.Create(&pitch)
+	contact := models.ContactForm{Name: "Иван", Phone: "+79211234567", Status: "new"}
+	h.db.Create(&contact)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_username", "manager")
+		c.Next()
+	})
+	router.GET("/admin/contacts/:id/quotes", h.GetContactQuotes)
+	router.POST("/admin/contacts/:id/quotes", h.CreateContactQuote)
+	router.GET("/admin/contacts/:id/quotes/:quote_id/pdf", h.DownloadContactQuote)
+
+	create := func(contactID uint, body map[string]interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/contacts/%d/quotes", contactID), bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	params := map[string]interface{}{"screen_type": "indoor", "pitch_id": pitch.ID, "width": 2, "height": 2}
+
+	w := create(contact.ID, params)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = create(contact.ID, params)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusNotFound, create(contact.ID+100, params).Code)
+	assert.Equal(t, http.StatusBadRequest, create(contact.ID, map[string]interface{}{
+		"screen_type": "indoor", "pitch_id": pitch.ID + 100, "width": 2, "height": 2,
+	}).Code)
+	assert.Equal(t, http.StatusBadRequest, create(contact.ID, map[string]interface{}{
+		"screen_type": "indoor", "pitch_id": pitch.ID, "width": 0, "height": 2,
+	}).Code)
+
+	var quotes []models.ContactQuote
+	h.db.Order("id").Find(&quotes)
+	if !assert.Len(t, quotes, 2) {
+		return
+	}
+	year := NowMSK().Year()
+	assert.Equal(t, fmt.Sprintf("%d-0001", year), quotes[0].Number)
+	assert.Equal(t, fmt.Sprintf("%d-0002", year), quotes[1].Number)
+	// (5 + 76 + 20 + 17.6 + 10×8) × 4 кабинета × 102 с округлением цены единицы
+	assert.Equal(t, 81028.0, quotes[0].Total)
+	assert.Equal(t, "manager", quotes[0].Author)
+
+	var notes []models.ContactNote
+	h.db.Where("contact_id = ?", contact.ID).Order("id").Find(&notes)
+	if assert.Len(t, notes, 2) {
+		assert.Equal(t, fmt.Sprintf("КП № %d-0001: Интерьерный LED экран P2,5, Standard, 1280×1280 мм (2×2 каб.), 81 028 ₽", year), notes[0].Text)
+	}
+
+	// Список без PDF
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/admin/contacts/%d/quotes", contact.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), quotes[1].Number)
+	assert.NotContains(t, w.Body.String(), "pdf")
+
+	// Скачивание: тот же PDF, что сохранён при создании
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/admin/contacts/%d/quotes/%d/pdf", contact.ID, quotes[0].ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "KP-"+quotes[0].Number+".pdf")
+	assert.Equal(t, quotes[0].PDF, w.Body.Bytes())
+
+	// КП другой заявки не отдаётся
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/admin/contacts/%d/quotes/%d/pdf", contact.ID+1, quotes[0].ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNextQuoteNumber(t *testing.T) {
+	_, h := setupTestRouter(t)
+
+	number, err := nextQuoteNumber(h.db, 2026)
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-0001", number)
+
+	for _, n := range []string{"2025-12000", "2026-0005", "2026-9999"} {
+		h.db.Create(&models.ContactQuote{ContactID: 1, Number: n})
+	}
+	number, _ = nextQuoteNumber(h.db, 2026)
+	assert.Equal(t, "2026-10000", number)
+
+	h.db.Create(&models.ContactQuote{ContactID: 1, Number: number})
+	number, _ = nextQuoteNumber(h.db, 2026)
+	assert.Equal(t, "2026-10001", number, "после 9999 номера идут по числу, не по строке")
+
+	// Занятый номер - нарушение уникального индекса, по нему CreateContactQuote повторяет попытку
+	err = h.db.Create(&models.ContactQuote{ContactID: 1, Number: "2026-0005"}).Error
+	assert.True(t, isUniqueViolation(err), "%v", err)
+	assert.False(t, isUniqueViolation(nil))
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission - право на группу действий в админке.
+// Значения используются как ключи в шаблонах: {{if .can.content_edit}}
+type Permission string
+
+const (
+	PermView           Permission = "view"            // просмотр страниц админки
+	PermContentEdit    Permission = "content_edit"    // проекты, категории, услуги, цены, карта, промо
+	PermContactsEdit   Permission = "contacts_edit"   // статусы, заметки, напоминания, архив заявок
+	PermContactsExport Permission = "contacts_export" // экспорт заявок в CSV
+	PermContactsDelete Permission = "contacts_delete" // безвозвратное удаление заявок
+	PermPricingEdit    Permission = "pricing_edit"    // настройки калькулятора
+	PermAnalyticsReset Permission = "analytics_reset" // сброс статистики просмотров
+	PermSettingsEdit   Permission = "settings_edit"   // настройки сайта
+	PermUsersManage    Permission = "users_manage"    // управление администраторами
+	PermAuditView      Permission = "audit_view"      // журнал изменений
+)
+
+// rolePermissions - набор прав для каждой роли.
+// Владелец получает все права, см. RoleHasPermission.
+var rolePermissions = map[string][]Permission{
+	models.RoleManager: {PermView, PermContentEdit, PermContactsEdit, PermContactsExport},
+	models.RoleEditor:  {PermView, PermContentEdit},
+	models.RoleViewer:  {PermView},
+}
+
+// allPermissions - полный список прав (для шаблонов)
+var allPermissions = []Permission{
+	PermView, PermContentEdit, PermContactsEdit, PermContactsExport,
+	PermContactsDelete, PermPricingEdit, PermAnalyticsReset, PermSettingsEdit, PermUsersManage,
+	PermAuditView,
+}
+
+// roleTitles - названия ролей для интерфейса
+var roleTitles = map[string]string{
+	models.RoleOwner:   "Владелец",
+	models.RoleManager: "Менеджер",
+	models.RoleEditor:  "Контент-редактор",
+	models.RoleViewer:  "Только просмотр",
+}
+
+// IsValidRole проверяет, что роль известна системе
+func IsValidRole(role string) bool {
+	_, ok := roleTitles[role]
+	return ok
+}
+
+// RoleHasPermission проверяет наличие права у роли.
+// Неизвестная или пустая роль (например, токен старого формата) имеет только право просмотра.
+func RoleHasPermission(role string, perm Permission) bool {
+	if role == models.RoleOwner {
+		return true
+	}
+	perms, ok := rolePermissions[role]
+	if !ok {
+		return perm == PermView
+	}
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet возвращает права роли в виде map для шаблонов
+func PermissionSet(role string) map[string]bool {
+	set := make(map[string]bool, len(allPermissions))
+	for _, p := range allPermissions {
+		set[string(p)] = RoleHasPermission(role, p)
+	}
+	return set
+}
+
+// renderAdmin рендерит страницу админки, добавляя права, данные текущего админа
+// и CSRF-токен сессии (выставляется middleware.CSRF)
+func renderAdmin(c *gin.Context, code int, data gin.H) {
+	role := c.GetString("admin_role")
+	data["can"] = PermissionSet(role)
+	data["csrfToken"] = c.GetString("csrf_token")
+	data["currentAdmin"] = gin.H{
+		"username":  c.GetString("admin_username"),
+		"role":      role,
+		"roleTitle": roleTitles[role],
+	}
+	c.HTML(code, "admin_base.html", data)
+}
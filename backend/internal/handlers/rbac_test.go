@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ledsite/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- RoleHasPermission ----------
+
+func TestRoleHasPermission(t *testing.T) {
+	for _, p := range allPermissions {
+		assert.True(t, RoleHasPermission(models.RoleOwner, p), "владелец: %s", p)
+	}
+
+	assert.True(t, RoleHasPermission(models.RoleManager, PermContactsEdit))
+	assert.False(t, RoleHasPermission(models.RoleManager, PermContactsDelete))
+	assert.False(t, RoleHasPermission(models.RoleManager, PermPricingEdit))
+	assert.False(t, RoleHasPermission(models.RoleManager, PermAuditView))
+
+	assert.True(t, RoleHasPermission(models.RoleEditor, PermContentEdit))
+	assert.False(t, RoleHasPermission(models.RoleEditor, PermContactsEdit))
+
+	assert.True(t, RoleHasPermission(models.RoleViewer, PermView))
+	assert.False(t, RoleHasPermission(models.RoleViewer, PermContentEdit))
+}
+
+func TestRoleHasPermission_UnknownRole(t *testing.T) {
+	// Токены старого формата без роли - только просмотр
+	assert.True(t, RoleHasPermission("", PermView))
+	assert.False(t, RoleHasPermission("", PermContentEdit))
+	assert.False(t, RoleHasPermission("superuser", PermSettingsEdit))
+}
+
+// ---------- renderAdmin ----------
+
+func TestRenderAdmin_PassesPermissions(t *testing.T) {
+	router, _ := setupTestRouter(t)
+	router.SetHTMLTemplate(template.Must(template.New("admin_base.html").Parse(
+		`{{.PageID}}|{{.currentAdmin.roleTitle}}|{{if .can.content_edit}}edit{{end}}|{{if .can.settings_edit}}settings{{end}}`,
+	)))
+	router.GET("/admin/test", func(c *gin.Context) {
+		c.Set("admin_role", models.RoleEditor)
+		renderAdmin(c, http.StatusOK, gin.H{"PageID": "admin-test"})
+	})
+
+	req, _ := http.NewRequest("GET", "/admin/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "admin-test|Контент-редактор|edit|", w.Body.String())
+}
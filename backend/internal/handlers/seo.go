@@ -47,7 +47,7 @@ func getBaseURL(c *gin.Context) string {
 //
 // Включает:
 //   - Статические страницы (главная, услуги, контакты и т.д.)
-//   - Динамические страницы проектов из БД
+//   - Динамические страницы услуг и проектов из БД
 //
 // Формат соответствует спецификации sitemap.org
 // Частота обновления и приоритет настроены для оптимальной индексации.
@@ -61,6 +61,10 @@ func (h *Handlers) Sitemap(c *gin.Context) {
 	var projects []models.Project
 	h.db.Select("id, slug, updated_at").Order("updated_at DESC").Find(&projects)
 
+	// Страницы услуг
+	var services []models.Service
+	h.db.Select("id, slug, updated_at").Order("sort_order ASC, id ASC").Find(&services)
+
 	// Текущая дата для lastmod
 	now := time.Now().Format("2006-01-02")
 
@@ -94,6 +98,18 @@ func (h *Handlers) Sitemap(c *gin.Context) {
 `, page.loc, now, page.changefreq, page.priority)
 	}
 
+	// Динамические страницы услуг
+	for _, service := range services {
+		lastmod := service.UpdatedAt.Format("2006-01-02")
+		xml += fmt.Sprintf(`  <url>
+    <loc>%s/services/%s</loc>
+    <lastmod>%s</lastmod>
+    <changefreq>monthly</changefreq>
+    <priority>0.7</priority>
+  </url>
+`, baseURL, service.Slug, lastmod)
+	}
+
 	// Динамические страницы проектов
 	for _, project := range projects {
 		lastmod := project.UpdatedAt.Format("2006-01-02")
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 
+	"ledsite/internal/models"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -179,3 +181,18 @@ func TestRobotsTxt_HTTPS(t *testing.T) {
 	assert.Contains(t, body, "Sitemap: https://s-n-r.ru/sitemap.xml")
 	assert.NotContains(t, body, "Sitemap: http://s-n-r.ru/sitemap.xml")
 }
+
+// TestSitemap_Services проверяет, что страницы услуг попадают в sitemap
+func TestSitemap_Services(t *testing.T) {
+	router, h := setupTestRouter(t)
+	router.GET("/sitemap.xml", h.Sitemap)
+
+	h.db.Create(&models.Service{Name: "Монтаж", Slug: "montage"})
+
+	req, _ := http.NewRequest("GET", "/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/services/montage</loc>")
+}
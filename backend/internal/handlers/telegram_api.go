@@ -11,8 +11,9 @@ import (
 
 // TelegramUpdateStatusRequest - запрос на изменение статуса из Telegram бота
 type TelegramUpdateStatusRequest struct {
-	ContactID uint   `json:"contact_id" binding:"required"`
-	Status    string `json:"status" binding:"required"`
+	ContactID  uint   `json:"contact_id" binding:"required"`
+	Status     string `json:"status" binding:"required"`
+	LostReason string `json:"lost_reason"` // обязательна для этапа проигрыша
 }
 
 // TelegramAddNoteRequest - запрос на добавление заметки из Telegram бота
@@ -47,24 +48,17 @@ func (h *Handlers) TelegramUpdateStatus(c *gin.Context) {
 		return
 	}
 
-	status, valid := parseStatus(req.Status)
+	status, valid := h.parseStatus(req.Status)
 	if !valid {
 		jsonErr(c, http.StatusBadRequest, "Недопустимый статус")
 		return
 	}
 
-	now := NowMSKUTC()
-	var archivedAt *time.Time
-	if status == "archived" {
-		archivedAt = &now
-	}
-
-	if err := h.db.Model(&models.ContactForm{}).
-		Where("id = ?", req.ContactID).
-		Updates(map[string]any{"status": status, "archived_at": archivedAt}).Error; err != nil {
-		jsonErr(c, http.StatusInternalServerError, "Не удалось обновить заявку")
+	if err := h.setContactsStatus([]uint{req.ContactID}, status, req.LostReason, 0); err != nil {
+		statusChangeErr(c, err, "Не удалось обновить заявку")
 		return
 	}
+	h.linkCustomersByIDs([]uint{req.ContactID})
 
 	jsonOK(c, gin.H{"message": "Статус изменён", "status": status})
 }
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"ledsite/internal/models"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
+// projectTypeCodes - типы проектов формы заявки (подписи - translateProjectType)
+var projectTypeCodes = []string{"indoor", "outdoor", "rental", "service", "repair", "consultation"}
+
 // translateProjectType переводит тип проекта с английского на русский
 func translateProjectType(projectType string) string {
 	translations := map[string]string{
@@ -28,90 +31,69 @@ func translateProjectType(projectType string) string {
 	return projectType
 }
 
-// TelegramNotification представляет структуру данных для отправки в Telegram бот
-type TelegramNotification struct {
-	Name        string `json:"name"`
-	Phone       string `json:"phone"`
-	Email       string `json:"email,omitempty"`
-	Company     string `json:"company,omitempty"`
-	ProjectType string `json:"project_type,omitempty"`
-	Message     string `json:"message,omitempty"`
-	ContactID   uint   `json:"contact_id,omitempty"`
-	Timestamp   string `json:"timestamp,omitempty"`
+// TelegramAlert - служебный алерт для администраторов (например, подбор паролей)
+type TelegramAlert struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
 }
 
-// SendTelegramNotification отправляет уведомление о новой заявке в Telegram
-func SendTelegramNotification(contact *models.ContactForm) {
-	// Проверяем что URL Telegram бота настроен
-	telegramBotURL := os.Getenv("TELEGRAM_BOT_URL")
-	if telegramBotURL == "" {
-		log.Println("TELEGRAM_BOT_URL не настроен, пропускаем отправку уведомления")
+// SendTelegramAlert отправляет служебный алерт в Telegram через эндпоинт бота /api/send-alert
+func SendTelegramAlert(title, text string) {
+	baseURL := telegramBotBaseURL()
+	if baseURL == "" {
+		log.Printf("TELEGRAM_BOT_URL не настроен, алерт не отправлен: %s", title)
 		return
 	}
 
-	// Формируем данные для отправки
-	notification := TelegramNotification{
-		Name:        contact.Name,
-		Phone:       contact.Phone,
-		Email:       contact.Email,
-		Company:     contact.Company,
-		ProjectType: translateProjectType(contact.ProjectType),
-		Message:     contact.Message,
-		ContactID:   contact.ID,
-		Timestamp:   time.Now().Format("02.01.2006 15:04"),
-	}
-
-	// Преобразуем в JSON
-	jsonData, err := json.Marshal(notification)
+	jsonData, err := json.Marshal(TelegramAlert{Title: title, Text: text})
 	if err != nil {
-		log.Printf("Ошибка сериализации данных для Telegram: %v", err)
+		log.Printf("Ошибка сериализации алерта для Telegram: %v", err)
 		return
 	}
 
-	// Отправляем POST запрос
-	resp, err := http.Post(
-		telegramBotURL,
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(baseURL+"/api/send-alert", "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		log.Printf("Ошибка отправки уведомления в Telegram: %v", err)
+		log.Printf("Ошибка отправки алерта в Telegram: %v", err)
 		return
 	}
 	defer resp.Body.Close()
 
-	// Проверяем статус ответа
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Telegram бот вернул ошибку: статус %d", resp.StatusCode)
+		log.Printf("Telegram бот вернул ошибку на алерт: статус %d", resp.StatusCode)
 		return
 	}
 
-	log.Printf("Уведомление успешно отправлено в Telegram для контакта: %s", contact.Name)
+	log.Printf("Алерт отправлен в Telegram: %s", title)
 }
 
-// SendTelegramNotificationAsync отправляет уведомление асинхронно в горутине
-func SendTelegramNotificationAsync(contact *models.ContactForm) {
+// SendTelegramAlertAsync отправляет алерт асинхронно в горутине
+func SendTelegramAlertAsync(title, text string) {
 	go func() {
-		// Восстановление после паники
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("Паника при отправке Telegram уведомления: %v", r)
+				log.Printf("Паника при отправке Telegram алерта: %v", r)
 			}
 		}()
 
-		SendTelegramNotification(contact)
+		SendTelegramAlert(title, text)
 	}()
 }
 
+// telegramBotBaseURL возвращает адрес бота без пути /api/send-notification
+func telegramBotBaseURL() string {
+	return strings.TrimSuffix(os.Getenv("TELEGRAM_BOT_URL"), "/api/send-notification")
+}
+
 // ValidateTelegramBotConnection проверяет доступность Telegram бота
 func ValidateTelegramBotConnection() error {
-	telegramBotURL := os.Getenv("TELEGRAM_BOT_URL")
-	if telegramBotURL == "" {
+	baseURL := telegramBotBaseURL()
+	if baseURL == "" {
 		return fmt.Errorf("TELEGRAM_BOT_URL не настроен")
 	}
 
 	// Проверяем healthcheck endpoint
-	healthURL := telegramBotURL[:len(telegramBotURL)-len("/api/send-notification")] + "/health"
+	healthURL := baseURL + "/health"
 
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Get(healthURL)
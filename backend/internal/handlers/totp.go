@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Параметры TOTP (RFC 6238) - значения по умолчанию, которые понимают все приложения
+// (Google Authenticator, Яндекс Ключ, 1Password и т.д.)
+const (
+	totpPeriod    = 30 // секунд на шаг
+	totpDigits    = 6
+	totpSkewSteps = 1 // допустимое расхождение часов: ±1 шаг
+	totpSecretLen = 20
+)
+
+// totpEncoding - base32 без паддинга, как в otpauth:// URI
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret генерирует новый секрет TOTP (160 бит, base32)
+func generateTOTPSecret() (string, error) {
+	buf := make([]byte, totpSecretLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// totpCode вычисляет код для шага step (HOTP из RFC 4226 со счётчиком = номер шага)
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение (RFC 4226, раздел 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// totpStep возвращает номер шага для момента времени
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// verifyTOTP проверяет код с учётом расхождения часов.
+// Возвращает шаг, которому соответствует код, - его нужно запомнить,
+// чтобы тот же код нельзя было использовать повторно (afterStep).
+func verifyTOTP(secret, code string, now time.Time, afterStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits || secret == "" {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for delta := int64(-totpSkewSteps); delta <= totpSkewSteps; delta++ {
+		step := current + delta
+		if step <= afterStep {
+			continue
+		}
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpIssuer - название сервиса в приложении-аутентификаторе (TOTP_ISSUER)
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "LED Admin"
+}
+
+// totpProvisioningURI формирует otpauth:// URI для QR-кода
+func totpProvisioningURI(secret, username string) string {
+	issuer := totpIssuer()
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret - секрет "12345678901234567890" из тестовых векторов RFC 6238 (SHA1) в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// В RFC коды 8-значные, приложение использует последние 6 цифр
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		code, err := totpCode(rfcSecret, totpStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "T=%d", unix)
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := totpStep(now)
+
+	got, ok := verifyTOTP(rfcSecret, "081 804", now, 0)
+	assert.True(t, ok, "пробелы в коде допускаются")
+	assert.Equal(t, step, got)
+
+	// Расхождение часов на один шаг
+	prev, _ := totpCode(rfcSecret, step-1)
+	_, ok = verifyTOTP(rfcSecret, prev, now, 0)
+	assert.True(t, ok)
+
+	old, _ := totpCode(rfcSecret, step-2)
+	_, ok = verifyTOTP(rfcSecret, old, now, 0)
+	assert.False(t, ok, "код двухшаговой давности не принимается")
+
+	// Уже использованный шаг не принимается повторно
+	_, ok = verifyTOTP(rfcSecret, "081804", now, step)
+	assert.False(t, ok)
+
+	_, ok = verifyTOTP(rfcSecret, "000000", now, 0)
+	assert.False(t, ok)
+	_, ok = verifyTOTP("", "081804", now, 0)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	t.Setenv("TOTP_ISSUER", "LED Test")
+
+	uri := totpProvisioningURI(rfcSecret, "admin")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/LED%20Test:admin?"))
+
+	parsed, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, rfcSecret, parsed.Query().Get("secret"))
+	assert.Equal(t, "LED Test", parsed.Query().Get("issuer"))
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32, "160 бит в base32 без паддинга")
+
+	_, err = totpCode(secret, 1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,117 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Тексты письма-подтверждения клиенту по умолчанию (пока в /admin/settings не задан свой)
+const (
+	DefaultAckSubject = "Ваша заявка {{.Reference}} принята"
+	DefaultAckBody    = `Здравствуйте, {{.Name}}!
+
+Спасибо за обращение. Ваша заявка {{.Reference}} принята, менеджер свяжется с вами в ближайшее время.
+{{- if .ProjectType}}
+Тип проекта: {{.ProjectType}}
+{{- end}}
+
+Если вопрос срочный - позвоните нам, назвав номер заявки.`
+)
+
+// AckEmail - данные письма-подтверждения клиенту после отправки формы
+type AckEmail struct {
+	ContactEmail
+	Reference string       // номер заявки для клиента
+	Site      SiteContacts // контакты компании из настроек сайта
+	Prices    []PriceLink  // позиции прайса по типу проекта
+	Body      string       // текст письма после подстановки шаблона (заполняет AckMessage)
+}
+
+// SiteContacts - контакты компании в подписи письма
+type SiteContacts struct {
+	Phone        string // для tel:
+	PhoneDisplay string
+	Email        string
+	Address      string
+	WorkHours    string
+	SiteURL      string
+}
+
+// PriceLink - ссылка на позицию прайса
+type PriceLink struct {
+	Title     string
+	PriceFrom string // цена "от" с разделителями тысяч
+	URL       string
+}
+
+var ackHTML = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/ack.html"))
+
+// ValidateAckTemplates проверяет тему и текст подтверждения перед сохранением в настройках
+func ValidateAckTemplates(subject, body string) error {
+	_, err := AckMessage(subject, body, AckEmail{Reference: "L-000001"})
+	return err
+}
+
+// AckMessage собирает письмо-подтверждение клиенту (без получателей).
+// subject и body - шаблоны text/template из настроек (пустые - значения по умолчанию),
+// в них доступны поля AckEmail: {{.Name}}, {{.Reference}}, {{.ProjectType}} и т.д.
+func AckMessage(subject, body string, data AckEmail) (Message, error) {
+	if strings.TrimSpace(subject) == "" {
+		subject = DefaultAckSubject
+	}
+	if strings.TrimSpace(body) == "" {
+		body = DefaultAckBody
+	}
+
+	renderedSubject, err := renderText("subject", subject, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("тема письма: %w", err)
+	}
+	renderedBody, err := renderText("body", body, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("текст письма: %w", err)
+	}
+	data.Body = strings.TrimSpace(renderedBody)
+
+	var text, html bytes.Buffer
+	text.WriteString(data.Body)
+	text.WriteString("\n")
+	if len(data.Prices) > 0 {
+		text.WriteString("\nЦены на подходящие решения:\n")
+		for _, p := range data.Prices {
+			fmt.Fprintf(&text, "- %s - от %s ₽: %s\n", p.Title, p.PriceFrom, p.URL)
+		}
+	}
+	text.WriteString("\n--\n")
+	for _, line := range []string{data.Site.PhoneDisplay, data.Site.Email, data.Site.Address, data.Site.WorkHours, data.Site.SiteURL} {
+		if line = strings.TrimSpace(line); line != "" {
+			text.WriteString(line + "\n")
+		}
+	}
+
+	if err := ackHTML.ExecuteTemplate(&html, "layout.html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.Join(strings.Fields(renderedSubject), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// renderText выполняет шаблон из настроек; ошибки выполнения (неизвестное поле) тоже возвращаются
+func renderText(name, tpl string, data AckEmail) (string, error) {
+	t, err := texttemplate.New(name).Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testAck() AckEmail {
+	return AckEmail{
+		ContactEmail: ContactEmail{ID: 42, Name: "Иван <script>", Company: "ООО Ромашка", ProjectType: "Наружные LED экраны"},
+		Reference:    "L-000042",
+		Site: SiteContacts{
+			Phone:        "+79675608858",
+			PhoneDisplay: "+7 967 560 88 58",
+			Email:        "info@example.com",
+			SiteURL:      "https://s-n-r.ru",
+		},
+		Prices: []PriceLink{{Title: "Билборд 6x3", PriceFrom: "1 200 000", URL: "https://s-n-r.ru/prices#price-5"}},
+	}
+}
+
+func TestAckMessage_Defaults(t *testing.T) {
+	msg, err := AckMessage("", "", testAck())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Ваша заявка L-000042 принята", msg.Subject)
+	assert.Contains(t, msg.Text, "Здравствуйте, Иван <script>!")
+	assert.Contains(t, msg.Text, "Тип проекта: Наружные LED экраны")
+	assert.Contains(t, msg.Text, "- Билборд 6x3 - от 1 200 000 ₽: https://s-n-r.ru/prices#price-5")
+	assert.Contains(t, msg.Text, "+7 967 560 88 58")
+	assert.Contains(t, msg.HTML, "Иван &lt;script&gt;", "HTML версия экранирует данные клиента")
+	assert.Contains(t, msg.HTML, `href="https://s-n-r.ru/prices#price-5"`)
+	assert.Contains(t, msg.HTML, `href="tel:&#43;79675608858"`)
+	assert.NotContains(t, msg.HTML, "Открыть в админке")
+}
+
+func TestAckMessage_CustomTemplate(t *testing.T) {
+	msg, err := AckMessage("{{.Reference}} - {{.Company}}", "Добрый день!\nЗаявка {{.Reference}}.", testAck())
+	assert.NoError(t, err)
+	assert.Equal(t, "L-000042 - ООО Ромашка", msg.Subject)
+	assert.Contains(t, msg.Text, "Добрый день!\nЗаявка L-000042.")
+}
+
+func TestValidateAckTemplates(t *testing.T) {
+	assert.NoError(t, ValidateAckTemplates("", ""))
+	assert.NoError(t, ValidateAckTemplates("Заявка {{.Reference}}", "{{if .ProjectType}}{{.ProjectType}}{{end}}"))
+	assert.Error(t, ValidateAckTemplates("Заявка {{.Reference", ""), "синтаксическая ошибка")
+	assert.Error(t, ValidateAckTemplates("", "{{.Unknown}}"), "неизвестное поле")
+}
@@ -0,0 +1,71 @@
+// Package mailer собирает email письма и отправляет их через SMTP.
+//
+// Письма собираются из шаблонов (HTML + текстовая версия, см. templates/).
+// Повторные попытки и журнал доставки - в outbox уведомлений (internal/notify):
+// канал email вызывает Sender для каждой доставки.
+//
+// Пример использования:
+//
+//	msg, err := mailer.NewContactMessage(mailer.ContactEmail{ID: 1, Name: "Иван", Phone: "+79991234567"})
+//	msg.To = []string{"sales@example.com"}
+//	err = mailer.NewSMTPSender(mailer.Config{Host: "smtp.example.com", Port: "587", From: "site@example.com"}).Send(msg)
+package mailer
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultTimeout - таймаут SMTP соединения по умолчанию
+const DefaultTimeout = 15 * time.Second
+
+// Config - настройки SMTP и получателей уведомлений
+type Config struct {
+	Host       string
+	Port       string // 465 - SMTPS (TLS сразу), иначе STARTTLS, если сервер его поддерживает
+	Username   string // пустой - без авторизации
+	Password   string
+	From       string   // адрес отправителя
+	Recipients []string // кому приходят уведомления о заявках и напоминаниях
+
+	Timeout time.Duration // таймаут SMTP соединения
+}
+
+// Enabled - настроена ли отправка (сервер, отправитель и хотя бы один получатель)
+func (c Config) Enabled() bool {
+	return c.Host != "" && c.From != "" && len(c.Recipients) > 0
+}
+
+// ParseRecipients разбирает список адресов через запятую или точку с запятой
+func ParseRecipients(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// Message - письмо с HTML и текстовой версией
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender доставляет одно письмо (SMTP в production, заглушка в тестах)
+type Sender interface {
+	Send(msg Message) error
+}
+
+// NewContactMessage собирает письмо о новой заявке (без получателей)
+func NewContactMessage(data ContactEmail) (Message, error) {
+	return renderMessage("new_contact", data)
+}
+
+// ReminderMessage собирает письмо-напоминание о перезвоне (без получателей)
+func ReminderMessage(data ContactEmail) (Message, error) {
+	return renderMessage("reminder", data)
+}
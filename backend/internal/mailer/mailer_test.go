@@ -0,0 +1,247 @@
+package mailer
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStub - минимальный SMTP сервер для тестов: принимает письма без TLS и авторизации.
+// Первые failFirst транзакций отклоняются кодом 451 (временная ошибка).
+type smtpStub struct {
+	ln        net.Listener
+	failFirst int
+
+	mu       sync.Mutex
+	attempts int
+	messages []stubMessage
+}
+
+// stubMessage - письмо, принятое заглушкой
+type stubMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+func startSMTPStub(t *testing.T, failFirst int) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("SMTP заглушка: %v", err)
+	}
+	s := &smtpStub{ln: ln, failFirst: failFirst}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+// config возвращает Config с адресом заглушки
+func (s *smtpStub) config() Config {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	return Config{
+		Host:       host,
+		Port:       port,
+		From:       "LED сайт <site@example.com>",
+		Recipients: []string{"sales@example.com", "boss@example.com"},
+		Timeout:    2 * time.Second,
+	}
+}
+
+func (s *smtpStub) received() []stubMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubMessage(nil), s.messages...)
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 stub ESMTP")
+	var msg stubMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 stub")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mu.Lock()
+			s.attempts++
+			fail := s.attempts <= s.failFirst
+			s.mu.Unlock()
+			if fail {
+				reply("451 try again later")
+				continue
+			}
+			msg = stubMessage{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.To = append(msg.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// parseParts разбирает письмо заглушки: заголовки и части multipart/alternative
+func parseParts(t *testing.T, data string) (mail.Header, map[string]string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("разбор письма: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Content-Type: %v", err)
+	}
+
+	parts := map[string]string{}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart() // quoted-printable декодируется автоматически
+		if err != nil {
+			break
+		}
+		body, _ := io.ReadAll(p)
+		mediaType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[mediaType] = string(body)
+	}
+	return m.Header, parts
+}
+
+func testContact() ContactEmail {
+	return ContactEmail{
+		ID:          42,
+		Name:        "Иван <script>",
+		Phone:       "+79991234567",
+		Email:       "ivan@example.com",
+		Company:     "ООО Ромашка",
+		ProjectType: "Наружные LED экраны",
+		Message:     "Нужен экран 3x2 м",
+		CreatedAt:   "16.10.2026 12:00",
+		RemindAt:    "17.10.2026 10:00",
+		AdminURL:    "https://s-n-r.ru/admin/contacts?search=%2B79991234567",
+	}
+}
+
+// send собирает письмо и отправляет его получателям из настроек заглушки
+func send(t *testing.T, stub *smtpStub, build func(ContactEmail) (Message, error)) error {
+	t.Helper()
+	cfg := stub.config()
+	msg, err := build(testContact())
+	if !assert.NoError(t, err) {
+		return err
+	}
+	msg.To = cfg.Recipients
+	return NewSMTPSender(cfg).Send(msg)
+}
+
+func TestNewContactMessage_DeliversHTMLAndText(t *testing.T) {
+	stub := startSMTPStub(t, 0)
+	assert.NoError(t, send(t, stub, NewContactMessage))
+
+	got := stub.received()
+	if !assert.Len(t, got, 1) {
+		return
+	}
+	assert.Equal(t, "site@example.com", got[0].From)
+	assert.Equal(t, []string{"sales@example.com", "boss@example.com"}, got[0].To)
+
+	header, parts := parseParts(t, got[0].Data)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	assert.Equal(t, "Новая заявка #42: Иван <script> (ООО Ромашка)", subject)
+
+	// Имя отправителя кодируется по RFC 2047: в заголовках только ASCII
+	assert.True(t, strings.HasPrefix(header.Get("From"), "=?utf-8?"), header.Get("From"))
+	from, err := header.AddressList("From")
+	if assert.NoError(t, err) && assert.Len(t, from, 1) {
+		assert.Equal(t, "LED сайт", from[0].Name)
+		assert.Equal(t, "site@example.com", from[0].Address)
+	}
+	assert.Equal(t, "<sales@example.com>, <boss@example.com>", header.Get("To"))
+
+	assert.Contains(t, parts["text/plain"], "Телефон: +79991234567")
+	assert.Contains(t, parts["text/plain"], "Нужен экран 3x2 м")
+	assert.Contains(t, parts["text/html"], "Иван &lt;script&gt;", "HTML версия экранирует данные заявки")
+	assert.Contains(t, parts["text/html"], `href="https://s-n-r.ru/admin/contacts?search=%2B79991234567"`)
+}
+
+func TestReminderMessage_Template(t *testing.T) {
+	stub := startSMTPStub(t, 0)
+	assert.NoError(t, send(t, stub, ReminderMessage))
+
+	got := stub.received()
+	if !assert.Len(t, got, 1) {
+		return
+	}
+	header, parts := parseParts(t, got[0].Data)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	assert.Equal(t, "Напоминание: Иван <script>, +79991234567 (17.10.2026 10:00)", subject)
+	assert.Contains(t, parts["text/plain"], "Время напоминания: 17.10.2026 10:00")
+	assert.Contains(t, parts["text/html"], "перезвонить по заявке #42")
+}
+
+func TestSMTPSender_TemporaryFailure(t *testing.T) {
+	// Повтор - забота outbox: отправитель только возвращает ошибку
+	stub := startSMTPStub(t, 1)
+	assert.Error(t, send(t, stub, NewContactMessage))
+	assert.NoError(t, send(t, stub, NewContactMessage))
+	assert.Len(t, stub.received(), 1)
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	assert.False(t, Config{Host: "smtp.example.com", From: "a@example.com"}.Enabled(), "без получателей")
+	assert.True(t, Config{Host: "smtp.example.com", From: "a@example.com", Recipients: []string{"b@example.com"}}.Enabled())
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, ParseRecipients(" a@example.com; b@example.com ,"))
+}
@@ -0,0 +1,173 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPSender отправляет письма через SMTP сервер из Config
+type SMTPSender struct {
+	cfg Config
+}
+
+// NewSMTPSender создаёт отправителя через SMTP
+func NewSMTPSender(cfg Config) *SMTPSender {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+// Send отправляет одно письмо. Порт 465 - TLS с самого начала (SMTPS),
+// иначе соединение повышается до TLS через STARTTLS, если сервер его поддерживает.
+func (s *SMTPSender) Send(msg Message) error {
+	body, err := buildMessage(s.cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: s.cfg.Timeout}
+	if s.cfg.Port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("подключение к SMTP %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(s.cfg.Timeout))
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("приветствие SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && s.cfg.Port != "465" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("авторизация SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(addressOnly(s.cfg.From)); err != nil {
+		return fmt.Errorf("MAIL FROM: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(addressOnly(to)); err != nil {
+			return fmt.Errorf("RCPT TO %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("отправка тела письма: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("отправка тела письма: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage собирает письмо multipart/alternative (текст + HTML) в UTF-8
+func buildMessage(from string, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	to := make([]string, len(msg.To))
+	for i, addr := range msg.To {
+		to[i] = formatAddress(addr)
+	}
+	header := []struct{ key, value string }{
+		{"From", formatAddress(from)},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.BEncoding.Encode("UTF-8", msg.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(from)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", `multipart/alternative; boundary="` + mw.Boundary() + `"`},
+	}
+	for _, h := range header {
+		buf.WriteString(h.key + ": " + h.value + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+	for _, p := range parts {
+		if p.body == "" {
+			continue
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID генерирует уникальный Message-ID в домене отправителя
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(addressOnly(from), "@"); at >= 0 {
+		domain = addressOnly(from)[at+1:]
+	}
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return "<" + hex.EncodeToString(buf) + "@" + domain + ">"
+}
+
+// formatAddress записывает адрес для заголовка: имя кодируется по RFC 2047 ("LED сайт" -> =?utf-8?...?=)
+func formatAddress(s string) string {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.String()
+	}
+	return strings.TrimSpace(s)
+}
+
+// addressOnly возвращает адрес из строки вида "Имя <user@example.com>"
+func addressOnly(s string) string {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Address
+	}
+	return strings.TrimSpace(s)
+}
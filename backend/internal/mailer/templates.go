@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// ContactEmail - данные заявки для писем (поля уже отформатированы для показа)
+type ContactEmail struct {
+	ID          uint
+	Name        string
+	Phone       string
+	Email       string
+	Company     string
+	ProjectType string // тип проекта на русском
+	Message     string
+	Source      string
+	AssignedTo  string // логин ответственного менеджера
+	CreatedAt   string // дата заявки по МСК
+	RemindAt    string // время напоминания по МСК (только для напоминаний)
+	AdminURL    string // ссылка на заявку в админке
+}
+
+// Каждое письмо состоит из трёх шаблонов: <name>.subject.txt, <name>.txt и <name>.html
+// (HTML вставляется в общий layout.html)
+var (
+	htmlTemplates = map[string]*htmltemplate.Template{}
+	textTemplates = map[string]*texttemplate.Template{}
+)
+
+func init() {
+	for _, name := range []string{"new_contact", "reminder"} {
+		htmlTemplates[name] = htmltemplate.Must(htmltemplate.ParseFS(templateFS,
+			"templates/layout.html", "templates/"+name+".html"))
+		textTemplates[name] = texttemplate.Must(texttemplate.ParseFS(templateFS,
+			"templates/"+name+".subject.txt", "templates/"+name+".txt"))
+	}
+}
+
+// renderMessage собирает письмо name из шаблонов (без получателей)
+func renderMessage(name string, data any) (Message, error) {
+	var subject, text, html bytes.Buffer
+
+	txt := textTemplates[name]
+	if err := txt.ExecuteTemplate(&subject, name+".subject.txt", data); err != nil {
+		return Message{}, err
+	}
+	if err := txt.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates[name].ExecuteTemplate(&html, "layout.html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"ledsite/internal/attribution"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Attribution запоминает в cookie, откуда пришёл посетитель публичных страниц (см. internal/attribution).
+//
+// Первый визит записывается один раз и хранится 90 дней. Последний перезаписывается при каждом
+// заходе с UTM-меткой, рекламным click ID или с внешнего сайта; внутренние переходы и прямые
+// заходы его не меняют. SubmitContact сохраняет оба визита вместе с заявкой.
+//
+// Пример использования:
+//
+//	pages := router.Group("/", middleware.Attribution())
+func Attribution() gin.HandlerFunc {
+	secure := os.Getenv("ENVIRONMENT") == "production"
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		now := time.Now().UTC()
+		touch, isNew := attribution.FromRequest(c.Request, now)
+		_, _, hasFirst := attribution.FromCookies(c.Request)
+		if !hasFirst {
+			if !isNew {
+				touch = attribution.Direct(c.Request, now)
+			}
+			c.SetCookie(attribution.FirstCookie, attribution.Encode(touch), attribution.CookieMaxAge, "/", "", secure, true)
+		}
+		if isNew || !hasFirst {
+			c.SetCookie(attribution.LastCookie, attribution.Encode(touch), attribution.CookieMaxAge, "/", "", secure, true)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ledsite/internal/attribution"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAttribution проверяет запись первого и последнего визита в cookie
+func TestAttribution(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(Attribution())
+	router.GET("/*path", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	jar := map[string]*http.Cookie{}
+	visit := func(target, referrer string) map[string]bool {
+		req, _ := http.NewRequest("GET", target, nil)
+		req.Host = "s-n-r.ru"
+		if referrer != "" {
+			req.Header.Set("Referer", referrer)
+		}
+		for _, c := range jar {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		set := map[string]bool{}
+		for _, c := range w.Result().Cookies() {
+			jar[c.Name] = c
+			set[c.Name] = true
+		}
+		return set
+	}
+	touch := func(name string) string {
+		tt, _ := attribution.Decode(jar[name].Value)
+		return tt.Source + "/" + tt.Medium
+	}
+
+	// Первый визит - прямой заход: записываются оба визита
+	set := visit("/", "")
+	assert.True(t, set[attribution.FirstCookie])
+	assert.True(t, set[attribution.LastCookie])
+	assert.Equal(t, "(direct)/(none)", touch(attribution.LastCookie))
+
+	// Переход по рекламе: меняется только последний визит
+	set = visit("/prices?utm_source=yandex&utm_medium=cpc", "")
+	assert.False(t, set[attribution.FirstCookie])
+	assert.True(t, set[attribution.LastCookie])
+	assert.Equal(t, "(direct)/(none)", touch(attribution.FirstCookie))
+	assert.Equal(t, "yandex/cpc", touch(attribution.LastCookie))
+
+	// Внутренний переход и повторный прямой заход ничего не меняют
+	assert.Empty(t, visit("/contact", "https://s-n-r.ru/prices"))
+	assert.Empty(t, visit("/", ""))
+	assert.Equal(t, "yandex/cpc", touch(attribution.LastCookie))
+
+	// Переход из поиска
+	visit("/projects", "https://www.google.ru/")
+	assert.Equal(t, "google/organic", touch(attribution.LastCookie))
+}
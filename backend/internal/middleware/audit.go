@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"ledsite/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditStore - журнал изменений админки (реализуется *handlers.Handlers).
+//
+// BeginAudit снимает состояние сущности до обработчика, FinishAudit - после него
+// и пишет в журнал изменившиеся поля, если запрос завершился успешно.
+type AuditStore interface {
+	BeginAudit(c *gin.Context) *handlers.AuditRecord
+	FinishAudit(c *gin.Context, rec *handlers.AuditRecord)
+}
+
+// Audit записывает в журнал каждый изменяющий запрос (POST, PUT, PATCH, DELETE).
+//
+// Должен стоять после AuthMiddleware (admin_id берётся из контекста).
+// GET/HEAD запросы пропускаются без обращения к БД.
+//
+// Пример использования:
+//
+//	admin.Use(middleware.AuthMiddleware(h), middleware.Audit(h))
+func Audit(store AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		rec := store.BeginAudit(c)
+		c.Next()
+		store.FinishAudit(c, rec)
+	}
+}
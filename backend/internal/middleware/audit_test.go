@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ledsite/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubAudit - журнал изменений в памяти: считает вызовы и статус ответа
+type stubAudit struct {
+	begun    int
+	statuses []int
+}
+
+func (s *stubAudit) BeginAudit(c *gin.Context) *handlers.AuditRecord {
+	s.begun++
+	return &handlers.AuditRecord{}
+}
+
+func (s *stubAudit) FinishAudit(c *gin.Context, rec *handlers.AuditRecord) {
+	s.statuses = append(s.statuses, c.Writer.Status())
+}
+
+// TestAudit_OnlyMutatingRequests проверяет, что GET не попадает в журнал
+func TestAudit_OnlyMutatingRequests(t *testing.T) {
+	store := &stubAudit{}
+	router := setupTestRouter()
+	router.Use(Audit(store))
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) }
+	router.GET("/admin/projects", ok)
+	router.POST("/admin/projects", ok)
+	router.DELETE("/admin/projects/:id", func(c *gin.Context) { c.JSON(http.StatusNotFound, gin.H{"error": "нет"}) })
+
+	for _, r := range []struct{ method, path string }{
+		{"GET", "/admin/projects"},
+		{"POST", "/admin/projects"},
+		{"DELETE", "/admin/projects/1"},
+	} {
+		req, _ := http.NewRequest(r.method, r.path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 2, store.begun)
+	assert.Equal(t, []int{http.StatusOK, http.StatusNotFound}, store.statuses,
+		"FinishAudit видит итоговый статус и сам решает, писать ли запись")
+}
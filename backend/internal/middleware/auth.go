@@ -1,7 +1,10 @@
 // Package middleware содержит HTTP middleware функции для обработки запросов.
 //
 // Middleware выполняются до основных handlers и используются для:
-//   - Аутентификации и авторизации (AuthMiddleware)
+//   - Аутентификации и авторизации (AuthMiddleware, AuthAPIMiddleware, RequirePermission)
+//   - Защиты от CSRF в админке (CSRF)
+//   - Журнала изменений в админке (Audit)
+//   - Проверки подписи межсервисных запросов (ServiceAuthMiddleware)
 //   - Логирования запросов (встроенный gin.Logger)
 //   - Обработки паник (встроенный gin.Recovery)
 //   - CORS (опционально)
@@ -16,16 +19,27 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// SessionStore - серверное хранилище сессий админки (реализуется *handlers.Handlers).
+//
+// CheckSession проверяет, что сессия access токена (claim "jti") не отозвана,
+// и обновляет роль в claims из БД. RefreshSession выдаёт новый access токен
+// по refresh cookie, ротируя его.
+type SessionStore interface {
+	CheckSession(claims *handlers.JWTClaims) error
+	RefreshSession(c *gin.Context) (*handlers.JWTClaims, error)
+}
+
 // AuthMiddleware проверяет наличие и валидность JWT токена для защищённых роутов.
 //
 // Выполняет следующие проверки:
 //  1. Извлекает JWT токен из HTTP-only cookie "admin_token"
 //  2. Валидирует токен (подпись, срок действия)
-//  3. Извлекает claims (admin_id, admin_username)
-//  4. Сохраняет данные админа в gin.Context для использования в handlers
+//  3. Проверяет серверную сессию по JTI (не отозвана, админ активен)
+//  4. Если access токен отсутствует или истёк - обновляет его по cookie "admin_refresh"
+//  5. Сохраняет данные админа в gin.Context для использования в handlers
 //
 // При ошибке:
-//   - Удаляет невалидный cookie
+//   - Удаляет cookie токенов
 //   - Перенаправляет на /admin/login
 //   - Вызывает c.Abort() для прерывания цепочки обработчиков
 //
@@ -34,35 +48,76 @@ import (
 // Пример использования:
 //
 //	admin := router.Group("/admin")
-//	admin.Use(middleware.AuthMiddleware())
+//	admin.Use(middleware.AuthMiddleware(h))
 //	{
 //	    admin.GET("/", h.AdminDashboard)
 //	}
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(sessions SessionStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Получаем токен из cookie
-		token, err := c.Cookie("admin_token")
-		if err != nil {
-			// Нет cookie - перенаправляем на страницу входа
+		claims, ok := authenticate(c, sessions)
+		if !ok {
 			c.Redirect(http.StatusFound, "/admin/login")
 			c.Abort()
 			return
 		}
 
-		// Проверяем валидность токена
-		claims, err := handlers.ValidateJWT(token)
-		if err != nil {
-			// Токен невалидный - удаляем cookie и перенаправляем
-			c.SetCookie("admin_token", "", -1, "/", "", false, true)
-			c.Redirect(http.StatusFound, "/admin/login")
-			c.Abort()
+		setAdminContext(c, claims)
+		c.Next()
+	}
+}
+
+// AuthAPIMiddleware - вариант AuthMiddleware для JSON API админки (/admin/api/*).
+//
+// Проверки те же, что и в AuthMiddleware, но вместо редиректа на /admin/login
+// возвращает 401 с JSON {error}, чтобы fetch-запросы на фронтенде
+// могли корректно обработать истёкшую сессию.
+//
+// Пример использования:
+//
+//	adminAPI := router.Group("/admin/api")
+//	adminAPI.Use(middleware.AuthAPIMiddleware(h))
+func AuthAPIMiddleware(sessions SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := authenticate(c, sessions)
+		if !ok {
+			abortJSON(c, http.StatusUnauthorized, "Требуется авторизация")
 			return
 		}
 
-		// Сохраняем информацию о пользователе в контекст
-		c.Set("admin_id", claims.UserID)
-		c.Set("admin_username", claims.Username)
-
+		setAdminContext(c, claims)
 		c.Next()
 	}
 }
+
+// authenticate извлекает и валидирует JWT из cookie "admin_token" и проверяет его сессию.
+// Истёкший или отсутствующий access токен обновляется по refresh cookie.
+// При неудаче cookie токенов удаляются.
+func authenticate(c *gin.Context, sessions SessionStore) (*handlers.JWTClaims, bool) {
+	if token, err := c.Cookie("admin_token"); err == nil {
+		if claims, err := handlers.ValidateJWT(token); err == nil {
+			if err := sessions.CheckSession(claims); err != nil {
+				// Сессия отозвана (выход, деактивация) - refresh тоже недействителен
+				handlers.ClearAuthCookies(c)
+				return nil, false
+			}
+			return claims, true
+		}
+	}
+
+	// Access токена нет или он истёк - пробуем скользящее продление по refresh cookie
+	claims, err := sessions.RefreshSession(c)
+	if err != nil {
+		handlers.ClearAuthCookies(c)
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// setAdminContext сохраняет информацию о пользователе в контекст
+func setAdminContext(c *gin.Context, claims *handlers.JWTClaims) {
+	c.Set("admin_id", claims.UserID)
+	c.Set("admin_username", claims.Username)
+	c.Set("admin_role", claims.Role)
+	c.Set("admin_session_jti", claims.ID)
+}
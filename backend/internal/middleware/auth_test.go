@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"ledsite/internal/handlers"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
@@ -26,6 +28,27 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
+// stubSessions - хранилище сессий для тестов: все сессии активны,
+// refresh работает только при заданном refreshed, revoked отзывает проверку access токена
+type stubSessions struct {
+	revoked   bool
+	refreshed *handlers.JWTClaims
+}
+
+func (s stubSessions) CheckSession(claims *handlers.JWTClaims) error {
+	if s.revoked {
+		return handlers.ErrSessionInvalid
+	}
+	return nil
+}
+
+func (s stubSessions) RefreshSession(c *gin.Context) (*handlers.JWTClaims, error) {
+	if s.refreshed == nil {
+		return nil, handlers.ErrSessionInvalid
+	}
+	return s.refreshed, nil
+}
+
 // createTestToken создает валидный JWT токен для тестов
 func createTestToken(adminID uint, expiresIn time.Duration) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
@@ -53,7 +76,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	router := setupTestRouter()
 
 	// Защищенный роут
-	router.GET("/admin/test", AuthMiddleware(), func(c *gin.Context) {
+	router.GET("/admin/test", AuthMiddleware(stubSessions{}), func(c *gin.Context) {
 		adminID, _ := c.Get("admin_id")
 		c.JSON(http.StatusOK, gin.H{"admin_id": adminID})
 	})
@@ -79,7 +102,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 func TestAuthMiddleware_MissingToken(t *testing.T) {
 	router := setupTestRouter()
 
-	router.GET("/admin/test", AuthMiddleware(), func(c *gin.Context) {
+	router.GET("/admin/test", AuthMiddleware(stubSessions{}), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 
@@ -97,7 +120,7 @@ func TestAuthMiddleware_MissingToken(t *testing.T) {
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	router := setupTestRouter()
 
-	router.GET("/admin/test", AuthMiddleware(), func(c *gin.Context) {
+	router.GET("/admin/test", AuthMiddleware(stubSessions{}), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 
@@ -119,7 +142,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 func TestAuthMiddleware_ExpiredToken(t *testing.T) {
 	router := setupTestRouter()
 
-	router.GET("/admin/test", AuthMiddleware(), func(c *gin.Context) {
+	router.GET("/admin/test", AuthMiddleware(stubSessions{}), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 
@@ -146,7 +169,7 @@ func TestAuthMiddleware_AdminIDInContext(t *testing.T) {
 
 	var capturedAdminID uint
 
-	router.GET("/admin/test", AuthMiddleware(), func(c *gin.Context) {
+	router.GET("/admin/test", AuthMiddleware(stubSessions{}), func(c *gin.Context) {
 		adminID, exists := c.Get("admin_id")
 		assert.True(t, exists, "admin_id должен быть в контексте")
 
@@ -175,7 +198,7 @@ func TestAuthMiddleware_AdminIDInContext(t *testing.T) {
 func TestAuthMiddleware_MultipleRequests(t *testing.T) {
 	router := setupTestRouter()
 
-	router.GET("/admin/test", AuthMiddleware(), func(c *gin.Context) {
+	router.GET("/admin/test", AuthMiddleware(stubSessions{}), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 
@@ -196,3 +219,117 @@ func TestAuthMiddleware_MultipleRequests(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code, "Запрос %d должен быть успешным", i+1)
 	}
 }
+
+// TestAuthAPIMiddleware_ValidToken проверяет доступ к JSON API с валидным токеном
+func TestAuthAPIMiddleware_ValidToken(t *testing.T) {
+	router := setupTestRouter()
+
+	router.GET("/admin/api/test", AuthAPIMiddleware(stubSessions{}), func(c *gin.Context) {
+		adminID, _ := c.Get("admin_id")
+		c.JSON(http.StatusOK, gin.H{"admin_id": adminID})
+	})
+
+	tokenString, err := createTestToken(7, time.Hour)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/admin/api/test", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "admin_token",
+		Value: tokenString,
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"admin_id":7`)
+}
+
+// TestAuthAPIMiddleware_Unauthorized проверяет 401 JSON вместо редиректа
+func TestAuthAPIMiddleware_Unauthorized(t *testing.T) {
+	router := setupTestRouter()
+
+	router.GET("/admin/api/test", AuthAPIMiddleware(stubSessions{}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	expired, err := createTestToken(1, -time.Hour)
+	assert.NoError(t, err)
+
+	cases := map[string]string{
+		"без токена":       "",
+		"невалидный токен": "invalid.token.here",
+		"истёкший токен":   expired,
+	}
+
+	for name, token := range cases {
+		req, _ := http.NewRequest("GET", "/admin/api/test", nil)
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "admin_token", Value: token})
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+		assert.Empty(t, w.Header().Get("Location"), name)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json", name)
+		assert.Contains(t, w.Body.String(), `"error"`, name)
+	}
+}
+
+// TestAuthMiddleware_RevokedSession проверяет, что отозванная сессия не пускает даже с валидным JWT
+func TestAuthMiddleware_RevokedSession(t *testing.T) {
+	router := setupTestRouter()
+
+	router.GET("/admin/test", AuthMiddleware(stubSessions{revoked: true}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	tokenString, err := createTestToken(1, time.Hour)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/admin/test", nil)
+	req.AddCookie(&http.Cookie{Name: "admin_token", Value: tokenString})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/admin/login", w.Header().Get("Location"))
+
+	cleared := map[string]bool{}
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge < 0 {
+			cleared[c.Name] = true
+		}
+	}
+	assert.True(t, cleared["admin_token"], "cookie admin_token должен быть удалён")
+	assert.True(t, cleared["admin_refresh"], "cookie admin_refresh должен быть удалён")
+}
+
+// TestAuthMiddleware_RefreshExpiredToken проверяет продление сессии по refresh при истёкшем access токене
+func TestAuthMiddleware_RefreshExpiredToken(t *testing.T) {
+	router := setupTestRouter()
+
+	refreshed := &handlers.JWTClaims{UserID: 5, Username: "testuser", Role: "viewer"}
+	refreshed.ID = "session-jti"
+
+	router.GET("/admin/test", AuthMiddleware(stubSessions{refreshed: refreshed}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"admin_id": c.GetUint("admin_id"),
+			"jti":      c.GetString("admin_session_jti"),
+		})
+	})
+
+	expired, err := createTestToken(5, -time.Hour)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/admin/test", nil)
+	req.AddCookie(&http.Cookie{Name: "admin_token", Value: expired})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"admin_id":5`)
+	assert.Contains(t, w.Body.String(), `"jti":"session-jti"`)
+}
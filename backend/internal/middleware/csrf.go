@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"ledsite/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Где браузер передаёт CSRF-токен: заголовок для fetch, поле для обычных HTML форм
+const (
+	HeaderCSRFToken = "X-CSRF-Token"
+	FormCSRFToken   = "csrf_token"
+)
+
+// CSRF защищает изменяющие запросы админки от межсайтовой подделки (synchronizer token).
+//
+// Токен выводится из серверной сессии (handlers.CSRFToken) и кладётся в gin.Context
+// под ключом "csrf_token" - renderAdmin передаёт его в admin_base.html (<meta name="csrf-token">),
+// откуда его берут fetch-запросы admin-base.js и скрытые поля форм.
+//
+// GET/HEAD/OPTIONS пропускаются. Для POST, PUT, PATCH, DELETE токен из заголовка X-CSRF-Token
+// или поля формы csrf_token должен совпасть с токеном сессии, иначе:
+//   - для обычной отправки формы (Accept: text/html) рендерится страница ошибки 403
+//   - для fetch/API запросов возвращается 403 JSON {error}
+//
+// Должен стоять после AuthMiddleware/AuthAPIMiddleware (JTI сессии берётся из контекста).
+//
+// Пример использования:
+//
+//	admin.Use(middleware.AuthMiddleware(h), middleware.CSRF())
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := handlers.CSRFToken(c.GetString("admin_session_jti"))
+		c.Set("csrf_token", expected)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		sent := c.GetHeader(HeaderCSRFToken)
+		if sent == "" {
+			sent = c.PostForm(FormCSRFToken)
+		}
+		if expected != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(expected)) == 1 {
+			c.Next()
+			return
+		}
+
+		log.Printf("CSRF: отклонён запрос %s %s от %s (токен %s)",
+			c.Request.Method, c.Request.URL.Path, c.GetString("admin_username"), csrfFailure(sent))
+
+		if strings.Contains(c.GetHeader("Accept"), "text/html") {
+			c.HTML(http.StatusForbidden, "admin_base.html", gin.H{
+				"title":     "Запрос отклонён",
+				"PageID":    "admin-error",
+				"error":     "Страница устарела. Обновите её и повторите действие",
+				"can":       handlers.PermissionSet(c.GetString("admin_role")),
+				"csrfToken": expected,
+			})
+			c.Abort()
+			return
+		}
+
+		abortJSON(c, http.StatusForbidden, "Недействительный CSRF-токен. Обновите страницу")
+	}
+}
+
+// csrfFailure - причина отказа для лога (сам токен не логируется)
+func csrfFailure(sent string) string {
+	if sent == "" {
+		return "отсутствует"
+	}
+	return "не совпадает"
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"ledsite/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupCSRFRouter создает router с сессией jti в контексте (как после AuthMiddleware)
+func setupCSRFRouter(jti string) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_session_jti", jti)
+		c.Next()
+	}, CSRF())
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"csrf_token": c.GetString("csrf_token")})
+	}
+	router.GET("/admin/contacts", ok)
+	router.POST("/admin/contacts/bulk", ok)
+	router.DELETE("/admin/projects/:id", ok)
+	return router
+}
+
+// TestCSRF_SafeMethodsPass проверяет, что GET проходит без токена и получает токен сессии
+func TestCSRF_SafeMethodsPass(t *testing.T) {
+	router := setupCSRFRouter("session-1")
+
+	req, _ := http.NewRequest("GET", "/admin/contacts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), handlers.CSRFToken("session-1"))
+}
+
+// TestCSRF_MissingToken проверяет отказ изменяющего запроса без токена
+func TestCSRF_MissingToken(t *testing.T) {
+	router := setupCSRFRouter("session-1")
+
+	for _, method := range []string{"POST", "DELETE"} {
+		path := "/admin/contacts/bulk"
+		if method == "DELETE" {
+			path = "/admin/projects/1"
+		}
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, method)
+		assert.Contains(t, w.Body.String(), `"error"`)
+	}
+}
+
+// TestCSRF_MismatchedToken проверяет отказ при чужом или подделанном токене
+func TestCSRF_MismatchedToken(t *testing.T) {
+	router := setupCSRFRouter("session-1")
+
+	for _, token := range []string{"forged", handlers.CSRFToken("session-2")} {
+		req, _ := http.NewRequest("DELETE", "/admin/projects/1", nil)
+		req.Header.Set(HeaderCSRFToken, token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, "токен другой сессии не подходит")
+	}
+
+	// Поддельное поле формы тоже отклоняется
+	form := url.Values{FormCSRFToken: {"forged"}}
+	req, _ := http.NewRequest("POST", "/admin/contacts/bulk", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestCSRF_NoSession проверяет, что без сессии пустой токен не считается совпадением
+func TestCSRF_NoSession(t *testing.T) {
+	router := setupCSRFRouter("")
+
+	req, _ := http.NewRequest("POST", "/admin/contacts/bulk", nil)
+	req.Header.Set(HeaderCSRFToken, "")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestCSRF_ValidToken проверяет прохождение с токеном в заголовке (fetch) и в поле формы
+func TestCSRF_ValidToken(t *testing.T) {
+	router := setupCSRFRouter("session-1")
+	token := handlers.CSRFToken("session-1")
+
+	req, _ := http.NewRequest("DELETE", "/admin/projects/1", nil)
+	req.Header.Set(HeaderCSRFToken, token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	form := url.Values{FormCSRFToken: {token}, "action": {"archive"}}
+	req, _ = http.NewRequest("POST", "/admin/contacts/bulk", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"ledsite/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission проверяет, что роль текущего админа имеет указанное право.
+//
+// Должен стоять после AuthMiddleware/AuthAPIMiddleware (роль берётся из "admin_role").
+// При отказе:
+//   - для обычной навигации браузера (Accept: text/html) рендерит страницу ошибки 403
+//   - для fetch/API запросов возвращает 403 JSON {error}
+//
+// Пример использования:
+//
+//	admin.Use(middleware.AuthMiddleware(h), middleware.RequirePermission(handlers.PermView))
+//	ct.DELETE("/:id", middleware.RequirePermission(handlers.PermContactsDelete), h.DeleteContact)
+func RequirePermission(perm handlers.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("admin_role")
+		if handlers.RoleHasPermission(role, perm) {
+			c.Next()
+			return
+		}
+
+		log.Printf("Доступ запрещён: %s (роль %q) -> %s %s [%s]",
+			c.GetString("admin_username"), role, c.Request.Method, c.Request.URL.Path, perm)
+
+		if c.Request.Method == http.MethodGet && strings.Contains(c.GetHeader("Accept"), "text/html") {
+			c.HTML(http.StatusForbidden, "admin_base.html", gin.H{
+				"title":  "Доступ запрещён",
+				"PageID": "admin-error",
+				"error":  "Недостаточно прав для этого действия",
+				"can":    handlers.PermissionSet(role),
+			})
+			c.Abort()
+			return
+		}
+
+		abortJSON(c, http.StatusForbidden, "Недостаточно прав")
+	}
+}
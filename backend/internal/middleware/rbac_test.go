@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledsite/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupRBACRouter создает router, где роль задаётся напрямую в контексте
+func setupRBACRouter(role string, perm handlers.Permission) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("admin_role", role)
+		c.Next()
+	})
+	router.DELETE("/admin/contacts/:id", RequirePermission(perm), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	return router
+}
+
+// TestRequirePermission_Allowed проверяет доступ при наличии права
+func TestRequirePermission_Allowed(t *testing.T) {
+	router := setupRBACRouter("owner", handlers.PermContactsDelete)
+
+	req, _ := http.NewRequest("DELETE", "/admin/contacts/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestRequirePermission_Forbidden проверяет 403 JSON при отсутствии права
+func TestRequirePermission_Forbidden(t *testing.T) {
+	for _, role := range []string{"manager", "editor", "viewer", ""} {
+		router := setupRBACRouter(role, handlers.PermContactsDelete)
+
+		req, _ := http.NewRequest("DELETE", "/admin/contacts/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, "роль %q", role)
+		assert.Contains(t, w.Body.String(), `"error"`)
+	}
+}
+
+// TestRequirePermission_WithToken проверяет, что роль из JWT доходит до проверки прав
+func TestRequirePermission_WithToken(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/admin/settings", AuthMiddleware(stubSessions{}), RequirePermission(handlers.PermSettingsEdit), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	// Токен тестового хелпера без роли - права только на просмотр
+	tokenString, err := createTestToken(1, time.Hour)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/admin/settings", nil)
+	req.AddCookie(&http.Cookie{Name: "admin_token", Value: tokenString})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Заголовки подписи межсервисных запросов (Telegram бот -> backend)
+const (
+	HeaderSignature          = "X-Signature"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp"
+	HeaderSignatureNonce     = "X-Signature-Nonce"
+)
+
+// maxSignedBodySize ограничивает размер тела, которое читается для проверки подписи
+const maxSignedBodySize = 1 << 20 // 1MB
+
+// ServiceAuthConfig содержит настройки проверки межсервисных запросов.
+//
+// Поля:
+//   - Secret: общий секрет HMAC-SHA256 (пустой = все запросы отклоняются)
+//   - MaxSkew: допустимое расхождение часов между сервисами
+//   - AllowedNets: разрешённые IP/подсети клиента (пусто = без ограничения по IP)
+//   - TrustedProxies: прокси, которым доверяем X-Forwarded-For
+type ServiceAuthConfig struct {
+	Secret         string
+	MaxSkew        time.Duration
+	AllowedNets    []*net.IPNet
+	TrustedProxies []*net.IPNet
+}
+
+// LoadServiceAuthConfig читает настройки Telegram API из переменных окружения.
+//
+// Переменные:
+//   - TELEGRAM_API_SECRET: общий секрет с Telegram ботом
+//   - TELEGRAM_API_ALLOWED_IPS: IP или CIDR через запятую (опционально)
+//   - TRUSTED_PROXIES: IP или CIDR reverse proxy через запятую (опционально)
+//   - TELEGRAM_API_MAX_SKEW_SEC: допустимое расхождение времени, по умолчанию 300
+func LoadServiceAuthConfig() ServiceAuthConfig {
+	skew := 300
+	if v, err := strconv.Atoi(os.Getenv("TELEGRAM_API_MAX_SKEW_SEC")); err == nil && v > 0 {
+		skew = v
+	}
+
+	return ServiceAuthConfig{
+		Secret:         os.Getenv("TELEGRAM_API_SECRET"),
+		MaxSkew:        time.Duration(skew) * time.Second,
+		AllowedNets:    ParseNetList(os.Getenv("TELEGRAM_API_ALLOWED_IPS")),
+		TrustedProxies: ParseNetList(os.Getenv("TRUSTED_PROXIES")),
+	}
+}
+
+// ParseNetList разбирает список IP/CIDR через запятую.
+// Одиночный IP превращается в подсеть /32 (или /128 для IPv6).
+// Некорректные значения пропускаются с записью в лог.
+func ParseNetList(raw string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				log.Printf("Некорректный IP в списке: %q", part)
+				continue
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(part)
+		if err != nil {
+			log.Printf("Некорректная подсеть в списке: %q", part)
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+// SignServiceRequest вычисляет HMAC-SHA256 подпись запроса (hex).
+//
+// Подписываемая строка: timestamp \n nonce \n METHOD \n path?query \n body
+// Та же схема реализована в telegram-bot/backend_client.py.
+func SignServiceRequest(secret, timestamp, nonce, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n" + strings.ToUpper(method) + "\n" + requestURI + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServiceAuthMiddleware проверяет подпись межсервисных запросов к /api/telegram/*.
+//
+// Выполняет следующие проверки:
+//  1. Если задан AllowedNets - IP клиента (с учётом TrustedProxies) должен входить в список
+//  2. Заголовки X-Signature, X-Signature-Timestamp, X-Signature-Nonce обязательны
+//  3. Timestamp не старше/новее MaxSkew относительно текущего времени
+//  4. HMAC подпись совпадает (сравнение за константное время)
+//  5. Nonce ещё не использовался в окне MaxSkew (защита от повторов)
+//
+// При ошибке возвращает JSON {error} со статусом 401/403/503 и вызывает c.Abort().
+//
+// Пример использования:
+//
+//	telegram := api.Group("/telegram")
+//	telegram.Use(middleware.ServiceAuthMiddleware(middleware.LoadServiceAuthConfig()))
+func ServiceAuthMiddleware(cfg ServiceAuthConfig) gin.HandlerFunc {
+	if cfg.MaxSkew <= 0 {
+		cfg.MaxSkew = 5 * time.Minute
+	}
+	if cfg.Secret == "" {
+		log.Println("TELEGRAM_API_SECRET не настроен - запросы к Telegram API будут отклоняться")
+	}
+	nonces := newNonceCache()
+
+	return func(c *gin.Context) {
+		if cfg.Secret == "" {
+			abortJSON(c, http.StatusServiceUnavailable, "Telegram API не настроен")
+			return
+		}
+
+		if len(cfg.AllowedNets) > 0 {
+			ip := ClientIP(c.Request, cfg.TrustedProxies)
+			if ip == nil || !containsIP(cfg.AllowedNets, ip) {
+				log.Printf("Telegram API: запрос с неразрешённого IP %v", ip)
+				abortJSON(c, http.StatusForbidden, "Доступ запрещён")
+				return
+			}
+		}
+
+		signature := c.GetHeader(HeaderSignature)
+		timestamp := c.GetHeader(HeaderSignatureTimestamp)
+		nonce := c.GetHeader(HeaderSignatureNonce)
+		if signature == "" || timestamp == "" || nonce == "" {
+			abortJSON(c, http.StatusUnauthorized, "Отсутствует подпись запроса")
+			return
+		}
+
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			abortJSON(c, http.StatusUnauthorized, "Некорректная метка времени")
+			return
+		}
+		now := time.Now()
+		if diff := now.Sub(time.Unix(ts, 0)); diff > cfg.MaxSkew || diff < -cfg.MaxSkew {
+			abortJSON(c, http.StatusUnauthorized, "Подпись устарела")
+			return
+		}
+
+		// Читаем тело и возвращаем его обратно для handler
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBodySize))
+		if err != nil {
+			abortJSON(c, http.StatusBadRequest, "Ошибка чтения запроса")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		expected := SignServiceRequest(cfg.Secret, timestamp, nonce, c.Request.Method, c.Request.URL.RequestURI(), body)
+		if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+			log.Printf("Telegram API: неверная подпись для %s %s", c.Request.Method, c.Request.URL.Path)
+			abortJSON(c, http.StatusUnauthorized, "Неверная подпись запроса")
+			return
+		}
+
+		// Nonce проверяем после подписи, чтобы мусорные запросы не засоряли кэш
+		if !nonces.add(nonce, now, cfg.MaxSkew) {
+			log.Printf("Telegram API: повторный запрос (nonce %s)", nonce)
+			abortJSON(c, http.StatusUnauthorized, "Повторный запрос")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ClientIP определяет IP клиента с учётом доверенных прокси.
+//
+// Если непосредственный отправитель (RemoteAddr) входит в trusted, X-Forwarded-For
+// разбирается справа налево: первый адрес не из trusted считается клиентом.
+// Заголовки от недоверенных отправителей игнорируются.
+func ClientIP(r *http.Request, trusted []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote := net.ParseIP(host)
+	if remote == nil || !containsIP(trusted, remote) {
+		return remote
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		if !containsIP(trusted, ip) {
+			return ip
+		}
+		remote = ip
+	}
+	return remote
+}
+
+// containsIP проверяет вхождение IP хотя бы в одну из подсетей
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// abortJSON прерывает цепочку с JSON ошибкой в формате {error: msg}
+func abortJSON(c *gin.Context, code int, msg string) {
+	c.AbortWithStatusJSON(code, gin.H{"error": msg})
+}
+
+// nonceCache хранит использованные nonce до истечения окна подписи
+type nonceCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time)}
+}
+
+// add запоминает nonce и возвращает false, если он уже встречался.
+// Попутно удаляет записи старше окна ttl.
+func (n *nonceCache) add(nonce string, now time.Time, ttl time.Duration) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for k, seenAt := range n.seen {
+		if now.Sub(seenAt) > 2*ttl {
+			delete(n.seen, k)
+		}
+	}
+
+	if _, exists := n.seen[nonce]; exists {
+		return false
+	}
+	n.seen[nonce] = now
+	return true
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testServiceSecret = "test-service-secret"
+
+// setupServiceAuthRouter создает router с ServiceAuthMiddleware и эхо-handler
+func setupServiceAuthRouter(cfg ServiceAuthConfig) *gin.Engine {
+	router := setupTestRouter()
+	router.POST("/api/telegram/test", ServiceAuthMiddleware(cfg), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(http.StatusOK, gin.H{"body": string(body)})
+	})
+	return router
+}
+
+// newSignedRequest создает подписанный запрос с указанными timestamp и nonce
+func newSignedRequest(secret string, ts time.Time, nonce string, body []byte) *http.Request {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req, _ := http.NewRequest("POST", "/api/telegram/test", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignatureTimestamp, timestamp)
+	req.Header.Set(HeaderSignatureNonce, nonce)
+	req.Header.Set(HeaderSignature, SignServiceRequest(secret, timestamp, nonce, "POST", "/api/telegram/test", body))
+	req.RemoteAddr = "127.0.0.1:12345"
+	return req
+}
+
+// TestServiceAuth_ValidSignature проверяет доступ с корректной подписью
+func TestServiceAuth_ValidSignature(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{Secret: testServiceSecret})
+
+	body := []byte(`{"contact_id":1}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedRequest(testServiceSecret, time.Now(), "nonce-1", body))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// Тело должно дойти до handler без изменений
+	assert.Contains(t, w.Body.String(), `{\"contact_id\":1}`)
+}
+
+// TestServiceAuth_MissingHeaders проверяет отказ без подписи
+func TestServiceAuth_MissingHeaders(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{Secret: testServiceSecret})
+
+	req, _ := http.NewRequest("POST", "/api/telegram/test", bytes.NewReader([]byte(`{}`)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestServiceAuth_WrongSecret проверяет отказ при подписи чужим секретом
+func TestServiceAuth_WrongSecret(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{Secret: testServiceSecret})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedRequest("other-secret", time.Now(), "nonce-1", []byte(`{}`)))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestServiceAuth_TamperedBody проверяет, что подпись покрывает тело запроса
+func TestServiceAuth_TamperedBody(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{Secret: testServiceSecret})
+
+	req := newSignedRequest(testServiceSecret, time.Now(), "nonce-1", []byte(`{"contact_id":1}`))
+	req.Body = io.NopCloser(bytes.NewReader([]byte(`{"contact_id":2}`)))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestServiceAuth_ExpiredTimestamp проверяет отказ для устаревшей подписи
+func TestServiceAuth_ExpiredTimestamp(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{Secret: testServiceSecret, MaxSkew: time.Minute})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedRequest(testServiceSecret, time.Now().Add(-2*time.Minute), "nonce-1", []byte(`{}`)))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestServiceAuth_ReplayRejected проверяет защиту от повторной отправки
+func TestServiceAuth_ReplayRejected(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{Secret: testServiceSecret})
+
+	now := time.Now()
+	body := []byte(`{}`)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedRequest(testServiceSecret, now, "same-nonce", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedRequest(testServiceSecret, now, "same-nonce", body))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "повторный запрос должен быть отклонён")
+}
+
+// TestServiceAuth_NoSecretConfigured проверяет, что без секрета API закрыт
+func TestServiceAuth_NoSecretConfigured(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedRequest("", time.Now(), "nonce-1", []byte(`{}`)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestServiceAuth_IPAllowlist проверяет ограничение по IP
+func TestServiceAuth_IPAllowlist(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{
+		Secret:      testServiceSecret,
+		AllowedNets: ParseNetList("127.0.0.1"),
+	})
+
+	// Разрешённый IP
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedRequest(testServiceSecret, time.Now(), "nonce-1", []byte(`{}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Чужой IP - даже с валидной подписью
+	req := newSignedRequest(testServiceSecret, time.Now(), "nonce-2", []byte(`{}`))
+	req.RemoteAddr = "203.0.113.5:4444"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestServiceAuth_SpoofedForwardedFor проверяет, что X-Forwarded-For от недоверенного источника игнорируется
+func TestServiceAuth_SpoofedForwardedFor(t *testing.T) {
+	router := setupServiceAuthRouter(ServiceAuthConfig{
+		Secret:      testServiceSecret,
+		AllowedNets: ParseNetList("127.0.0.1"),
+	})
+
+	req := newSignedRequest(testServiceSecret, time.Now(), "nonce-1", []byte(`{}`))
+	req.RemoteAddr = "203.0.113.5:4444"
+	req.Header.Set("X-Forwarded-For", "127.0.0.1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestClientIP_TrustedProxy проверяет разбор X-Forwarded-For за доверенным прокси
+func TestClientIP_TrustedProxy(t *testing.T) {
+	trusted := ParseNetList("10.0.0.0/8, 127.0.0.1")
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "127.0.0.1:8080"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7, 203.0.113.9, 10.0.0.2")
+
+	// Самый правый недоверенный адрес - реальный клиент
+	assert.Equal(t, "203.0.113.9", ClientIP(req, trusted).String())
+
+	// Без доверенных прокси заголовок игнорируется
+	assert.Equal(t, "127.0.0.1", ClientIP(req, nil).String())
+}
+
+// TestParseNetList проверяет разбор списка IP/CIDR
+func TestParseNetList(t *testing.T) {
+	nets := ParseNetList("127.0.0.1, 10.0.0.0/8, ::1, bad-value, ")
+
+	assert.Len(t, nets, 3)
+	assert.True(t, containsIP(nets, []byte{10, 1, 2, 3}))
+}
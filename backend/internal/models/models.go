@@ -9,8 +9,11 @@
 // Основные сущности:
 //   - Category, Project, Image - портфолио проектов
 //   - ContactForm, ContactNote - система CRM для заявок
+//   - PipelineStage, ContactStageChange - этапы воронки продаж и история переходов
+//   - NotificationDelivery - outbox уведомлений о заявках (Telegram, email, webhook)
 //   - Service - услуги компании
 //   - Admin - администраторы системы
+//   - AuditLog - журнал изменений в админке
 //   - ProjectViewDaily - аналитика просмотров
 package models
 
@@ -23,12 +26,16 @@ import (
 // Связи:
 //   - many-to-many с Project через таблицу project_categories
 //
+// Управляются из админки (/admin/categories), SortOrder задаёт порядок
+// фильтров на странице портфолио.
+//
 // Примеры категорий: "Рекламные щиты", "АЗС", "Торговые центры"
 type Category struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	Name        string    `json:"name" gorm:"not null"`
 	Slug        string    `json:"slug" gorm:"unique;not null"`
 	Description string    `json:"description"`
+	SortOrder   int       `json:"sort_order" gorm:"default:0;index"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
@@ -115,17 +122,25 @@ type Image struct {
 //   - Изготовление металлоконструкций
 //
 // Featured услуги отображаются на главной странице.
+// Управляются из админки (/admin/services), у каждой услуги есть
+// публичная страница /services/:slug.
 type Service struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Slug        string    `json:"slug" gorm:"unique;not null"`
-	ShortDesc   string    `json:"short_desc"`
-	Description string    `json:"description"`
-	Icon        string    `json:"icon"` // Имя иконки FontAwesome или путь к SVG файлу
-	Featured    bool      `json:"featured" gorm:"default:false"`
-	SortOrder   int       `json:"sort_order" gorm:"default:0"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"not null"`
+	Slug        string `json:"slug" gorm:"unique;not null"`
+	ShortDesc   string `json:"short_desc"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"` // Имя иконки FontAwesome или путь к SVG файлу
+	Featured    bool   `json:"featured" gorm:"default:false"`
+	SortOrder   int    `json:"sort_order" gorm:"default:0"`
+
+	// Загруженная иконка (оригинал + миниатюры, как у PriceItem)
+	IconPath            string `json:"icon_path"`             // Веб-путь к оригиналу (/static/uploads/...)
+	ThumbnailSmallPath  string `json:"thumbnail_small_path"`  // 400x300 для карточек
+	ThumbnailMediumPath string `json:"thumbnail_medium_path"` // 1200x900 для страницы услуги и OG
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // ContactForm представляет заявку клиента с сайта (CRM система).
@@ -135,6 +150,9 @@ type Service struct {
 //  2. Обработка менеджером -> статус "processed"
 //  3. Архивирование -> статус "archived" + установка ArchivedAt
 //
+// Подозрительные заявки (антиспам, см. handlers/contact_spam.go) сохраняются со статусом "spam":
+// уведомления по ним не отправляются, менеджер просматривает их в фильтре «Спам».
+//
 // Дополнительные возможности:
 //   - Заметки (ContactNote) для истории взаимодействия с клиентом
 //   - Напоминания (RemindAt + RemindFlag) для follow-up звонков
@@ -149,12 +167,147 @@ type ContactForm struct {
 	ProjectType string     `json:"project_type"`
 	Message     string     `json:"message"`
 	Website     string     `json:"website" form:"website" gorm:"-"`                    // Honeypot поле (не сохраняется в БД)
+	FormToken   string     `json:"form_token" form:"form_token" gorm:"-"`              // Токен времени заполнения формы (антиспам, не сохраняется)
 	Source      string     `json:"source"`                                             // Источник заявки: "contact_form", "calculator", "phone_call" и т.д.
-	Status      string     `json:"status" gorm:"type:varchar(20);default:'new';index"` // Статус: "new", "processed", "archived"
+	Status      string     `json:"status" gorm:"type:varchar(20);default:'new';index"` // Этап воронки (PipelineStage.Slug) или "archived", "spam"
 	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
 	ArchivedAt  *time.Time `json:"archived_at" gorm:"index"`         // NULL = активная заявка, NOT NULL = архив
 	RemindAt    *time.Time `json:"remind_at" gorm:"index"`           // Дата/время напоминания для перезвона (МСК)
 	RemindFlag  bool       `json:"remind_flag" gorm:"default:false"` // Флаг активного напоминания
+	// Когда напоминание поставлено в outbox (email, webhook): один раз на каждое значение RemindAt
+	ReminderEmailedAt *time.Time `json:"-"`
+
+	IP         string `json:"ip" gorm:"size:45;index"` // IP отправителя формы (ограничение частоты заявок)
+	SpamReason string `json:"spam_reason"`             // Почему заявка попала в спам (пусто - не спам)
+
+	CustomerID *uint `json:"customer_id" gorm:"index"` // Клиент, к которому относится заявка (NULL - спам или ещё не привязана)
+
+	StageChangedAt *time.Time `json:"stage_changed_at"` // Когда заявка попала в текущий статус
+	LostReason     string     `json:"lost_reason"`      // Причина проигрыша (для этапов вида "lost")
+
+	AssignedAdminID *uint `json:"assigned_admin_id" gorm:"index"` // Ответственный менеджер (NULL - не назначен)
+}
+
+// Виды этапов воронки (PipelineStage.Kind)
+const (
+	StageOpen = "open" // заявка в работе
+	StageWon  = "won"  // сделка заключена
+	StageLost = "lost" // сделка проиграна, при переходе обязательна причина
+)
+
+// PipelineStage - этап воронки продаж, значение ContactForm.Status.
+//
+// Этапы настраиваются в админке (/admin/pipeline): название, вид и порядок колонок
+// на канбан-доске. Slug не меняется после создания - он хранится в заявках и истории.
+// Системные статусы "archived" и "spam" этапами не являются.
+type PipelineStage struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Slug      string    `json:"slug" gorm:"size:20;uniqueIndex;not null"`
+	Title     string    `json:"title" gorm:"size:100;not null"`
+	Kind      string    `json:"kind" gorm:"size:10;not null;default:'open'"` // open | won | lost
+	SortOrder int       `json:"sort_order" gorm:"default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultPipelineStages - этапы, которые создаются в пустой БД.
+// "new" и "processed" используются формой заявки и Telegram ботом.
+func DefaultPipelineStages() []PipelineStage {
+	return []PipelineStage{
+		{Slug: "new", Title: "Новая", Kind: StageOpen, SortOrder: 0},
+		{Slug: "processed", Title: "Обработана", Kind: StageOpen, SortOrder: 1},
+		{Slug: "qualified", Title: "Квалифицирована", Kind: StageOpen, SortOrder: 2},
+		{Slug: "quote_sent", Title: "КП отправлено", Kind: StageOpen, SortOrder: 3},
+		{Slug: "negotiating", Title: "Переговоры", Kind: StageOpen, SortOrder: 4},
+		{Slug: "won", Title: "Сделка", Kind: StageWon, SortOrder: 5},
+		{Slug: "lost", Title: "Проиграна", Kind: StageLost, SortOrder: 6},
+	}
+}
+
+// ContactStageChange - переход заявки из статуса в статус.
+//
+// Пишется при каждой смене статуса (админка, канбан, Telegram бот) и при создании
+// заявки (FromStatus пустой). По истории считаются конверсия по этапам и время в этапе.
+type ContactStageChange struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ContactID  uint      `json:"contact_id" gorm:"index;not null"`
+	FromStatus string    `json:"from_status" gorm:"size:20"`
+	ToStatus   string    `json:"to_status" gorm:"size:20;index"`
+	AdminID    uint      `json:"admin_id"` // 0 - форма на сайте или Telegram бот
+	ChangedAt  time.Time `json:"changed_at" gorm:"index"`
+}
+
+// ContactQuote - коммерческое предложение (КП) по заявке, сформированное из калькулятора.
+//
+// PDF хранится в том виде, в котором ушёл клиенту: повторное скачивание не пересчитывает
+// цены по новому курсу. Номер сквозной в пределах года: "2026-0001".
+type ContactQuote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ContactID uint      `json:"contact_id" gorm:"index;not null"`
+	Number    string    `json:"number" gorm:"size:20;uniqueIndex;not null"`
+	Summary   string    `json:"summary" gorm:"size:255"` // описание экрана: тип, шаг, размер
+	Total     float64   `json:"total"`                   // итог, ₽
+	Spec      string    `json:"-" gorm:"type:text"`      // расчёт (JSON quote.Calculation)
+	PDF       []byte    `json:"-"`                       // готовый файл
+	Author    string    `json:"author" gorm:"size:100"`  // логин администратора
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// AttributionTouch - визит на сайт, после которого клиент оставил заявку
+// (UTM-метки, реферер, страница входа). Разбор - internal/attribution.
+type AttributionTouch struct {
+	Source   string     `json:"source" gorm:"size:100"`   // utm_source или домен реферера, "(direct)" - прямой заход
+	Medium   string     `json:"medium" gorm:"size:100"`   // utm_medium: cpc, organic, referral, social, (none)
+	Campaign string     `json:"campaign" gorm:"size:255"` // utm_campaign
+	Term     string     `json:"term" gorm:"size:255"`     // utm_term
+	Content  string     `json:"content" gorm:"size:255"`  // utm_content
+	Referrer string     `json:"referrer" gorm:"size:500"` // внешняя страница, с которой пришёл посетитель
+	Landing  string     `json:"landing" gorm:"size:500"`  // страница входа (путь с параметрами)
+	At       *time.Time `json:"at"`                       // время визита
+}
+
+// ContactAttribution - откуда пришёл клиент, оставивший заявку.
+//
+// First - первый визит (cookie хранится 90 дней), Last - последний визит с меткой или внешним
+// реферером: прямые заходы его не перезаписывают. Заявки без cookie (Telegram бот, старые заявки)
+// записи не имеют.
+type ContactAttribution struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	ContactID uint             `json:"contact_id" gorm:"uniqueIndex;not null"`
+	First     AttributionTouch `json:"first" gorm:"embedded;embeddedPrefix:first_"`
+	Last      AttributionTouch `json:"last" gorm:"embedded;embeddedPrefix:last_"`
+	FormPage  string           `json:"form_page" gorm:"size:500"` // страница, с которой отправлена заявка
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// ContactView - сохранённый вид списка заявок: вкладка над списком у одного администратора.
+//
+// Query - параметры фильтра в виде query строки /admin/contacts (search, status, from, to, source, ...),
+// без страницы и размера страницы.
+type ContactView struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	AdminID   uint      `json:"admin_id" gorm:"index;not null"`
+	Name      string    `json:"name" gorm:"size:100;not null"`
+	Query     string    `json:"query" gorm:"size:2000;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Customer - клиент: все заявки с одного телефона или email.
+//
+// Связи:
+//   - one-to-many с ContactForm (повторные обращения одного клиента)
+//
+// Заявка привязывается к клиенту при сохранении: сначала по последней заявке
+// с тем же телефоном или email, затем по ключам самого клиента. Менеджер может
+// объединить двух клиентов или отделить заявку в нового клиента.
+type Customer struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	Phone     string    `json:"phone" gorm:"size:20;index"` // Нормализованный телефон +7XXXXXXXXXX
+	Email     string    `json:"email" gorm:"index"`         // Email в нижнем регистре
+	Company   string    `json:"company"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // ContactNote представляет заметку менеджера по заявке клиента.
@@ -176,6 +329,36 @@ type ContactNote struct {
 	CreatedAt time.Time `json:"created_at" gorm:"index"`
 }
 
+// Статусы доставки уведомления (NotificationDelivery.Status)
+const (
+	DeliveryPending = "pending" // ждёт отправки или повторной попытки
+	DeliverySent    = "sent"    // доставлено
+	DeliveryDead    = "dead"    // попытки исчерпаны (dead-letter), повтор только вручную из админки
+)
+
+// NotificationDelivery - доставка одного уведомления в один канал (outbox).
+//
+// Строка создаётся в одной транзакции с заявкой, поэтому уведомление не теряется,
+// даже если бот или SMTP недоступны. Фоновый воркер (internal/notify) отправляет
+// строки с наступившим NextAttemptAt, при ошибке откладывает с растущей задержкой,
+// после исчерпания попыток переводит в статус dead.
+//
+// Payload - JSON события (notify.Event) на момент создания: повтор отправляет те же данные.
+type NotificationDelivery struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	ContactID     uint       `json:"contact_id" gorm:"index"`
+	Channel       string     `json:"channel" gorm:"size:20;not null"`               // telegram | email | webhook | customer_email
+	Event         string     `json:"event" gorm:"size:30;not null"`                 // new_contact | reminder | customer_ack
+	Payload       string     `json:"-" gorm:"type:text"`                            // JSON события
+	Status        string     `json:"status" gorm:"size:20;default:'pending';index"` // pending | sent | dead
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastError     string     `json:"last_error" gorm:"type:text"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
 // ProjectViewDaily представляет агрегированные просмотры проекта по дням.
 //
 // Связи:
@@ -204,25 +387,119 @@ type ProjectViewDaily struct {
 //   - JSON тег "-" исключает PasswordHash из API ответов
 //   - IsActive позволяет деактивировать аккаунты без удаления
 //   - LastLoginAt отслеживает активность администратора
+//   - Role определяет набор прав (см. handlers.RoleHasPermission)
+//   - TOTPEnabled включает второй шаг входа (RFC 6238), коды восстановления - в AdminRecoveryCode
 //
 // Создание админа:
 //   - Через утилиту: go run cmd/create-admin/main.go
 //   - Программно через handlers.HashPassword() + db.Create()
 //
 // Аутентификация:
-//   - JWT токены (HS256) с истечением 7 дней
-//   - Хранение в HTTP-only cookie "admin_token"
+//   - Короткоживущий JWT (HS256, 15 минут) в HTTP-only cookie "admin_token"
+//   - Refresh токен в HTTP-only cookie "admin_refresh", серверная сессия в AdminSession
 type Admin struct {
 	ID           uint       `json:"id" gorm:"primaryKey"`
 	Username     string     `json:"username" gorm:"unique;not null;size:50"`
 	PasswordHash string     `json:"-" gorm:"not null"` // JSON:"-" исключает поле из сериализации (безопасность)
 	Email        string     `json:"email" gorm:"size:100"`
+	Role         string     `json:"role" gorm:"size:20;not null;default:'owner'"` // owner | manager | editor | viewer
 	IsActive     bool       `json:"is_active" gorm:"default:true"`
 	LastLoginAt  *time.Time `json:"last_login_at"`
+	TOTPSecret   string     `json:"-" gorm:"size:64"`                  // base32 секрет TOTP (до подтверждения - ожидает включения)
+	TOTPEnabled  bool       `json:"totp_enabled" gorm:"default:false"` // вход требует второй шаг (код из приложения)
+	TOTPLastStep int64      `json:"-"`                                 // последний принятый шаг TOTP (защита от повторного кода)
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
+// AdminRecoveryCode - одноразовый код восстановления для входа без приложения 2FA.
+// Код - 10 случайных байт (80 бит), хранится только его SHA-256 хеш: соль не нужна,
+// перебрать такой код по хешу нельзя. Использованный код помечается UsedAt.
+type AdminRecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	AdminID   uint       `json:"admin_id" gorm:"index;not null;constraint:OnDelete:CASCADE"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Роли администраторов
+const (
+	RoleOwner   = "owner"   // владелец: полный доступ
+	RoleManager = "manager" // менеджер: заявки и контент
+	RoleEditor  = "editor"  // контент-редактор: проекты, услуги, цены, карта
+	RoleViewer  = "viewer"  // только просмотр
+)
+
+// AdminSession представляет серверную сессию администратора (один вход = одна сессия).
+//
+// Связи:
+//   - many-to-one с Admin (CASCADE DELETE при удалении администратора)
+//
+// Особенности:
+//   - JTI записывается в claim "jti" каждого access токена сессии и проверяется на каждом запросе
+//   - Хранится только SHA-256 хеш refresh токена; при каждом обновлении токен ротируется
+//   - PrevRefreshHash принимается короткое время после ротации (параллельные запросы вкладок)
+//   - ExpiresAt сдвигается при обновлении (скользящий срок), но не дальше абсолютного лимита
+//   - RevokedAt заполняется при выходе, деактивации админа, сбросе пароля или завершении сессии вручную
+type AdminSession struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	AdminID         uint       `json:"admin_id" gorm:"index;not null;constraint:OnDelete:CASCADE"`
+	Admin           Admin      `json:"-" gorm:"foreignKey:AdminID"`
+	JTI             string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	RefreshHash     string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	PrevRefreshHash string     `json:"-" gorm:"size:64;index"`
+	RotatedAt       *time.Time `json:"-"`
+	UserAgent       string     `json:"user_agent" gorm:"size:255"`
+	IP              string     `json:"ip" gorm:"size:45"`
+	LastSeenAt      time.Time  `json:"last_seen_at"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// LoginAttempt - запись журнала попыток входа в админку.
+//
+// Используется для:
+//   - Ограничения частоты попыток по логину и IP (экспоненциальная задержка, блокировка)
+//   - Журнала попыток на dashboard
+//   - Telegram-алерта при всплеске блокировок
+//
+// Result: success | bad_password | unknown_user | inactive | bad_2fa | throttled | locked.
+// Lockout=true у неудачной попытки, после которой логин был временно заблокирован.
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Username  string    `json:"username" gorm:"size:50;index"`
+	IP        string    `json:"ip" gorm:"size:45;index"`
+	UserAgent string    `json:"user_agent" gorm:"size:255"`
+	Result    string    `json:"result" gorm:"size:20;not null;index"`
+	Lockout   bool      `json:"lockout" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// AuditLog - запись журнала изменений в админке (кто, что и как изменил).
+//
+// Пишется middleware.Audit для каждого успешного изменяющего запроса под /admin
+// (POST, PUT, PATCH, DELETE). Before/After содержат JSON только изменившихся полей:
+// при создании Before пустой, при удалении пустой After. Params - параметры запроса
+// для операций без одной сущности (массовые действия, сортировка).
+// Секреты (хеши паролей, токены, TOTP) в журнал не попадают.
+type AuditLog struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	AdminID       uint      `json:"admin_id" gorm:"index"`
+	AdminUsername string    `json:"admin_username" gorm:"size:50"`
+	Action        string    `json:"action" gorm:"size:50;not null;index"`
+	Entity        string    `json:"entity" gorm:"size:50;not null;index:idx_audit_entity"`
+	EntityID      uint      `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Method        string    `json:"method" gorm:"size:10"`
+	Path          string    `json:"path" gorm:"size:255"`
+	IP            string    `json:"ip" gorm:"size:45"`
+	Before        string    `json:"before" gorm:"type:text"`
+	After         string    `json:"after" gorm:"type:text"`
+	Params        string    `json:"params" gorm:"type:text"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
 // PriceItem представляет позицию в прайс-листе (например, "Билборд 6x3").
 //
 // Связи:
@@ -376,8 +653,26 @@ type SiteSettings struct {
 	StatsProjects int       `json:"stats_projects" gorm:"not null;default:0"`   // кол-во проектов в статистике
 	StatsYears    int       `json:"stats_years" gorm:"not null;default:0"`      // лет опыта в статистике
 	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Письмо-подтверждение клиенту после отправки формы (если указан email).
+	// Тема и текст - шаблоны с полями {{.Name}}, {{.Reference}}, {{.ProjectType}}; пустые - текст по умолчанию
+	AckEmailEnabled bool   `json:"ack_email_enabled" gorm:"not null;default:false"`
+	AckEmailSubject string `json:"ack_email_subject" gorm:"not null;default:''"`
+	AckEmailBody    string `json:"ack_email_body" gorm:"type:text;not null;default:''"`
+
+	// Стоп-слова антиспама формы заявки (по одному на строку, без учёта регистра)
+	SpamKeywords string `json:"spam_keywords" gorm:"type:text;not null;default:''"`
+
+	// Автоматическое назначение ответственного за новую заявку: "" (вручную) | LeadAssignRoundRobin | LeadAssignLeastLoaded
+	LeadAssignMode string `json:"lead_assign_mode" gorm:"size:20;not null;default:''"`
 }
 
+// Режимы распределения новых заявок между менеджерами (SiteSettings.LeadAssignMode)
+const (
+	LeadAssignRoundRobin  = "round_robin"  // по очереди
+	LeadAssignLeastLoaded = "least_loaded" // тому, у кого меньше заявок в работе
+)
+
 // CalculatorSettings хранит настройки калькулятора стоимости LED экрана (singleton).
 //
 // Управляется через /admin/calculator
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"ledsite/internal/mailer"
+)
+
+// Email отправляет письма о новых заявках и напоминаниях (шаблоны из internal/mailer).
+// Письмо отправляется синхронно: повторы делает outbox, а не очередь mailer.
+type Email struct {
+	sender     mailer.Sender
+	recipients []string
+}
+
+// NewEmail создаёт канал email
+func NewEmail(sender mailer.Sender, recipients []string) *Email {
+	return &Email{sender: sender, recipients: recipients}
+}
+
+// Channel реализует Notifier
+func (e *Email) Channel() string { return "email" }
+
+// Supports реализует Notifier
+func (e *Email) Supports(kind EventKind) bool {
+	return kind == EventNewContact || kind == EventReminder
+}
+
+// Notify реализует Notifier
+func (e *Email) Notify(_ context.Context, ev Event) error {
+	data := mailer.ContactEmail{
+		ID:          ev.Contact.ID,
+		Name:        ev.Contact.Name,
+		Phone:       ev.Contact.Phone,
+		Email:       ev.Contact.Email,
+		Company:     ev.Contact.Company,
+		ProjectType: ev.Contact.ProjectTitle,
+		Message:     ev.Contact.Message,
+		Source:      ev.Contact.Source,
+		AssignedTo:  ev.Contact.AssignedTo,
+		CreatedAt:   formatMSK(ev.Contact.CreatedAt),
+		AdminURL:    ev.AdminURL,
+	}
+	if ev.Contact.RemindAt != nil {
+		data.RemindAt = formatMSK(*ev.Contact.RemindAt)
+	}
+
+	var msg mailer.Message
+	var err error
+	switch ev.Kind {
+	case EventNewContact:
+		msg, err = mailer.NewContactMessage(data)
+	case EventReminder:
+		msg, err = mailer.ReminderMessage(data)
+	default:
+		return fmt.Errorf("email не поддерживает событие %s", ev.Kind)
+	}
+	if err != nil {
+		return err
+	}
+	msg.To = e.recipients
+	return e.sender.Send(msg)
+}
+
+// CustomerEmail отправляет клиенту письмо-подтверждение, собранное при постановке в outbox
+type CustomerEmail struct {
+	sender mailer.Sender
+}
+
+// NewCustomerEmail создаёт канал писем клиентам
+func NewCustomerEmail(sender mailer.Sender) *CustomerEmail {
+	return &CustomerEmail{sender: sender}
+}
+
+// Channel реализует Notifier
+func (e *CustomerEmail) Channel() string { return "customer_email" }
+
+// Supports реализует Notifier: только подтверждения клиенту
+func (e *CustomerEmail) Supports(kind EventKind) bool { return kind == EventCustomerAck }
+
+// Notify реализует Notifier
+func (e *CustomerEmail) Notify(_ context.Context, ev Event) error {
+	if ev.Mail == nil || ev.Contact.Email == "" {
+		return fmt.Errorf("нет письма или адреса клиента")
+	}
+	msg := *ev.Mail
+	msg.To = []string{ev.Contact.Email}
+	return e.sender.Send(msg)
+}
@@ -0,0 +1,129 @@
+// Package notify доставляет уведомления о заявках по каналам (Telegram, email, webhook)
+// и письма-подтверждения клиентам.
+//
+// Уведомления не отправляются напрямую из HTTP обработчика: событие записывается
+// в таблицу notification_deliveries (outbox) в той же транзакции, что и заявка,
+// а фоновый воркер доставляет его с повторными попытками. Недоступность канала
+// не теряет заявку: после MaxAttempts неудачных попыток запись остаётся в статусе
+// "dead" и может быть отправлена повторно из админки.
+//
+// Пример использования:
+//
+//	outbox := notify.NewOutbox(db, notify.OutboxConfig{},
+//	    notify.NewTelegram(botURL), notify.NewWebhook(hookURL, secret))
+//	stop := outbox.Start()
+//	defer stop()
+//	db.Transaction(func(tx *gorm.DB) error {
+//	    tx.Create(&contact)
+//	    ev := notify.Event{Kind: notify.EventNewContact, Contact: notify.ContactSnapshot(&contact, title)}
+//	    return outbox.EnqueueTx(tx, ev)
+//	})
+//	outbox.Wake()
+package notify
+
+import (
+	"context"
+	"time"
+
+	"ledsite/internal/mailer"
+	"ledsite/internal/models"
+)
+
+// EventKind - тип события
+type EventKind string
+
+// События, о которых уведомляют каналы
+const (
+	EventNewContact  EventKind = "new_contact"  // новая заявка с сайта
+	EventReminder    EventKind = "reminder"     // наступило время перезвонить
+	EventCustomerAck EventKind = "customer_ack" // подтверждение клиенту, что заявка принята
+)
+
+// Contact - снимок заявки на момент события (хранится в outbox в JSON)
+type Contact struct {
+	ID           uint       `json:"id"`
+	Name         string     `json:"name"`
+	Phone        string     `json:"phone"`
+	Email        string     `json:"email,omitempty"`
+	Company      string     `json:"company,omitempty"`
+	ProjectType  string     `json:"project_type,omitempty"`
+	ProjectTitle string     `json:"project_title,omitempty"` // тип проекта на русском
+	Message      string     `json:"message,omitempty"`
+	Source       string     `json:"source,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RemindAt     *time.Time `json:"remind_at,omitempty"`
+
+	// Повторное обращение: клиент и число его предыдущих заявок (заполняет handlers)
+	CustomerID       uint `json:"customer_id,omitempty"`
+	PreviousContacts int  `json:"previous_contacts,omitempty"`
+
+	// Логин ответственного менеджера, пусто - не назначен (заполняет handlers)
+	AssignedTo string `json:"assigned_to,omitempty"`
+}
+
+// Returning - клиент обращается не впервые
+func (c Contact) Returning() bool { return c.PreviousContacts > 0 }
+
+// Event - уведомление для доставки
+type Event struct {
+	Kind     EventKind `json:"event"`
+	Contact  Contact   `json:"contact"`
+	AdminURL string    `json:"admin_url,omitempty"` // ссылка на заявку в админке
+
+	// Готовое письмо клиенту (только EventCustomerAck): шаблон из настроек подставляется
+	// при постановке в outbox, повтор отправляет тот же текст
+	Mail *mailer.Message `json:"mail,omitempty"`
+}
+
+// Notifier - канал доставки уведомлений
+type Notifier interface {
+	// Channel - имя канала, под которым доставки хранятся в outbox
+	Channel() string
+	// Supports - отправляет ли канал события этого типа
+	Supports(kind EventKind) bool
+	// Notify доставляет событие; ошибка означает повторную попытку позже
+	Notify(ctx context.Context, ev Event) error
+}
+
+// ContactSnapshot копирует поля заявки для события.
+// projectTitle - тип проекта на русском (перевод живёт в handlers).
+func ContactSnapshot(c *models.ContactForm, projectTitle string) Contact {
+	return Contact{
+		ID:           c.ID,
+		Name:         c.Name,
+		Phone:        c.Phone,
+		Email:        c.Email,
+		Company:      c.Company,
+		ProjectType:  c.ProjectType,
+		ProjectTitle: projectTitle,
+		Message:      c.Message,
+		Source:       c.Source,
+		CreatedAt:    c.CreatedAt,
+		RemindAt:     c.RemindAt,
+		CustomerID:   derefUint(c.CustomerID),
+	}
+}
+
+func derefUint(v *uint) uint {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// moscowLoc - часовой пояс для дат в текстах уведомлений
+var moscowLoc = func() *time.Location {
+	loc, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		return time.FixedZone("MSK", 3*60*60)
+	}
+	return loc
+}()
+
+// formatMSK форматирует время по Москве для показа в уведомлениях
+func formatMSK(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(moscowLoc).Format("02.01.2006 15:04")
+}
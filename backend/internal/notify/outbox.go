@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ledsite/internal/models"
+)
+
+// Значения OutboxConfig по умолчанию
+const (
+	DefaultMaxAttempts   = 8
+	DefaultRetryDelay    = 30 * time.Second
+	DefaultMaxRetryDelay = time.Hour
+	DefaultPollInterval  = 10 * time.Second
+	DefaultSendTimeout   = 15 * time.Second
+	batchSize            = 50
+)
+
+// ErrNotDead - повтор вручную возможен только для доставки в статусе dead
+var ErrNotDead = errors.New("доставка не в статусе dead")
+
+// OutboxConfig - параметры повторных попыток
+type OutboxConfig struct {
+	MaxAttempts   int           // после стольких неудач доставка переходит в dead
+	RetryDelay    time.Duration // задержка перед второй попыткой, дальше удваивается
+	MaxRetryDelay time.Duration // верхняя граница задержки
+	PollInterval  time.Duration // как часто воркер проверяет outbox
+	SendTimeout   time.Duration // таймаут одной попытки
+}
+
+// Outbox хранит уведомления в notification_deliveries и доставляет их фоновым воркером
+type Outbox struct {
+	db        *gorm.DB
+	cfg       OutboxConfig
+	notifiers map[string]Notifier
+	channels  []string // порядок каналов при постановке в очередь
+	wake      chan struct{}
+	mu        sync.Mutex // одна обработка outbox за раз
+}
+
+// NewOutbox создаёт outbox с каналами notifiers (нулевые поля cfg - значения по умолчанию)
+func NewOutbox(db *gorm.DB, cfg OutboxConfig, notifiers ...Notifier) *Outbox {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	if cfg.MaxRetryDelay <= 0 {
+		cfg.MaxRetryDelay = DefaultMaxRetryDelay
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = DefaultSendTimeout
+	}
+
+	o := &Outbox{
+		db:        db,
+		cfg:       cfg,
+		notifiers: map[string]Notifier{},
+		wake:      make(chan struct{}, 1),
+	}
+	for _, n := range notifiers {
+		o.notifiers[n.Channel()] = n
+		o.channels = append(o.channels, n.Channel())
+	}
+	return o
+}
+
+// Channels - подключённые каналы
+func (o *Outbox) Channels() []string {
+	return o.channels
+}
+
+// Supports - есть ли канал, отправляющий события kind
+func (o *Outbox) Supports(kind EventKind) bool {
+	for _, n := range o.notifiers {
+		if n.Supports(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnqueueTx создаёт доставки события во все подходящие каналы внутри транзакции tx.
+// После коммита стоит вызвать Wake, чтобы не ждать следующей проверки воркера.
+func (o *Outbox) EnqueueTx(tx *gorm.DB, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var rows []models.NotificationDelivery
+	for _, ch := range o.channels {
+		if !o.notifiers[ch].Supports(ev.Kind) {
+			continue
+		}
+		rows = append(rows, models.NotificationDelivery{
+			ContactID:     ev.Contact.ID,
+			Channel:       ch,
+			Event:         string(ev.Kind),
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// Wake будит воркер, не дожидаясь PollInterval
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start запускает фоновый воркер. Возвращает функцию остановки.
+func (o *Outbox) Start() func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(o.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			case <-o.wake:
+			}
+			o.ProcessDue(context.Background(), time.Now())
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// ProcessDue отправляет доставки, время попытки которых наступило.
+// Возвращает количество успешно доставленных.
+func (o *Outbox) ProcessDue(ctx context.Context, now time.Time) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now = now.UTC()
+	var due []models.NotificationDelivery
+	if err := o.db.
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(batchSize).
+		Find(&due).Error; err != nil {
+		log.Printf("Ошибка чтения outbox уведомлений: %v", err)
+		return 0
+	}
+
+	delivered := 0
+	for i := range due {
+		if o.attempt(ctx, &due[i], now) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// attempt делает одну попытку доставки d, возвращает true при успехе
+func (o *Outbox) attempt(ctx context.Context, d *models.NotificationDelivery, now time.Time) bool {
+	// Захват строки: попытка засчитывается до отправки, а next_attempt_at отодвигается,
+	// чтобы другой экземпляр приложения не отправил то же уведомление параллельно
+	res := o.db.Model(&models.NotificationDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", d.ID, models.DeliveryPending, d.Attempts).
+		Updates(map[string]any{
+			"attempts":        d.Attempts + 1,
+			"next_attempt_at": now.Add(2 * o.cfg.SendTimeout),
+		})
+	if res.Error != nil {
+		log.Printf("Ошибка захвата доставки ID=%d: %v", d.ID, res.Error)
+		return false
+	}
+	if res.RowsAffected == 0 {
+		return false
+	}
+	d.Attempts++
+
+	err := o.send(ctx, d)
+	if err == nil {
+		sentAt := time.Now().UTC()
+		o.db.Model(d).Updates(map[string]any{
+			"status":     models.DeliverySent,
+			"sent_at":    &sentAt,
+			"last_error": "",
+		})
+		return true
+	}
+
+	updates := map[string]any{"last_error": err.Error()}
+	if d.Attempts >= o.cfg.MaxAttempts {
+		updates["status"] = models.DeliveryDead
+		log.Printf("Уведомление %s по заявке ID=%d не доставлено после %d попыток: %v",
+			d.Channel, d.ContactID, d.Attempts, err)
+	} else {
+		updates["next_attempt_at"] = now.Add(o.backoff(d.Attempts))
+		log.Printf("Ошибка доставки %s по заявке ID=%d (попытка %d): %v", d.Channel, d.ContactID, d.Attempts, err)
+	}
+	o.db.Model(d).Updates(updates)
+	return false
+}
+
+// send отправляет доставку в её канал с таймаутом SendTimeout
+func (o *Outbox) send(ctx context.Context, d *models.NotificationDelivery) error {
+	n, ok := o.notifiers[d.Channel]
+	if !ok {
+		return fmt.Errorf("канал %s не настроен", d.Channel)
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(d.Payload), &ev); err != nil {
+		return fmt.Errorf("некорректные данные события: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.cfg.SendTimeout)
+	defer cancel()
+	return n.Notify(ctx, ev)
+}
+
+// backoff - задержка после attempts неудачных попыток: RetryDelay, 2x, 4x ... до MaxRetryDelay
+func (o *Outbox) backoff(attempts int) time.Duration {
+	d := o.cfg.RetryDelay
+	for i := 1; i < attempts && d < o.cfg.MaxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, o.cfg.MaxRetryDelay)
+}
+
+// Retry возвращает доставку из dead в очередь с новым счётчиком попыток
+func (o *Outbox) Retry(id uint) error {
+	res := o.db.Model(&models.NotificationDelivery{}).
+		Where("id = ? AND status = ?", id, models.DeliveryDead).
+		Updates(map[string]any{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotDead
+	}
+	o.Wake()
+	return nil
+}
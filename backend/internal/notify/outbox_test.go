@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"ledsite/internal/mailer"
+	"ledsite/internal/models"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.NotificationDelivery{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// fakeNotifier - канал в памяти, падающий первые failures раз
+type fakeNotifier struct {
+	name     string
+	kinds    []EventKind
+	failures int
+
+	mu    sync.Mutex
+	calls int
+	got   []Event
+}
+
+func (f *fakeNotifier) Channel() string { return f.name }
+
+func (f *fakeNotifier) Supports(kind EventKind) bool {
+	for _, k := range f.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, ev Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("канал недоступен")
+	}
+	f.got = append(f.got, ev)
+	return nil
+}
+
+func testEvent(kind EventKind) Event {
+	return Event{Kind: kind, Contact: Contact{ID: 7, Name: "Иван", Phone: "+79211234567"}}
+}
+
+func enqueue(t *testing.T, o *Outbox, ev Event) {
+	t.Helper()
+	assert.NoError(t, o.db.Transaction(func(tx *gorm.DB) error { return o.EnqueueTx(tx, ev) }))
+}
+
+func TestEnqueueTx_OnlySupportingChannels(t *testing.T) {
+	db := setupTestDB(t)
+	tg := &fakeNotifier{name: "telegram", kinds: []EventKind{EventNewContact}}
+	hook := &fakeNotifier{name: "webhook", kinds: []EventKind{EventNewContact, EventReminder}}
+	o := NewOutbox(db, OutboxConfig{}, tg, hook)
+
+	enqueue(t, o, testEvent(EventNewContact))
+	enqueue(t, o, testEvent(EventReminder))
+
+	var rows []models.NotificationDelivery
+	db.Order("id").Find(&rows)
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "telegram", rows[0].Channel)
+		assert.Equal(t, "webhook", rows[1].Channel)
+		assert.Equal(t, "webhook", rows[2].Channel)
+		assert.Equal(t, "reminder", rows[2].Event)
+		assert.Equal(t, models.DeliveryPending, rows[0].Status)
+		assert.Equal(t, uint(7), rows[0].ContactID)
+	}
+}
+
+func TestProcessDue_Delivers(t *testing.T) {
+	db := setupTestDB(t)
+	tg := &fakeNotifier{name: "telegram", kinds: []EventKind{EventNewContact}}
+	o := NewOutbox(db, OutboxConfig{}, tg)
+	enqueue(t, o, testEvent(EventNewContact))
+
+	assert.Equal(t, 1, o.ProcessDue(context.Background(), time.Now()))
+	assert.Equal(t, 0, o.ProcessDue(context.Background(), time.Now()), "доставленное не отправляется повторно")
+
+	if assert.Len(t, tg.got, 1) {
+		assert.Equal(t, "Иван", tg.got[0].Contact.Name)
+	}
+	var d models.NotificationDelivery
+	db.First(&d)
+	assert.Equal(t, models.DeliverySent, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.NotNil(t, d.SentAt)
+}
+
+func TestProcessDue_BackoffThenDead(t *testing.T) {
+	db := setupTestDB(t)
+	tg := &fakeNotifier{name: "telegram", kinds: []EventKind{EventNewContact}, failures: 100}
+	o := NewOutbox(db, OutboxConfig{MaxAttempts: 3, RetryDelay: time.Minute}, tg)
+	enqueue(t, o, testEvent(EventNewContact))
+
+	now := time.Now()
+	assert.Zero(t, o.ProcessDue(context.Background(), now))
+
+	var d models.NotificationDelivery
+	db.First(&d)
+	assert.Equal(t, models.DeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, "канал недоступен", d.LastError)
+	assert.WithinDuration(t, now.Add(time.Minute), d.NextAttemptAt, time.Second)
+
+	// До наступления next_attempt_at повтора нет
+	o.ProcessDue(context.Background(), now.Add(30*time.Second))
+	assert.Equal(t, 1, tg.calls)
+
+	o.ProcessDue(context.Background(), now.Add(time.Minute))
+	db.First(&d)
+	assert.Equal(t, 2, d.Attempts)
+	assert.WithinDuration(t, now.Add(3*time.Minute), d.NextAttemptAt, time.Second, "задержка удваивается")
+
+	o.ProcessDue(context.Background(), now.Add(3*time.Minute))
+	db.First(&d)
+	assert.Equal(t, models.DeliveryDead, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+
+	o.ProcessDue(context.Background(), now.Add(time.Hour))
+	assert.Equal(t, 3, tg.calls, "dead не отправляется автоматически")
+}
+
+func TestRetry(t *testing.T) {
+	db := setupTestDB(t)
+	tg := &fakeNotifier{name: "telegram", kinds: []EventKind{EventNewContact}, failures: 1}
+	o := NewOutbox(db, OutboxConfig{MaxAttempts: 1}, tg)
+	enqueue(t, o, testEvent(EventNewContact))
+
+	o.ProcessDue(context.Background(), time.Now())
+	var d models.NotificationDelivery
+	db.First(&d)
+	assert.Equal(t, models.DeliveryDead, d.Status)
+
+	assert.NoError(t, o.Retry(d.ID))
+	assert.ErrorIs(t, o.Retry(d.ID), ErrNotDead)
+
+	assert.Equal(t, 1, o.ProcessDue(context.Background(), time.Now()))
+	db.First(&d)
+	assert.Equal(t, models.DeliverySent, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+}
+
+func TestProcessDue_UnknownChannel(t *testing.T) {
+	db := setupTestDB(t)
+	o := NewOutbox(db, OutboxConfig{MaxAttempts: 1})
+	db.Create(&models.NotificationDelivery{
+		Channel: "webhook", Event: "new_contact", Payload: "{}",
+		Status: models.DeliveryPending, NextAttemptAt: time.Now().UTC(),
+	})
+
+	o.ProcessDue(context.Background(), time.Now())
+	var d models.NotificationDelivery
+	db.First(&d)
+	assert.Equal(t, models.DeliveryDead, d.Status)
+	assert.Contains(t, d.LastError, "не настроен")
+}
+
+func TestBackoff_Capped(t *testing.T) {
+	o := NewOutbox(nil, OutboxConfig{RetryDelay: time.Minute, MaxRetryDelay: 5 * time.Minute})
+	assert.Equal(t, time.Minute, o.backoff(1))
+	assert.Equal(t, 2*time.Minute, o.backoff(2))
+	assert.Equal(t, 4*time.Minute, o.backoff(3))
+	assert.Equal(t, 5*time.Minute, o.backoff(4))
+	assert.Equal(t, 5*time.Minute, o.backoff(50))
+}
+
+func TestWebhook_SignsBody(t *testing.T) {
+	var gotBody []byte
+	var gotHeaders http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeaders = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	ev := testEvent(EventReminder)
+	assert.NoError(t, NewWebhook(srv.URL, "secret").Notify(context.Background(), ev))
+
+	assert.Equal(t, "reminder", gotHeaders.Get(HeaderEvent))
+	assert.Equal(t, "sha256="+Sign("secret", gotBody), gotHeaders.Get(HeaderSignature))
+	var decoded Event
+	assert.NoError(t, json.Unmarshal(gotBody, &decoded))
+	assert.Equal(t, ev, decoded)
+}
+
+func TestTelegram_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := NewTelegram(srv.URL).Notify(context.Background(), testEvent(EventNewContact))
+	assert.ErrorContains(t, err, "502")
+}
+
+// recordingSender - письма в память вместо SMTP
+type recordingSender struct{ sent []mailer.Message }
+
+func (s *recordingSender) Send(msg mailer.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestEmail_Reminder(t *testing.T) {
+	sender := &recordingSender{}
+	remindAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	ev := testEvent(EventReminder)
+	ev.Contact.RemindAt = &remindAt
+
+	assert.NoError(t, NewEmail(sender, []string{"sales@example.com"}).Notify(context.Background(), ev))
+	if assert.Len(t, sender.sent, 1) {
+		assert.Equal(t, []string{"sales@example.com"}, sender.sent[0].To)
+		assert.Contains(t, sender.sent[0].Subject, "Иван")
+		assert.Contains(t, sender.sent[0].Text, "01.03.2026 12:30")
+	}
+}
+
+func TestCustomerEmail_SendsToClient(t *testing.T) {
+	sender := &recordingSender{}
+	ev := testEvent(EventCustomerAck)
+	ev.Contact.Email = "ivan@example.com"
+	ev.Mail = &mailer.Message{Subject: "Ваша заявка L-000007 принята", Text: "Спасибо"}
+
+	ch := NewCustomerEmail(sender)
+	assert.True(t, ch.Supports(EventCustomerAck))
+	assert.False(t, ch.Supports(EventNewContact))
+	assert.False(t, NewWebhook("http://example.com", "").Supports(EventCustomerAck), "письма клиенту не уходят в webhook")
+
+	assert.NoError(t, ch.Notify(context.Background(), ev))
+	if assert.Len(t, sender.sent, 1) {
+		assert.Equal(t, []string{"ivan@example.com"}, sender.sent[0].To)
+		assert.Equal(t, "Спасибо", sender.sent[0].Text)
+	}
+
+	ev.Mail = nil
+	assert.Error(t, ch.Notify(context.Background(), ev))
+}
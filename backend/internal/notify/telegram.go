@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Telegram отправляет новые заявки в Telegram бот (POST TELEGRAM_BOT_URL).
+// Напоминания бот забирает сам через /api/telegram/due-reminders.
+type Telegram struct {
+	url    string
+	client *http.Client
+}
+
+// TelegramNotification - тело запроса к эндпоинту бота /api/send-notification
+type TelegramNotification struct {
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email,omitempty"`
+	Company     string `json:"company,omitempty"`
+	ProjectType string `json:"project_type,omitempty"`
+	Message     string `json:"message,omitempty"`
+	ContactID   uint   `json:"contact_id,omitempty"`
+	Timestamp   string `json:"timestamp,omitempty"`
+
+	ReturningCustomer bool `json:"returning_customer"`          // клиент уже оставлял заявки
+	PreviousContacts  int  `json:"previous_contacts,omitempty"` // сколько заявок было раньше
+
+	AssignedTo string `json:"assigned_to,omitempty"` // логин ответственного менеджера
+}
+
+// NewTelegram создаёт канал Telegram; url - полный адрес /api/send-notification бота
+func NewTelegram(url string) *Telegram {
+	return &Telegram{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Channel реализует Notifier
+func (t *Telegram) Channel() string { return "telegram" }
+
+// Supports реализует Notifier: только новые заявки
+func (t *Telegram) Supports(kind EventKind) bool { return kind == EventNewContact }
+
+// Notify реализует Notifier
+func (t *Telegram) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(TelegramNotification{
+		Name:        ev.Contact.Name,
+		Phone:       ev.Contact.Phone,
+		Email:       ev.Contact.Email,
+		Company:     ev.Contact.Company,
+		ProjectType: ev.Contact.ProjectTitle,
+		Message:     ev.Contact.Message,
+		ContactID:   ev.Contact.ID,
+		Timestamp:   formatMSK(ev.Contact.CreatedAt),
+
+		ReturningCustomer: ev.Contact.Returning(),
+		PreviousContacts:  ev.Contact.PreviousContacts,
+
+		AssignedTo: ev.Contact.AssignedTo,
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, t.client, t.url, body, nil)
+}
+
+// postJSON отправляет JSON и считает ошибкой любой ответ кроме 2xx
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("ответ %d", resp.StatusCode)
+	}
+	return nil
+}
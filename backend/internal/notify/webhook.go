@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Заголовки запроса webhook
+const (
+	HeaderEvent     = "X-Notify-Event"     // тип события
+	HeaderSignature = "X-Notify-Signature" // "sha256=<hex HMAC тела>", если задан секрет
+)
+
+// Webhook отправляет события о заявках JSON-ом (Event) на произвольный URL (CRM, Zapier и т.п.)
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhook создаёт канал webhook. secret - ключ подписи тела (пустой - без подписи).
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{url: url, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Channel реализует Notifier
+func (w *Webhook) Channel() string { return "webhook" }
+
+// Supports реализует Notifier: все события, кроме писем клиенту
+func (w *Webhook) Supports(kind EventKind) bool { return kind != EventCustomerAck }
+
+// Notify реализует Notifier
+func (w *Webhook) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{HeaderEvent: string(ev.Kind)}
+	if w.secret != "" {
+		headers[HeaderSignature] = "sha256=" + Sign(w.secret, body)
+	}
+	return postJSON(ctx, w.client, w.url, body, headers)
+}
+
+// Sign возвращает hex HMAC-SHA256 тела - получатель webhook проверяет им подпись
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
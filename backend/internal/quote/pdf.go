@@ -0,0 +1,175 @@
+package quote
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Шрифт DejaVu Sans (лицензия в fonts/LICENSE): стандартные шрифты PDF не содержат кириллицы
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSans-Bold.ttf
+	fontBold []byte
+)
+
+// ValidDays - срок действия предложения (цены зависят от курса доллара)
+const ValidDays = 14
+
+// sellerName - название компании в шапке КП
+const sellerName = "Service 'n' Repair"
+
+// Seller - контакты компании из настроек сайта
+type Seller struct {
+	Phone   string
+	Email   string
+	Address string
+}
+
+// Customer - заказчик из заявки
+type Customer struct {
+	Name    string
+	Company string
+	Phone   string
+	Email   string
+}
+
+// Document - данные коммерческого предложения
+type Document struct {
+	Number   string
+	Date     time.Time // дата КП (по МСК)
+	Seller   Seller
+	Customer Customer
+	Manager  string // логин администратора, сформировавшего КП
+	Calc     Calculation
+}
+
+// Ширины колонок таблицы спецификации (A4, поля 15 мм: 180 мм)
+var columnWidths = []float64{10, 80, 16, 18, 26, 30}
+
+// Render собирает PDF коммерческого предложения
+func Render(doc Document) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddUTF8FontFromBytes("DejaVu", "", fontRegular)
+	pdf.AddUTF8FontFromBytes("DejaVu", "B", fontBold)
+	pdf.SetTitle("Коммерческое предложение № "+doc.Number, true)
+	pdf.SetAuthor(sellerName, true)
+	pdf.SetCreationDate(doc.Date)
+	pdf.SetModificationDate(doc.Date)
+	pdf.AddPage()
+
+	// Шапка: компания и контакты
+	pdf.SetFont("DejaVu", "B", 16)
+	pdf.CellFormat(90, 8, sellerName, "", 0, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 9)
+	contacts := []string{"s-n-r.ru"}
+	for _, s := range []string{doc.Seller.Phone, doc.Seller.Email, strings.ReplaceAll(doc.Seller.Address, "\n", ", ")} {
+		if s != "" {
+			contacts = append(contacts, s)
+		}
+	}
+	pdf.MultiCell(0, 4.5, strings.Join(contacts, "\n"), "", "R", false)
+	pdf.Ln(6)
+
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(0, 8, fmt.Sprintf("Коммерческое предложение № %s от %s", doc.Number, doc.Date.Format("02.01.2006")), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	// Заказчик
+	pdf.SetFont("DejaVu", "", 10)
+	customer := doc.Customer.Name
+	if doc.Customer.Company != "" {
+		customer += ", " + doc.Customer.Company
+	}
+	pdf.CellFormat(0, 6, "Заказчик: "+customer, "", 1, "L", false, 0, "")
+	var reach []string
+	for _, s := range []string{doc.Customer.Phone, doc.Customer.Email} {
+		if s != "" {
+			reach = append(reach, s)
+		}
+	}
+	if len(reach) > 0 {
+		pdf.CellFormat(0, 6, "Контакты: "+strings.Join(reach, ", "), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+
+	// Экран
+	calc := doc.Calc
+	pdf.SetFont("DejaVu", "B", 11)
+	pdf.MultiCell(0, 6, calc.Summary(), "", "L", false)
+	pdf.SetFont("DejaVu", "", 10)
+	area := float64(calc.WidthMM*calc.HeightMM) / 1e6
+	pdf.CellFormat(0, 6, fmt.Sprintf("Площадь экрана: %s м², кабинетов: %d", formatDecimal(area, 2), calc.Cabinets), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	// Спецификация
+	pdf.SetFont("DejaVu", "B", 9)
+	pdf.SetFillColor(240, 240, 240)
+	for i, title := range []string{"№", "Наименование", "Ед.", "Кол-во", "Цена, ₽", "Сумма, ₽"} {
+		pdf.CellFormat(columnWidths[i], 7, title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("DejaVu", "", 9)
+	for i, line := range calc.Lines {
+		cells := []string{strconv.Itoa(i + 1), line.Title, line.Unit, strconv.Itoa(line.Qty), FormatMoney(line.Price), FormatMoney(line.Sum)}
+		aligns := []string{"C", "L", "C", "R", "R", "R"}
+		for j, cell := range cells {
+			pdf.CellFormat(columnWidths[j], 7, cell, "1", 0, aligns[j], false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetFont("DejaVu", "B", 10)
+	labelWidth := 0.0
+	for _, w := range columnWidths[:len(columnWidths)-1] {
+		labelWidth += w
+	}
+	pdf.CellFormat(labelWidth, 8, "Итого:", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(columnWidths[len(columnWidths)-1], 8, FormatMoney(calc.Total), "1", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	// Условия
+	pdf.SetFont("DejaVu", "", 9)
+	terms := []string{
+		fmt.Sprintf("Цены рассчитаны по курсу ЦБ РФ с надбавкой: 1 $ = %s ₽.", formatDecimal(calc.UsdRate, 2)),
+		fmt.Sprintf("Предложение действительно %d дней, до %s.", ValidDays, doc.Date.AddDate(0, 0, ValidDays).Format("02.01.2006")),
+		"Доставка и монтаж рассчитываются после осмотра места установки.",
+	}
+	pdf.MultiCell(0, 5, strings.Join(terms, "\n"), "", "L", false)
+	if doc.Manager != "" {
+		pdf.Ln(4)
+		pdf.CellFormat(0, 6, "Менеджер: "+doc.Manager, "", 1, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatMoney - сумма в рублях с разделителем тысяч: 1234567 → "1 234 567"
+func FormatMoney(v float64) string {
+	s := strconv.FormatInt(int64(math.Round(v)), 10)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 && s[i-1] != '-' {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// formatDecimal - число с запятой: 95.4 → "95,40"
+func formatDecimal(v float64, prec int) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', prec, 64), ".", ",", 1)
+}
@@ -0,0 +1,151 @@
+// Package quote считает спецификацию LED экрана по данным калькулятора
+// и собирает из неё коммерческое предложение (КП) в PDF.
+package quote
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"ledsite/internal/models"
+)
+
+// MaxCabinets - ограничение ширины и высоты экрана в кабинетах
+const MaxCabinets = 50
+
+// ErrNoRate - курс доллара недоступен (ЦБ не ответил и кэша нет)
+var ErrNoRate = errors.New("Курс доллара недоступен, попробуйте позже")
+
+// Params - параметры экрана, как в калькуляторе на странице цен
+type Params struct {
+	ScreenType string // "indoor" | "outdoor"
+	Width      int    // ширина в кабинетах
+	Height     int    // высота в кабинетах
+	Light      bool   // Light исполнение (упрощённый корпус кабинета)
+}
+
+// Line - строка спецификации
+type Line struct {
+	Title    string  `json:"title"`
+	Unit     string  `json:"unit"`
+	Qty      int     `json:"qty"`
+	PriceUSD float64 `json:"price_usd"` // цена единицы, $
+	Price    float64 `json:"price"`     // цена единицы, ₽ (округлена до рубля)
+	Sum      float64 `json:"sum"`       // Price × Qty, ₽
+}
+
+// Calculation - спецификация экрана и итог
+type Calculation struct {
+	ScreenType string  `json:"screen_type"`
+	PitchName  string  `json:"pitch_name"`
+	Light      bool    `json:"light"`
+	Width      int     `json:"width"`  // в кабинетах
+	Height     int     `json:"height"` // в кабинетах
+	WidthMM    int     `json:"width_mm"`
+	HeightMM   int     `json:"height_mm"`
+	Cabinets   int     `json:"cabinets"`
+	UsdRate    float64 `json:"usd_rate"` // курс ЦБ с надбавкой
+	Lines      []Line  `json:"lines"`
+	TotalUSD   float64 `json:"total_usd"`
+	Total      float64 `json:"total"` // сумма строк, ₽
+}
+
+// screenTypeTitles - названия типов экрана
+var screenTypeTitles = map[string]string{
+	"indoor":  "Интерьерный",
+	"outdoor": "Уличный",
+}
+
+// ScreenTypeTitle - название типа экрана ("indoor" → "Интерьерный")
+func ScreenTypeTitle(screenType string) string {
+	if t, ok := screenTypeTitles[screenType]; ok {
+		return t
+	}
+	return screenType
+}
+
+// cabinet - константы кабинета одного типа экрана из CalculatorSettings
+type cabinet struct {
+	size        int
+	modules     int
+	commutation float64
+	std         float64
+	light       float64
+	card        float64
+	power       float64
+}
+
+func cabinetFor(s models.CalculatorSettings, screenType string) (cabinet, bool) {
+	switch screenType {
+	case "indoor":
+		return cabinet{s.IndoorCabinetSize, s.IndoorModulesPerCab, s.IndoorCommutation,
+			s.IndoorCabinetStd, s.IndoorCabinetLight, s.IndoorReceivingCard, s.IndoorPowerSupply}, true
+	case "outdoor":
+		return cabinet{s.OutdoorCabinetSize, s.OutdoorModulesPerCab, s.OutdoorCommutation,
+			s.OutdoorCabinetStd, s.OutdoorCabinetLight, s.OutdoorReceivingCard, s.OutdoorPowerSupply}, true
+	}
+	return cabinet{}, false
+}
+
+// Calculate считает спецификацию по той же формуле, что калькулятор на странице цен:
+// (коммутация + кабинет + принимающая карта + блоки питания + модули × шт.) × кабинетов × курс.
+// usdRate - курс с надбавкой (getOrRefreshUSDRate). Цена единицы округляется до рубля,
+// поэтому итог может отличаться от калькулятора на несколько рублей.
+func Calculate(s models.CalculatorSettings, pitch models.CalculatorPixelPitch, p Params, usdRate float64) (Calculation, error) {
+	cab, ok := cabinetFor(s, p.ScreenType)
+	if !ok {
+		return Calculation{}, errors.New("Неизвестный тип экрана")
+	}
+	if pitch.ScreenType != p.ScreenType {
+		return Calculation{}, errors.New("Шаг пикселя не подходит к типу экрана")
+	}
+	if p.Width < 1 || p.Height < 1 || p.Width > MaxCabinets || p.Height > MaxCabinets {
+		return Calculation{}, fmt.Errorf("Ширина и высота - от 1 до %d кабинетов", MaxCabinets)
+	}
+	if usdRate <= 0 {
+		return Calculation{}, ErrNoRate
+	}
+
+	cabinets := p.Width * p.Height
+	cabTitle, cabPrice := "Standard", cab.std
+	if p.Light {
+		cabTitle, cabPrice = "Light", cab.light
+	}
+
+	calc := Calculation{
+		ScreenType: p.ScreenType,
+		PitchName:  pitch.Name,
+		Light:      p.Light,
+		Width:      p.Width,
+		Height:     p.Height,
+		WidthMM:    p.Width * cab.size,
+		HeightMM:   p.Height * cab.size,
+		Cabinets:   cabinets,
+		UsdRate:    usdRate,
+	}
+	add := func(title, unit string, qty int, priceUSD float64) {
+		price := math.Round(priceUSD * usdRate)
+		calc.Lines = append(calc.Lines, Line{
+			Title: title, Unit: unit, Qty: qty,
+			PriceUSD: priceUSD, Price: price, Sum: price * float64(qty),
+		})
+		calc.TotalUSD += priceUSD * float64(qty)
+		calc.Total += price * float64(qty)
+	}
+	add("Светодиодный модуль "+pitch.Name, "шт", cab.modules*cabinets, pitch.ModulePrice)
+	add(fmt.Sprintf("Кабинет %s %d×%d мм", cabTitle, cab.size, cab.size), "шт", cabinets, cabPrice)
+	add("Принимающая карта", "шт", cabinets, cab.card)
+	add("Блоки питания (комплект на кабинет)", "компл.", cabinets, cab.power)
+	add("Коммутация (комплект на кабинет)", "компл.", cabinets, cab.commutation)
+	return calc, nil
+}
+
+// Summary - описание экрана одной строкой: "Интерьерный LED экран P2,5, Standard, 1920×1280 мм (3×2 каб.)"
+func (c Calculation) Summary() string {
+	kind := "Standard"
+	if c.Light {
+		kind = "Light"
+	}
+	return fmt.Sprintf("%s LED экран %s, %s, %d×%d мм (%d×%d каб.)",
+		ScreenTypeTitle(c.ScreenType), c.PitchName, kind, c.WidthMM, c.HeightMM, c.Width, c.Height)
+}
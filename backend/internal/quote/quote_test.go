@@ -0,0 +1,103 @@
+package quote
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"ledsite/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSettings() models.CalculatorSettings {
+	return models.CalculatorSettings{
+		IndoorCabinetSize: 640, IndoorModulesPerCab: 8, IndoorCommutation: 5,
+		IndoorCabinetStd: 76, IndoorCabinetLight: 60, IndoorReceivingCard: 20, IndoorPowerSupply: 17.6,
+		OutdoorCabinetSize: 960, OutdoorModulesPerCab: 18, OutdoorCommutation: 5,
+		OutdoorCabinetStd: 200, OutdoorCabinetLight: 160, OutdoorReceivingCard: 20, OutdoorPowerSupply: 52.8,
+	}
+}
+
+func TestCalculate_Indoor(t *testing.T) {
+	pitch := models.CalculatorPixelPitch{ScreenType: "indoor", Name: "P2,5", ModulePrice: 10}
+	calc, err := Calculate(testSettings(), pitch, Params{ScreenType: "indoor", Width: 3, Height: 2}, 100)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 6, calc.Cabinets)
+	assert.Equal(t, 1920, calc.WidthMM)
+	assert.Equal(t, 1280, calc.HeightMM)
+	if assert.Len(t, calc.Lines, 5) {
+		assert.Equal(t, "Светодиодный модуль P2,5", calc.Lines[0].Title)
+		assert.Equal(t, 48, calc.Lines[0].Qty)
+		assert.Equal(t, "Кабинет Standard 640×640 мм", calc.Lines[1].Title)
+		assert.Equal(t, 1760.0, calc.Lines[3].Price, "цена единицы округляется до рубля")
+	}
+	// Калькулятор на сайте: (5 + 76 + 20 + 17.6 + 10×8) × 6 × 100
+	assert.InDelta(t, 119160.0, calc.TotalUSD*100, 0.001)
+	assert.Equal(t, 119160.0, calc.Total)
+	assert.Equal(t, "Интерьерный LED экран P2,5, Standard, 1920×1280 мм (3×2 каб.)", calc.Summary())
+}
+
+func TestCalculate_Light(t *testing.T) {
+	pitch := models.CalculatorPixelPitch{ScreenType: "outdoor", Name: "P5", ModulePrice: 20}
+	calc, err := Calculate(testSettings(), pitch, Params{ScreenType: "outdoor", Width: 1, Height: 1, Light: true}, 100)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Кабинет Light 960×960 мм", calc.Lines[1].Title)
+	assert.Equal(t, 16000.0, calc.Lines[1].Sum)
+	assert.Equal(t, 59780.0, calc.Total)
+}
+
+func TestCalculate_Invalid(t *testing.T) {
+	s := testSettings()
+	indoor := models.CalculatorPixelPitch{ScreenType: "indoor", Name: "P2,5", ModulePrice: 10}
+
+	_, err := Calculate(s, indoor, Params{ScreenType: "stage", Width: 1, Height: 1}, 100)
+	assert.Error(t, err)
+	_, err = Calculate(s, indoor, Params{ScreenType: "outdoor", Width: 1, Height: 1}, 100)
+	assert.Error(t, err, "шаг от другого типа экрана")
+	_, err = Calculate(s, indoor, Params{ScreenType: "indoor", Width: 0, Height: 1}, 100)
+	assert.Error(t, err)
+	_, err = Calculate(s, indoor, Params{ScreenType: "indoor", Width: 1, Height: MaxCabinets + 1}, 100)
+	assert.Error(t, err)
+	_, err = Calculate(s, indoor, Params{ScreenType: "indoor", Width: 1, Height: 1}, 0)
+	assert.True(t, errors.Is(err, ErrNoRate))
+}
+
+func TestRender(t *testing.T) {
+	pitch := models.CalculatorPixelPitch{ScreenType: "indoor", Name: "P2,5", ModulePrice: 10}
+	calc, err := Calculate(testSettings(), pitch, Params{ScreenType: "indoor", Width: 3, Height: 2}, 95.4)
+	if !assert.NoError(t, err) {
+		return
+	}
+	doc := Document{
+		Number:   "2026-0001",
+		Date:     time.Date(2026, 4, 12, 10, 0, 0, 0, time.UTC),
+		Seller:   Seller{Phone: "+7 (921) 123-45-67", Email: "info@s-n-r.ru", Address: "Санкт-Петербург"},
+		Customer: Customer{Name: "Иван", Company: "ООО Ромашка", Phone: "+79211234567"},
+		Manager:  "manager",
+		Calc:     calc,
+	}
+	out, err := Render(doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-")))
+
+	again, err := Render(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, out, again, "одинаковые данные - одинаковый PDF")
+}
+
+func TestFormatMoney(t *testing.T) {
+	assert.Equal(t, "0", FormatMoney(0))
+	assert.Equal(t, "999", FormatMoney(999))
+	assert.Equal(t, "1 000", FormatMoney(999.5))
+	assert.Equal(t, "1 234 567", FormatMoney(1234567))
+	assert.Equal(t, "-12 345", FormatMoney(-12345))
+}
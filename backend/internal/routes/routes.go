@@ -1,16 +1,20 @@
 // Package routes содержит настройку всех HTTP маршрутов приложения.
 //
 // Организация маршрутов:
-//   - Публичные страницы (GET /, /projects, /services, /contact, /privacy)
+//   - Публичные страницы (GET /, /projects, /projects/:slug, /services, /services/:slug, /contact, /privacy; cookie источника визита)
 //   - Публичные API (/api/projects, /api/contact, /api/track/*)
-//   - Админ страницы без защиты (GET/POST /admin/login)
-//   - Защищённые админ роуты (/admin/* с JWT middleware)
+//   - Админ страницы без защиты (GET/POST /admin/login, POST /admin/login/2fa)
+//   - JSON API админки (/admin/api/* с JWT middleware, ошибки в формате JSON)
+//   - Защищённые админ роуты (/admin/* с JWT middleware, CSRF-токеном, изменения пишутся в журнал)
 //
 // Все админские роуты (кроме login) защищены AuthMiddleware,
-// который проверяет JWT токен из HTTP-only cookie.
+// который проверяет JWT токен из HTTP-only cookie. Изменяющие роуты
+// дополнительно объявляют необходимое право через RequirePermission.
 package routes
 
 import (
+	"net/http"
+
 	"ledsite/internal/handlers"
 	"ledsite/internal/middleware"
 
@@ -27,6 +31,7 @@ import (
 //   - Публичные страницы (без защиты)
 //   - /api - публичные API эндпоинты
 //   - /admin/login - страница входа (без защиты)
+//   - /admin/api/* - JSON API админки (JWT required, 401 JSON)
 //   - /admin/* - защищённые админские роуты (JWT required)
 //
 // Вызывается из main.go:
@@ -44,28 +49,44 @@ func Setup(router *gin.Engine, h *handlers.Handlers) {
 	router.GET("/sitemap.xml", h.Sitemap)
 	router.GET("/robots.txt", h.RobotsTxt)
 
-	// Публичные страницы
-	router.GET("/", h.HomePage)
-	router.GET("/projects", h.ProjectsPage)
-	router.GET("/services", h.ServicesPage)
-	router.GET("/led-screens-guide", h.LEDGuidePage)
-	router.GET("/prices", h.PricesPage)
-	router.GET("/contact", h.ContactPage)
-	router.GET("/privacy", h.PrivacyPage)
+	// Публичные страницы - источник визита (UTM, реферер) запоминается в cookie для атрибуции заявок
+	pages := router.Group("/", middleware.Attribution())
+	{
+		pages.GET("/", h.HomePage)
+		pages.GET("/projects", h.ProjectsPage)
+		pages.GET("/projects/:slug", h.ProjectDetailPage)
+		pages.GET("/services", h.ServicesPage)
+		pages.GET("/services/:slug", h.ServiceDetailPage)
+		pages.GET("/led-screens-guide", h.LEDGuidePage)
+		pages.GET("/prices", h.PricesPage)
+		pages.GET("/contact", h.ContactPage)
+		pages.GET("/privacy", h.PrivacyPage)
+	}
 
 	// API (публичное) - JSON эндпоинты без авторизации
 	api := router.Group("/api")
 	{
 		api.GET("/projects", h.GetProjects)                     // Список проектов с пагинацией
 		api.POST("/contact", h.SubmitContact)                   // Отправка заявки от клиента
-		api.GET("/admin/contacts-7d", h.AdminContacts7Days)     // Статистика заявок за 7 дней (для dashboard)
 		api.POST("/track/project-view/:id", h.TrackProjectView) // Трекинг просмотров проекта
 		api.POST("/track/price-view/:id", h.TrackPriceView)     // Трекинг просмотров позиции прайса
 		api.GET("/promo", h.GetActivePromo)                     // Получение активного popup для страницы
 		api.GET("/calculator", h.GetCalculatorData)             // Данные калькулятора (курс, настройки, шаги)
 
-		// Telegram Bot API - доступ только с localhost (127.0.0.1)
+		// Устаревший путь статистики dashboard - редирект на /admin/api/contacts-7d.
+		// TODO: удалить в следующем релизе
+		api.GET("/admin/contacts-7d", func(c *gin.Context) {
+			target := "/admin/api/contacts-7d"
+			if q := c.Request.URL.RawQuery; q != "" {
+				target += "?" + q
+			}
+			c.Redirect(http.StatusTemporaryRedirect, target)
+		})
+
+		// Telegram Bot API - только подписанные запросы бота (HMAC + timestamp + nonce),
+		// опционально ограничение по IP (TELEGRAM_API_ALLOWED_IPS, TRUSTED_PROXIES)
 		telegram := api.Group("/telegram")
+		telegram.Use(middleware.ServiceAuthMiddleware(middleware.LoadServiceAuthConfig()))
 		{
 			telegram.POST("/update-status", h.TelegramUpdateStatus)          // Изменить статус заявки
 			telegram.POST("/add-note", h.TelegramAddNote)                    // Добавить заметку
@@ -78,107 +99,232 @@ func Setup(router *gin.Engine, h *handlers.Handlers) {
 	// Публичные роуты админки (без авторизации)
 	router.GET("/admin/login", h.ShowLoginPage)
 	router.POST("/admin/login", h.Login)
+	router.POST("/admin/login/2fa", h.LoginTwoFactor) // Второй шаг входа (код 2FA), требует cookie admin_2fa
+
+	// JSON API админки - при отсутствии/истечении JWT отвечает 401 JSON вместо редиректа
+	adminAPI := router.Group("/admin/api")
+	adminAPI.Use(middleware.AuthAPIMiddleware(h), middleware.RequirePermission(handlers.PermView), middleware.CSRF())
+	{
+		adminAPI.GET("/contacts-7d", h.AdminContacts7Days) // Статистика заявок за 7 дней (для dashboard)
+	}
+
+	// Права на действия (роль берётся из сессии админа в БД, см. handlers.RoleHasPermission).
+	// GET страницы доступны любой роли, изменяющие роуты объявляют своё право явно.
+	var (
+		canEditContent   = middleware.RequirePermission(handlers.PermContentEdit)
+		canEditContacts  = middleware.RequirePermission(handlers.PermContactsEdit)
+		canExport        = middleware.RequirePermission(handlers.PermContactsExport)
+		canDeleteContact = middleware.RequirePermission(handlers.PermContactsDelete)
+		canEditPricing   = middleware.RequirePermission(handlers.PermPricingEdit)
+		canResetViews    = middleware.RequirePermission(handlers.PermAnalyticsReset)
+		canEditSettings  = middleware.RequirePermission(handlers.PermSettingsEdit)
+		canManageUsers   = middleware.RequirePermission(handlers.PermUsersManage)
+		canViewAudit     = middleware.RequirePermission(handlers.PermAuditView)
+	)
 
 	// Админка (защищённые роуты) - требуют валидный JWT токен
 	admin := router.Group("/admin")
-	admin.Use(middleware.AuthMiddleware()) // JWT проверка для всех роутов ниже
+	admin.Use(
+		middleware.AuthMiddleware(h),                    // JWT + серверная сессия для всех роутов ниже
+		middleware.RequirePermission(handlers.PermView), // Любая роль может просматривать админку
+		middleware.CSRF(),                               // CSRF-токен сессии для POST/PUT/PATCH/DELETE
+		middleware.Audit(h),                             // Журнал изменений для POST/PUT/PATCH/DELETE
+	)
 	{
 		admin.GET("/", h.AdminDashboard) // Главная страница админки с аналитикой
-		admin.GET("/logout", h.Logout)   // Выход (удаление JWT cookie)
+		admin.GET("/logout", h.Logout)   // Выход (отзыв сессии и удаление cookie)
+
+		// Сессии текущего администратора - доступны любой роли
+		sess := admin.Group("/sessions")
+		{
+			sess.GET("", h.AdminSessionsPage)                       // Список активных сессий
+			sess.POST("/:id/revoke", h.RevokeAdminSession)          // Завершить одну сессию
+			sess.POST("/revoke-others", h.RevokeOtherAdminSessions) // Завершить все, кроме текущей
+		}
+
+		// Двухфакторная аутентификация текущего администратора (блок на странице сессий)
+		tfa := admin.Group("/security/2fa")
+		{
+			tfa.POST("/setup", h.SetupTwoFactor)                   // Новый секрет и QR-код
+			tfa.POST("/enable", h.EnableTwoFactor)                 // Подтверждение кодом, выдача кодов восстановления
+			tfa.POST("/disable", h.DisableTwoFactor)               // Отключение (требует пароль)
+			tfa.POST("/recovery-codes", h.RegenerateRecoveryCodes) // Новые коды восстановления (требует пароль)
+		}
 
 		// Проекты - CRUD операции и управление порядком
 		pr := admin.Group("/projects")
 		{
-			pr.GET("", h.AdminProjects)                      // Страница управления проектами
-			pr.POST("", h.CreateProject)                     // Создание нового проекта
-			pr.GET("/:id", h.GetProject)                     // Получение проекта для редактирования (JSON)
-			pr.POST("/:id/update", h.UpdateProject)          // Обновление проекта
-			pr.DELETE("/:id", h.DeleteProject)               // Удаление проекта с изображениями
-			pr.POST("/:id/reorder", h.ReorderProject)        // Изменение порядка одного проекта
-			pr.POST("/:id/reset-views", h.ResetProjectViews) // Сброс просмотров конкретного проекта
-			pr.POST("/:id/duplicate", h.DuplicateProject)    // Дублирование проекта
-			pr.POST("/bulk-reorder", h.BulkReorderProjects)  // Массовая сортировка (drag & drop)
+			pr.GET("", h.AdminProjects)                                     // Страница управления проектами
+			pr.POST("", canEditContent, h.CreateProject)                    // Создание нового проекта
+			pr.GET("/:id", h.GetProject)                                    // Получение проекта для редактирования (JSON)
+			pr.POST("/:id/update", canEditContent, h.UpdateProject)         // Обновление проекта
+			pr.DELETE("/:id", canEditContent, h.DeleteProject)              // Удаление проекта с изображениями
+			pr.POST("/:id/reorder", canEditContent, h.ReorderProject)       // Изменение порядка одного проекта
+			pr.POST("/:id/reset-views", canResetViews, h.ResetProjectViews) // Сброс просмотров конкретного проекта
+			pr.POST("/:id/duplicate", canEditContent, h.DuplicateProject)   // Дублирование проекта
+			pr.POST("/bulk-reorder", canEditContent, h.BulkReorderProjects) // Массовая сортировка (drag & drop)
+		}
+
+		// Категории проектов - CRUD и порядок фильтров на странице портфолио
+		cats := admin.Group("/categories")
+		{
+			cats.GET("", h.AdminCategoriesPage)                           // Страница управления категориями
+			cats.POST("", canEditContent, h.CreateCategory)               // Создание категории (slug генерируется автоматически)
+			cats.GET("/:id", h.GetCategory)                               // Получение категории для редактирования (JSON)
+			cats.POST("/:id/update", canEditContent, h.UpdateCategory)    // Переименование категории
+			cats.DELETE("/:id", canEditContent, h.DeleteCategory)         // Удаление (?reassign_to=ID - перенос проектов)
+			cats.POST("/sort", canEditContent, h.UpdateCategoriesSorting) // Обновление порядка (drag & drop)
+		}
+
+		// Услуги - CRUD, иконки и порядок отображения
+		svc := admin.Group("/services")
+		{
+			svc.GET("", h.AdminServicesPage)                           // Страница управления услугами
+			svc.POST("", canEditContent, h.CreateService)              // Создание услуги (иконка через пайплайн миниатюр)
+			svc.GET("/:id", h.GetService)                              // Получение услуги для редактирования (JSON)
+			svc.POST("/:id/update", canEditContent, h.UpdateService)   // Обновление услуги
+			svc.DELETE("/:id", canEditContent, h.DeleteService)        // Удаление услуги с иконкой
+			svc.POST("/sort", canEditContent, h.UpdateServicesSorting) // Обновление порядка (drag & drop)
 		}
 
 		// Изображения - загрузка, удаление, кроппинг
 		img := admin.Group("/")
 		{
-			img.POST("upload-images", h.UploadImages)                // Загрузка изображений для проекта
-			img.DELETE("images/:id", h.DeleteImage)                  // Удаление изображения
-			img.POST("images/:id/crop", h.UpdateImageCrop)           // Обновление настроек кроппинга
-			img.POST("images/:id/set-primary", h.SetPrimaryImage)    // Установка главного изображения проекта
-			img.POST("analytics/reset", h.ResetAllViews)             // Глобальный сброс статистики просмотров проектов
-			img.POST("analytics/reset-prices", h.ResetAllPriceViews) // Глобальный сброс статистики просмотров прайса
+			img.POST("upload-images", canEditContent, h.UploadImages)               // Загрузка изображений для проекта
+			img.DELETE("images/:id", canEditContent, h.DeleteImage)                 // Удаление изображения
+			img.POST("images/:id/crop", canEditContent, h.UpdateImageCrop)          // Обновление настроек кроппинга
+			img.POST("images/:id/set-primary", canEditContent, h.SetPrimaryImage)   // Установка главного изображения проекта
+			img.POST("analytics/reset", canResetViews, h.ResetAllViews)             // Глобальный сброс статистики просмотров проектов
+			img.POST("analytics/reset-prices", canResetViews, h.ResetAllPriceViews) // Глобальный сброс статистики просмотров прайса
 		}
 
 		// Заявки (контакты) - CRM система
 		ct := admin.Group("/contacts")
 		{
-			ct.GET("", h.AdminContactsPage)                 // Страница активных заявок (с фильтрами)
-			ct.GET("/archive", h.AdminContactsArchivePage)  // Страница архива заявок
-			ct.GET("/export.csv", h.AdminContactsExportCSV) // Экспорт в CSV (UTF-8 BOM)
-			ct.POST("/bulk", h.BulkUpdateContacts)          // Массовое изменение статуса
-			ct.POST("/:id/status", h.UpdateContactStatus)   // Изменение статуса одной заявки
-			ct.PATCH("/:id/archive", h.ArchiveContact)      // Архивирование заявки
-			ct.PATCH("/:id/restore", h.RestoreContact)      // Восстановление из архива
-			ct.DELETE("/:id", h.DeleteContact)              // Удаление (soft или hard)
+			ct.GET("", h.AdminContactsPage)                                     // Страница активных заявок (с фильтрами)
+			ct.GET("/archive", h.AdminContactsArchivePage)                      // Страница архива заявок
+			ct.GET("/board", h.AdminContactsBoardPage)                          // Канбан-доска по этапам воронки
+			ct.POST("", canEditContacts, h.CreateContact)                       // Новая заявка, заведённая менеджером
+			ct.GET("/export.csv", canExport, h.AdminContactsExportCSV)          // Экспорт в CSV (UTF-8 BOM)
+			ct.GET("/import", canEditContacts, h.AdminContactsImportPage)       // Страница импорта из CSV/XLSX
+			ct.POST("/import/preview", canEditContacts, h.PreviewContactImport) // Заголовки файла и сопоставление колонок
+			ct.POST("/import", canEditContacts, h.ImportContacts)               // Проверка (dry_run) или импорт
+			ct.POST("/views", h.CreateContactView)                              // Сохранить фильтры как вид (вкладку) администратора
+			ct.DELETE("/views/:id", h.DeleteContactView)                        // Удалить свой вид
+			ct.POST("/bulk", canEditContacts, h.BulkUpdateContacts)             // Массовое изменение статуса
+			ct.POST("/:id/status", canEditContacts, h.UpdateContactStatus)      // Изменение статуса одной заявки
+			ct.PATCH("/:id/archive", canEditContacts, h.ArchiveContact)         // Архивирование заявки
+			ct.PATCH("/:id/restore", canEditContacts, h.RestoreContact)         // Восстановление из архива
+			ct.POST("/:id/assign", canEditContacts, h.AssignContact)            // Назначение ответственного
+			ct.DELETE("/:id", canDeleteContact, h.DeleteContact)                // Удаление (soft или hard)
+
+			// Источник заявки: первый и последний визит (UTM-метки, реферер, страница входа)
+			ct.GET("/:id/attribution", h.GetContactAttribution)
 
 			// Заметки и напоминания для follow-up
-			ct.GET("/:id/notes", h.GetContactNotes)               // Получить все заметки по заявке
-			ct.POST("/:id/notes", h.CreateContactNote)            // Добавить заметку
-			ct.DELETE("/:id/notes/:note_id", h.DeleteContactNote) // Удалить заметку
-			ct.PATCH("/:id/reminder", h.UpdateContactReminder)    // Установить/снять напоминание
+			ct.GET("/:id/notes", h.GetContactNotes)                                // Получить все заметки по заявке
+			ct.POST("/:id/notes", canEditContacts, h.CreateContactNote)            // Добавить заметку
+			ct.DELETE("/:id/notes/:note_id", canEditContacts, h.DeleteContactNote) // Удалить заметку
+			ct.PATCH("/:id/reminder", canEditContacts, h.UpdateContactReminder)    // Установить/снять напоминание
+
+			// Коммерческие предложения (PDF по данным калькулятора)
+			ct.GET("/:id/quotes", h.GetContactQuotes)                     // Список КП по заявке
+			ct.POST("/:id/quotes", canEditContacts, h.CreateContactQuote) // Сформировать КП
+			ct.GET("/:id/quotes/:quote_id/pdf", h.DownloadContactQuote)   // Скачать PDF
+
+			// Доставка уведомлений о заявке (outbox)
+			ct.GET("/:id/notifications", h.GetContactNotifications)                                       // Статусы доставки по каналам
+			ct.POST("/:id/notifications/:delivery_id/retry", canEditContacts, h.RetryContactNotification) // Повтор недоставленного уведомления
+		}
+
+		// Клиенты - заявки одного клиента, объединение и разделение
+		cust := admin.Group("/customers")
+		{
+			cust.GET("", h.AdminCustomersPage)                                                      // Список клиентов с числом заявок
+			cust.GET("/:id", h.AdminCustomerPage)                                                   // Карточка клиента: заявки и заметки
+			cust.POST("/:id/merge", canEditContacts, h.MergeCustomer)                               // Объединить с другим клиентом
+			cust.POST("/:id/contacts/:contact_id/detach", canEditContacts, h.DetachCustomerContact) // Отделить заявку в нового клиента
+		}
+
+		// Этапы воронки продаж - колонки канбан-доски и статусы заявок
+		pipeline := admin.Group("/pipeline")
+		{
+			pipeline.GET("", h.AdminPipelinePage)                                  // Страница настройки этапов
+			pipeline.POST("", canEditSettings, h.CreatePipelineStage)              // Создание этапа (slug генерируется автоматически)
+			pipeline.POST("/:id/update", canEditSettings, h.UpdatePipelineStage)   // Название и вид этапа
+			pipeline.DELETE("/:id", canEditSettings, h.DeletePipelineStage)        // Удаление этапа без заявок
+			pipeline.POST("/sort", canEditSettings, h.UpdatePipelineStagesSorting) // Порядок этапов (drag & drop)
 		}
 
 		// Цены - CRUD операции для прайс-листа
 		prices := admin.Group("/prices")
 		{
-			prices.GET("", h.AdminPricesPage)                // Страница управления ценами
-			prices.POST("", h.CreatePriceItem)               // Создание новой позиции прайса
-			prices.GET("/:id", h.GetPriceItem)               // Получение позиции для редактирования (JSON)
-			prices.POST("/:id/update", h.UpdatePriceItem)    // Обновление позиции прайса
-			prices.DELETE("/:id", h.DeletePriceItem)         // Удаление позиции прайса
-			prices.POST("/sort", h.UpdatePriceItemsSorting)  // Обновление порядка позиций (drag & drop)
-			prices.POST("/:id/crop", h.UpdatePriceImageCrop) // Обновление настроек кроппинга изображения (старый формат)
-			prices.DELETE("/:id/image", h.DeletePriceImage)  // Удаление изображения из позиции (старый формат)
+			prices.GET("", h.AdminPricesPage)                                // Страница управления ценами
+			prices.POST("", canEditContent, h.CreatePriceItem)               // Создание новой позиции прайса
+			prices.GET("/:id", h.GetPriceItem)                               // Получение позиции для редактирования (JSON)
+			prices.POST("/:id/update", canEditContent, h.UpdatePriceItem)    // Обновление позиции прайса
+			prices.DELETE("/:id", canEditContent, h.DeletePriceItem)         // Удаление позиции прайса
+			prices.POST("/sort", canEditContent, h.UpdatePriceItemsSorting)  // Обновление порядка позиций (drag & drop)
+			prices.POST("/:id/crop", canEditContent, h.UpdatePriceImageCrop) // Обновление настроек кроппинга изображения (старый формат)
+			prices.DELETE("/:id/image", canEditContent, h.DeletePriceImage)  // Удаление изображения из позиции (старый формат)
 
 			// Новые endpoints для множественных изображений
-			prices.POST("/upload-images", h.UploadPriceImages)             // Загрузка изображений для позиции
-			prices.DELETE("/images/:id", h.DeletePriceImageNew)            // Удаление изображения из price_images
-			prices.POST("/images/:id/crop", h.UpdatePriceImageCropNew)     // Обновление настроек кроппинга изображения
-			prices.POST("/images/:id/set-primary", h.SetPrimaryPriceImage) // Установка главного изображения позиции
-			prices.POST("/:id/reset-views", h.ResetPriceItemViews)         // Сброс просмотров конкретной позиции прайса
-			prices.POST("/:id/duplicate", h.DuplicatePriceItem)            // Дублирование позиции прайса
+			prices.POST("/upload-images", canEditContent, h.UploadPriceImages)             // Загрузка изображений для позиции
+			prices.DELETE("/images/:id", canEditContent, h.DeletePriceImageNew)            // Удаление изображения из price_images
+			prices.POST("/images/:id/crop", canEditContent, h.UpdatePriceImageCropNew)     // Обновление настроек кроппинга изображения
+			prices.POST("/images/:id/set-primary", canEditContent, h.SetPrimaryPriceImage) // Установка главного изображения позиции
+			prices.POST("/:id/reset-views", canResetViews, h.ResetPriceItemViews)          // Сброс просмотров конкретной позиции прайса
+			prices.POST("/:id/duplicate", canEditContent, h.DuplicatePriceItem)            // Дублирование позиции прайса
 		}
 
 		// Калькулятор - настройки и шаги пикселя
 		calc := admin.Group("/calculator")
 		{
-			calc.GET("", h.AdminCalculatorPage)                       // Страница настроек калькулятора
-			calc.POST("/settings", h.AdminCalculatorUpdateSettings)   // Сохранение констант
-			calc.POST("/pitches", h.AdminCalculatorCreatePitch)       // Создание шага пикселя
-			calc.POST("/pitches/:id", h.AdminCalculatorUpdatePitch)   // Обновление шага пикселя
-			calc.DELETE("/pitches/:id", h.AdminCalculatorDeletePitch) // Удаление шага пикселя
+			calc.GET("", h.AdminCalculatorPage)                                       // Страница настроек калькулятора
+			calc.POST("/settings", canEditPricing, h.AdminCalculatorUpdateSettings)   // Сохранение констант
+			calc.POST("/pitches", canEditPricing, h.AdminCalculatorCreatePitch)       // Создание шага пикселя
+			calc.POST("/pitches/:id", canEditPricing, h.AdminCalculatorUpdatePitch)   // Обновление шага пикселя
+			calc.DELETE("/pitches/:id", canEditPricing, h.AdminCalculatorDeletePitch) // Удаление шага пикселя
 		}
 
 		// Промо popup - управление всплывающим окном для акций
-		admin.GET("/promo", h.AdminPromoPage)    // Страница настроек popup
-		admin.POST("/promo", h.AdminPromoUpdate) // Сохранение настроек popup
+		admin.GET("/promo", h.AdminPromoPage)                    // Страница настроек popup
+		admin.POST("/promo", canEditContent, h.AdminPromoUpdate) // Сохранение настроек popup
 
 		// Настройки сайта - телефон и email
-		admin.GET("/settings", h.AdminSettingsPage)    // Страница настроек
-		admin.POST("/settings", h.AdminSettingsUpdate) // Сохранение настроек
+		admin.GET("/settings", h.AdminSettingsPage)                     // Страница настроек
+		admin.POST("/settings", canEditSettings, h.AdminSettingsUpdate) // Сохранение настроек
+
+		// Пользователи админки - приглашение, роли, деактивация, сброс пароля (только владелец)
+		users := admin.Group("/users")
+		users.Use(canManageUsers)
+		{
+			users.GET("", h.AdminUsersPage)                             // Страница управления администраторами
+			users.POST("", h.InviteAdmin)                               // Приглашение (временный пароль в ответе)
+			users.GET("/:id", h.GetAdminUser)                           // Получение администратора (JSON)
+			users.POST("/:id/update", h.UpdateAdminUser)                // Изменение email и роли
+			users.POST("/:id/active", h.SetAdminUserActive)             // Активация/деактивация
+			users.POST("/:id/reset-password", h.ResetAdminUserPassword) // Сброс пароля
+		}
+
+		// Журнал изменений - кто и что менял в админке (только владелец)
+		audit := admin.Group("/audit")
+		audit.Use(canViewAudit)
+		{
+			audit.GET("", h.AdminAuditPage)                 // Журнал с фильтрами
+			audit.GET("/export.csv", h.AdminAuditExportCSV) // Экспорт с теми же фильтрами в CSV
+		}
 
 		// Точки на карте - CRUD операции
 		mapPoints := admin.Group("/map-points")
 		{
-			mapPoints.GET("", h.AdminMapPointsPage)               // Страница управления точками
-			mapPoints.POST("", h.CreateMapPoint)                  // Создание точки
-			mapPoints.GET("/:id", h.GetMapPoint)                  // Получение точки (JSON)
-			mapPoints.POST("/:id/update", h.UpdateMapPoint)       // Обновление точки
-			mapPoints.DELETE("/:id", h.DeleteMapPoint)            // Удаление точки
-			mapPoints.POST("/sort", h.UpdateMapPointsSorting)     // Сортировка (drag & drop)
-			mapPoints.POST("/bulk-import", h.BulkImportMapPoints) // Массовый импорт из ссылок
+			mapPoints.GET("", h.AdminMapPointsPage)                               // Страница управления точками
+			mapPoints.POST("", canEditContent, h.CreateMapPoint)                  // Создание точки
+			mapPoints.GET("/:id", h.GetMapPoint)                                  // Получение точки (JSON)
+			mapPoints.POST("/:id/update", canEditContent, h.UpdateMapPoint)       // Обновление точки
+			mapPoints.DELETE("/:id", canEditContent, h.DeleteMapPoint)            // Удаление точки
+			mapPoints.POST("/sort", canEditContent, h.UpdateMapPointsSorting)     // Сортировка (drag & drop)
+			mapPoints.POST("/bulk-import", canEditContent, h.BulkImportMapPoints) // Массовый импорт из ссылок
 		}
 	}
 }
@@ -0,0 +1,84 @@
+// Package spreadsheet читает таблицы для импорта: CSV (как экспорт заявок - UTF-8 с BOM,
+// разделитель ";") и XLSX (первый лист книги). Результат - строки ячеек без пустых строк.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ErrUnsupported - файл не CSV и не XLSX
+var ErrUnsupported = errors.New("поддерживаются файлы CSV и XLSX")
+
+// Read разбирает файл по расширению имени
+func Read(filename string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".txt":
+		return readCSV(data)
+	case ".xlsx":
+		return readXLSX(data)
+	}
+	return nil, ErrUnsupported
+}
+
+// readCSV читает CSV: BOM отбрасывается, файл не в UTF-8 считается Windows-1251
+// (выгрузки из Excel и старых CRM), разделитель определяется по первой строке
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if !utf8.Valid(data) {
+		decoded, err := charmap.Windows1251.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = detectComma(data)
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return compact(records), nil
+}
+
+// detectComma - самый частый из разделителей ";", "," и табуляции в первой строке (по умолчанию ";")
+func detectComma(data []byte) rune {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	comma, best := ';', bytes.Count(line, []byte{';'})
+	for _, sep := range []rune{',', '\t'} {
+		if n := bytes.Count(line, []byte(string(sep))); n > best {
+			comma, best = sep, n
+		}
+	}
+	return comma
+}
+
+// compact обрезает пробелы в ячейках и убирает пустые строки
+func compact(rows [][]string) [][]string {
+	out := rows[:0]
+	for _, row := range rows {
+		empty := true
+		for i, cell := range row {
+			row[i] = strings.TrimSpace(cell)
+			if row[i] != "" {
+				empty = false
+			}
+		}
+		if !empty {
+			out = append(out, row)
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// buildXLSX собирает минимальную книгу из частей (путь -> XML)
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadCSV(t *testing.T) {
+	data := append([]byte{0xEF, 0xBB, 0xBF}, []byte("Имя;Телефон;Сообщение\n"+
+		"Иван ; 8 921 123-45-67;\"Экран; 3×2 м\"\n"+
+		";;\n"+
+		"Пётр;+79211111111\n")...)
+	rows, err := Read("contacts.csv", data)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{
+			{"Имя", "Телефон", "Сообщение"},
+			{"Иван", "8 921 123-45-67", "Экран; 3×2 м"},
+			{"Пётр", "+79211111111"},
+		}, rows)
+	}
+
+	// Выгрузка Excel в Windows-1251 с запятой
+	cp1251, _ := charmap.Windows1251.NewEncoder().Bytes([]byte("Имя,Телефон\r\nАнна,89210000000\r\n"))
+	rows, err = Read("old_crm.CSV", cp1251)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{{"Имя", "Телефон"}, {"Анна", "89210000000"}}, rows)
+	}
+
+	_, err = Read("contacts.xls", data)
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Заявки" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId3" Target="worksheets/leads.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Имя</t></si><si><t>Телефон</t></si><si><r><t>Ива</t></r><r><t>н</t></r></si></sst>`,
+		"xl/worksheets/leads.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>Дата</t></is></c></row>
+			<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>7.9211234567E10</v></c><c r="D2"><v>46023.5</v></c></row>
+			<row r="3"><c r="A3"/></row>
+		</sheetData></worksheet>`,
+	})
+	rows, err := Read("leads.xlsx", data)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{
+			{"Имя", "Телефон", "", "Дата"},
+			{"Иван", "79211234567", "", "46023.5"},
+		}, rows)
+	}
+
+	_, err = Read("broken.xlsx", []byte("not a zip"))
+	assert.ErrorIs(t, err, ErrBadXLSX)
+
+	// Ячейка за пределами листа (правее XFD) - отказ вместо выделения памяти под строку
+	huge := buildXLSX(t, map[string]string{
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="ZZZZZZZ1" t="inlineStr"><is><t>x</t></is></c></row></sheetData></worksheet>`,
+	})
+	_, err = Read("huge.xlsx", huge)
+	assert.ErrorIs(t, err, ErrBadXLSX)
+}
+
+func TestColumnIndex(t *testing.T) {
+	assert.Equal(t, 0, columnIndex("A1"))
+	assert.Equal(t, 25, columnIndex("Z9"))
+	assert.Equal(t, 27, columnIndex("AB12"))
+	assert.Equal(t, 16383, columnIndex("XFD1"))
+	assert.GreaterOrEqual(t, columnIndex("XFE1"), xlsxMaxColumns)
+}
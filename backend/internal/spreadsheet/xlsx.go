@@ -0,0 +1,186 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxMaxPart    = 64 << 20 // ограничение на распакованный размер части книги (защита от zip-бомб)
+	xlsxMaxColumns = 16384    // колонок на листе Excel (A..XFD)
+)
+
+// ErrBadXLSX - файл не удалось прочитать как книгу Excel
+var ErrBadXLSX = errors.New("не удалось прочитать XLSX файл")
+
+// xlsxText - текст ячейки: простой (<t>) или форматированный по частям (<r><t>)
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX читает первый лист книги. Даты Excel хранит числами - их разбирает вызывающий код
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrBadXLSX
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []xlsxText
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := decodePart(f, &sst); err != nil {
+			return nil, ErrBadXLSX
+		}
+		shared = sst.Items
+	}
+
+	f, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, ErrBadXLSX
+	}
+	var sheet xlsxSheet
+	if err := decodePart(f, &sheet); err != nil {
+		return nil, ErrBadXLSX
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, r := range sheet.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			if col < 0 {
+				continue
+			}
+			if col >= xlsxMaxColumns {
+				return nil, ErrBadXLSX
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			row[col] = cellValue(c, shared)
+		}
+		rows = append(rows, row)
+	}
+	return compact(rows), nil
+}
+
+// firstSheetPath - путь первого листа по workbook.xml и его связям (по умолчанию sheet1.xml)
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+	var wb struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	wbFile, ok1 := files["xl/workbook.xml"]
+	relsFile, ok2 := files["xl/_rels/workbook.xml.rels"]
+	if !ok1 || !ok2 || decodePart(wbFile, &wb) != nil || decodePart(relsFile, &rels) != nil || len(wb.Sheets) == 0 {
+		return fallback
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != wb.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+// decodePart разбирает XML часть книги
+func decodePart(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, xlsxMaxPart)).Decode(v)
+}
+
+// cellValue - значение ячейки строкой
+func cellValue(c xlsxCell, shared []xlsxText) string {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i].String()
+	case "inlineStr":
+		return c.Inline.String()
+	case "str", "b", "e":
+		return c.Value
+	}
+	// Число: длинные номера телефонов Excel может записать в экспоненциальной форме
+	if strings.ContainsAny(c.Value, "eE") {
+		if f, err := strconv.ParseFloat(c.Value, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return c.Value
+}
+
+// columnIndex - номер колонки (с нуля) по адресу ячейки: A1 -> 0, AB12 -> 27.
+// Адрес правее XFD даёт значение не меньше xlsxMaxColumns
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if col = col*26 + int(r-'A'+1); col > xlsxMaxColumns {
+			return xlsxMaxColumns // за пределами листа; дальше не считаем, чтобы не переполнить int
+		}
+	}
+	return col - 1
+}
@@ -15,7 +15,9 @@ import (
 	"ledsite/internal/config"
 	"ledsite/internal/database"
 	"ledsite/internal/handlers"
+	"ledsite/internal/mailer"
 	"ledsite/internal/models"
+	"ledsite/internal/notify"
 	"ledsite/internal/routes"
 )
 
@@ -36,6 +38,10 @@ func main() {
 				"Generate a strong secret with: openssl rand -base64 32")
 		}
 		log.Println("✓ JWT_SECRET validation passed")
+
+		if os.Getenv("TELEGRAM_API_SECRET") == "" {
+			log.Println("WARNING: TELEGRAM_API_SECRET is not set - /api/telegram/* will reject all requests")
+		}
 	}
 
 	// Подключаемся к базе данных
@@ -52,6 +58,49 @@ func main() {
 	// Инициализируем handlers
 	h := handlers.New(db, cfg.MaxUploadSize, cfg.UploadPath)
 
+	// Заявки, сохранённые до появления клиентов, привязываются к клиентам (повторно - только новые)
+	if linked, err := h.BackfillCustomers(); err != nil {
+		log.Printf("Ошибка привязки заявок к клиентам: %v", err)
+	} else if linked > 0 {
+		log.Printf("✓ Заявок привязано к клиентам: %d", linked)
+	}
+
+	// Уведомления о заявках и напоминаниях: каналы подключаются по настройкам,
+	// доставка идёт через outbox (таблица notification_deliveries) с повторами
+	var notifiers []notify.Notifier
+	if botURL := os.Getenv("TELEGRAM_BOT_URL"); botURL != "" {
+		notifiers = append(notifiers, notify.NewTelegram(botURL))
+		log.Println("✓ Telegram уведомления включены")
+	} else {
+		log.Println("TELEGRAM_BOT_URL не настроен, Telegram уведомления отключены")
+	}
+	mailCfg := mailer.Config{
+		Host:       cfg.SMTPHost,
+		Port:       cfg.SMTPPort,
+		Username:   cfg.SMTPUsername,
+		Password:   cfg.SMTPPassword,
+		From:       cfg.SMTPFrom,
+		Recipients: mailer.ParseRecipients(cfg.NotifyEmails),
+	}
+	if mailCfg.Enabled() {
+		notifiers = append(notifiers, notify.NewEmail(mailer.NewSMTPSender(mailCfg), mailCfg.Recipients))
+		log.Printf("✓ Email уведомления включены: %s", strings.Join(mailCfg.Recipients, ", "))
+	} else {
+		log.Println("SMTP_FROM / NOTIFY_EMAILS не настроены, email уведомления отключены")
+	}
+	if cfg.SMTPFrom != "" {
+		// Подтверждения клиентам не зависят от NOTIFY_EMAILS; включаются в /admin/settings
+		notifiers = append(notifiers, notify.NewCustomerEmail(mailer.NewSMTPSender(mailCfg)))
+	}
+	if cfg.NotifyWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhook(cfg.NotifyWebhookURL, cfg.NotifyWebhookSecret))
+		log.Println("✓ Webhook уведомления включены")
+	}
+	outbox := notify.NewOutbox(db, notify.OutboxConfig{MaxAttempts: cfg.NotifyMaxAttempts}, notifiers...)
+	h.SetOutbox(outbox, cfg.SiteURL)
+	outbox.Start()
+	h.StartReminderNotifications()
+
 	// Настраиваем Gin
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -59,6 +108,21 @@ func main() {
 
 	router := gin.Default()
 
+	// IP клиента (c.ClientIP) берётся из X-Forwarded-For только от доверенных прокси (nginx).
+	// Без этого ограничение попыток входа по IP обходится подделкой заголовка.
+	var trustedProxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			trustedProxies = append(trustedProxies, p)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	if cfg.Environment == "production" && len(trustedProxies) == 0 {
+		log.Println("WARNING: TRUSTED_PROXIES is not set - behind nginx all admin logins share one IP for rate limiting")
+	}
+
 	funcMap := template.FuncMap{
 		"mul": func(a, b float64) float64 { return a * b },
 		"sub": func(a, b float64) float64 { return a - b },
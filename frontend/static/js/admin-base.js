@@ -1,5 +1,31 @@
 // Базовые функции для админки
 
+// CSRF-токен сессии из <meta name="csrf-token"> (выводится в admin_base.html)
+function getCsrfToken() {
+    const meta = document.querySelector('meta[name="csrf-token"]');
+    return meta ? meta.content : '';
+}
+
+// Все изменяющие fetch-запросы к своему сайту отправляются с заголовком X-CSRF-Token,
+// поэтому отдельные скрипты админки не передают токен вручную
+(function setupCsrfFetch() {
+    const originalFetch = window.fetch.bind(window);
+    const safeMethods = ['GET', 'HEAD', 'OPTIONS'];
+
+    window.fetch = function(input, init = {}) {
+        const method = (init.method || (input instanceof Request ? input.method : 'GET')).toUpperCase();
+        const url = new URL(input instanceof Request ? input.url : input, window.location.href);
+
+        if (safeMethods.includes(method) || url.origin !== window.location.origin) {
+            return originalFetch(input, init);
+        }
+
+        const headers = new Headers(init.headers || (input instanceof Request ? input.headers : undefined));
+        headers.set('X-CSRF-Token', getCsrfToken());
+        return originalFetch(input, { ...init, headers });
+    };
+})();
+
 // Показ сообщений
 function showAdminMessage(message, type = 'success', timeout = 4000) {
     let root = document.getElementById('message');
@@ -265,4 +291,5 @@ window.showAdminMessage = showAdminMessage;
 window.handleFetchResponse = handleFetchResponse;
 window.submitForm = submitForm;
 window.fetchData = fetchData;
-window.deleteData = deleteData;
\ No newline at end of file
+window.deleteData = deleteData;
+window.getCsrfToken = getCsrfToken;
\ No newline at end of file
@@ -0,0 +1,118 @@
+// Управление категориями проектов в админке
+document.addEventListener('DOMContentLoaded', function() {
+  // Drag & drop сортировка
+  var sortableList = document.getElementById('sortable-categories');
+  if (sortableList && typeof Sortable !== 'undefined') {
+    Sortable.create(sortableList, {
+      handle: '.drag-handle',
+      animation: 150,
+      onEnd: function() {
+        var ids = [];
+        sortableList.querySelectorAll('.project-item').forEach(function(item) {
+          ids.push(parseInt(item.dataset.categoryId));
+        });
+        fetch('/admin/categories/sort', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ ids: ids })
+        }).then(function(r) { return r.json(); })
+          .then(function(data) {
+            if (data.success) showAdminMessage('Порядок обновлён');
+          });
+      }
+    });
+  }
+
+  function handleResult(modalId) {
+    return function(data) {
+      if (data.success) {
+        showAdminMessage(data.message);
+        if (modalId) closeModal(modalId);
+        location.reload();
+      } else {
+        showAdminMessage(data.error || 'Ошибка', 'error');
+      }
+    };
+  }
+
+  // === Создание категории ===
+
+  // Кнопки тулбара выводятся только при наличии прав на редактирование
+  var openCreateCategoryModalBtn = document.getElementById('openCreateCategoryModal');
+  if (openCreateCategoryModalBtn) openCreateCategoryModalBtn.addEventListener('click', function() {
+    document.getElementById('createCategoryForm').reset();
+    openModal('createCategoryModal');
+  });
+
+  document.getElementById('createCategoryForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    fetch('/admin/categories', { method: 'POST', body: new FormData(this) })
+      .then(function(r) { return r.json(); })
+      .then(handleResult('createCategoryModal'))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+
+  // === Редактирование категории ===
+
+  window.editCategory = function(id) {
+    fetch('/admin/categories/' + id)
+      .then(function(r) { return r.json(); })
+      .then(function(data) {
+        if (!data.success) { showAdminMessage(data.error || 'Ошибка', 'error'); return; }
+        var cat = data.category;
+        document.getElementById('editCategoryId').value = cat.id;
+        document.getElementById('editCategoryName').value = cat.name;
+        document.getElementById('editCategoryDescription').value = cat.description || '';
+        openModal('editCategoryModal');
+      });
+  };
+
+  document.getElementById('editCategoryForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    var id = document.getElementById('editCategoryId').value;
+    fetch('/admin/categories/' + id + '/update', { method: 'POST', body: new FormData(this) })
+      .then(function(r) { return r.json(); })
+      .then(handleResult('editCategoryModal'))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+
+  // === Удаление категории ===
+
+  window.deleteCategory = function(id, name, projectsCount) {
+    if (projectsCount === 0) {
+      if (!confirm('Удалить категорию "' + name + '"?')) return;
+      fetch('/admin/categories/' + id, { method: 'DELETE' })
+        .then(function(r) { return r.json(); })
+        .then(handleResult(null))
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+      return;
+    }
+
+    // Есть привязанные проекты - предлагаем перенести их в другую категорию
+    var select = document.getElementById('deleteCategoryTarget');
+    Array.prototype.forEach.call(select.options, function(opt) {
+      opt.hidden = opt.disabled = (parseInt(opt.value) === id);
+    });
+    var firstAvailable = Array.prototype.find.call(select.options, function(opt) { return !opt.disabled; });
+    if (!firstAvailable) {
+      showAdminMessage('Нельзя удалить единственную категорию с проектами', 'error');
+      return;
+    }
+    select.value = firstAvailable.value;
+
+    document.getElementById('deleteCategoryId').value = id;
+    document.getElementById('deleteCategoryName').textContent = name;
+    document.getElementById('deleteCategoryCount').textContent = projectsCount;
+    openModal('deleteCategoryModal');
+  };
+
+  document.getElementById('deleteCategoryForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    var id = document.getElementById('deleteCategoryId').value;
+    var target = document.getElementById('deleteCategoryTarget').value;
+    fetch('/admin/categories/' + id + '?reassign_to=' + encodeURIComponent(target), { method: 'DELETE' })
+      .then(function(r) { return r.json(); })
+      .then(handleResult('deleteCategoryModal'))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+});
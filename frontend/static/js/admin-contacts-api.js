@@ -20,12 +20,21 @@
     const api = {
         request, // пусть будет доступен, вдруг пригодится где-то ещё
 
-        updateStatus(id, status) {
-        return request(`/admin/contacts/${id}/status`, { method: 'POST', body: { status } });
+        // Заявка, заведённая менеджером: поля формы, source, remind_at, note, assigned_admin_id
+        create(body) {
+        return request('/admin/contacts', { method: 'POST', body });
         },
 
-        bulk(action, ids) {
-        return request('/admin/contacts/bulk', { method: 'POST', body: { action, ids } });
+        updateStatus(id, status, lostReason) {
+        return request(`/admin/contacts/${id}/status`, { method: 'POST', body: { status, lost_reason: lostReason } });
+        },
+
+        bulk(action, ids, lostReason) {
+        return request('/admin/contacts/bulk', { method: 'POST', body: { action, ids, lost_reason: lostReason } });
+        },
+
+        assign(id, adminId) {
+        return request(`/admin/contacts/${id}/assign`, { method: 'POST', body: { admin_id: adminId } });
         },
 
         archive(id) {
@@ -41,17 +50,21 @@
         return request(url, { method: 'DELETE' });
         },
 
+        // Экспорт с фильтрами из адреса страницы (без пагинации и вкладки)
         exportUrlFromLocation() {
-        const current = new URLSearchParams(location.search);
-        const p = new URLSearchParams();
-        if (current.get('search')) p.set('q', current.get('search'));
-        else if (current.get('q')) p.set('q', current.get('q'));
-        if (current.get('status')) p.set('status', current.get('status'));
-        if (current.get('date'))   p.set('date',   current.get('date'));
-        if (current.get('reminder')) p.set('reminder', current.get('reminder'));
+        const p = new URLSearchParams(location.search);
+        ['page', 'limit', 'view'].forEach((k) => p.delete(k));
         return '/admin/contacts/export.csv' + (p.toString() ? ('?' + p.toString()) : '');
         },
 
+        createView(name, query) {
+        return request('/admin/contacts/views', { method: 'POST', body: { name, query } });
+        },
+
+        deleteView(id) {
+        return request(`/admin/contacts/views/${id}`, { method: 'DELETE' });
+        },
+
         getNotes(id) {
         return request(`/admin/contacts/${id}/notes`, { method: 'GET' });
         },
@@ -66,6 +79,34 @@
 
         setReminder(id, { remind_at, remind_flag } = {}) {
         return request(`/admin/contacts/${id}/reminder`, { method: 'PATCH', body: { remind_at, remind_flag } });
+        },
+
+        getNotifications(id) {
+        return request(`/admin/contacts/${id}/notifications`, { method: 'GET' });
+        },
+
+        retryNotification(id, deliveryId) {
+        return request(`/admin/contacts/${id}/notifications/${deliveryId}/retry`, { method: 'POST' });
+        },
+
+        getAttribution(id) {
+        return request(`/admin/contacts/${id}/attribution`, { method: 'GET' });
+        },
+
+        getQuotes(id) {
+        return request(`/admin/contacts/${id}/quotes`, { method: 'GET' });
+        },
+
+        createQuote(id, { screen_type, pitch_id, width, height, light }) {
+        return request(`/admin/contacts/${id}/quotes`, { method: 'POST', body: { screen_type, pitch_id, width, height, light } });
+        },
+
+        quotePdfUrl(id, quoteId) {
+        return `/admin/contacts/${id}/quotes/${quoteId}/pdf`;
+        },
+
+        getCalculator() {
+        return request('/api/calculator', { method: 'GET' });
         }
     };
 
@@ -39,6 +39,12 @@
       noteAuthor: document.getElementById('cd-note-author'),
       noteText:   document.getElementById('cd-note-text'),
       noteAdd:    document.getElementById('cd-note-add'),
+
+      // доставка уведомлений
+      deliveriesList: document.getElementById('cd-deliveries-list'),
+
+      // коммерческие предложения (только список)
+      quotesList: document.getElementById('cd-quotes-list'),
     };
 
     let currentId = null;
@@ -58,6 +64,8 @@
         noteAuthor: f.noteAuthor,
         noteText:   f.noteText,
         noteAdd:    f.noteAdd,
+        deliveriesList: f.deliveriesList,
+        quotesList: f.quotesList,
       },
     });
 
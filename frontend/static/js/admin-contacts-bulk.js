@@ -2,7 +2,7 @@
 (function (w) {
     function initBulk() {
         // Общая привязка селекторов и кнопок
-        w.ContactsShared.wireSelection(['#bulk-process', '#bulk-restore', '#bulk-archive']);
+        w.ContactsShared.wireSelection(['#bulk-process', '#bulk-restore', '#bulk-archive', '#bulk-spam', '#bulk-move']);
 
         // Действия
         document.getElementById('bulk-process')?.addEventListener('click', async () => {
@@ -34,6 +34,34 @@
             w.ContactsUI.show('ok', 'Отправлено в архив');
         } catch (err) { w.ContactsUI.show('error', err.message); }
         });
+
+        document.getElementById('bulk-spam')?.addEventListener('click', async () => {
+        const ids = w.ContactsShared.getSelectedIds(); if (!ids.length) return;
+        try {
+            await w.ContactsAPI.bulk('spam', ids);
+            ids.forEach(id => w.ContactsUI.setRowStatusById(id, 'spam'));
+            w.ContactsShared.clearSelection();
+            w.ContactsUI.show('ok', 'Помечено как спам');
+        } catch (err) { w.ContactsUI.show('error', err.message); }
+        });
+
+        // Перевод в выбранный этап воронки; для этапа проигрыша спрашиваем причину
+        document.getElementById('bulk-move')?.addEventListener('click', async () => {
+        const ids = w.ContactsShared.getSelectedIds(); if (!ids.length) return;
+        const select = document.getElementById('bulk-stage');
+        const stage = select?.value; if (!stage) return;
+        let reason;
+        if (select.selectedOptions[0]?.dataset.kind === 'lost') {
+            reason = (prompt('Причина проигрыша:') || '').trim();
+            if (!reason) return;
+        }
+        try {
+            await w.ContactsAPI.bulk(stage, ids, reason);
+            ids.forEach(id => w.ContactsUI.setRowStatusById(id, stage));
+            w.ContactsShared.clearSelection();
+            w.ContactsUI.show('ok', 'Этап изменён');
+        } catch (err) { w.ContactsUI.show('error', err.message); }
+        });
     }
 
     w.ContactsBulkInit = initBulk;
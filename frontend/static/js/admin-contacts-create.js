@@ -0,0 +1,66 @@
+// Новая заявка из админки: звонок, письмо, WhatsApp, выставка.
+// После создания страница перезагружается - заявка появится в списке с учётом фильтров.
+
+(function (w) {
+  function initCreate() {
+    const overlay = document.getElementById('contact-create-modal');
+    const form = document.getElementById('contact-create-form');
+    const openBtn = document.getElementById('contact-create-open');
+    if (!overlay || !form || !openBtn) return;
+
+    function open() {
+      overlay.classList.remove('hidden');
+      overlay.setAttribute('aria-hidden', 'false');
+      form.elements.name.focus();
+    }
+    function close() {
+      overlay.classList.add('hidden');
+      overlay.setAttribute('aria-hidden', 'true');
+    }
+
+    openBtn.addEventListener('click', open);
+    overlay.querySelector('.modal-close')?.addEventListener('click', close);
+    overlay.querySelector('.js-contact-create-cancel')?.addEventListener('click', close);
+    overlay.addEventListener('click', (e) => { if (e.target === overlay) close(); });
+
+    form.addEventListener('submit', async (e) => {
+      e.preventDefault();
+      const v = (name) => (form.elements[name]?.value || '').trim();
+
+      if (!v('name') || !v('phone')) {
+        w.ContactsUI.show('error', 'Укажите имя и телефон');
+        return;
+      }
+
+      const body = {
+        name: v('name'),
+        phone: v('phone'),
+        email: v('email'),
+        company: v('company'),
+        project_type: v('project_type'),
+        message: v('message'),
+        source: v('source'),
+        remind_at: v('remind_at').replace('T', ' '), // "YYYY-MM-DD HH:MM" по МСК
+        note: v('note'),
+      };
+      // Пусто - ответственный по режиму распределения из настроек
+      if (v('assigned_admin_id') !== '') body.assigned_admin_id = Number(v('assigned_admin_id'));
+
+      const submit = form.querySelector('[type="submit"]');
+      submit.disabled = true;
+      try {
+        await w.ContactsAPI.create(body);
+        w.ContactsUI.show('ok', 'Заявка создана');
+        form.reset();
+        close();
+        location.reload();
+      } catch (err) {
+        w.ContactsUI.show('error', err.message);
+      } finally {
+        submit.disabled = false;
+      }
+    });
+  }
+
+  w.ContactsCreateInit = initCreate;
+})(window);
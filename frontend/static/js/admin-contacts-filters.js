@@ -1,23 +1,64 @@
 // Фильтры и экспорт
 (function (w) {
-    function applyFilters() {
-        const search   = document.getElementById('search-input')?.value || '';
-        const status   = document.getElementById('status-filter')?.value || '';
-        const date     = document.getElementById('date-filter')?.value || '';
-        const reminder = document.getElementById('filter-reminder')?.value || '';
-        const limitSel = document.getElementById('limit-select');
+    // Значение поля фильтра по id
+    function val(id) {
+        return document.getElementById(id)?.value || '';
+    }
 
+    function applyFilters() {
         const params = new URLSearchParams();
-        if (search)   params.set('search', search);
-        if (status)   params.set('status', status);
-        if (date)     params.set('date', date);
-        if (reminder) params.set('reminder', reminder);
-        if (limitSel && limitSel.value) params.set('limit', limitSel.value);
+        const fields = {
+            search: 'search-input',
+            status: 'status-filter',
+            date: 'date-filter',
+            reminder: 'filter-reminder',
+            assigned: 'assigned-filter',
+            from: 'from-filter',
+            to: 'to-filter',
+            source: 'source-filter',
+            project_type: 'project-type-filter',
+            notes: 'notes-filter',
+        };
+        for (const [key, id] of Object.entries(fields)) {
+            const v = val(id).trim();
+            if (v) params.set(key, v);
+        }
+        if (val('match-filter') === 'any') params.set('match', 'any');
+        const limit = val('limit-select');
+        if (limit) params.set('limit', limit);
         params.set('page', '1');
 
         window.location = '/admin/contacts' + (params.toString() ? '?' + params.toString() : '');
     }
 
+    // Сохранить фильтры из адреса страницы как вкладку
+    async function saveView() {
+        const name = (prompt('Название вида (вкладки):') || '').trim();
+        if (!name) return;
+        try {
+            const data = await window.ContactsAPI.createView(name, location.search);
+            window.location = data.url;
+        } catch (err) {
+            window.ContactsUI?.show('error', err.message);
+        }
+    }
+
+    async function deleteView(btn) {
+        if (!confirm('Удалить вид?')) return;
+        const id = Number(btn.dataset.id || 0);
+        try {
+            await window.ContactsAPI.deleteView(id);
+            const current = new URLSearchParams(location.search);
+            if (current.get('view') === String(id)) {
+                window.location = '/admin/contacts';
+            } else {
+                btn.closest('.view-tab')?.remove();
+            }
+        } catch (err) {
+            window.ContactsUI?.show('error', err.message);
+        }
+    }
+
     function initFilters() {
         document.getElementById('apply-filters')?.addEventListener('click', applyFilters);
 
@@ -26,6 +67,12 @@
         if (e.key === 'Enter') applyFilters();
         });
 
+        document.getElementById('view-save')?.addEventListener('click', saveView);
+        document.querySelector('.view-tabs')?.addEventListener('click', (e) => {
+        const btn = e.target.closest('.js-view-delete');
+        if (btn) deleteView(btn);
+        });
+
         // Экспорт CSV по текущим параметрам из URL
         document.getElementById('export-csv')?.addEventListener('click', () => {
         const url = window.ContactsAPI.exportUrlFromLocation();
@@ -0,0 +1,128 @@
+// Импорт заявок из CSV/XLSX: загрузка файла, сопоставление колонок, проверка и импорт
+document.addEventListener('DOMContentLoaded', function() {
+  var root = document.getElementById('contactImport');
+  if (!root) return;
+
+  var fileInput = document.getElementById('import-file');
+  var mappingBox = document.getElementById('import-mapping');
+  var reportBox = document.getElementById('import-report');
+  var runBtn = document.getElementById('import-run');
+  var selects = root.querySelectorAll('.js-import-column');
+
+  var resultTitles = {
+    ok: 'Будет создана',
+    created: 'Создана',
+    duplicate: 'Повтор',
+    invalid: 'Ошибка'
+  };
+
+  function esc(s) {
+    return String(s == null ? '' : s).replace(/[&<>"']/g, function(ch) {
+      return { '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' }[ch];
+    });
+  }
+
+  function send(url, extra) {
+    var file = fileInput.files[0];
+    if (!file) {
+      showAdminMessage('Выберите файл CSV или XLSX', 'error');
+      return Promise.reject(new Error('no file'));
+    }
+    var fd = new FormData();
+    fd.append('file', file);
+    Object.keys(extra || {}).forEach(function(k) { fd.append(k, extra[k]); });
+    return fetch(url, { method: 'POST', body: fd })
+      .then(function(r) { return r.json(); })
+      .then(function(data) {
+        if (data.error || data.success === false) {
+          showAdminMessage(data.error || 'Ошибка', 'error');
+          throw new Error(data.error);
+        }
+        return data;
+      });
+  }
+
+  function currentMapping() {
+    var mapping = {};
+    selects.forEach(function(sel) {
+      if (sel.value !== '') mapping[sel.dataset.key] = Number(sel.value);
+    });
+    return mapping;
+  }
+
+  function renderPreview(data) {
+    selects.forEach(function(sel) {
+      sel.length = 1;
+      data.headers.forEach(function(h, i) {
+        sel.add(new Option(h || ('Колонка ' + (i + 1)), String(i)));
+      });
+      var col = data.mapping[sel.dataset.key];
+      sel.value = col === undefined ? '' : String(col);
+    });
+
+    var html = '<thead><tr>' + data.headers.map(function(h) { return '<th>' + esc(h) + '</th>'; }).join('') + '</tr></thead><tbody>';
+    data.rows.forEach(function(row) {
+      html += '<tr>' + data.headers.map(function(_, i) { return '<td>' + esc(row[i]) + '</td>'; }).join('') + '</tr>';
+    });
+    document.getElementById('import-sample').innerHTML = html + '</tbody>';
+    document.getElementById('import-total').textContent = '· строк: ' + data.total;
+
+    mappingBox.classList.remove('hidden');
+    reportBox.classList.add('hidden');
+    runBtn.disabled = true;
+  }
+
+  function renderReport(data) {
+    var summary = data.dry_run
+      ? 'Проверка: будет создано ' + data.ready
+      : 'Импорт: создано ' + data.created;
+    summary += ', повторов ' + data.duplicates + ', ошибок ' + data.invalid + ' из ' + data.total;
+    document.getElementById('import-summary').textContent = summary;
+
+    document.getElementById('import-rows').innerHTML = data.rows.map(function(r) {
+      var result = resultTitles[r.result] || r.result;
+      if (r.duplicate_of) {
+        result += ': <a href="/admin/contacts?search=' + encodeURIComponent(r.phone) + '">заявка #' + r.duplicate_of + '</a>';
+      } else if (r.error) {
+        result += ': ' + esc(r.error);
+      }
+      return '<tr class="import-row-' + esc(r.result) + '"><td>' + r.line + '</td><td>' + esc(r.name) + '</td><td>' +
+        esc(r.phone) + '</td><td>' + result + '</td></tr>';
+    }).join('');
+    reportBox.classList.remove('hidden');
+  }
+
+  document.getElementById('import-preview').addEventListener('click', function() {
+    send('/admin/contacts/import/preview').then(renderPreview).catch(function() {});
+  });
+
+  document.getElementById('import-check').addEventListener('click', function() {
+    send('/admin/contacts/import', { mapping: JSON.stringify(currentMapping()), dry_run: 'true' })
+      .then(function(data) {
+        renderReport(data);
+        runBtn.disabled = data.ready === 0;
+      })
+      .catch(function() {});
+  });
+
+  runBtn.addEventListener('click', function() {
+    if (!confirm('Импортировать заявки из файла?')) return;
+    runBtn.disabled = true;
+    send('/admin/contacts/import', { mapping: JSON.stringify(currentMapping()), dry_run: 'false' })
+      .then(function(data) {
+        renderReport(data);
+        showAdminMessage('Импортировано заявок: ' + data.created);
+      })
+      .catch(function() { runBtn.disabled = false; });
+  });
+
+  // После смены файла или колонок импорт доступен только после новой проверки
+  fileInput.addEventListener('change', function() {
+    mappingBox.classList.add('hidden');
+    reportBox.classList.add('hidden');
+    runBtn.disabled = true;
+  });
+  selects.forEach(function(sel) {
+    sel.addEventListener('change', function() { runBtn.disabled = true; });
+  });
+});
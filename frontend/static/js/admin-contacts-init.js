@@ -4,6 +4,7 @@
     window.ContactsFiltersInit?.();
     window.ContactsModalInit?.();
     window.ContactsBulkInit?.();
+    window.ContactsCreateInit?.();
 
     // Делегирование: "Обработать" в строке
     document.querySelector('table.table tbody')?.addEventListener('click', async (e) => {
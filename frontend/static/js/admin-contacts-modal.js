@@ -23,12 +23,28 @@
       type:    document.getElementById('cd-type'),
       date:    document.getElementById('cd-date'),
       status:  document.getElementById('cd-status'),
+      customerRow: document.getElementById('cd-customer-row'),
+      customer:    document.getElementById('cd-customer'),
+      spamRow:    document.getElementById('cd-spam-reason-row'),
+      spamReason: document.getElementById('cd-spam-reason'),
+      lostRow:    document.getElementById('cd-lost-reason-row'),
+      lostReason: document.getElementById('cd-lost-reason'),
+      assignee:     document.getElementById('cd-assignee'),
+      assigneeName: document.getElementById('cd-assignee-name'),
       msg:     document.getElementById('cd-message'),
+      attribution:     document.getElementById('cd-attribution'),
+      attributionBody: document.getElementById('cd-attribution-body'),
 
       // действия (инбокс)
       btnProcessed: document.getElementById('cd-mark-processed'),
       btnNew:       document.getElementById('cd-mark-new'),
       btnArchive:   document.getElementById('cd-archive'),
+      btnSpam:      document.getElementById('cd-mark-spam'),
+
+      // этап воронки
+      stage:      document.getElementById('cd-stage'),
+      lostInput:  document.getElementById('cd-lost-input'),
+      stageApply: document.getElementById('cd-stage-apply'),
 
       // напоминание
       remAt:   document.getElementById('cd-remind-at'),
@@ -40,6 +56,18 @@
       noteAuthor: document.getElementById('cd-note-author'),
       noteText:   document.getElementById('cd-note-text'),
       noteAdd:    document.getElementById('cd-note-add'),
+
+      // доставка уведомлений
+      deliveriesList: document.getElementById('cd-deliveries-list'),
+
+      // коммерческие предложения
+      quotesList:  document.getElementById('cd-quotes-list'),
+      quoteType:   document.getElementById('cd-quote-type'),
+      quotePitch:  document.getElementById('cd-quote-pitch'),
+      quoteWidth:  document.getElementById('cd-quote-width'),
+      quoteHeight: document.getElementById('cd-quote-height'),
+      quoteLight:  document.getElementById('cd-quote-light'),
+      quoteCreate: document.getElementById('cd-quote-create'),
     };
 
     const hasReminder = !!(f.remAt && f.remSave && f.remClear);
@@ -60,6 +88,14 @@
         noteAuthor: f.noteAuthor,
         noteText:   f.noteText,
         noteAdd:    f.noteAdd,
+        deliveriesList: f.deliveriesList,
+        quotesList:  f.quotesList,
+        quoteType:   f.quoteType,
+        quotePitch:  f.quotePitch,
+        quoteWidth:  f.quoteWidth,
+        quoteHeight: f.quoteHeight,
+        quoteLight:  f.quoteLight,
+        quoteCreate: f.quoteCreate,
       },
     });
 
@@ -94,6 +130,30 @@
 
       w.ContactsUI.setModalStatusBadge(f.status, btn.dataset.status || 'new');
 
+      // клиент: все его заявки и заметки на одной странице
+      const customerId = btn.dataset.customerId || '';
+      if (f.customer && customerId) {
+        const n = Number(btn.dataset.customerContacts || 0);
+        f.customer.href = `/admin/customers/${customerId}`;
+        f.customer.textContent = n > 1 ? `все заявки клиента (${n})` : 'первое обращение';
+      }
+      f.customerRow?.classList.toggle('hidden', !customerId);
+
+      // причина, по которой антиспам отметил заявку
+      const spamReason = btn.dataset.spamReason || '';
+      if (f.spamReason) f.spamReason.textContent = spamReason || '—';
+      f.spamRow?.classList.toggle('hidden', !spamReason);
+
+      loadAttribution(currentId);
+
+      showAssignee(btn.dataset.assignedId || '', btn.dataset.assignedTo || '');
+      showLostReason(btn.dataset.lostReason || '');
+      if (f.stage) {
+        f.stage.value = btn.dataset.status || 'new';
+        if (f.lostInput) f.lostInput.value = btn.dataset.lostReason || '';
+        toggleLostInput();
+      }
+
       // вкладка «Заметки»: подставить напоминание и загрузить заметки
       const rem = btn.dataset.remindAt || '';
       if (hasReminder) {
@@ -105,22 +165,122 @@
       open();
     });
 
+    // Источник заявки: последний и первый визит (UTM-метки или реферер), страница формы
+    async function loadAttribution(id) {
+      if (!f.attribution || !f.attributionBody) return;
+      f.attribution.classList.add('hidden');
+      f.attributionBody.innerHTML = '';
+      let a = null;
+      try {
+        ({ attribution: a } = await w.ContactsAPI.getAttribution(id));
+      } catch {
+        return;
+      }
+      if (!a || id !== currentId) return;
+
+      const esc = (s) => String(s || '').replace(/[&<>"']/g, ch => ({ '&':'&amp;', '<':'&lt;', '>':'&gt;', '"':'&quot;', "'":'&#39;' }[ch]));
+      const touch = (title, t) => {
+        const parts = [t.source, t.medium, t.campaign].filter(Boolean).map(esc).join(' / ');
+        const extra = [t.term && `ключевое слово: ${esc(t.term)}`, t.content && `объявление: ${esc(t.content)}`].filter(Boolean).join(', ');
+        const when = t.at ? new Date(t.at).toLocaleString('ru-RU') : '';
+        return `<div><em>${title}:</em> ${parts || '—'}${extra ? ` (${extra})` : ''}
+                  <span style="color:#777;">${when}</span></div>
+                ${t.referrer ? `<div style="color:#777;font-size:12px;">Реферер: ${esc(t.referrer)}</div>` : ''}
+                ${t.landing ? `<div style="color:#777;font-size:12px;">Страница входа: ${esc(t.landing)}</div>` : ''}`;
+      };
+      const same = JSON.stringify(a.first) === JSON.stringify(a.last);
+      f.attributionBody.innerHTML = touch(same ? 'Визит' : 'Последний визит', a.last) +
+        (same ? '' : touch('Первый визит', a.first)) +
+        (a.form_page ? `<div style="color:#777;font-size:12px;">Страница заявки: ${esc(a.form_page)}</div>` : '');
+      f.attribution.classList.remove('hidden');
+    }
+
+    function showAssignee(id, name) {
+      if (f.assigneeName) f.assigneeName.textContent = name || '—';
+      if (!f.assignee) return;
+      // ответственный мог быть отключён: показываем его, хотя назначить заново нельзя
+      if (id && !f.assignee.querySelector(`option[value="${id}"]`)) {
+        const opt = document.createElement('option');
+        opt.value = id;
+        opt.textContent = name || `#${id}`;
+        opt.disabled = true;
+        f.assignee.appendChild(opt);
+      }
+      f.assignee.value = id || '0';
+      f.assignee.dataset.current = f.assignee.value;
+    }
+
+    // Смена ответственного: сервер пишет заметку, поэтому список заметок обновляется
+    f.assignee?.addEventListener('change', async () => {
+      if (!currentId) return;
+      try {
+        const data = await w.ContactsAPI.assign(currentId, Number(f.assignee.value));
+        const id = data.assigned_admin_id ? String(data.assigned_admin_id) : '';
+        const name = data.assigned_to || '';
+        f.assignee.dataset.current = f.assignee.value;
+
+        const detailsBtn = document.querySelector(`.js-contact-details[data-id="${currentId}"]`);
+        if (detailsBtn) {
+          detailsBtn.dataset.assignedId = id;
+          detailsBtn.dataset.assignedTo = name;
+        }
+        const rowAssignee = document.querySelector(`tr[data-id="${currentId}"] .js-assignee`);
+        if (rowAssignee) {
+          rowAssignee.querySelector('span').textContent = name;
+          rowAssignee.classList.toggle('hidden', !name);
+        }
+        await Notes?.reloadNotes();
+        w.ContactsUI.show('ok', data.message);
+      } catch (err) {
+        f.assignee.value = f.assignee.dataset.current || '0';
+        w.ContactsUI.show('error', err.message);
+      }
+    });
+
+    function showLostReason(reason) {
+      if (f.lostReason) f.lostReason.textContent = reason || '—';
+      f.lostRow?.classList.toggle('hidden', !reason);
+    }
+
+    // Поле причины нужно только для этапа проигрыша
+    function toggleLostInput() {
+      const lost = f.stage?.selectedOptions[0]?.dataset.kind === 'lost';
+      f.lostInput?.classList.toggle('hidden', !lost);
+      return lost;
+    }
+
     // Смена статуса из модалки
-    async function change(status, okMsg) {
+    async function change(status, okMsg, lostReason) {
       if (!currentId) return;
       try {
-        await w.ContactsAPI.updateStatus(currentId, status);
+        await w.ContactsAPI.updateStatus(currentId, status, lostReason);
         w.ContactsUI.setModalStatusBadge(f.status, status);
         w.ContactsUI.setRowStatusById(currentId, status);
+        showLostReason(lostReason);
+        const detailsBtn = document.querySelector(`.js-contact-details[data-id="${currentId}"]`);
+        if (detailsBtn) detailsBtn.dataset.lostReason = lostReason || '';
         w.ContactsUI.show('ok', okMsg);
       } catch (err) {
         w.ContactsUI.show('error', err.message);
       }
     }
 
+    f.stage?.addEventListener('change', toggleLostInput);
+    f.stageApply?.addEventListener('click', () => {
+      const lost = toggleLostInput();
+      const reason = lost ? (f.lostInput?.value || '').trim() : '';
+      if (lost && !reason) {
+        w.ContactsUI.show('error', 'Укажите причину проигрыша');
+        f.lostInput?.focus();
+        return;
+      }
+      change(f.stage.value, 'Этап изменён', reason);
+    });
+
     f.btnProcessed?.addEventListener('click', () => change('processed', 'Помечено как обработано'));
     f.btnNew?.addEventListener('click',       () => change('new',       'Возвращено в новые'));
     f.btnArchive?.addEventListener('click',   () => change('archived',  'Отправлено в архив'));
+    f.btnSpam?.addEventListener('click',      () => change('spam',      'Помечено как спам'));
   }
 
   w.ContactsModalInit = initModal;
@@ -1,8 +1,9 @@
-// Общая логика вкладки «Заметки» и «Перезвонить позже» для обеих модалок.
+// Общая логика вкладки «Заметки» (заметки, «Перезвонить позже», КП, доставка уведомлений) для обеих модалок.
 // Использование:
 //   const Notes = ContactsNotes.init({
 //     getCurrentId: () => currentId,
-//     els: { tabInfoBtn, tabNotesBtn, tabInfo, tabNotes, remAt, remSave, remClear, notesList, noteAuthor, noteText, noteAdd }
+//     els: { tabInfoBtn, tabNotesBtn, tabInfo, tabNotes, remAt, remSave, remClear, notesList, noteAuthor, noteText, noteAdd, deliveriesList,
+//            quotesList, quoteType, quotePitch, quoteWidth, quoteHeight, quoteLight, quoteCreate }
 //   });
 //   // при открытии модалки:
 //   Notes.onOpen({ remindAt: btn.dataset.remindAt || '' });
@@ -49,8 +50,11 @@
         li.dataset.noteId = n.id;
         const dateStr = n.created_at ? new Date(n.created_at).toLocaleString("ru-RU") : "";
         const author = n.author ? ` — ${n.author}` : "";
+        // data-readonly ставит шаблон, если у роли нет прав на изменение заявок
+        const delBtn = f.notesList.dataset.readonly === "1" ? "" :
+          `<button type="button" class="btn btn-small js-note-del" style="margin-left:6px;">Удалить</button>`;
         li.innerHTML = `<span>${escapeHtml(n.text)}</span><span style="color:#777;"> (${dateStr}${author})</span>
-                        <button type="button" class="btn btn-small js-note-del" style="margin-left:6px;">Удалить</button>`;
+                        ${delBtn}`;
         f.notesList.appendChild(li);
       }
     }
@@ -94,6 +98,141 @@
       }
     });
 
+    // ——— Доставка уведомлений ———
+    async function loadDeliveriesSafe() {
+      const id = getCurrentId?.();
+      if (!f.deliveriesList || !id) return;
+      try {
+        const { deliveries } = await w.ContactsAPI.getNotifications(id);
+        renderDeliveries(deliveries || []);
+      } catch {
+        renderDeliveries([]);
+      }
+    }
+
+    function renderDeliveries(deliveries) {
+      if (!f.deliveriesList) return;
+      f.deliveriesList.innerHTML = "";
+      if (!deliveries.length) {
+        const li = document.createElement("li");
+        li.style.color = "#777";
+        li.textContent = "Уведомлений не было";
+        f.deliveriesList.appendChild(li);
+        return;
+      }
+      const colors = { pending: "#b7791f", sent: "#2f855a", dead: "#c53030" };
+      for (const d of deliveries) {
+        const li = document.createElement("li");
+        li.dataset.deliveryId = d.id;
+        const when = d.sent_at || d.created_at;
+        const dateStr = when ? new Date(when).toLocaleString("ru-RU") : "";
+        const err = d.status !== "sent" && d.last_error
+          ? `<div style="color:#777;font-size:12px;">Попыток: ${d.attempts}. ${escapeHtml(d.last_error)}</div>` : "";
+        const retryBtn = d.status === "dead" && f.deliveriesList.dataset.readonly !== "1"
+          ? `<button type="button" class="btn btn-small js-delivery-retry" style="margin-left:6px;">Повторить</button>` : "";
+        li.innerHTML = `<span>${escapeHtml(d.channel_title || d.channel)} (${escapeHtml(d.event_title || d.event)}):</span>
+                        <span style="color:${colors[d.status] || "#777"};">${escapeHtml(d.status_title || d.status)}</span>
+                        <span style="color:#777;"> ${dateStr}</span>${retryBtn}${err}`;
+        f.deliveriesList.appendChild(li);
+      }
+    }
+
+    f.deliveriesList?.addEventListener("click", async (e) => {
+      const btn = e.target.closest(".js-delivery-retry");
+      if (!btn) return;
+      const id = getCurrentId?.();
+      const deliveryId = Number(btn.closest("li")?.dataset.deliveryId || 0);
+      if (!id || !deliveryId) return;
+      try {
+        await w.ContactsAPI.retryNotification(id, deliveryId);
+        await loadDeliveriesSafe();
+        w.ContactsUI.show("ok", "Уведомление поставлено в очередь");
+      } catch (err) {
+        w.ContactsUI.show("error", err.message);
+      }
+    });
+
+    // ——— Коммерческие предложения ———
+    async function loadQuotesSafe() {
+      const id = getCurrentId?.();
+      if (!f.quotesList || !id) return;
+      try {
+        const { quotes } = await w.ContactsAPI.getQuotes(id);
+        renderQuotes(quotes || []);
+      } catch {
+        renderQuotes([]);
+      }
+    }
+
+    function renderQuotes(quotes) {
+      if (!f.quotesList) return;
+      f.quotesList.innerHTML = "";
+      if (!quotes.length) {
+        const li = document.createElement("li");
+        li.style.color = "#777";
+        li.textContent = "КП пока нет";
+        f.quotesList.appendChild(li);
+        return;
+      }
+      const id = getCurrentId?.();
+      for (const q of quotes) {
+        const li = document.createElement("li");
+        const dateStr = q.created_at ? new Date(q.created_at).toLocaleString("ru-RU") : "";
+        const author = q.author ? ` — ${escapeHtml(q.author)}` : "";
+        const total = Math.round(q.total || 0).toLocaleString("ru-RU");
+        li.innerHTML = `<a href="${w.ContactsAPI.quotePdfUrl(id, q.id)}">КП № ${escapeHtml(q.number)}</a>
+                        <span>${escapeHtml(q.summary)}, ${total} ₽</span>
+                        <span style="color:#777;"> (${dateStr}${author})</span>`;
+        f.quotesList.appendChild(li);
+      }
+    }
+
+    // Шаги пикселя из данных калькулятора (загружаются один раз)
+    let calcPitches = null;
+    async function fillPitches() {
+      if (!f.quotePitch) return;
+      if (!calcPitches) {
+        try {
+          const { pitches } = await w.ContactsAPI.getCalculator();
+          calcPitches = pitches || [];
+        } catch {
+          return;
+        }
+      }
+      const type = f.quoteType?.value || "indoor";
+      f.quotePitch.innerHTML = "";
+      for (const p of calcPitches.filter(p => p.screen_type === type)) {
+        const opt = document.createElement("option");
+        opt.value = p.id;
+        opt.textContent = p.name;
+        f.quotePitch.appendChild(opt);
+      }
+    }
+    f.quoteType?.addEventListener("change", fillPitches);
+
+    f.quoteCreate?.addEventListener("click", async () => {
+      const id = getCurrentId?.();
+      if (!id) return;
+      const pitchId = Number(f.quotePitch?.value || 0);
+      if (!pitchId) { w.ContactsUI.show("error", "Выберите шаг пикселя"); return; }
+      f.quoteCreate.disabled = true;
+      try {
+        const { message } = await w.ContactsAPI.createQuote(id, {
+          screen_type: f.quoteType.value,
+          pitch_id: pitchId,
+          width: Number(f.quoteWidth?.value || 0),
+          height: Number(f.quoteHeight?.value || 0),
+          light: !!f.quoteLight?.checked,
+        });
+        await Promise.all([loadQuotesSafe(), loadNotesSafe()]);
+        w.ContactsUI.show("ok", message || "КП сформировано");
+      } catch (err) {
+        w.ContactsUI.show("error", err.message);
+      } finally {
+        f.quoteCreate.disabled = false;
+      }
+    });
+
     // ——— Напоминание ———
     f.remSave?.addEventListener("click", async () => {
       const id = getCurrentId?.();
@@ -123,11 +262,11 @@
     // ——— Публичный API модуля ———
     async function onOpen({ remindAt } = {}) {
       if (f.remAt) f.remAt.value = remindAt || "";
-      await loadNotesSafe();
+      await Promise.all([loadNotesSafe(), loadQuotesSafe(), loadDeliveriesSafe(), fillPitches()]);
       showTab("info");
     }
 
-    return { onOpen, showTab, reloadNotes: loadNotesSafe };
+    return { onOpen, showTab, reloadNotes: loadNotesSafe, reloadQuotes: loadQuotesSafe, reloadDeliveries: loadDeliveriesSafe };
   }
 
   w.ContactsNotes = { init };
@@ -1,10 +1,29 @@
 // UI helpers: единые функции для обновления DOM
 (function (w) {
+    // Подписи и классы статусов: этапы воронки из админки (window.ContactStages) + системные
+    const fallbackStages = {
+        new:      { Title: 'Новая',    Class: 'badge-blue' },
+        spam:     { Title: 'Спам',     Class: 'badge-error' },
+        archived: { Title: 'В архиве', Class: '' },
+    };
+
+    function stageBadge(status) {
+        return (w.ContactStages || {})[status] || fallbackStages[status] || { Title: status, Class: '' };
+    }
+
+    function badgeHTML(status) {
+        const b = stageBadge(status);
+        const span = document.createElement('span');
+        span.className = ('badge ' + (b.Class || '')).trim();
+        span.textContent = b.Title;
+        return span.outerHTML;
+    }
+
     function setModalStatusBadge(el, status) {
         if (!el) return;
-        if (status === 'processed') { el.textContent = 'Обработано'; el.className = 'badge badge-ok'; }
-        else if (status === 'new')  { el.textContent = 'Новая';      el.className = 'badge badge-blue'; }
-        else                        { el.textContent = 'В архиве';   el.className = 'badge'; }
+        const b = stageBadge(status);
+        el.textContent = b.Title;
+        el.className = ('badge ' + (b.Class || '')).trim();
     }
 
     function setRowStatus(tr, status) {
@@ -15,12 +34,10 @@
         const cell = tr.querySelector('.status-cell');
         if (!cell) return;
 
-        if (status === 'processed') {
-        cell.innerHTML = '<span class="badge badge-ok">Обработано</span>';
-        } else if (status === 'new') {
+        if (status === 'new') {
         cell.innerHTML = '<button class="btn btn-small mark-done" type="button">Обработать</button>';
         } else {
-        cell.innerHTML = '<span class="badge">В архиве</span>';
+        cell.innerHTML = badgeHTML(status);
         }
     }
 
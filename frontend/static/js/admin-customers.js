@@ -0,0 +1,50 @@
+// Карточка клиента: объединение с другим клиентом и отделение заявки
+document.addEventListener('DOMContentLoaded', function() {
+  var card = document.getElementById('customerCard');
+  if (!card) return;
+  var customerId = card.dataset.customerId;
+
+  function postJSON(url, body) {
+    return fetch(url, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body || {})
+    }).then(function(r) { return r.json(); });
+  }
+
+  var mergeBtn = document.getElementById('mergeCustomer');
+  if (mergeBtn) {
+    mergeBtn.addEventListener('click', function() {
+      var sourceId = Number(document.getElementById('mergeSourceId').value);
+      if (!sourceId) {
+        showAdminMessage('Укажите ID клиента', 'error');
+        return;
+      }
+      if (!confirm('Перенести заявки клиента #' + sourceId + ' в эту карточку? Карточка #' + sourceId + ' будет удалена.')) return;
+      postJSON('/admin/customers/' + customerId + '/merge', { source_id: sourceId })
+        .then(function(data) {
+          if (!data.success) {
+            showAdminMessage(data.error || 'Ошибка', 'error');
+            return;
+          }
+          showAdminMessage(data.message);
+          location.reload();
+        })
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+    });
+  }
+
+  window.detachCustomerContact = function(contactId) {
+    if (!confirm('Отделить заявку #' + contactId + ' в нового клиента?')) return;
+    postJSON('/admin/customers/' + customerId + '/contacts/' + contactId + '/detach')
+      .then(function(data) {
+        if (!data.success) {
+          showAdminMessage(data.error || 'Ошибка', 'error');
+          return;
+        }
+        showAdminMessage(data.message);
+        location.reload();
+      })
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  };
+});
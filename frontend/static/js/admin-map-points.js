@@ -86,7 +86,9 @@ document.addEventListener('DOMContentLoaded', function() {
 
   // === Создание точки ===
 
-  document.getElementById('openCreateMapPointModal').addEventListener('click', function() {
+  // Кнопки тулбара выводятся только при наличии прав на редактирование
+  var openCreateMapPointModalBtn = document.getElementById('openCreateMapPointModal');
+  if (openCreateMapPointModalBtn) openCreateMapPointModalBtn.addEventListener('click', function() {
     document.getElementById('createMapPointForm').reset();
     document.getElementById('createPointActive').checked = true;
     openModal('createMapPointModal');
@@ -212,7 +214,8 @@ document.addEventListener('DOMContentLoaded', function() {
 
   // === Импорт из одной ссылки ===
 
-  document.getElementById('openImportLinkModal').addEventListener('click', function() {
+  var openImportLinkModalBtn = document.getElementById('openImportLinkModal');
+  if (openImportLinkModalBtn) openImportLinkModalBtn.addEventListener('click', function() {
     document.getElementById('importLinkForm').reset();
     openModal('importLinkModal');
   });
@@ -268,7 +271,8 @@ document.addEventListener('DOMContentLoaded', function() {
 
   // === Массовый импорт ===
 
-  document.getElementById('openBulkImportModal').addEventListener('click', function() {
+  var openBulkImportModalBtn = document.getElementById('openBulkImportModal');
+  if (openBulkImportModalBtn) openBulkImportModalBtn.addEventListener('click', function() {
     document.getElementById('bulkImportForm').reset();
     openModal('bulkImportModal');
   });
@@ -0,0 +1,157 @@
+// Воронка заявок: канбан-доска (/admin/contacts/board) и настройка этапов (/admin/pipeline)
+document.addEventListener('DOMContentLoaded', function() {
+  initBoard();
+  initStages();
+
+  // === Канбан-доска ===
+
+  function initBoard() {
+    var board = document.getElementById('pipelineBoard');
+    if (!board || !board.dataset.editable || typeof Sortable === 'undefined') return;
+
+    board.querySelectorAll('.board-cards').forEach(function(list) {
+      Sortable.create(list, {
+        group: 'pipeline',
+        animation: 150,
+        onEnd: function(evt) {
+          if (evt.from === evt.to) return;
+          moveCard(evt);
+        }
+      });
+    });
+  }
+
+  // Вернуть карточку в исходную колонку (отмена или ошибка сервера)
+  function revert(evt) {
+    evt.from.insertBefore(evt.item, evt.from.children[evt.oldIndex] || null);
+  }
+
+  function updateCount(list, delta) {
+    var badge = list.closest('.board-column').querySelector('.board-column__count');
+    var n = parseInt(badge.textContent || '0', 10);
+    if (!isNaN(n)) badge.textContent = String(Math.max(0, n + delta));
+  }
+
+  function moveCard(evt) {
+    var column = evt.to.closest('.board-column');
+    var body = { status: column.dataset.stage };
+
+    if (column.dataset.kind === 'lost') {
+      var reason = prompt('Причина проигрыша:');
+      if (!reason || !reason.trim()) {
+        revert(evt);
+        showAdminMessage('Без причины заявку нельзя перевести в этап проигрыша', 'error');
+        return;
+      }
+      body.lost_reason = reason.trim();
+    }
+
+    fetch('/admin/contacts/' + evt.item.dataset.id + '/status', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body)
+    }).then(function(r) { return r.json(); })
+      .then(function(data) {
+        if (!data.success) {
+          revert(evt);
+          showAdminMessage(data.error || 'Ошибка', 'error');
+          return;
+        }
+        updateCount(evt.from, -1);
+        updateCount(evt.to, 1);
+        var reasonEl = evt.item.querySelector('.board-card__reason');
+        if (reasonEl) {
+          reasonEl.textContent = body.lost_reason || '';
+          reasonEl.classList.toggle('hidden', !body.lost_reason);
+        }
+        showAdminMessage('Этап изменён');
+      })
+      .catch(function() {
+        revert(evt);
+        showAdminMessage('Ошибка сети', 'error');
+      });
+  }
+
+  // === Настройка этапов ===
+
+  function initStages() {
+    var sortableList = document.getElementById('sortable-stages');
+    if (!sortableList) return;
+
+    if (typeof Sortable !== 'undefined' && sortableList.querySelector('.drag-handle')) {
+      Sortable.create(sortableList, {
+        handle: '.drag-handle',
+        animation: 150,
+        onEnd: function() {
+          var ids = [];
+          sortableList.querySelectorAll('.project-item').forEach(function(item) {
+            ids.push(parseInt(item.dataset.stageId));
+          });
+          fetch('/admin/pipeline/sort', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ ids: ids })
+          }).then(function(r) { return r.json(); })
+            .then(function(data) {
+              if (data.success) showAdminMessage('Порядок обновлён');
+            });
+        }
+      });
+    }
+
+    function handleResult(modalId) {
+      return function(data) {
+        if (data.success) {
+          showAdminMessage(data.message);
+          if (modalId) closeModal(modalId);
+          location.reload();
+        } else {
+          showAdminMessage(data.error || 'Ошибка', 'error');
+        }
+      };
+    }
+
+    var form = document.getElementById('stageForm');
+    var kindSelect = document.getElementById('stageKind');
+
+    // Этапы формы заявки и бота всегда остаются «в работе»
+    function openStageModal(title, id, name, kind, isProtected) {
+      form.reset();
+      document.getElementById('stageModalTitle').textContent = title;
+      document.getElementById('stageId').value = id || '';
+      document.getElementById('stageTitle').value = name || '';
+      kindSelect.value = kind || 'open';
+      Array.prototype.forEach.call(kindSelect.options, function(opt) {
+        opt.disabled = !!isProtected && opt.value !== 'open';
+      });
+      openModal('stageModal');
+    }
+
+    var openCreateBtn = document.getElementById('openCreateStageModal');
+    if (openCreateBtn) openCreateBtn.addEventListener('click', function() {
+      openStageModal('Добавить этап');
+    });
+
+    window.editStage = function(id, name, kind, isProtected) {
+      openStageModal('Редактировать этап', id, name, kind, isProtected);
+    };
+
+    if (form) form.addEventListener('submit', function(e) {
+      e.preventDefault();
+      var id = document.getElementById('stageId').value;
+      var url = id ? '/admin/pipeline/' + id + '/update' : '/admin/pipeline';
+      fetch(url, { method: 'POST', body: new FormData(this) })
+        .then(function(r) { return r.json(); })
+        .then(handleResult('stageModal'))
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+    });
+
+    window.deleteStage = function(id, name) {
+      if (!confirm('Удалить этап "' + name + '"?')) return;
+      fetch('/admin/pipeline/' + id, { method: 'DELETE' })
+        .then(function(r) { return r.json(); })
+        .then(handleResult(null))
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+    };
+  }
+});
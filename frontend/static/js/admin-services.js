@@ -0,0 +1,105 @@
+// Управление услугами в админке
+document.addEventListener('DOMContentLoaded', function() {
+  // Drag & drop сортировка
+  var sortableList = document.getElementById('sortable-services');
+  if (sortableList && typeof Sortable !== 'undefined') {
+    Sortable.create(sortableList, {
+      handle: '.drag-handle',
+      animation: 150,
+      onEnd: function() {
+        var ids = [];
+        sortableList.querySelectorAll('.project-item').forEach(function(item) {
+          ids.push(parseInt(item.dataset.serviceId));
+        });
+        fetch('/admin/services/sort', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ ids: ids })
+        }).then(function(r) { return r.json(); })
+          .then(function(data) {
+            if (data.success) showAdminMessage('Порядок обновлён');
+          });
+      }
+    });
+  }
+
+  function handleResult(modalId) {
+    return function(data) {
+      if (data.success) {
+        showAdminMessage(data.message);
+        if (modalId) closeModal(modalId);
+        location.reload();
+      } else {
+        showAdminMessage(data.error || 'Ошибка', 'error');
+      }
+    };
+  }
+
+  // === Создание услуги ===
+
+  // Кнопки тулбара выводятся только при наличии прав на редактирование
+  var openCreateServiceModalBtn = document.getElementById('openCreateServiceModal');
+  if (openCreateServiceModalBtn) openCreateServiceModalBtn.addEventListener('click', function() {
+    document.getElementById('createServiceForm').reset();
+    openModal('createServiceModal');
+  });
+
+  document.getElementById('createServiceForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    fetch('/admin/services', { method: 'POST', body: new FormData(this) })
+      .then(function(r) { return r.json(); })
+      .then(handleResult('createServiceModal'))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+
+  // === Редактирование услуги ===
+
+  window.editService = function(id) {
+    fetch('/admin/services/' + id)
+      .then(function(r) { return r.json(); })
+      .then(function(data) {
+        if (!data.success) { showAdminMessage(data.error || 'Ошибка', 'error'); return; }
+        var s = data.service;
+        document.getElementById('editServiceForm').reset();
+        document.getElementById('editServiceId').value = s.id;
+        document.getElementById('editServiceIcon').value = s.icon || '';
+        document.getElementById('editServiceName').value = s.name;
+        document.getElementById('editServiceShortDesc').value = s.short_desc || '';
+        document.getElementById('editServiceDescription').value = s.description || '';
+        document.getElementById('editServiceFeatured').checked = s.featured;
+
+        var preview = document.getElementById('editServiceIconPreview');
+        preview.innerHTML = '';
+        var src = s.thumbnail_small_path || s.icon_path;
+        if (src) {
+          var img = document.createElement('img');
+          img.src = src;
+          img.alt = s.name;
+          img.style.maxWidth = '160px';
+          img.style.borderRadius = '8px';
+          preview.appendChild(img);
+        }
+
+        openModal('editServiceModal');
+      });
+  };
+
+  document.getElementById('editServiceForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    var id = document.getElementById('editServiceId').value;
+    fetch('/admin/services/' + id + '/update', { method: 'POST', body: new FormData(this) })
+      .then(function(r) { return r.json(); })
+      .then(handleResult('editServiceModal'))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+
+  // === Удаление услуги ===
+
+  window.deleteService = function(id, name) {
+    if (!confirm('Удалить услугу "' + name + '"?')) return;
+    fetch('/admin/services/' + id, { method: 'DELETE' })
+      .then(function(r) { return r.json(); })
+      .then(handleResult(null))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  };
+});
@@ -0,0 +1,108 @@
+// Страница «Безопасность»: активные сессии и двухфакторная аутентификация
+document.addEventListener('DOMContentLoaded', function() {
+  function handleResult(data) {
+    if (data.success) {
+      showAdminMessage(data.message);
+      location.reload();
+    } else {
+      showAdminMessage(data.error || 'Ошибка', 'error');
+    }
+  }
+
+  window.revokeSession = function(id) {
+    if (!confirm('Завершить эту сессию? На том устройстве потребуется повторный вход.')) return;
+    fetch('/admin/sessions/' + id + '/revoke', { method: 'POST' })
+      .then(function(r) { return r.json(); })
+      .then(handleResult)
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  };
+
+  document.getElementById('revokeOtherSessions').addEventListener('click', function() {
+    if (!confirm('Завершить все сессии, кроме текущей?')) return;
+    fetch('/admin/sessions/revoke-others', { method: 'POST' })
+      .then(function(r) { return r.json(); })
+      .then(handleResult)
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+
+  // ---------- Двухфакторная аутентификация ----------
+
+  function post2FA(action, body) {
+    return fetch('/admin/security/2fa/' + action, { method: 'POST', body: body })
+      .then(function(r) { return r.json(); });
+  }
+
+  function showRecoveryCodes(codes) {
+    document.getElementById('recoveryCodesList').textContent = codes.join('\n');
+    document.getElementById('recoveryCodes').style.display = '';
+  }
+
+  function passwordBody(message) {
+    var password = prompt(message);
+    if (!password) return null;
+    var body = new FormData();
+    body.append('password', password);
+    return body;
+  }
+
+  var setupBtn = document.getElementById('setupTwoFactor');
+  if (setupBtn) {
+    setupBtn.addEventListener('click', function() {
+      post2FA('setup')
+        .then(function(data) {
+          if (!data.success) {
+            showAdminMessage(data.error || 'Ошибка', 'error');
+            return;
+          }
+          document.getElementById('twoFactorQR').src = data.qr;
+          document.getElementById('twoFactorSecret').textContent = data.secret;
+          document.getElementById('twoFactorSetup').style.display = '';
+          setupBtn.style.display = 'none';
+          document.getElementById('twoFactorCode').focus();
+        })
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+    });
+
+    document.getElementById('enableTwoFactor').addEventListener('click', function() {
+      var body = new FormData();
+      body.append('code', document.getElementById('twoFactorCode').value);
+      post2FA('enable', body)
+        .then(function(data) {
+          if (!data.success) {
+            showAdminMessage(data.error || 'Ошибка', 'error');
+            return;
+          }
+          showAdminMessage(data.message);
+          document.getElementById('twoFactorSetup').style.display = 'none';
+          showRecoveryCodes(data.codes);
+        })
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+    });
+  }
+
+  var regenBtn = document.getElementById('regenRecoveryCodes');
+  if (regenBtn) {
+    regenBtn.addEventListener('click', function() {
+      var body = passwordBody('Введите пароль. Старые коды восстановления перестанут действовать.');
+      if (!body) return;
+      post2FA('recovery-codes', body)
+        .then(function(data) {
+          if (!data.success) {
+            showAdminMessage(data.error || 'Ошибка', 'error');
+            return;
+          }
+          showAdminMessage(data.message);
+          showRecoveryCodes(data.codes);
+        })
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+    });
+
+    document.getElementById('disableTwoFactor').addEventListener('click', function() {
+      var body = passwordBody('Введите пароль для отключения двухфакторной аутентификации.');
+      if (!body) return;
+      post2FA('disable', body)
+        .then(handleResult)
+        .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+    });
+  }
+});
@@ -0,0 +1,107 @@
+// Управление пользователями админки
+document.addEventListener('DOMContentLoaded', function() {
+  function handleResult(modalId) {
+    return function(data) {
+      if (data.success) {
+        showAdminMessage(data.message);
+        if (modalId) closeModal(modalId);
+        location.reload();
+      } else {
+        showAdminMessage(data.error || 'Ошибка', 'error');
+      }
+    };
+  }
+
+  // Показывает выданный пароль; страница перезагружается после закрытия окна
+  function showIssuedPassword(username, password) {
+    document.getElementById('issuedPasswordUsername').textContent = username;
+    document.getElementById('issuedPasswordValue').textContent = password;
+    openModal('issuedPasswordModal');
+  }
+
+  // === Приглашение ===
+
+  document.getElementById('openInviteUserModal').addEventListener('click', function() {
+    document.getElementById('inviteUserForm').reset();
+    openModal('inviteUserModal');
+  });
+
+  document.getElementById('inviteUserForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    fetch('/admin/users', { method: 'POST', body: new FormData(this) })
+      .then(function(r) { return r.json(); })
+      .then(function(data) {
+        if (!data.success) { showAdminMessage(data.error || 'Ошибка', 'error'); return; }
+        closeModal('inviteUserModal');
+        showIssuedPassword(data.user.username, data.password);
+      })
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+
+  // === Редактирование ===
+
+  window.editUser = function(id) {
+    fetch('/admin/users/' + id)
+      .then(function(r) { return r.json(); })
+      .then(function(data) {
+        if (!data.success) { showAdminMessage(data.error || 'Ошибка', 'error'); return; }
+        var user = data.user;
+        document.getElementById('editUserId').value = user.id;
+        document.getElementById('editUsername').value = user.username;
+        document.getElementById('editUserEmail').value = user.email || '';
+        document.getElementById('editUserRole').value = user.role;
+        openModal('editUserModal');
+      });
+  };
+
+  document.getElementById('editUserForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    var id = document.getElementById('editUserId').value;
+    fetch('/admin/users/' + id + '/update', { method: 'POST', body: new FormData(this) })
+      .then(function(r) { return r.json(); })
+      .then(handleResult('editUserModal'))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+
+  // === Активация / деактивация ===
+
+  window.setUserActive = function(id, active, username) {
+    var question = active ? 'Активировать пользователя "' + username + '"?'
+                          : 'Деактивировать пользователя "' + username + '"? Он не сможет войти в админку.';
+    if (!confirm(question)) return;
+
+    var body = new FormData();
+    body.append('active', active ? 'true' : 'false');
+    fetch('/admin/users/' + id + '/active', { method: 'POST', body: body })
+      .then(function(r) { return r.json(); })
+      .then(handleResult(null))
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  };
+
+  // === Сброс пароля ===
+
+  window.resetUserPassword = function(id, username) {
+    document.getElementById('resetPasswordForm').reset();
+    document.getElementById('resetPasswordUserId').value = id;
+    document.getElementById('resetPasswordUsername').textContent = username;
+    openModal('resetPasswordModal');
+  };
+
+  document.getElementById('resetPasswordForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+    var id = document.getElementById('resetPasswordUserId').value;
+    var username = document.getElementById('resetPasswordUsername').textContent;
+    fetch('/admin/users/' + id + '/reset-password', { method: 'POST', body: new FormData(this) })
+      .then(function(r) { return r.json(); })
+      .then(function(data) {
+        if (!data.success) { showAdminMessage(data.error || 'Ошибка', 'error'); return; }
+        closeModal('resetPasswordModal');
+        if (data.password) {
+          showIssuedPassword(username, data.password);
+        } else {
+          showAdminMessage(data.message);
+        }
+      })
+      .catch(function() { showAdminMessage('Ошибка сети', 'error'); });
+  });
+});
@@ -103,7 +103,7 @@ async function submitContactForm(data) {
     
     return {
         success: response.ok,
-        message: result.message,
+        message: response.ok ? result.message : result.error,
         data: result
     };
 }
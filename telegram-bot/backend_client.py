@@ -0,0 +1,71 @@
+"""
+Подписанные запросы к Go backend (/api/telegram/*)
+
+Схема подписи совпадает с middleware.SignServiceRequest в backend:
+HMAC-SHA256(secret, timestamp \\n nonce \\n METHOD \\n path?query \\n body)
+"""
+
+import hashlib
+import hmac
+import json
+import secrets
+import time
+from typing import Any, Optional
+
+import httpx
+from config import settings
+
+
+def sign_request(method: str, path: str, body: bytes, secret: Optional[str] = None) -> dict:
+    """
+    Сформировать заголовки подписи для запроса к backend
+
+    Args:
+        method: HTTP метод (GET, POST)
+        path: путь с query-строкой, например "/api/telegram/due-reminders"
+        body: тело запроса в байтах (b"" для GET)
+        secret: общий секрет (по умолчанию TELEGRAM_API_SECRET из настроек)
+
+    Returns:
+        Словарь заголовков X-Signature-*
+    """
+    secret = settings.TELEGRAM_API_SECRET if secret is None else secret
+    timestamp = str(int(time.time()))
+    nonce = secrets.token_hex(16)
+
+    message = f"{timestamp}\n{nonce}\n{method.upper()}\n{path}\n".encode() + body
+    signature = hmac.new(secret.encode(), message, hashlib.sha256).hexdigest()
+
+    return {
+        "X-Signature": signature,
+        "X-Signature-Timestamp": timestamp,
+        "X-Signature-Nonce": nonce,
+    }
+
+
+async def backend_request(
+    client: httpx.AsyncClient,
+    backend_url: str,
+    method: str,
+    path: str,
+    payload: Optional[Any] = None,
+    timeout: float = 10.0,
+) -> httpx.Response:
+    """
+    Выполнить подписанный запрос к backend
+
+    Тело сериализуется здесь же, чтобы подпись считалась
+    ровно по тем байтам, которые уйдут в запросе.
+    """
+    body = b"" if payload is None else json.dumps(payload, ensure_ascii=False).encode("utf-8")
+    headers = sign_request(method, path, body)
+    if payload is not None:
+        headers["Content-Type"] = "application/json"
+
+    return await client.request(
+        method,
+        f"{backend_url}{path}",
+        content=body,
+        headers=headers,
+        timeout=timeout,
+    )
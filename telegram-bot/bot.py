@@ -2,6 +2,7 @@
 Telegram Bot логика для отправки уведомлений
 """
 
+import html
 import logging
 from telegram import Bot, InlineKeyboardButton, InlineKeyboardMarkup
 from telegram.error import TelegramError
@@ -96,6 +97,10 @@ class TelegramNotifier:
             f"📞 <b>Телефон:</b> {notification.phone}"
         ]
 
+        # Повторное обращение: клиент уже оставлял заявки
+        if notification.returning_customer:
+            message_parts.insert(1, f"🔁 <b>Повторный клиент</b> (заявок ранее: {notification.previous_contacts})")
+
         # Добавляем опциональные поля
         if notification.email:
             message_parts.append(f"📧 <b>Email:</b> {notification.email}")
@@ -116,6 +121,9 @@ class TelegramNotifier:
         if notification.timestamp:
             message_parts.append(f"🕐 <b>Получена:</b> {notification.timestamp}")
 
+        if notification.assigned_to:
+            message_parts.append(f"👨‍💼 <b>Ответственный:</b> {notification.assigned_to}")
+
         return "\n".join(message_parts)
 
     async def send_reminder_notification(self, contact_name: str, phone: str, note: str) -> bool:
@@ -152,6 +160,36 @@ class TelegramNotifier:
             logger.error(f"Ошибка при отправке напоминания: {str(e)}")
             return False
 
+    async def send_alert(self, title: str, text: str) -> bool:
+        """
+        Отправить служебный алерт (например, о подборе паролей к админке)
+
+        Args:
+            title: Заголовок алерта
+            text: Текст алерта
+
+        Returns:
+            bool: True если отправлено успешно
+        """
+        try:
+            message = (
+                f"🚨 <b>{html.escape(title)}</b>\n\n"
+                f"{html.escape(text)}"
+            )
+
+            await self.bot.send_message(
+                chat_id=self.chat_id,
+                text=message,
+                parse_mode='HTML'
+            )
+
+            logger.info(f"Алерт отправлен: {title}")
+            return True
+
+        except TelegramError as e:
+            logger.error(f"Ошибка при отправке алерта: {str(e)}")
+            return False
+
     async def remove_buttons_from_message(self, chat_id: str, message_id: int, success_text: str) -> bool:
         """
         Убрать кнопки из сообщения после обработки и добавить текст о результате
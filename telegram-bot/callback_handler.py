@@ -8,6 +8,7 @@ from telegram import Update, InlineKeyboardButton, InlineKeyboardMarkup
 from telegram.ext import ContextTypes
 import httpx
 from config import settings
+from backend_client import backend_request
 
 logger = logging.getLogger(__name__)
 
@@ -81,22 +82,20 @@ class CallbackHandler:
         try:
             async with httpx.AsyncClient() as client:
                 # Меняем статус на "processed"
-                status_response = await client.post(
-                    f"{self.backend_url}/api/telegram/update-status",
-                    json={"contact_id": contact_id, "status": "processed"},
-                    timeout=10.0
+                status_response = await backend_request(
+                    client, self.backend_url, "POST", "/api/telegram/update-status",
+                    {"contact_id": contact_id, "status": "processed"}
                 )
                 status_response.raise_for_status()
 
                 # Добавляем системную заметку
-                note_response = await client.post(
-                    f"{self.backend_url}/api/telegram/add-note",
-                    json={
+                note_response = await backend_request(
+                    client, self.backend_url, "POST", "/api/telegram/add-note",
+                    {
                         "contact_id": contact_id,
                         "text": "Обработано",
                         "author": "Telegram Bot"
-                    },
-                    timeout=10.0
+                    }
                 )
                 note_response.raise_for_status()
 
@@ -148,13 +147,12 @@ class CallbackHandler:
 
             async with httpx.AsyncClient() as client:
                 # Устанавливаем напоминание
-                response = await client.post(
-                    f"{self.backend_url}/api/telegram/set-reminder",
-                    json={
+                response = await backend_request(
+                    client, self.backend_url, "POST", "/api/telegram/set-reminder",
+                    {
                         "contact_id": contact_id,
                         "remind_at": remind_at_str
-                    },
-                    timeout=10.0
+                    }
                 )
                 response.raise_for_status()
 
@@ -13,6 +13,10 @@ class Settings(BaseSettings):
     TELEGRAM_BOT_TOKEN: str
     TELEGRAM_CHAT_ID: str
 
+    # Общий секрет для подписи запросов к Go backend (/api/telegram/*)
+    # Должен совпадать с TELEGRAM_API_SECRET в backend/.env
+    TELEGRAM_API_SECRET: str = ""
+
     # Настройки сервера
     HOST: str = "127.0.0.1"
     PORT: int = 5000
@@ -61,6 +61,15 @@ class ContactNotification(BaseModel):
     message: Optional[str] = None
     contact_id: Optional[int] = None
     timestamp: Optional[str] = None
+    returning_customer: bool = False
+    previous_contacts: int = 0
+    assigned_to: Optional[str] = None
+
+
+class AlertNotification(BaseModel):
+    """Модель данных для служебного алерта"""
+    title: str
+    text: str
 
 
 @app.on_event("startup")
@@ -154,6 +163,29 @@ async def send_notification(notification: ContactNotification):
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@app.post("/api/send-alert")
+async def send_alert(alert: AlertNotification):
+    """
+    Отправить служебный алерт в Telegram (например, о подборе паролей к админке)
+
+    Args:
+        alert: Заголовок и текст алерта
+
+    Returns:
+        dict: Статус отправки
+    """
+    logger.info(f"Получен алерт: {alert.title}")
+
+    success = await notifier.send_alert(alert.title, alert.text)
+    if not success:
+        raise HTTPException(status_code=500, detail="Failed to send alert")
+
+    return {
+        "status": "success",
+        "message": "Alert sent successfully"
+    }
+
+
 if __name__ == "__main__":
     import uvicorn
     uvicorn.run(
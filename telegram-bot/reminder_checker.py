@@ -7,6 +7,7 @@ import asyncio
 from telegram import Bot
 from telegram.error import TelegramError
 import httpx
+from backend_client import backend_request
 
 logger = logging.getLogger(__name__)
 
@@ -67,9 +68,8 @@ class ReminderChecker:
         try:
             async with httpx.AsyncClient() as client:
                 # Получаем список напоминаний которые пора отправить
-                response = await client.get(
-                    f"{self.backend_url}/api/telegram/due-reminders",
-                    timeout=10.0
+                response = await backend_request(
+                    client, self.backend_url, "GET", "/api/telegram/due-reminders"
                 )
                 response.raise_for_status()
 
@@ -88,10 +88,9 @@ class ReminderChecker:
                         await self._send_reminder_notification(reminder)
 
                         # Помечаем напоминание как отправленное
-                        await client.post(
-                            f"{self.backend_url}/api/telegram/mark-reminder-sent",
-                            json={"contact_id": reminder["contact_id"]},
-                            timeout=10.0
+                        await backend_request(
+                            client, self.backend_url, "POST", "/api/telegram/mark-reminder-sent",
+                            {"contact_id": reminder["contact_id"]}
                         )
 
                         logger.info(f"✓ Напоминание отправлено для контакта ID {reminder['contact_id']}")